@@ -9,21 +9,24 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -32,18 +35,39 @@ import (
 	"k8s.io/client-go/tools/record"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/controller"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned"
+	agicscheme "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/scheme"
 	istio "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/doctor"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/migrate"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/version"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
 )
 
 const (
 	verbosityFlag = "verbosity"
 	maxAuthRetry  = 10
+
+	// doctorCommand is the subcommand, which diagnoses the common reasons AGIC fails, prints a report and exits.
+	doctorCommand = "doctor"
+
+	// migrateCommand is the subcommand, which prints the Ingresses and prohibited targets equivalent to the config of an existing
+	// App Gateway, and exits.
+	migrateCommand = "migrate"
+
+	// detachCommand is the subcommand, which removes the resources AGIC created from the App Gateway, and exits.
+	detachCommand = "detach"
+
+	// eventFlushDelay is how long to wait for the event broadcaster to deliver an event before exiting.
+	eventFlushDelay = 2 * time.Second
 )
 
 var (
@@ -64,6 +88,78 @@ var (
 	versionInfo = flags.Bool("version", false, "Print version")
 
 	verbosity = flags.Int(verbosityFlag, 1, "Set logging verbosity level")
+
+	recordARMDir = flags.String("record-arm", "",
+		"Directory to save all requests to Azure Resource Manager and their responses to. Optional.")
+
+	replayARMDir = flags.String("replay-arm", "",
+		"Directory with ARM interactions saved with --record-arm; these are served instead of calling Azure. Optional.")
+
+	debugListenAddress = flags.String("debug-listen-address", "",
+		"Address, such as 127.0.0.1:8123, of the debug endpoint; the audit log of App Gateway updates is served at /debug/audit. Disabled when empty.")
+
+	gatewayFile = flags.String("gateway-file", "",
+		"Path to an exported App Gateway JSON, which is used and updated instead of the App Gateway in Azure. Optional.")
+
+	healthListenAddress = flags.String("health-listen-address", ":8080",
+		"Address of the health endpoints: /readyz fails until the first App Gateway config is built, /healthz reports the last sync and error, /metrics the time of the last successful sync and the metrics of the App Gateway. Disabled when empty.")
+
+	readyAfterApply = flags.Bool("ready-after-apply", false,
+		"Keep /readyz failing until the first App Gateway config is successfully applied, rather than built.")
+
+	armGetTimeout = flags.Duration("arm-get-timeout", 0,
+		"Timeout of requests getting the App Gateway from Azure Resource Manager. No timeout when zero.")
+
+	armPutTimeout = flags.Duration("arm-put-timeout", autorest.DefaultPollingDuration,
+		"Timeout of App Gateway updates, including waiting for Azure Resource Manager to complete them.")
+
+	armPollingInterval = flags.Duration("arm-polling-interval", autorest.DefaultPollingDelay,
+		"Interval at which the state of an App Gateway update is polled, unless Azure Resource Manager asks for another.")
+
+	shutdownTimeout = flags.Duration("shutdown-timeout", 2*time.Minute,
+		"On SIGTERM, how long to wait for an App Gateway update in progress to complete before exiting. Keep it below the termination grace period of the Pod.")
+
+	applyJitter = flags.Duration("apply-jitter", 0,
+		"Upper bound of a random pause after each App Gateway update; changes arriving in the meantime are applied together. No pause when zero.")
+
+	errorBackoff = flags.Duration("error-backoff", worker.DefaultErrorBackoff,
+		"Pause after a failed App Gateway update; it doubles with each consecutive failure, up to --max-error-backoff.")
+
+	maxErrorBackoff = flags.Duration("max-error-backoff", worker.DefaultMaxErrorBackoff,
+		"Upper bound of the pause after consecutive failed App Gateway updates.")
+
+	subnetCheckInterval = flags.Duration("subnet-check-interval", time.Hour,
+		"Interval at which the subnet of the App Gateway is validated; problems are emitted as events of the AGIC Pod. Disabled when zero.")
+
+	frontendIPCheckInterval = flags.Duration("frontend-ip-check-interval", 5*time.Minute,
+		"Interval at which the frontend IP address of the App Gateway is checked, and published in the status of the Ingresses when it changed. Disabled when zero.")
+
+	backendHealthCheckInterval = flags.Duration("backend-health-check-interval", 0,
+		"Interval at which the health of the backends is requested from the App Gateway; unhealthy Pods get a warning event. Disabled when zero.")
+
+	customMetricsInterval = flags.Duration("custom-metrics-interval", 0,
+		"Interval at which the sync duration, failed syncs and number of managed resources are published as custom metrics of the App Gateway in Azure Monitor. Disabled when zero.")
+
+	dataPlaneMetricsInterval = flags.Duration("data-plane-metrics-interval", 0,
+		"Interval at which the throughput, requests, failed requests, backend health and compute units of the App Gateway are read from Azure Monitor, and exported on /metrics. Disabled when zero.")
+
+	watchdogInterval = flags.Duration("watchdog-interval", 0,
+		"Interval at which the caches of the informers are compared with the API server, and the worker is checked for an event processed for longer than --stalled-event-timeout; stalled informers and workers are restarted. Disabled when zero.")
+
+	stalledEventTimeout = flags.Duration("stalled-event-timeout", 30*time.Minute,
+		"How long the worker may process an event: its App Gateway requests are cancelled past it, and the watchdog restarts the worker when the event still does not end. It should exceed --arm-put-timeout. No limit when zero.")
+
+	twoPhaseApplyTimeout = flags.Duration("two-phase-apply-timeout", controller.DefaultTwoPhaseApplyTimeout,
+		"With APPGW_ENABLE_TWO_PHASE_APPLY, how long the new backends may take to be healthy before the App Gateway config is applied anyway.")
+
+	migrateNamespace = flags.String("migrate-namespace", "default",
+		"Namespace of the Ingresses, Services and Endpoints printed by the migrate command.")
+
+	detachDryRun = flags.Bool("detach-dry-run", false,
+		"Only print the App Gateway resources the detach command would remove.")
+
+	eventAggregationInterval = flags.Duration("event-aggregation-interval", events.DefaultAggregationInterval,
+		"Identical warning events are emitted at most once per interval; the count of the event is refreshed instead of emitting it again on every resync. Disabled when zero.")
 )
 
 func main() {
@@ -88,10 +184,27 @@ func main() {
 	_ = flag.Lookup("logtostderr").Value.Set("true")
 	_ = flag.Set("v", strconv.Itoa(*verbosity))
 
+	if flags.Arg(1) == doctorCommand {
+		runDoctor(env)
+	}
+	if flags.Arg(1) == migrateCommand {
+		runMigrate(env)
+	}
+	if flags.Arg(1) == detachCommand {
+		runDetach(env)
+	}
+
 	// initialize clients and dependencies
+	apiConfig := getKubeClientConfig()
+	kubeClient := kubernetes.NewForConfigOrDie(apiConfig)
+	crdClient := versioned.NewForConfigOrDie(apiConfig)
+	istioCrdClient := istio.NewForConfigOrDie(apiConfig)
+	dynamicClient := dynamic.NewForConfigOrDie(apiConfig)
+	recorder := getEventRecorder(kubeClient)
+
 	appGwClient, err := initAppGwClient(env)
 	if err != nil {
-		glog.Fatal("Error creating Azure client: ", err)
+		fatalWithEvent(kubeClient, recorder, env, events.ReasonARMAuthFailure, fmt.Sprintf("Error creating Azure client: %s", err))
 	}
 
 	appGwIdentifier := appgw.Identifier{
@@ -99,14 +212,16 @@ func main() {
 		ResourceGroup:  env.ResourceGroupName,
 		AppGwName:      env.AppGwName,
 	}
-
-	apiConfig := getKubeClientConfig()
-	kubeClient := kubernetes.NewForConfigOrDie(apiConfig)
-	crdClient := versioned.NewForConfigOrDie(apiConfig)
-	istioCrdClient := istio.NewForConfigOrDie(apiConfig)
-	recorder := getEventRecorder(kubeClient)
 	namespaces := getNamespacesToWatch(env.WatchNamespace)
-	k8sContext := k8scontext.NewContext(kubeClient, crdClient, istioCrdClient, namespaces, *resyncPeriod)
+	k8sContext := k8scontext.NewContext(kubeClient, crdClient, istioCrdClient, dynamicClient, namespaces, *resyncPeriod)
+
+	// fail fast when informers would never sync due to missing RBAC permissions
+	if err := k8scontext.CheckPermissions(kubeClient, namespaces, env); err != nil {
+		if _, notChecked := err.(k8scontext.PermissionsNotCheckedError); !notChecked {
+			glog.Fatal("Ingress Controller does not have the Kubernetes permissions it requires: ", err)
+		}
+		glog.Warning(err)
+	}
 
 	// namespace validations
 	validateNamespaces(namespaces, kubeClient) // side-effect: will panic on non-existent namespace
@@ -123,10 +238,220 @@ func main() {
 	}
 
 	// initiliaze controller
-	appGwIngressController := controller.NewAppGwIngressController(*appGwClient, appGwIdentifier, k8sContext, recorder)
+	appGwIngressController := controller.NewAppGwIngressController(*appGwClient, appGwIdentifier, k8sContext, kubeClient, recorder, *armGetTimeout, worker.Options{
+		Jitter:          *applyJitter,
+		ErrorBackoff:    *errorBackoff,
+		MaxErrorBackoff: *maxErrorBackoff,
+	})
+
+	appGwIngressController.SetTwoPhaseApplyTimeout(*twoPhaseApplyTimeout)
+	appGwIngressController.SetEventTimeout(*stalledEventTimeout)
+
+	if *debugListenAddress != "" {
+		startDebugServer(*debugListenAddress, appGwIngressController)
+	}
+
+	if *healthListenAddress != "" {
+		startHealthServer(*healthListenAddress, appGwIngressController)
+	}
+
+	stopOnSignal(appGwIngressController)
+
+	if *subnetCheckInterval > 0 {
+		go appGwIngressController.ValidateSubnetPeriodically(env, *subnetCheckInterval)
+	}
 
-	// start controller
+	if *frontendIPCheckInterval > 0 {
+		go appGwIngressController.MonitorFrontendIP(env, *frontendIPCheckInterval)
+	}
+
+	if *backendHealthCheckInterval > 0 {
+		go appGwIngressController.MonitorBackendHealth(*backendHealthCheckInterval)
+	}
+
+	if *customMetricsInterval > 0 {
+		startCustomMetrics(env, appGwIngressController)
+	}
+
+	if *dataPlaneMetricsInterval > 0 {
+		if *gatewayFile != "" || *replayARMDir != "" {
+			glog.Info("Not exporting the metrics of the App Gateway, as the App Gateway is not in Azure")
+		} else {
+			go appGwIngressController.ExportDataPlaneMetrics(*dataPlaneMetricsInterval)
+		}
+	}
+
+	if *watchdogInterval > 0 {
+		go appGwIngressController.RunWatchdog(env, *watchdogInterval, *stalledEventTimeout)
+	}
+
+	// start controller; returns once stopped
 	appGwIngressController.Start(env)
+	glog.Info("Ingress Controller stopped")
+}
+
+// stopOnSignal stops the controller on SIGTERM or SIGINT, waiting up to --shutdown-timeout for an App Gateway update in progress.
+func stopOnSignal(appGwIngressController *controller.AppGwIngressController) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		glog.Infof("Received %s; stopping the Ingress Controller", sig)
+		appGwIngressController.Stop(*shutdownTimeout)
+	}()
+}
+
+// runDoctor prints the findings of the checks of the doctor, most severe first, and exits with 1 when any check failed.
+func runDoctor(env environment.EnvVariables) {
+	apiConfig := getKubeClientConfig()
+	kubeClient := kubernetes.NewForConfigOrDie(apiConfig)
+	crdClient := versioned.NewForConfigOrDie(apiConfig)
+	namespaces := getNamespacesToWatch(env.WatchNamespace)
+
+	report := doctor.NewDoctor(env, namespaces, kubeClient, crdClient).Run()
+	report.Print(os.Stdout)
+	glog.Flush()
+	if report.HasErrors() {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runMigrate prints the manifests equivalent to the config of the App Gateway as YAML, and exits.
+// The App Gateway is only read; the manifests are meant to be reviewed before they are applied.
+func runMigrate(env environment.EnvVariables) {
+	appGwClient := n.NewApplicationGatewaysClient(env.SubscriptionID)
+	if *gatewayFile != "" {
+		sender, err := azure.NewFileGatewaySender(*gatewayFile)
+		if err != nil {
+			glog.Fatal("Unable to read the App Gateway from file: ", err)
+		}
+		appGwClient.Sender = sender
+		appGwClient.Authorizer = autorest.NullAuthorizer{}
+	} else {
+		authorizer, _, err := azure.GetAuthorizer(azure.NewDefaultCredentialChain(env))
+		if err != nil {
+			glog.Fatal("Unable to authenticate with Azure: ", err)
+		}
+		appGwClient.Authorizer = authorizer
+	}
+
+	appGw, err := appGwClient.Get(context.Background(), env.ResourceGroupName, env.AppGwName)
+	if err != nil {
+		glog.Fatalf("Unable to get App Gateway %s: %s", env.AppGwName, err)
+	}
+	if err := migrate.Generate(appGw, *migrateNamespace).WriteYAML(os.Stdout); err != nil {
+		glog.Fatal("Unable to write the manifests: ", err)
+	}
+	glog.Flush()
+	os.Exit(0)
+}
+
+// runDetach removes the resources AGIC created from the App Gateway, keeping the ones of the prohibited targets, and exits.
+// AGIC must be stopped first, or it creates the resources again on its next sync.
+func runDetach(env environment.EnvVariables) {
+	var prohibitedTargets []*ptv1.AzureIngressProhibitedTarget
+	if env.EnableBrownfieldDeployment == "true" {
+		apiConfig := getKubeClientConfig()
+		prohibitedTargets = listProhibitedTargets(env, kubernetes.NewForConfigOrDie(apiConfig), versioned.NewForConfigOrDie(apiConfig))
+	}
+
+	appGwClient, err := initAppGwClient(env)
+	if err != nil {
+		glog.Fatal("Error creating Azure client: ", err)
+	}
+	appGw, err := appGwClient.Get(context.Background(), env.ResourceGroupName, env.AppGwName)
+	if err != nil {
+		glog.Fatalf("Unable to get App Gateway %s: %s", env.AppGwName, err)
+	}
+	detached, removed, err := appgw.GetDetachedConfig(appGw, prohibitedTargets)
+	if err != nil {
+		glog.Fatalf("Unable to detach AGIC from App Gateway %s: %s", env.AppGwName, err)
+	}
+	for _, resource := range removed {
+		fmt.Println(resource)
+	}
+	if *detachDryRun {
+		glog.Infof("Dry run: %d resources would be removed from App Gateway %s", len(removed), env.AppGwName)
+		glog.Flush()
+		os.Exit(0)
+	}
+
+	ctx := context.Background()
+	appGwFuture, err := appGwClient.CreateOrUpdate(ctx, env.ResourceGroupName, env.AppGwName, detached)
+	if err == nil {
+		err = appGwFuture.WaitForCompletionRef(ctx, appGwClient.BaseClient.Client)
+	}
+	if err != nil {
+		glog.Fatalf("Unable to update App Gateway %s: %s", env.AppGwName, err)
+	}
+	glog.Infof("Removed %d resources created by AGIC from App Gateway %s", len(removed), env.AppGwName)
+	glog.Flush()
+	os.Exit(0)
+}
+
+// listProhibitedTargets returns the prohibited targets of the CRDs and of the ConfigMap, as AGIC is configured to read them.
+func listProhibitedTargets(env environment.EnvVariables, kubeClient kubernetes.Interface, crdClient versioned.Interface) []*ptv1.AzureIngressProhibitedTarget {
+	var prohibitedTargets []*ptv1.AzureIngressProhibitedTarget
+	if env.ProhibitedTargetsCRD != "false" {
+		targets, err := crdClient.AzureingressprohibitedtargetsV1().AzureIngressProhibitedTargets(metav1.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			glog.Fatal("Unable to list AzureIngressProhibitedTargets: ", err)
+		}
+		for idx := range targets.Items {
+			prohibitedTargets = append(prohibitedTargets, &targets.Items[idx])
+		}
+	}
+	if env.ProhibitedTargetsConfigMap != "" {
+		configMap, err := kubeClient.CoreV1().ConfigMaps(env.AGICPodNamespace).Get(env.ProhibitedTargetsConfigMap, metav1.GetOptions{})
+		if err != nil {
+			glog.Fatal("Unable to get the ConfigMap with prohibited targets: ", err)
+		}
+		configMapTargets, errs := brownfield.ParseProhibitedTargets(configMap)
+		for _, err := range errs {
+			glog.Warningf("ConfigMap %s/%s: %s", configMap.Namespace, configMap.Name, err)
+		}
+		prohibitedTargets = append(prohibitedTargets, configMapTargets...)
+	}
+	return prohibitedTargets
+}
+
+// startCustomMetrics publishes the metrics of the controller to Azure Monitor, with a token for Azure Monitor rather than ARM.
+func startCustomMetrics(env environment.EnvVariables, appGwIngressController *controller.AppGwIngressController) {
+	if *gatewayFile != "" || *replayARMDir != "" {
+		glog.Info("Not publishing custom metrics to Azure Monitor, as the App Gateway is not in Azure")
+		return
+	}
+	authorizer, _, err := azure.GetAuthorizerForResource(azure.NewDefaultCredentialChain(env), azure.MonitoringResource)
+	if err != nil {
+		glog.Error("Unable to authenticate with Azure Monitor; custom metrics are not published: ", err)
+		return
+	}
+	go appGwIngressController.PublishCustomMetrics(authorizer, *customMetricsInterval)
+}
+
+func startDebugServer(address string, appGwIngressController *controller.AppGwIngressController) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/audit", appGwIngressController.AuditLog())
+	go func() {
+		glog.Infof("Serving debug endpoint on %s", address)
+		if err := http.ListenAndServe(address, mux); err != nil {
+			glog.Error("Debug endpoint stopped: ", err)
+		}
+	}()
+}
+
+func startHealthServer(address string, appGwIngressController *controller.AppGwIngressController) {
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", appGwIngressController.ReadinessHandler(*readyAfterApply))
+	mux.Handle("/healthz", appGwIngressController.HealthHandler())
+	mux.Handle("/metrics", appGwIngressController.MetricsHandler())
+	go func() {
+		glog.Infof("Serving health endpoint on %s", address)
+		if err := http.ListenAndServe(address, mux); err != nil {
+			glog.Error("Health endpoint stopped: ", err)
+		}
+	}()
 }
 
 func validateNamespaces(namespaces []string, kubeClient *kubernetes.Clientset) {
@@ -165,10 +490,47 @@ func getNamespacesToWatch(namespaceEnvVar string) []string {
 
 func initAppGwClient(env environment.EnvVariables) (*n.ApplicationGatewaysClient, error) {
 	appGwClient := n.NewApplicationGatewaysClient(env.SubscriptionID)
+	appGwClient.PollingDuration = *armPutTimeout
+	appGwClient.PollingDelay = *armPollingInterval
+
+	// ARM requests are served from disk; there is no need to authenticate.
+	if *gatewayFile != "" || *replayARMDir != "" {
+		var err error
+		if *gatewayFile != "" {
+			glog.Infof("Using App Gateway from file %s instead of Azure", *gatewayFile)
+			appGwClient.Sender, err = azure.NewFileGatewaySender(*gatewayFile)
+		} else {
+			glog.Infof("Replaying ARM interactions from %s instead of calling Azure", *replayARMDir)
+			appGwClient.Sender, err = azure.NewReplayingSender(*replayARMDir)
+		}
+		if err != nil {
+			return nil, err
+		}
+		appGwClient.Authorizer = autorest.NullAuthorizer{}
+		return &appGwClient, nil
+	}
+
+	if *recordARMDir != "" {
+		glog.Infof("Recording ARM interactions to %s", *recordARMDir)
+		sender, err := azure.NewRecordingSender(appGwClient.Sender, *recordARMDir)
+		if err != nil {
+			return nil, err
+		}
+		appGwClient.Sender = sender
+	}
+
 	if err := waitForAzureAuth(env, &appGwClient); err != nil {
 		return nil, err
 	}
 
+	// A missing write permission would otherwise only surface on the first App Gateway update.
+	if err := azure.CheckAppGwPermissions(appGwClient, env.SubscriptionID, env.ResourceGroupName, env.AppGwName, env.ObserveOnly == "true"); err != nil {
+		if _, notListed := err.(azure.PermissionsNotListedError); !notListed {
+			return nil, err
+		}
+		glog.Warning(err)
+	}
+
 	return &appGwClient, nil
 }
 
@@ -178,14 +540,12 @@ func waitForAzureAuth(env environment.EnvVariables, client *n.ApplicationGateway
 	const retryTime = 10 * time.Second
 	for counter := 0; counter <= maxAuthRetry; counter++ {
 		// Fetch a new token
-		if client.Authorizer, err = getAzAuth(env); err != nil || client.Authorizer == nil {
-			glog.Fatal("Error creating Azure client", err)
-		}
-
-		// Get Application Gateway
-		response, err = client.Get(context.Background(), env.ResourceGroupName, env.AppGwName)
-		if err == nil {
-			return nil
+		if client.Authorizer, _, err = azure.GetAuthorizer(azure.NewDefaultCredentialChain(env)); err == nil {
+			// Get Application Gateway
+			response, err = client.Get(context.Background(), env.ResourceGroupName, env.AppGwName)
+			if err == nil {
+				return nil
+			}
 		}
 
 		// Tries remaining
@@ -196,6 +556,11 @@ func waitForAzureAuth(env environment.EnvVariables, client *n.ApplicationGateway
 		}
 	}
 
+	// Authentication failed or App Gateway could not be reached
+	if response.Response.Response == nil {
+		return err
+	}
+
 	// Reasons for 403 errors
 	if response.Response.StatusCode == 403 {
 		infoLine := "Possible reasons:"
@@ -215,15 +580,17 @@ func waitForAzureAuth(env environment.EnvVariables, client *n.ApplicationGateway
 	return err
 }
 
-func getAzAuth(vars environment.EnvVariables) (autorest.Authorizer, error) {
-	if vars.AuthLocation == "" {
-		// requires aad-pod-identity to be deployed in the AKS cluster
-		// see https://github.com/Azure/aad-pod-identity for more information
-		glog.V(1).Infoln("Creating authorizer from Azure Managed Service Identity")
-		return auth.NewAuthorizerFromEnvironment()
+// fatalWithEvent emits a warning event on the AGIC Pod, so the failure is visible with "kubectl describe pod", and exits.
+func fatalWithEvent(kubeClient kubernetes.Interface, recorder record.EventRecorder, env environment.EnvVariables, reason, message string) {
+	if env.AGICPodName != "" && env.AGICPodNamespace != "" {
+		if pod, err := kubeClient.CoreV1().Pods(env.AGICPodNamespace).Get(env.AGICPodName, metav1.GetOptions{}); err != nil {
+			glog.Error("Unable to find the AGIC Pod to attach the event to: ", err)
+		} else {
+			recorder.Event(pod, v1.EventTypeWarning, reason, message)
+			time.Sleep(eventFlushDelay)
+		}
 	}
-	glog.V(1).Infoln("Creating authorizer from file referenced by AZURE_AUTH_LOCATION")
-	return auth.NewAuthorizerFromFile(n.DefaultBaseURI)
+	glog.Fatal(message)
 }
 
 func getKubeClientConfig() *rest.Config {
@@ -263,7 +630,11 @@ func getEventRecorder(kubeClient kubernetes.Interface) record.EventRecorder {
 		Component: annotations.ApplicationGatewayIngressClass,
 		Host:      hostname,
 	}
-	return eventBroadcaster.NewRecorder(scheme.Scheme, source)
+	// Events are also emitted on AzureIngressProhibitedTargets; the recorder looks up their kind in the scheme.
+	if err := agicscheme.AddToScheme(scheme.Scheme); err != nil {
+		glog.Error("Could not add the AGIC custom resources to the scheme of the event recorder", err)
+	}
+	return events.NewAggregatingRecorder(eventBroadcaster.NewRecorder(scheme.Scheme, source), *eventAggregationInterval)
 }
 
 func getVerbosity(flagVerbosity int, envVerbosity string) int {
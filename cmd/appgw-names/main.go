@@ -0,0 +1,129 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+// appgw-names prints the names of the App Gateway resources AGIC generates for the paths of an Ingress, before it is deployed.
+// Like the controller, it honors the APPGW_CONFIG_NAME_PREFIX environment variable. Given the App Gateway config built by
+// AGIC, such as the last applied config stored in the appgw-last-applied-config ConfigMap, it reads the names from it instead.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/spf13/pflag"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+)
+
+var (
+	flags = pflag.NewFlagSet(`appgw-names`, pflag.ExitOnError)
+
+	ingressFile = flags.String("ingress", "-",
+		"Path to a YAML or JSON file with the Ingress resource; defaults to standard input.")
+
+	backendPort = flags.Int32("backend-port", 0,
+		"Port of the Pods the Service forwards traffic to. Defaults to the service port of each Ingress backend.")
+
+	appGwFile = flags.String("appgw", "",
+		"Path to a JSON file with the App Gateway config built by AGIC, such as the appGateway.json key of the appgw-last-applied-config ConfigMap "+
+			"(large configs are stored gzipped in its appGateway.json.gz binary key). "+
+			"The names are read from the config rather than predicted from the Ingress.")
+
+	https = flags.Bool("https", false,
+		"Resolve the names of the HTTPS (port 443) listener instead of the HTTP (port 80) listener.")
+)
+
+type pathNames struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+	appgw.ResourceNames
+
+	// Error tells why the config given with --appgw does not route the path, as when it conflicts with a path of an older Ingress.
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	if err := flags.Parse(os.Args); err != nil {
+		fail("Error parsing command line arguments: %s", err)
+	}
+
+	var content []byte
+	var err error
+	if *ingressFile == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(*ingressFile)
+	}
+	if err != nil {
+		fail("Error reading Ingress: %s", err)
+	}
+
+	var ingress v1beta1.Ingress
+	if err := yaml.Unmarshal(content, &ingress); err != nil {
+		fail("Error parsing Ingress: %s", err)
+	}
+
+	var appGw *n.ApplicationGateway
+	if *appGwFile != "" {
+		content, err = ioutil.ReadFile(*appGwFile)
+		if err != nil {
+			fail("Error reading App Gateway config: %s", err)
+		}
+		appGw = &n.ApplicationGateway{}
+		if err := json.Unmarshal(content, appGw); err != nil {
+			fail("Error parsing App Gateway config: %s", err)
+		}
+	}
+
+	frontendPort := int32(80)
+	if *https {
+		frontendPort = int32(443)
+	}
+
+	var names []pathNames
+	for ruleIdx := range ingress.Spec.Rules {
+		rule := &ingress.Spec.Rules[ruleIdx]
+		if rule.HTTP == nil {
+			continue
+		}
+		for pathIdx, path := range rule.HTTP.Paths {
+			pathName := pathNames{Host: rule.Host, Path: path.Path}
+			if appGw != nil {
+				if pathName.ResourceNames, err = appgw.GetResourceNames(*appGw, &ingress, rule, pathIdx, frontendPort); err != nil {
+					pathName.Error = err.Error()
+				}
+				names = append(names, pathName)
+				continue
+			}
+
+			port := *backendPort
+			if port == 0 {
+				if path.Backend.ServicePort.Type != intstr.Int {
+					fail("Service port %s of path %s is a name; use --backend-port to provide the port number", path.Backend.ServicePort.String(), path.Path)
+				}
+				port = path.Backend.ServicePort.IntVal
+			}
+			pathName.ResourceNames = appgw.PredictResourceNames(&ingress, rule, pathIdx, frontendPort, port)
+			names = append(names, pathName)
+		}
+	}
+
+	output, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		fail("Error serializing resource names: %s", err)
+	}
+	fmt.Println(string(output))
+}
+
+func fail(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(1)
+}
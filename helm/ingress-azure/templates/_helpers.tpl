@@ -101,4 +101,20 @@ If release name contains chart name it will be used as a full name.
 {{- printf "%s-azidbinding-%s" .Release.Name $name | trunc 63 | trimSuffix "-" -}}
 {{- end -}}
 {{- end -}}
-{{- end -}}
\ No newline at end of file
+{{- end -}}
+
+{{/*
+Create a default fully qualified name of the configmap with the backend defaults.
+We truncate at 63 chars because some Kubernetes name fields are limited to this (by the DNS naming spec).
+*/}}
+{{- define "application-gateway-kubernetes-ingress.backenddefaultsname" -}}
+{{- printf "%s-backend-defaults" (include "application-gateway-kubernetes-ingress.fullname" .) | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
+{{/*
+Create a default fully qualified name of the configmap with the prohibited targets.
+We truncate at 63 chars because some Kubernetes name fields are limited to this (by the DNS naming spec).
+*/}}
+{{- define "application-gateway-kubernetes-ingress.prohibitedtargetsname" -}}
+{{- printf "%s-prohibited-targets" (include "application-gateway-kubernetes-ingress.fullname" .) | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
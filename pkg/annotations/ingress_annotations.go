@@ -6,10 +6,13 @@
 package annotations
 
 import (
-	"strconv"
+	"sort"
+	"strings"
 
 	"github.com/knative/pkg/apis/istio/v1alpha3"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/errors"
 )
@@ -34,9 +37,65 @@ const (
 	// ConnectionDrainingTimeoutKey defines the drain timeout for the backends.
 	ConnectionDrainingTimeoutKey = ApplicationGatewayPrefix + "/connection-draining-timeout"
 
+	// HealthProbePathsKey defines the key for declaring a dedicated health probe for individual Ingress paths.
+	// The value is a comma separated list of `<ingress path>=<probe path>` pairs, for example: "/api=/api/healthz,/web=/ping".
+	// Paths listed here get their own HTTP settings and probe, instead of sharing the probe of the backend Service.
+	HealthProbePathsKey = ApplicationGatewayPrefix + "/health-probe-paths"
+
+	// HealthProbeMatchBodyKey defines the key for a string, which the body of the responses to the health probes of
+	// the backends must contain for the backends to be considered healthy, in addition to a healthy status code.
+	HealthProbeMatchBodyKey = ApplicationGatewayPrefix + "/health-probe-match-body"
+
+	// BackendHTTPSettingsNameKey defines the key for the name of existing HTTP settings of the App Gateway, which the backends of the
+	// Ingress use instead of the ones AGIC would create. AGIC keeps these HTTP settings, and never modifies them.
+	BackendHTTPSettingsNameKey = ApplicationGatewayPrefix + "/backend-http-settings-name"
+
+	// HealthProbeNameKey defines the key for the name of an existing health probe of the App Gateway, which the HTTP settings
+	// of the backends of the Ingress use instead of the probe AGIC would create. AGIC keeps this probe, and never modifies it.
+	HealthProbeNameKey = ApplicationGatewayPrefix + "/health-probe-name"
+
+	// ListenerNameKey defines the key for the name of an existing listener of the App Gateway, to which the request routing rule
+	// of the Ingress is attached instead of the listeners AGIC would create. AGIC keeps this listener, and never modifies it.
+	ListenerNameKey = ApplicationGatewayPrefix + "/listener-name"
+
+	// GRPCBackendKey defines the key for designating the backends of an Ingress as gRPC servers, which require HTTP/2 end to end.
+	GRPCBackendKey = ApplicationGatewayPrefix + "/grpc-backend"
+
+	// ExcludeVirtualNodePodsKey defines the key for excluding the Pods of a Service, which run on virtual nodes (virtual-kubelet, such as ACI),
+	// from the backend pools. It is set on the Service, and overrides the default of the controller for that Service.
+	ExcludeVirtualNodePodsKey = ApplicationGatewayPrefix + "/exclude-virtual-node-pods"
+
 	// SslRedirectKey defines the key for defining with SSL redirect should be turned on for an HTTP endpoint.
 	SslRedirectKey = ApplicationGatewayPrefix + "/ssl-redirect"
 
+	// HTTPSOnlyKey defines the key for serving the Ingress over HTTPS only: no HTTP listener is created for its hosts,
+	// not even one redirecting to HTTPS. Rules without a TLS certificate are not served at all.
+	HTTPSOnlyKey = ApplicationGatewayPrefix + "/https-only"
+
+	// RequireServerNameIndicationKey defines the key for requiring clients to send the hostname with SNI on the HTTPS listeners
+	// of the Ingress. Turning it off lets clients without SNI, such as legacy devices, connect to the listener.
+	RequireServerNameIndicationKey = ApplicationGatewayPrefix + "/require-server-name-indication"
+
+	// OrphanPolicyKey defines the key for what becomes of the listeners and rules of the Ingress once it is deleted: with "delete",
+	// the default, they are removed; with "retain", AGIC keeps them on the App Gateway until they are released manually.
+	OrphanPolicyKey = ApplicationGatewayPrefix + "/orphan-policy"
+
+	// DryRunKey defines the key for validating the Ingress, and reporting the App Gateway config it would generate with events,
+	// without applying it to the App Gateway.
+	DryRunKey = ApplicationGatewayPrefix + "/dry-run"
+
+	// WAFMaxRequestBodySizeKey defines the key for the size, in KB, of the largest request body the web application firewall inspects.
+	// The firewall configuration is shared by all sites of the App Gateway, which get the largest size among the Ingresses.
+	WAFMaxRequestBodySizeKey = ApplicationGatewayPrefix + "/waf-max-request-body-size"
+
+	// WAFFileUploadLimitKey defines the key for the size, in MB, of the largest file upload the web application firewall lets through.
+	// Like the request body size, the App Gateway gets the largest limit among the Ingresses.
+	WAFFileUploadLimitKey = ApplicationGatewayPrefix + "/waf-file-upload-limit"
+
+	// BackendPodSelectorsKey defines the key for the backends of the Ingress, which are the Pods matching a label selector
+	// rather than a Service, as in "db-0: statefulset.kubernetes.io/pod-name=db-0; db-1: statefulset.kubernetes.io/pod-name=db-1".
+	BackendPodSelectorsKey = ApplicationGatewayPrefix + "/backend-pod-selectors"
+
 	// IngressClassKey defines the key of the annotation which needs to be set in order to specify
 	// that this is an ingress resource meant for the application gateway ingress controller.
 	IngressClassKey = "kubernetes.io/ingress.class"
@@ -50,14 +109,39 @@ const (
 	ApplicationGatewayIngressClass = "azure/application-gateway"
 )
 
+const (
+	// minRequestTimeout and maxRequestTimeout are the bounds, in seconds, of the request timeout of the HTTP settings of an App Gateway.
+	minRequestTimeout = 1
+	maxRequestTimeout = 86400
+
+	// minConnectionDrainingTimeout and maxConnectionDrainingTimeout are the bounds, in seconds, of the connection draining timeout.
+	minConnectionDrainingTimeout = 1
+	maxConnectionDrainingTimeout = 3600
+
+	// MinWAFMaxRequestBodySize and MaxWAFMaxRequestBodySize are the bounds, in KB, of the request body size the firewall inspects.
+	MinWAFMaxRequestBodySize = 1
+	MaxWAFMaxRequestBodySize = 128
+
+	// MaxWAFFileUploadLimit is the largest file upload limit, in MB, of a WAF_v2 App Gateway; a WAF one allows up to 100 MB.
+	MaxWAFFileUploadLimit = 500
+
+	// OrphanPolicyDelete and OrphanPolicyRetain are the values of the orphan-policy annotation.
+	OrphanPolicyDelete = "delete"
+	OrphanPolicyRetain = "retain"
+
+	backendPodSelectorsFormat = "a semicolon separated list of <backend service name>: <label selector> pairs, such as db-0: app=db,statefulset.kubernetes.io/pod-name=db-0"
+
+	healthProbePathsFormat = "a comma separated list of <ingress path>=<probe path> pairs, such as /api=/api/healthz,/web=/ping"
+)
+
 // IngressClass ingress class
 func IngressClass(ing *v1beta1.Ingress) (string, error) {
-	return parseString(ing, IngressClassKey)
+	return parseString(ing.Annotations, IngressClassKey)
 }
 
 // IsApplicationGatewayIngress checks if the Ingress resource can be handled by the Application Gateway ingress controller.
 func IsApplicationGatewayIngress(ing *v1beta1.Ingress) (bool, error) {
-	controllerName, err := parseString(ing, IngressClassKey)
+	controllerName, err := parseString(ing.Annotations, IngressClassKey)
 	return controllerName == ApplicationGatewayIngressClass, err
 }
 
@@ -72,65 +156,256 @@ func IsIstioGatewayIngress(gateway *v1alpha3.Gateway) (bool, error) {
 
 // IsSslRedirect for HTTP end points.
 func IsSslRedirect(ing *v1beta1.Ingress) (bool, error) {
-	return parseBool(ing, SslRedirectKey)
+	return parseBool(ing.Annotations, SslRedirectKey)
+}
+
+// IsHTTPSOnly provides whether the Ingress is served over HTTPS only.
+func IsHTTPSOnly(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing.Annotations, HTTPSOnlyKey)
+}
+
+// RequireServerNameIndication provides whether the HTTPS listeners of the Ingress require SNI.
+func RequireServerNameIndication(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing.Annotations, RequireServerNameIndicationKey)
 }
 
 // BackendPathPrefix override path
 func BackendPathPrefix(ing *v1beta1.Ingress) (string, error) {
-	return parseString(ing, BackendPathPrefixKey)
+	return parseString(ing.Annotations, BackendPathPrefixKey)
 }
 
 // RequestTimeout provides value for request timeout on the backend connection
 func RequestTimeout(ing *v1beta1.Ingress) (int32, error) {
-	return parseInt32(ing, RequestTimeoutKey)
+	return parseSeconds(ing.Annotations, RequestTimeoutKey, minRequestTimeout, maxRequestTimeout)
 }
 
 // IsConnectionDraining provides whether connection draining is enabled or not.
 func IsConnectionDraining(ing *v1beta1.Ingress) (bool, error) {
-	return parseBool(ing, CookieBasedAffinityKey)
+	return parseBool(ing.Annotations, ConnectionDrainingKey)
 }
 
 // ConnectionDrainingTimeout provides value for draining timeout for backends.
 func ConnectionDrainingTimeout(ing *v1beta1.Ingress) (int32, error) {
-	return parseInt32(ing, ConnectionDrainingTimeoutKey)
+	return parseSeconds(ing.Annotations, ConnectionDrainingTimeoutKey, minConnectionDrainingTimeout, maxConnectionDrainingTimeout)
 }
 
 // IsCookieBasedAffinity provides value to enable/disable cookie based affinity for client connection.
 func IsCookieBasedAffinity(ing *v1beta1.Ingress) (bool, error) {
-	return parseBool(ing, CookieBasedAffinityKey)
+	return parseBool(ing.Annotations, CookieBasedAffinityKey)
 }
 
-func parseBool(ing *v1beta1.Ingress, name string) (bool, error) {
-	val, ok := ing.Annotations[name]
-	if ok {
-		boolVal, err := strconv.ParseBool(val)
-		if err != nil {
-			return false, errors.NewInvalidAnnotationContent(name, val)
+// HealthProbeMatchBody provides the string, which the body of health probe responses must contain.
+func HealthProbeMatchBody(ing *v1beta1.Ingress) (string, error) {
+	val, err := parseString(ing.Annotations, HealthProbeMatchBodyKey)
+	if err != nil {
+		return "", err
+	}
+	if val == "" {
+		return "", errors.NewInvalidAnnotationContent(HealthProbeMatchBodyKey, val, "a non-empty string")
+	}
+	return val, nil
+}
+
+// BackendHTTPSettingsName provides the name of the existing HTTP settings the backends of the Ingress use.
+func BackendHTTPSettingsName(ing *v1beta1.Ingress) (string, error) {
+	return parseName(ing.Annotations, BackendHTTPSettingsNameKey)
+}
+
+// HealthProbeName provides the name of the existing health probe the backends of the Ingress use.
+func HealthProbeName(ing *v1beta1.Ingress) (string, error) {
+	return parseName(ing.Annotations, HealthProbeNameKey)
+}
+
+// ListenerName provides the name of the existing listener the paths of the Ingress are routed through.
+func ListenerName(ing *v1beta1.Ingress) (string, error) {
+	return parseName(ing.Annotations, ListenerNameKey)
+}
+
+// IsGRPCBackend provides whether the backends of the Ingress are gRPC servers.
+func IsGRPCBackend(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing.Annotations, GRPCBackendKey)
+}
+
+// OrphanPolicy provides what becomes of the App Gateway config of the Ingress once it is deleted.
+func OrphanPolicy(ing *v1beta1.Ingress) (string, error) {
+	return parseEnum(ing.Annotations, OrphanPolicyKey, OrphanPolicyDelete, OrphanPolicyRetain)
+}
+
+// IsDryRun provides whether the config of the Ingress is only reported, rather than applied to the App Gateway.
+func IsDryRun(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing.Annotations, DryRunKey)
+}
+
+// WAFMaxRequestBodySize provides the size, in KB, of the largest request body the web application firewall inspects.
+func WAFMaxRequestBodySize(ing *v1beta1.Ingress) (int32, error) {
+	return parseInt32(ing.Annotations, WAFMaxRequestBodySizeKey, MinWAFMaxRequestBodySize, MaxWAFMaxRequestBodySize)
+}
+
+// WAFFileUploadLimit provides the size, in MB, of the largest file upload the web application firewall lets through.
+func WAFFileUploadLimit(ing *v1beta1.Ingress) (int32, error) {
+	return parseInt32(ing.Annotations, WAFFileUploadLimitKey, 0, MaxWAFFileUploadLimit)
+}
+
+// ExcludeVirtualNodePods provides whether the Pods of the Service, which run on virtual nodes, are left out of the backend pools.
+func ExcludeVirtualNodePods(service *v1.Service) (bool, error) {
+	return parseBool(service.Annotations, ExcludeVirtualNodePodsKey)
+}
+
+// HealthProbePaths provides the probe path to be used for each Ingress path, which declared its own health probe.
+func HealthProbePaths(ing *v1beta1.Ingress) (map[string]string, error) {
+	val, err := parseString(ing.Annotations, HealthProbePathsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	probePaths := make(map[string]string)
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		chunks := strings.SplitN(pair, "=", 2)
+		if len(chunks) != 2 {
+			return nil, errors.NewInvalidAnnotationContent(HealthProbePathsKey, val, healthProbePathsFormat)
+		}
+		ingressPath := strings.TrimSpace(chunks[0])
+		probePath := strings.TrimSpace(chunks[1])
+		if ingressPath == "" || !strings.HasPrefix(probePath, "/") {
+			return nil, errors.NewInvalidAnnotationContent(HealthProbePathsKey, val, healthProbePathsFormat)
 		}
-		return boolVal, nil
+		probePaths[ingressPath] = probePath
 	}
-	return false, errors.ErrMissingAnnotations
+	return probePaths, nil
 }
 
-func parseString(ing *v1beta1.Ingress, name string) (string, error) {
-	val, ok := ing.Annotations[name]
-	if ok {
-		return val, nil
+// BackendPodSelectors provides the labels of the Pods of each backend, by the service name the backends of the Ingress use.
+// The label selectors are equality based ("key=value" pairs), like the selectors of Services.
+func BackendPodSelectors(ing *v1beta1.Ingress) (map[string]map[string]string, error) {
+	val, err := parseString(ing.Annotations, BackendPodSelectorsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	selectors := make(map[string]map[string]string)
+	for _, pair := range strings.Split(val, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		chunks := strings.SplitN(pair, ":", 2)
+		if len(chunks) != 2 {
+			return nil, errors.NewInvalidAnnotationContent(BackendPodSelectorsKey, val, backendPodSelectorsFormat)
+		}
+		serviceName := strings.TrimSpace(chunks[0])
+		selector, err := labels.ConvertSelectorToLabelsMap(strings.TrimSpace(chunks[1]))
+		if serviceName == "" || err != nil || len(selector) == 0 {
+			return nil, errors.NewInvalidAnnotationContent(BackendPodSelectorsKey, val, backendPodSelectorsFormat)
+		}
+		selectors[serviceName] = selector
 	}
+	return selectors, nil
+}
 
-	return "", errors.ErrMissingAnnotations
+// annotationParsers parses the value of each annotation of Application Gateway Ingress Controller.
+var annotationParsers = map[string]func(*v1beta1.Ingress) error{
+	BackendPathPrefixKey: func(ing *v1beta1.Ingress) error {
+		_, err := BackendPathPrefix(ing)
+		return err
+	},
+	CookieBasedAffinityKey: func(ing *v1beta1.Ingress) error {
+		_, err := IsCookieBasedAffinity(ing)
+		return err
+	},
+	RequestTimeoutKey: func(ing *v1beta1.Ingress) error {
+		_, err := RequestTimeout(ing)
+		return err
+	},
+	ConnectionDrainingKey: func(ing *v1beta1.Ingress) error {
+		_, err := IsConnectionDraining(ing)
+		return err
+	},
+	ConnectionDrainingTimeoutKey: func(ing *v1beta1.Ingress) error {
+		_, err := ConnectionDrainingTimeout(ing)
+		return err
+	},
+	HealthProbePathsKey: func(ing *v1beta1.Ingress) error {
+		_, err := HealthProbePaths(ing)
+		return err
+	},
+	HealthProbeMatchBodyKey: func(ing *v1beta1.Ingress) error {
+		_, err := HealthProbeMatchBody(ing)
+		return err
+	},
+	BackendHTTPSettingsNameKey: func(ing *v1beta1.Ingress) error {
+		_, err := BackendHTTPSettingsName(ing)
+		return err
+	},
+	HealthProbeNameKey: func(ing *v1beta1.Ingress) error {
+		_, err := HealthProbeName(ing)
+		return err
+	},
+	ListenerNameKey: func(ing *v1beta1.Ingress) error {
+		_, err := ListenerName(ing)
+		return err
+	},
+	GRPCBackendKey: func(ing *v1beta1.Ingress) error {
+		_, err := IsGRPCBackend(ing)
+		return err
+	},
+	SslRedirectKey: func(ing *v1beta1.Ingress) error {
+		_, err := IsSslRedirect(ing)
+		return err
+	},
+	HTTPSOnlyKey: func(ing *v1beta1.Ingress) error {
+		_, err := IsHTTPSOnly(ing)
+		return err
+	},
+	RequireServerNameIndicationKey: func(ing *v1beta1.Ingress) error {
+		_, err := RequireServerNameIndication(ing)
+		return err
+	},
+	OrphanPolicyKey: func(ing *v1beta1.Ingress) error {
+		_, err := OrphanPolicy(ing)
+		return err
+	},
+	DryRunKey: func(ing *v1beta1.Ingress) error {
+		_, err := IsDryRun(ing)
+		return err
+	},
+	WAFMaxRequestBodySizeKey: func(ing *v1beta1.Ingress) error {
+		_, err := WAFMaxRequestBodySize(ing)
+		return err
+	},
+	WAFFileUploadLimitKey: func(ing *v1beta1.Ingress) error {
+		_, err := WAFFileUploadLimit(ing)
+		return err
+	},
+	BackendPodSelectorsKey: func(ing *v1beta1.Ingress) error {
+		_, err := BackendPodSelectors(ing)
+		return err
+	},
 }
 
-func parseInt32(ing *v1beta1.Ingress, name string) (int32, error) {
-	val, ok := ing.Annotations[name]
-	if ok {
-		intVal, err := strconv.Atoi(val)
-		if err == nil {
-			int32Val := int32(intVal)
-			return int32Val, nil
+// Validate returns an error for each annotation with the prefix of Application Gateway Ingress Controller, which has an invalid value
+// or is not known to the controller (usually a misspelled annotation, which is silently ignored otherwise). Errors are sorted by annotation.
+func Validate(ing *v1beta1.Ingress) []error {
+	var keys []string
+	for key := range ing.Annotations {
+		if strings.HasPrefix(key, ApplicationGatewayPrefix+"/") {
+			keys = append(keys, key)
 		}
-		return 0, errors.NewInvalidAnnotationContent(name, val)
 	}
+	sort.Strings(keys)
 
-	return 0, errors.ErrMissingAnnotations
+	var errs []error
+	for _, key := range keys {
+		parse, known := annotationParsers[key]
+		if !known {
+			errs = append(errs, errors.NewUnknownAnnotation(key))
+			continue
+		}
+		if err := parse(ing); err != nil && !errors.IsMissingAnnotations(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
 }
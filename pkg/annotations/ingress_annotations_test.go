@@ -7,9 +7,12 @@ package annotations
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -29,7 +32,7 @@ func TestParseBoolTrue(t *testing.T) {
 	key := "key"
 	value := "true"
 	ingress.Annotations[key] = value
-	parsedVal, err := parseBool(&ingress, key)
+	parsedVal, err := parseBool(ingress.Annotations, key)
 	if !parsedVal || err != nil {
 		t.Error(fmt.Sprintf(NoError, value, parsedVal, err))
 	}
@@ -39,7 +42,7 @@ func TestParseBoolFalse(t *testing.T) {
 	key := "key"
 	value := "false"
 	ingress.Annotations[key] = value
-	parsedVal, err := parseBool(&ingress, key)
+	parsedVal, err := parseBool(ingress.Annotations, key)
 	if parsedVal || err != nil {
 		t.Error(fmt.Sprintf(NoError, value, parsedVal, err))
 	}
@@ -49,7 +52,7 @@ func TestParseBoolInvalid(t *testing.T) {
 	key := "key"
 	value := "nope"
 	ingress.Annotations[key] = value
-	parsedVal, err := parseBool(&ingress, key)
+	parsedVal, err := parseBool(ingress.Annotations, key)
 	if !errors.IsInvalidContent(err) {
 		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
 	}
@@ -58,7 +61,7 @@ func TestParseBoolInvalid(t *testing.T) {
 func TestParseBoolMissingKey(t *testing.T) {
 	key := "key"
 	delete(ingress.Annotations, key)
-	parsedVal, err := parseBool(&ingress, key)
+	parsedVal, err := parseBool(ingress.Annotations, key)
 	if !errors.IsMissingAnnotations(err) || parsedVal {
 		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, parsedVal, err))
 	}
@@ -68,7 +71,7 @@ func TestParseInt32(t *testing.T) {
 	key := "key"
 	value := "20"
 	ingress.Annotations[key] = value
-	parsedVal, err := parseInt32(&ingress, key)
+	parsedVal, err := parseInt32(ingress.Annotations, key, 0, 100)
 	if err != nil || fmt.Sprint(parsedVal) != value {
 		t.Error(fmt.Sprintf(NoError, value, parsedVal, err))
 	}
@@ -78,16 +81,26 @@ func TestParseInt32Invalid(t *testing.T) {
 	key := "key"
 	value := "20asd"
 	ingress.Annotations[key] = value
-	parsedVal, err := parseInt32(&ingress, key)
+	parsedVal, err := parseInt32(ingress.Annotations, key, 0, 100)
 	if !errors.IsInvalidContent(err) {
 		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
 	}
 }
 
+func TestParseInt32OutOfRange(t *testing.T) {
+	key := "key"
+	value := "101"
+	ingress.Annotations[key] = value
+	parsedVal, err := parseInt32(ingress.Annotations, key, 0, 100)
+	if !errors.IsInvalidContent(err) || !strings.Contains(err.Error(), "an integer between 0 and 100") {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+}
+
 func TestParseInt32MissingKey(t *testing.T) {
 	key := "key"
 	delete(ingress.Annotations, key)
-	parsedVal, err := parseInt32(&ingress, key)
+	parsedVal, err := parseInt32(ingress.Annotations, key, 0, 100)
 	if !errors.IsMissingAnnotations(err) || parsedVal != 0 {
 		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, parsedVal, err))
 	}
@@ -97,7 +110,7 @@ func TestParseString(t *testing.T) {
 	key := "key"
 	value := "/path"
 	ingress.Annotations[key] = value
-	parsedVal, err := parseString(&ingress, key)
+	parsedVal, err := parseString(ingress.Annotations, key)
 	if parsedVal != value || err != nil {
 		t.Error(fmt.Sprintf(NoError, value, parsedVal, err))
 	}
@@ -106,8 +119,213 @@ func TestParseString(t *testing.T) {
 func TestParseStringMissingKey(t *testing.T) {
 	key := "key"
 	delete(ingress.Annotations, key)
-	parsedVal, err := parseString(&ingress, key)
+	parsedVal, err := parseString(ingress.Annotations, key)
+	if !errors.IsMissingAnnotations(err) {
+		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, parsedVal, err))
+	}
+}
+
+func TestHealthProbePaths(t *testing.T) {
+	value := "/api=/api/healthz, /web=/ping"
+	ingress.Annotations[HealthProbePathsKey] = value
+	parsedVal, err := HealthProbePaths(&ingress)
+	if err != nil || len(parsedVal) != 2 || parsedVal["/api"] != "/api/healthz" || parsedVal["/web"] != "/ping" {
+		t.Error(fmt.Sprintf(NoError, value, parsedVal, err))
+	}
+}
+
+func TestHealthProbePathsInvalid(t *testing.T) {
+	value := "/api"
+	ingress.Annotations[HealthProbePathsKey] = value
+	parsedVal, err := HealthProbePaths(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, HealthProbePathsKey)
+}
+
+func TestHealthProbeMatchBodyEmpty(t *testing.T) {
+	ingress.Annotations[HealthProbeMatchBodyKey] = ""
+	parsedVal, err := HealthProbeMatchBody(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, HealthProbeMatchBodyKey)
+}
+
+func TestBackendHTTPSettingsAndHealthProbeName(t *testing.T) {
+	ingress.Annotations[BackendHTTPSettingsNameKey] = " tuned-settings "
+	parsedVal, err := BackendHTTPSettingsName(&ingress)
+	if parsedVal != "tuned-settings" || err != nil {
+		t.Error(fmt.Sprintf(NoError, "tuned-settings", parsedVal, err))
+	}
+	ingress.Annotations[HealthProbeNameKey] = ""
+	parsedVal, err = HealthProbeName(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, BackendHTTPSettingsNameKey)
+	delete(ingress.Annotations, HealthProbeNameKey)
+}
+
+func TestIsHTTPSOnly(t *testing.T) {
+	ingress.Annotations[HTTPSOnlyKey] = "true"
+	parsedVal, err := IsHTTPSOnly(&ingress)
+	if !parsedVal || err != nil {
+		t.Error(fmt.Sprintf(NoError, "true", parsedVal, err))
+	}
+	ingress.Annotations[HTTPSOnlyKey] = "only"
+	parsedVal, err = IsHTTPSOnly(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, HTTPSOnlyKey)
+}
+
+func TestIsConnectionDraining(t *testing.T) {
+	// Connection draining is read from its own annotation, not from the cookie based affinity one.
+	ingress.Annotations[ConnectionDrainingKey] = "true"
+	ingress.Annotations[CookieBasedAffinityKey] = "false"
+	parsedVal, err := IsConnectionDraining(&ingress)
+	if !parsedVal || err != nil {
+		t.Error(fmt.Sprintf(NoError, "true", parsedVal, err))
+	}
+	delete(ingress.Annotations, ConnectionDrainingKey)
+	parsedVal, err = IsConnectionDraining(&ingress)
+	if !errors.IsMissingAnnotations(err) {
+		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, parsedVal, err))
+	}
+	delete(ingress.Annotations, CookieBasedAffinityKey)
+}
+
+func TestRequireServerNameIndication(t *testing.T) {
+	ingress.Annotations[RequireServerNameIndicationKey] = "false"
+	parsedVal, err := RequireServerNameIndication(&ingress)
+	if parsedVal || err != nil {
+		t.Error(fmt.Sprintf(NoError, "false", parsedVal, err))
+	}
+	delete(ingress.Annotations, RequireServerNameIndicationKey)
+	_, err = RequireServerNameIndication(&ingress)
 	if !errors.IsMissingAnnotations(err) {
+		t.Error(fmt.Sprintf(Error, err, false, err))
+	}
+}
+
+func TestListenerName(t *testing.T) {
+	ingress.Annotations[ListenerNameKey] = "ops-listener"
+	parsedVal, err := ListenerName(&ingress)
+	if parsedVal != "ops-listener" || err != nil {
+		t.Error(fmt.Sprintf(NoError, "ops-listener", parsedVal, err))
+	}
+	ingress.Annotations[ListenerNameKey] = " "
+	parsedVal, err = ListenerName(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, ListenerNameKey)
+}
+
+func TestOrphanPolicy(t *testing.T) {
+	ingress.Annotations[OrphanPolicyKey] = "Retain"
+	parsedVal, err := OrphanPolicy(&ingress)
+	if parsedVal != OrphanPolicyRetain || err != nil {
+		t.Error(fmt.Sprintf(NoError, OrphanPolicyRetain, parsedVal, err))
+	}
+	ingress.Annotations[OrphanPolicyKey] = "keep"
+	parsedVal, err = OrphanPolicy(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, OrphanPolicyKey)
+}
+
+func TestIsDryRun(t *testing.T) {
+	ingress.Annotations[DryRunKey] = "true"
+	parsedVal, err := IsDryRun(&ingress)
+	if !parsedVal || err != nil {
+		t.Error(fmt.Sprintf(NoError, "true", parsedVal, err))
+	}
+	delete(ingress.Annotations, DryRunKey)
+	parsedVal, err = IsDryRun(&ingress)
+	if parsedVal || !errors.IsMissingAnnotations(err) {
 		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, parsedVal, err))
 	}
 }
+
+func TestWAFMaxRequestBodySize(t *testing.T) {
+	ingress.Annotations[WAFMaxRequestBodySizeKey] = "128"
+	parsedVal, err := WAFMaxRequestBodySize(&ingress)
+	if parsedVal != 128 || err != nil {
+		t.Error(fmt.Sprintf(NoError, "128", parsedVal, err))
+	}
+	ingress.Annotations[WAFMaxRequestBodySizeKey] = "256"
+	parsedVal, err = WAFMaxRequestBodySize(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, WAFMaxRequestBodySizeKey)
+}
+
+func TestWAFFileUploadLimit(t *testing.T) {
+	ingress.Annotations[WAFFileUploadLimitKey] = "0"
+	parsedVal, err := WAFFileUploadLimit(&ingress)
+	if parsedVal != 0 || err != nil {
+		t.Error(fmt.Sprintf(NoError, "0", parsedVal, err))
+	}
+	ingress.Annotations[WAFFileUploadLimitKey] = "1GB"
+	parsedVal, err = WAFFileUploadLimit(&ingress)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+	delete(ingress.Annotations, WAFFileUploadLimitKey)
+}
+
+func TestBackendPodSelectors(t *testing.T) {
+	ingress.Annotations[BackendPodSelectorsKey] = "db-0: app=db,statefulset.kubernetes.io/pod-name=db-0; db-1: statefulset.kubernetes.io/pod-name=db-1;"
+	parsedVal, err := BackendPodSelectors(&ingress)
+	expected := map[string]map[string]string{
+		"db-0": {"app": "db", "statefulset.kubernetes.io/pod-name": "db-0"},
+		"db-1": {"statefulset.kubernetes.io/pod-name": "db-1"},
+	}
+	if !reflect.DeepEqual(parsedVal, expected) || err != nil {
+		t.Error(fmt.Sprintf(NoError, "the selectors of db-0 and db-1", parsedVal, err))
+	}
+	for _, invalid := range []string{"app=db", "db-0: app!=db", "db-0:", ": app=db"} {
+		ingress.Annotations[BackendPodSelectorsKey] = invalid
+		parsedVal, err = BackendPodSelectors(&ingress)
+		if !errors.IsInvalidContent(err) {
+			t.Error(fmt.Sprintf(Error, invalid, parsedVal, err))
+		}
+	}
+	delete(ingress.Annotations, BackendPodSelectorsKey)
+}
+
+func TestExcludeVirtualNodePods(t *testing.T) {
+	service := corev1.Service{ObjectMeta: v1.ObjectMeta{Annotations: map[string]string{ExcludeVirtualNodePodsKey: "false"}}}
+	parsedVal, err := ExcludeVirtualNodePods(&service)
+	if parsedVal || err != nil {
+		t.Error(fmt.Sprintf(NoError, "false", parsedVal, err))
+	}
+	service.Annotations[ExcludeVirtualNodePodsKey] = "nope"
+	parsedVal, err = ExcludeVirtualNodePods(&service)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, err, parsedVal, err))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ing := v1beta1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Annotations: map[string]string{
+				SslRedirectKey:    "true",
+				RequestTimeoutKey: "thirty",
+				ApplicationGatewayPrefix + "/ssl-redirekt": "true",
+				IngressClassKey: ApplicationGatewayIngressClass,
+			},
+		},
+	}
+	errs := Validate(&ing)
+	if len(errs) != 2 || !errors.IsInvalidContent(errs[0]) || !errors.IsInvalidContent(errs[1]) {
+		t.Errorf("Expected an error for the request timeout and the misspelled SSL redirect. Returned %v.", errs)
+	}
+}
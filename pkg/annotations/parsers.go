@@ -0,0 +1,154 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/errors"
+)
+
+// The parsers below read an annotation as a typed value. They return errors.ErrMissingAnnotations when the annotation is not set,
+// and an InvalidContent error naming the annotation, its value and the accepted format when the value cannot be parsed.
+
+const (
+	boolFormat     = "true or false"
+	nameFormat     = "a non-empty name"
+	durationFormat = "a duration, such as 30s or 5m"
+	cidrsFormat    = "a comma separated list of IP addresses or CIDR ranges, such as 10.0.0.0/8,192.168.1.1"
+)
+
+func parseString(annotations map[string]string, name string) (string, error) {
+	val, ok := annotations[name]
+	if !ok {
+		return "", errors.ErrMissingAnnotations
+	}
+	return val, nil
+}
+
+func parseName(annotations map[string]string, name string) (string, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(val) == "" {
+		return "", errors.NewInvalidAnnotationContent(name, val, nameFormat)
+	}
+	return strings.TrimSpace(val), nil
+}
+
+func parseBool(annotations map[string]string, name string) (bool, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return false, err
+	}
+	boolVal, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		return false, errors.NewInvalidAnnotationContent(name, val, boolFormat)
+	}
+	return boolVal, nil
+}
+
+// parseInt32 parses an integer between min and max, inclusive.
+func parseInt32(annotations map[string]string, name string, min, max int32) (int32, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return 0, err
+	}
+	intVal, err := strconv.ParseInt(strings.TrimSpace(val), 10, 32)
+	if err != nil || int32(intVal) < min || int32(intVal) > max {
+		return 0, errors.NewInvalidAnnotationContent(name, val, fmt.Sprintf("an integer between %d and %d", min, max))
+	}
+	return int32(intVal), nil
+}
+
+func parseDuration(annotations map[string]string, name string) (time.Duration, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return 0, err
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(val))
+	if err != nil || duration < 0 {
+		return 0, errors.NewInvalidAnnotationContent(name, val, durationFormat)
+	}
+	return duration, nil
+}
+
+// parseSeconds parses a number of seconds between min and max, inclusive, given either as an integer or as a duration
+// of whole seconds, such as "90" or "1m30s".
+func parseSeconds(annotations map[string]string, name string, min, max int32) (int32, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return 0, err
+	}
+	format := fmt.Sprintf("a number of seconds between %d and %d, or a duration such as 30s or 5m", min, max)
+	seconds, err := strconv.ParseInt(strings.TrimSpace(val), 10, 32)
+	if err != nil {
+		duration, durationErr := parseDuration(annotations, name)
+		if durationErr != nil || duration%time.Second != 0 {
+			return 0, errors.NewInvalidAnnotationContent(name, val, format)
+		}
+		seconds = int64(duration / time.Second)
+	}
+	if seconds < int64(min) || seconds > int64(max) {
+		return 0, errors.NewInvalidAnnotationContent(name, val, format)
+	}
+	return int32(seconds), nil
+}
+
+// parseEnum parses one of the allowed values; the comparison ignores case, and the allowed value is returned.
+func parseEnum(annotations map[string]string, name string, allowed ...string) (string, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return "", err
+	}
+	for _, value := range allowed {
+		if strings.EqualFold(strings.TrimSpace(val), value) {
+			return value, nil
+		}
+	}
+	return "", errors.NewInvalidAnnotationContent(name, val, "one of "+strings.Join(allowed, ", "))
+}
+
+// parseCIDRs parses a comma separated list of IP addresses and CIDR ranges; an IP address is returned as a range of its own.
+func parseCIDRs(annotations map[string]string, name string) ([]*net.IPNet, error) {
+	val, err := parseString(annotations, name)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []*net.IPNet
+	for _, chunk := range strings.Split(val, ",") {
+		chunk = strings.TrimSpace(chunk)
+		if chunk == "" {
+			continue
+		}
+		if !strings.Contains(chunk, "/") {
+			ip := net.ParseIP(chunk)
+			if ip == nil {
+				return nil, errors.NewInvalidAnnotationContent(name, val, cidrsFormat)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			ranges = append(ranges, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(chunk)
+		if err != nil {
+			return nil, errors.NewInvalidAnnotationContent(name, val, cidrsFormat)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	if len(ranges) == 0 {
+		return nil, errors.NewInvalidAnnotationContent(name, val, cidrsFormat)
+	}
+	return ranges, nil
+}
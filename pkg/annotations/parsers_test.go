@@ -0,0 +1,85 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/errors"
+)
+
+func TestParseSeconds(t *testing.T) {
+	key := "key"
+	for value, expected := range map[string]int32{"30": 30, " 45 ": 45, "1m30s": 90, "2h": 7200} {
+		parsedVal, err := parseSeconds(map[string]string{key: value}, key, 1, 86400)
+		if err != nil || parsedVal != expected {
+			t.Error(fmt.Sprintf(NoError, fmt.Sprint(expected), parsedVal, err))
+		}
+	}
+}
+
+func TestParseSecondsInvalid(t *testing.T) {
+	key := "key"
+	for _, value := range []string{"thirty", "0", "1500ms", "25h", "-5s"} {
+		parsedVal, err := parseSeconds(map[string]string{key: value}, key, 1, 86400)
+		expected := fmt.Sprintf("the annotation key has the invalid value %q; expected a number of seconds between 1 and 86400, or a duration such as 30s or 5m", value)
+		if !errors.IsInvalidContent(err) || err.Error() != expected {
+			t.Error(fmt.Sprintf(Error, expected, parsedVal, err))
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	key := "key"
+	parsedVal, err := parseDuration(map[string]string{key: "5m"}, key)
+	if err != nil || parsedVal != 5*time.Minute {
+		t.Error(fmt.Sprintf(NoError, "5m", parsedVal, err))
+	}
+	parsedVal, err = parseDuration(map[string]string{key: "5"}, key)
+	if !errors.IsInvalidContent(err) {
+		t.Error(fmt.Sprintf(Error, "invalid content", parsedVal, err))
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	key := "key"
+	parsedVal, err := parseEnum(map[string]string{key: "Prevention"}, key, "detection", "prevention")
+	if err != nil || parsedVal != "prevention" {
+		t.Error(fmt.Sprintf(NoError, "prevention", parsedVal, err))
+	}
+	parsedVal, err = parseEnum(map[string]string{key: "block"}, key, "detection", "prevention")
+	if !errors.IsInvalidContent(err) || err.Error() != `the annotation key has the invalid value "block"; expected one of detection, prevention` {
+		t.Error(fmt.Sprintf(Error, "one of detection, prevention", parsedVal, err))
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	key := "key"
+	parsedVal, err := parseCIDRs(map[string]string{key: "10.0.0.0/8, 192.168.1.1,fd00::/8"}, key)
+	if err != nil || len(parsedVal) != 3 || parsedVal[0].String() != "10.0.0.0/8" || parsedVal[1].String() != "192.168.1.1/32" || parsedVal[2].String() != "fd00::/8" {
+		t.Error(fmt.Sprintf(NoError, "3 ranges", parsedVal, err))
+	}
+	for _, value := range []string{"10.0.0.0/33", "10.0.0", ", "} {
+		parsedVal, err = parseCIDRs(map[string]string{key: value}, key)
+		if !errors.IsInvalidContent(err) {
+			t.Error(fmt.Sprintf(Error, "invalid content", parsedVal, err))
+		}
+	}
+}
+
+func TestParseMissingKey(t *testing.T) {
+	if _, err := parseSeconds(nil, "key", 1, 10); !errors.IsMissingAnnotations(err) {
+		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, nil, err))
+	}
+	if _, err := parseEnum(nil, "key", "a"); !errors.IsMissingAnnotations(err) {
+		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, nil, err))
+	}
+	if _, err := parseCIDRs(nil, "key"); !errors.IsMissingAnnotations(err) {
+		t.Error(fmt.Sprintf(Error, errors.ErrMissingAnnotations, nil, err))
+	}
+}
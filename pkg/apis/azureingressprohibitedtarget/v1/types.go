@@ -20,6 +20,9 @@ type AzureIngressProhibitedTarget struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	Spec AzureIngressProhibitedTargetSpec `json:"spec"`
+
+	// +optional
+	Status AzureIngressProhibitedTargetStatus `json:"status,omitempty"`
 }
 
 // AzureIngressProhibitedTargetSpec defines a list of uniquely identifiable targets for which the AGIC is not allowed to mutate config.
@@ -45,6 +48,21 @@ type AzureIngressProhibitedTargetSpec struct {
 	Paths []string `json:"paths,omitempty"`
 }
 
+// AzureIngressProhibitedTargetStatus is written by the Ingress Controller, to tell whether it honors the prohibited target.
+type AzureIngressProhibitedTargetStatus struct {
+	// +optional
+	// ObservedGeneration is the generation of the spec, which the status was written for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// +optional
+	// Accepted tells whether the Ingress Controller honors the prohibited target as written
+	Accepted bool `json:"accepted"`
+
+	// +optional
+	// Message describes what the prohibited target prohibits, or why it was not accepted
+	Message string `json:"message,omitempty"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // AzureIngressProhibitedTargetList is the list of prohibited targets
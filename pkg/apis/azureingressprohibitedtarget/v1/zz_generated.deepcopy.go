@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 /*
@@ -30,6 +31,7 @@ func (in *AzureIngressProhibitedTarget) DeepCopyInto(out *AzureIngressProhibited
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
 	return
 }
 
@@ -106,3 +108,19 @@ func (in *AzureIngressProhibitedTargetSpec) DeepCopy() *AzureIngressProhibitedTa
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureIngressProhibitedTargetStatus) DeepCopyInto(out *AzureIngressProhibitedTargetStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureIngressProhibitedTargetStatus.
+func (in *AzureIngressProhibitedTargetStatus) DeepCopy() *AzureIngressProhibitedTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureIngressProhibitedTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -13,12 +13,15 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
 	istio_fake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
@@ -416,7 +419,7 @@ var _ = Describe("Tests `appgw.ConfigBuilder`", func() {
 
 		// Create a `k8scontext` to start listiening to ingress resources.
 
-		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, []string{ingressNS}, 1000*time.Second)
+		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), []string{ingressNS}, 1000*time.Second)
 		Expect(ctxt).ShouldNot(BeNil(), "Unable to create `k8scontext`")
 
 		// Initialize the `ConfigBuilder`
@@ -798,6 +801,35 @@ var _ = Describe("Tests `appgw.ConfigBuilder`", func() {
 		})
 	})
 
+	Context("Tests the tags of the Application Gateway", func() {
+		It("Should list the deleted Ingresses whose config is retained, and remove the tag once there are none", func() {
+			cb := newConfigBuilderFixture(nil)
+			cb.addTags(&ConfigBuilderContext{OrphanedIngresses: []string{"shop/checkout", "shop/cart"}})
+			Expect(cb.appGw.Tags).To(HaveKeyWithValue(orphanedK8sIngresses, to.StringPtr("shop/checkout,shop/cart")))
+
+			cb.addTags(&ConfigBuilderContext{})
+			Expect(cb.appGw.Tags).ToNot(HaveKey(orphanedK8sIngresses))
+			Expect(cb.appGw.Tags).To(HaveKey(managedByK8sIngress))
+		})
+
+		It("Should keep the existing config of the deleted Ingresses without turning brownfield deployment on", func() {
+			prohibited := &ptv1.AzureIngressProhibitedTarget{Spec: ptv1.AzureIngressProhibitedTargetSpec{Hostname: "shared.com"}}
+			retained := &ptv1.AzureIngressProhibitedTarget{Spec: ptv1.AzureIngressProhibitedTargetSpec{Hostname: "shop.com"}}
+
+			cbCtx := &ConfigBuilderContext{ProhibitedTargets: []*ptv1.AzureIngressProhibitedTarget{prohibited}}
+			Expect(cbCtx.keepsExistingConfig()).To(BeFalse())
+
+			cbCtx.RetainedTargets = []*ptv1.AzureIngressProhibitedTarget{retained}
+			Expect(cbCtx.keepsExistingConfig()).To(BeTrue())
+			Expect(cbCtx.EnableBrownfieldDeployment).To(BeFalse())
+			Expect(cbCtx.existingTargets()).To(Equal([]*ptv1.AzureIngressProhibitedTarget{retained}))
+
+			cbCtx.EnableBrownfieldDeployment = true
+			Expect(cbCtx.existingTargets()).To(Equal([]*ptv1.AzureIngressProhibitedTarget{prohibited, retained}))
+			Expect(cbCtx.ProhibitedTargets).To(HaveLen(1))
+		})
+	})
+
 	Context("Tests Application Gateway Generate HTTP Settings Name", func() {
 		It("Should be create an Application Gateway Backend Pool Name With Less than 80 Characters", func() {
 			// Start the informers. This will sync the cache with the latest ingress.
@@ -0,0 +1,97 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// Keys of the ConfigMap with the backend defaults. The ones matching annotations are overridden by the annotation of the Ingress;
+// the probe parameters are overridden by the probes of the containers.
+const (
+	defaultRequestTimeoutKey            = "request-timeout"
+	defaultCookieBasedAffinityKey       = "cookie-based-affinity"
+	defaultConnectionDrainingKey        = "connection-draining"
+	defaultConnectionDrainingTimeoutKey = "connection-draining-timeout"
+	defaultProbeIntervalKey             = "health-probe-interval"
+	defaultProbeTimeoutKey              = "health-probe-timeout"
+	defaultProbeUnhealthyThresholdKey   = "health-probe-unhealthy-threshold"
+)
+
+// BackendDefaults are the backend settings applied to all Ingresses, unless an Ingress sets its own. Unset values are nil.
+type BackendDefaults struct {
+	RequestTimeout            *int32
+	CookieBasedAffinity       *bool
+	ConnectionDraining        *bool
+	ConnectionDrainingTimeout *int32
+	ProbeInterval             *int32
+	ProbeTimeout              *int32
+	ProbeUnhealthyThreshold   *int32
+}
+
+// ParseBackendDefaults reads the data of the ConfigMap with the backend defaults.
+// Invalid values and unknown keys are left out, and an error is returned for each of them.
+func ParseBackendDefaults(data map[string]string) (BackendDefaults, []error) {
+	var defaults BackendDefaults
+	ints := map[string]**int32{
+		defaultRequestTimeoutKey:            &defaults.RequestTimeout,
+		defaultConnectionDrainingTimeoutKey: &defaults.ConnectionDrainingTimeout,
+		defaultProbeIntervalKey:             &defaults.ProbeInterval,
+		defaultProbeTimeoutKey:              &defaults.ProbeTimeout,
+		defaultProbeUnhealthyThresholdKey:   &defaults.ProbeUnhealthyThreshold,
+	}
+	bools := map[string]**bool{
+		defaultCookieBasedAffinityKey: &defaults.CookieBasedAffinity,
+		defaultConnectionDrainingKey:  &defaults.ConnectionDraining,
+	}
+
+	var keys []string
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		value := data[key]
+		if field, isInt := ints[key]; isInt {
+			intValue, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || intValue <= 0 {
+				errs = append(errs, fmt.Errorf("backend default %s must be a positive number of seconds or probes, not %q", key, value))
+				continue
+			}
+			*field = to.Int32Ptr(int32(intValue))
+		} else if field, isBool := bools[key]; isBool {
+			boolValue, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("backend default %s must be true or false, not %q", key, value))
+				continue
+			}
+			*field = to.BoolPtr(boolValue)
+		} else {
+			errs = append(errs, fmt.Errorf("unknown backend default %s", key))
+		}
+	}
+	return defaults, errs
+}
+
+// applyToProbe sets the probe parameters of the defaults on a probe, before it is adjusted to the probes of the containers.
+func (d BackendDefaults) applyToProbe(probe *n.ApplicationGatewayProbe) {
+	if d.ProbeInterval != nil {
+		probe.Interval = to.Int32Ptr(*d.ProbeInterval)
+	}
+	if d.ProbeTimeout != nil {
+		probe.Timeout = to.Int32Ptr(*d.ProbeTimeout)
+	}
+	if d.ProbeUnhealthyThreshold != nil {
+		probe.UnhealthyThreshold = to.Int32Ptr(*d.ProbeUnhealthyThreshold)
+	}
+}
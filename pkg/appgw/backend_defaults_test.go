@@ -0,0 +1,120 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test backend defaults", func() {
+	Context("Test ParseBackendDefaults()", func() {
+		It("parses the known keys", func() {
+			defaults, errs := ParseBackendDefaults(map[string]string{
+				"request-timeout":                  "45",
+				"cookie-based-affinity":            "true",
+				"connection-draining":              "false",
+				"connection-draining-timeout":      "10",
+				"health-probe-interval":            "15",
+				"health-probe-timeout":             "5",
+				"health-probe-unhealthy-threshold": "2",
+			})
+			Expect(errs).To(BeEmpty())
+			Expect(defaults).To(Equal(BackendDefaults{
+				RequestTimeout:            to.Int32Ptr(45),
+				CookieBasedAffinity:       to.BoolPtr(true),
+				ConnectionDraining:        to.BoolPtr(false),
+				ConnectionDrainingTimeout: to.Int32Ptr(10),
+				ProbeInterval:             to.Int32Ptr(15),
+				ProbeTimeout:              to.Int32Ptr(5),
+				ProbeUnhealthyThreshold:   to.Int32Ptr(2),
+			}))
+		})
+
+		It("leaves out invalid values and unknown keys", func() {
+			defaults, errs := ParseBackendDefaults(map[string]string{
+				"request-timeout":       "-1",
+				"cookie-based-affinity": "yes please",
+				"request-timeuot":       "30",
+				"health-probe-timeout":  "5",
+			})
+			Expect(defaults).To(Equal(BackendDefaults{ProbeTimeout: to.Int32Ptr(5)}))
+			Expect(errs).To(HaveLen(3))
+			Expect(errs[0].Error()).To(ContainSubstring("cookie-based-affinity must be true or false"))
+			Expect(errs[1].Error()).To(ContainSubstring("request-timeout must be a positive number"))
+			Expect(errs[2].Error()).To(Equal("unknown backend default request-timeuot"))
+		})
+
+		It("has no defaults without a ConfigMap", func() {
+			defaults, errs := ParseBackendDefaults(nil)
+			Expect(defaults).To(Equal(BackendDefaults{}))
+			Expect(errs).To(BeEmpty())
+		})
+	})
+
+	Context("Test the HTTP settings and probes with backend defaults", func() {
+		var cb appGwConfigBuilder
+		var ingress *v1beta1.Ingress
+		var backendID backendIdentifier
+		defaults := BackendDefaults{
+			RequestTimeout:            to.Int32Ptr(45),
+			CookieBasedAffinity:       to.BoolPtr(true),
+			ConnectionDraining:        to.BoolPtr(true),
+			ConnectionDrainingTimeout: to.Int32Ptr(10),
+			ProbeInterval:             to.Int32Ptr(15),
+		}
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			ingress = tests.NewIngressFixture()
+			rule := &ingress.Spec.Rules[0]
+			path := &rule.HTTP.Paths[0]
+			backendID = backendIdentifier{
+				serviceIdentifier: serviceIdentifier{Namespace: tests.Namespace, Name: tests.ServiceName},
+				Ingress:           ingress,
+				Rule:              rule,
+				Path:              path,
+				Backend:           &path.Backend,
+			}
+		})
+
+		It("applies the defaults to Ingresses without annotations", func() {
+			cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}, BackendDefaults: defaults}
+			settings := cb.generateHTTPSettings(backendID, 80, nil, cbCtx)
+			Expect(*settings.RequestTimeout).To(Equal(int32(45)))
+			Expect(settings.CookieBasedAffinity).To(Equal(n.Enabled))
+			Expect(*settings.ConnectionDraining.Enabled).To(BeTrue())
+			Expect(*settings.ConnectionDraining.DrainTimeoutInSec).To(Equal(int32(10)))
+		})
+
+		It("lets the annotations of the Ingress override the defaults", func() {
+			ingress.Annotations[annotations.RequestTimeoutKey] = "90"
+			ingress.Annotations[annotations.CookieBasedAffinityKey] = "false"
+			ingress.Annotations[annotations.ConnectionDrainingKey] = "false"
+			cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}, BackendDefaults: defaults}
+			settings := cb.generateHTTPSettings(backendID, 80, nil, cbCtx)
+			Expect(*settings.RequestTimeout).To(Equal(int32(90)))
+			Expect(settings.CookieBasedAffinity).To(BeEmpty())
+			Expect(settings.ConnectionDraining).To(BeNil())
+		})
+
+		It("applies the probe parameters to the probes", func() {
+			_ = cb.k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))
+			probe := cb.generateHealthProbe(backendID, defaults)
+			Expect(probe).ToNot(BeNil())
+			Expect(*probe.Interval).To(Equal(int32(15)))
+			Expect(*probe.Timeout).To(Equal(int32(30)))
+		})
+	})
+})
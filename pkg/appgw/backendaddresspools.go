@@ -36,7 +36,7 @@ func (c appGwConfigBuilder) getPools(cbCtx *ConfigBuilderContext) []n.Applicatio
 	_, _, serviceBackendPairMap, _ := c.getBackendsAndSettingsMap(cbCtx)
 	for backendID, serviceBackendPair := range serviceBackendPairMap {
 		glog.V(5).Info("Constructing backend pool for service:", backendID.serviceKey())
-		if pool := c.getBackendAddressPool(backendID, serviceBackendPair, managedPoolsByName); pool != nil {
+		if pool := c.getBackendAddressPool(backendID, serviceBackendPair, managedPoolsByName, cbCtx); pool != nil {
 			managedPoolsByName[*pool.Name] = pool
 		}
 	}
@@ -56,8 +56,8 @@ func (c appGwConfigBuilder) getPools(cbCtx *ConfigBuilderContext) []n.Applicatio
 		agicCreatedPools = append(agicCreatedPools, *managedPool)
 	}
 
-	if cbCtx.EnableBrownfieldDeployment {
-		er := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, &defaultPool)
+	if cbCtx.keepsExistingConfig() {
+		er := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), &defaultPool)
 
 		// Split the existing pools we obtained from App Gateway into ones AGIC is and is not allowed to change.
 		existingBlacklisted, existingNonBlacklisted := er.GetBlacklistedPools()
@@ -81,7 +81,7 @@ func (c *appGwConfigBuilder) newBackendPoolMap(cbCtx *ConfigBuilderContext) map[
 	_, _, serviceBackendPairMap, _ := c.getBackendsAndSettingsMap(cbCtx)
 	for backendID, serviceBackendPair := range serviceBackendPairMap {
 		backendPoolMap[backendID] = &defaultPool
-		if pool := c.getBackendAddressPool(backendID, serviceBackendPair, addressPools); pool != nil {
+		if pool := c.getBackendAddressPool(backendID, serviceBackendPair, addressPools, cbCtx); pool != nil {
 			backendPoolMap[backendID] = pool
 		}
 	}
@@ -101,7 +101,7 @@ func (c *appGwConfigBuilder) getIstioBackendAddressPool(destinationID istioDesti
 		if _, portExists := getUniqueTCPPorts(subset)[serviceBackendPair.BackendPort]; portExists {
 			backendServicePort := ""
 			if destinationID.Destination.Port.Number != 0 {
-				backendServicePort = string(destinationID.Destination.Port.Number)
+				backendServicePort = fmt.Sprint(destinationID.Destination.Port.Number)
 			} else {
 				backendServicePort = destinationID.Destination.Port.Name
 			}
@@ -118,8 +118,8 @@ func (c *appGwConfigBuilder) getIstioBackendAddressPool(destinationID istioDesti
 	return nil
 }
 
-func (c *appGwConfigBuilder) getBackendAddressPool(backendID backendIdentifier, serviceBackendPair serviceBackendPortPair, addressPools map[string]*n.ApplicationGatewayBackendAddressPool) *n.ApplicationGatewayBackendAddressPool {
-	endpoints, err := c.k8sContext.GetEndpointsByService(backendID.serviceKey())
+func (c *appGwConfigBuilder) getBackendAddressPool(backendID backendIdentifier, serviceBackendPair serviceBackendPortPair, addressPools map[string]*n.ApplicationGatewayBackendAddressPool, cbCtx *ConfigBuilderContext) *n.ApplicationGatewayBackendAddressPool {
+	endpoints, err := c.getEndpoints(backendID)
 	if err != nil {
 		logLine := fmt.Sprintf("Failed fetching endpoints for service: %s", backendID.serviceKey())
 		glog.Errorf(logLine)
@@ -129,12 +129,21 @@ func (c *appGwConfigBuilder) getBackendAddressPool(backendID backendIdentifier,
 
 	for _, subset := range endpoints.Subsets {
 		if _, portExists := getUniqueTCPPorts(subset)[serviceBackendPair.BackendPort]; portExists {
-			poolName := generateAddressPoolName(backendID.serviceFullName(), backendID.Backend.ServicePort.String(), serviceBackendPair.BackendPort)
+			poolName := backendID.addressPoolName(serviceBackendPair.BackendPort)
 			// The same service might be referenced in multiple ingress resources, this might result in multiple `serviceBackendPairMap` having the same service key but different
 			// ingress resource. Thus, while generating the backend address pool, we should make sure that we are generating unique backend address pools.
 			if pool, ok := addressPools[poolName]; ok {
 				return pool
 			}
+			if c.excludesVirtualNodePods(backendID.serviceKey(), cbCtx) {
+				subset = c.dropAddressesOnNodes(backendID.serviceKey(), subset, "virtual nodes", isVirtualNode)
+			}
+			if exclusion := c.getNodeExclusion(cbCtx); exclusion != nil {
+				subset = c.dropAddressesOnNodes(backendID.serviceKey(), subset, "excluded nodes", exclusion.matches)
+			}
+			if cbCtx.EnvVariables.EnableZoneAwareBackends == "true" {
+				subset = c.keepAddressesInZones(subset)
+			}
 			return newPool(poolName, subset)
 		}
 		logLine := fmt.Sprintf("Backend target port %d does not have matching endpoint port", serviceBackendPair.BackendPort)
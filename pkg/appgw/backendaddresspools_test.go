@@ -13,6 +13,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
 
@@ -134,7 +135,7 @@ var _ = Describe("Test the creation of Backend Pools from Ingress definition", f
 		}
 
 		// -- Action --
-		actual := cb.getBackendAddressPool(backendID, serviceBackendPair, addressPools)
+		actual := cb.getBackendAddressPool(backendID, serviceBackendPair, addressPools, &ConfigBuilderContext{EnvVariables: environment.GetFakeEnv()})
 
 		It("should have constructed correct ApplicationGatewayBackendAddressPool", func() {
 			// The order here is deliberate -- ensure this is properly sorted
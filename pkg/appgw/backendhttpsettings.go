@@ -31,8 +31,8 @@ const (
 func (c *appGwConfigBuilder) BackendHTTPSettingsCollection(cbCtx *ConfigBuilderContext) error {
 	agicHTTPSettings, _, _, err := c.getBackendsAndSettingsMap(cbCtx)
 
-	if cbCtx.EnableBrownfieldDeployment {
-		rCtx := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
+	if cbCtx.keepsExistingConfig() {
+		rCtx := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), nil)
 		allExistingSettings := rCtx.HTTPSettings
 
 		// PathMaps we obtained from App Gateway - we segment them into ones AGIC is and is not allowed to change.
@@ -42,7 +42,12 @@ func (c *appGwConfigBuilder) BackendHTTPSettingsCollection(cbCtx *ConfigBuilderC
 
 		// MergePathMaps would produce unique list of routing rules based on Name. Routing rules, which have the same name
 		// as a managed rule would be overwritten.
-		agicHTTPSettings = brownfield.MergeHTTPSettings(allExistingSettings, agicHTTPSettings)
+		if cbCtx.EnableBrownfieldDeployment {
+			agicHTTPSettings = brownfield.MergeHTTPSettings(allExistingSettings, agicHTTPSettings)
+		} else {
+			// Only the settings of the config retained for the deleted Ingresses are kept.
+			agicHTTPSettings = brownfield.MergeHTTPSettings(existingBlacklisted, agicHTTPSettings)
+		}
 	}
 	if cbCtx.EnableIstioIntegration {
 		istioHTTPSettings, _, _, _ := c.getIstioDestinationsAndSettingsMap(cbCtx)
@@ -88,7 +93,7 @@ func newBackendIdsFiltered(cbCtx *ConfigBuilderContext) map[backendIdentifier]in
 	serviceSet := newServiceSet(&cbCtx.ServiceList)
 	// Filter out backends, where Ingresses reference non-existent Services
 	for be := range backendIDs {
-		if _, exists := serviceSet[be.serviceKey()]; !exists {
+		if _, exists := serviceSet[be.serviceKey()]; !exists && podSelector(be) == nil {
 			glog.Errorf("Ingress %s/%s references non existent Service %s. Please correct the Service section of your Kubernetes YAML", be.Ingress.Namespace, be.Ingress.Name, be.serviceKey())
 			// TODO(draychev): Enable this filter when we are certain this won't break anything!
 			// continue
@@ -170,7 +175,7 @@ func (c *appGwConfigBuilder) getIstioDestinationsAndSettingsMap(cbCtx *ConfigBui
 				if sp.Port == destinationPortNum ||
 					sp.Name == destinationID.Destination.Port.Name ||
 					sp.TargetPort.String() == destinationID.Destination.Port.Name ||
-					sp.TargetPort.String() == string(destinationPortNum) {
+					sp.TargetPort.String() == fmt.Sprint(destinationPortNum) {
 					// matched a service port with a port from the service
 
 					if sp.TargetPort.String() == "" {
@@ -237,7 +242,7 @@ func (c *appGwConfigBuilder) getIstioDestinationsAndSettingsMap(cbCtx *ConfigBui
 			// more than one possible backend port exposed through ingress
 			backendServicePort := ""
 			if destinationID.Destination.Port.Number != 0 {
-				backendServicePort = string(destinationID.Destination.Port.Number)
+				backendServicePort = fmt.Sprint(destinationID.Destination.Port.Number)
 			} else {
 				backendServicePort = destinationID.Destination.Port.Name
 			}
@@ -277,7 +282,7 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 	for backendID := range newBackendIdsFiltered(cbCtx) {
 		resolvedBackendPorts := make(map[serviceBackendPortPair]interface{})
 
-		service := c.k8sContext.GetService(backendID.serviceKey())
+		service := c.getService(backendID)
 		if service == nil {
 			// This should never happen since newBackendIdsFiltered() already filters out backends for non-existent Services
 			logLine := fmt.Sprintf("Unable to get the service [%s]", backendID.serviceKey())
@@ -357,6 +362,7 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 		return nil, nil, nil, errors.New("unable to resolve backend port for some services")
 	}
 
+	_, probesMap := c.newProbesMap(cbCtx)
 	httpSettingsCollection := make(map[string]n.ApplicationGatewayBackendHTTPSettings)
 	defaultBackend := defaultBackendHTTPSettings(c.appGwIdentifier, defaultProbeName)
 	httpSettingsCollection[*defaultBackend.Name] = defaultBackend
@@ -379,7 +385,14 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 		}
 
 		finalServiceBackendPairMap[backendID] = uniquePair
-		httpSettings := c.generateHTTPSettings(backendID, uniquePair.BackendPort, cbCtx)
+		// Existing HTTP settings named by the Ingress are kept as they are.
+		if existing := c.getReferencedHTTPSettings(backendID); existing != nil {
+			glog.V(5).Infof("Using existing HTTP settings %s for backend: '%s'", *existing.Name, backendID.Name)
+			httpSettingsCollection[*existing.Name] = *existing
+			backendHTTPSettingsMap[backendID] = existing
+			continue
+		}
+		httpSettings := c.generateHTTPSettings(backendID, uniquePair.BackendPort, probesMap[backendID], cbCtx)
 		httpSettingsCollection[*httpSettings.Name] = httpSettings
 		backendHTTPSettingsMap[backendID] = &httpSettings
 	}
@@ -392,8 +405,8 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 	return httpSettings, backendHTTPSettingsMap, finalServiceBackendPairMap, nil
 }
 
-func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, port int32, cbCtx *ConfigBuilderContext) n.ApplicationGatewayBackendHTTPSettings {
-	httpSettingsName := generateHTTPSettingsName(backendID.serviceFullName(), backendID.Backend.ServicePort.String(), port, backendID.Ingress.Name)
+func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, port int32, probe *n.ApplicationGatewayProbe, cbCtx *ConfigBuilderContext) n.ApplicationGatewayBackendHTTPSettings {
+	httpSettingsName := backendID.httpSettingsName(port)
 	glog.V(5).Infof("Created a new HTTP setting w/ name: %s\n", httpSettingsName)
 	httpSettings := n.ApplicationGatewayBackendHTTPSettings{
 		Etag: to.StringPtr("*"),
@@ -405,11 +418,8 @@ func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, p
 		},
 	}
 
-	_, probesMap := c.newProbesMap(cbCtx)
-
-	if probesMap[backendID] != nil {
-		probeName := probesMap[backendID].Name
-		probeID := c.appGwIdentifier.probeID(*probeName)
+	if probe != nil {
+		probeID := c.appGwIdentifier.probeID(*probe.Name)
 		httpSettings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat.Probe = resourceRef(probeID)
 	}
 
@@ -417,24 +427,38 @@ func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, p
 		httpSettings.Path = to.StringPtr(pathPrefix)
 	}
 
-	if isConnDrain, err := annotations.IsConnectionDraining(backendID.Ingress); err == nil && isConnDrain {
+	// Annotations of the Ingress override the backend defaults.
+	defaults := cbCtx.BackendDefaults
+	connDrain := defaults.ConnectionDraining != nil && *defaults.ConnectionDraining
+	if isConnDrain, err := annotations.IsConnectionDraining(backendID.Ingress); err == nil {
+		connDrain = isConnDrain
+	}
+	if connDrain {
 		httpSettings.ConnectionDraining = &n.ApplicationGatewayConnectionDraining{
 			Enabled: to.BoolPtr(true),
 		}
 
 		if connDrainTimeout, err := annotations.ConnectionDrainingTimeout(backendID.Ingress); err == nil {
 			httpSettings.ConnectionDraining.DrainTimeoutInSec = to.Int32Ptr(connDrainTimeout)
+		} else if defaults.ConnectionDrainingTimeout != nil {
+			httpSettings.ConnectionDraining.DrainTimeoutInSec = to.Int32Ptr(*defaults.ConnectionDrainingTimeout)
 		} else {
 			httpSettings.ConnectionDraining.DrainTimeoutInSec = to.Int32Ptr(DefaultConnDrainTimeoutInSec)
 		}
 	}
 
-	if affinity, err := annotations.IsCookieBasedAffinity(backendID.Ingress); err == nil && affinity {
+	affinity := defaults.CookieBasedAffinity != nil && *defaults.CookieBasedAffinity
+	if isAffinity, err := annotations.IsCookieBasedAffinity(backendID.Ingress); err == nil {
+		affinity = isAffinity
+	}
+	if affinity {
 		httpSettings.CookieBasedAffinity = n.Enabled
 	}
 
 	if reqTimeout, err := annotations.RequestTimeout(backendID.Ingress); err == nil {
 		httpSettings.RequestTimeout = to.Int32Ptr(reqTimeout)
+	} else if defaults.RequestTimeout != nil {
+		httpSettings.RequestTimeout = to.Int32Ptr(*defaults.RequestTimeout)
 	}
 
 	return httpSettings
@@ -443,7 +467,7 @@ func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, p
 func (c *appGwConfigBuilder) generateIstioHTTPSettings(destinationID istioDestinationIdentifier, port int32, cbCtx *ConfigBuilderContext) n.ApplicationGatewayBackendHTTPSettings {
 	backendServicePort := ""
 	if destinationID.Destination.Port.Number != 0 {
-		backendServicePort = string(destinationID.Destination.Port.Number)
+		backendServicePort = fmt.Sprint(destinationID.Destination.Port.Number)
 	} else {
 		backendServicePort = destinationID.Destination.Port.Name
 	}
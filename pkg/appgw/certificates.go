@@ -15,9 +15,11 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
 
 // getSslCertificates obtains all SSL Certificates for the given Ingress object.
@@ -30,15 +32,43 @@ func (c *appGwConfigBuilder) getSslCertificates(cbCtx *ConfigBuilderContext) *[]
 		}
 	}
 
+	// The default certificate is only installed when a listener uses it.
+	for _, config := range c.getListenerConfigs(cbCtx.IngressList) {
+		if config.Protocol != n.HTTPS {
+			continue
+		}
+		if _, exists := secretIDCertificateMap[config.Secret]; exists {
+			continue
+		}
+		if cert, secID := c.getDefaultCertificate(); secID != nil && *secID == config.Secret {
+			secretIDCertificateMap[*secID] = cert
+		}
+	}
+
 	var sslCertificates []n.ApplicationGatewaySslCertificate
 	for secretID, cert := range secretIDCertificateMap {
 		sslCertificates = append(sslCertificates, c.newCert(secretID, cert))
 	}
 
+	// Existing certificates of the listeners named by the Ingresses are kept as they are.
+	for _, listener := range c.getReferencedListeners(cbCtx.IngressList) {
+		if cert := c.getCertificateOfReferencedListener(listener); cert != nil && !hasCertificate(sslCertificates, *cert.Name) {
+			sslCertificates = append(sslCertificates, *cert)
+		}
+	}
+
 	if cbCtx.EnableBrownfieldDeployment {
 		// MergePools would produce unique list of pools based on Name. Blacklisted pools, which have the same name
 		// as a managed pool would be overwritten.
 		sslCertificates = brownfield.MergeCerts(*c.appGw.SslCertificates, sslCertificates)
+	} else if len(cbCtx.RetainedTargets) > 0 {
+		// Only the certificates of the listeners retained for the deleted Ingresses are kept.
+		retainedListeners, _ := brownfield.NewExistingResources(c.appGw, cbCtx.RetainedTargets, nil).GetBlacklistedListeners()
+		for _, listener := range retainedListeners {
+			if cert := c.getCertificateOfReferencedListener(listener); cert != nil && !hasCertificate(sslCertificates, *cert.Name) {
+				sslCertificates = append(sslCertificates, *cert)
+			}
+		}
 	}
 
 	sort.Sort(sorter.ByCertificateName(sslCertificates))
@@ -78,7 +108,10 @@ func (c *appGwConfigBuilder) getCertificate(ingress *v1beta1.Ingress, hostname s
 		secID, exists = hostnameSecretIDMap[""]
 	}
 	if !exists {
-		// no wildcard or matched certificate
+		// no wildcard or matched certificate; Ingresses meant to be served over HTTPS fall back to the default certificate
+		if httpsOnly, _ := annotations.IsHTTPSOnly(ingress); len(ingress.Spec.TLS) > 0 || httpsOnly {
+			return c.getDefaultCertificate()
+		}
 		return nil, nil
 	}
 
@@ -90,6 +123,20 @@ func (c *appGwConfigBuilder) getCertificate(ingress *v1beta1.Ingress, hostname s
 	return cert, &secID
 }
 
+// getDefaultCertificate returns the certificate configured with APPGW_DEFAULT_SSL_CERTIFICATE, or nil when there is none,
+// or its secret is not found.
+func (c *appGwConfigBuilder) getDefaultCertificate() (*string, *secretIdentifier) {
+	if c.k8sContext.DefaultSSLCertificate == "" {
+		return nil, nil
+	}
+	cert := c.k8sContext.CertificateSecretStore.GetPfxCertificate(c.k8sContext.DefaultSSLCertificate)
+	if cert == nil {
+		return nil, nil
+	}
+	namespace, name := utils.ParseResourceKey(c.k8sContext.DefaultSSLCertificate)
+	return to.StringPtr(base64.StdEncoding.EncodeToString(cert)), &secretIdentifier{Namespace: namespace, Name: name}
+}
+
 func (c *appGwConfigBuilder) newHostToSecretMap(ingress *v1beta1.Ingress) map[string]secretIdentifier {
 	hostToSecretMap := make(map[string]secretIdentifier)
 	for _, tls := range ingress.Spec.TLS {
@@ -136,3 +183,12 @@ func (c *appGwConfigBuilder) newCert(secretID secretIdentifier, cert *string) n.
 		},
 	}
 }
+
+func hasCertificate(certificates []n.ApplicationGatewaySslCertificate, name string) bool {
+	for _, cert := range certificates {
+		if cert.Name != nil && *cert.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,12 @@
 package appgw
 
 import (
-	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
 
 // appgw_suite_test.go launches these Ginkgo tests
@@ -76,4 +79,53 @@ var _ = Describe("Testing function newHostToSecretMap", func() {
 			Expect(*actualSecretID).To(Equal(expectedSecret))
 		})
 	})
+
+	Context("Test falling back to the default certificate", func() {
+		const unknownHost = "unknown.contoso.com"
+		defaultSecret := secretIdentifier{
+			Namespace: tests.Namespace,
+			Name:      "default-tls",
+		}
+
+		var cb appGwConfigBuilder
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(&map[string]interface{}{defaultSecret.secretKey(): []byte("abc")})
+			cb.k8sContext.DefaultSSLCertificate = defaultSecret.secretKey()
+		})
+
+		It("should use the default certificate for a host without a matching TLS section", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Spec.TLS = ingress.Spec.TLS[:1]
+			ingress.Spec.TLS[0].Hosts = []string{host1}
+			actualSecret, actualSecretID := cb.getCertificate(ingress, unknownHost, cb.newHostToSecretMap(ingress))
+			Expect(*actualSecret).To(Equal("YWJj"))
+			Expect(*actualSecretID).To(Equal(defaultSecret))
+		})
+
+		It("should not use the default certificate for an Ingress without TLS sections", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Spec.TLS = nil
+			actualSecret, actualSecretID := cb.getCertificate(ingress, unknownHost, cb.newHostToSecretMap(ingress))
+			Expect(actualSecret).To(BeNil())
+			Expect(actualSecretID).To(BeNil())
+		})
+
+		It("should use the default certificate for an Ingress annotated with https-only", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Spec.TLS = nil
+			ingress.Annotations[annotations.HTTPSOnlyKey] = "true"
+			_, actualSecretID := cb.getCertificate(ingress, unknownHost, cb.newHostToSecretMap(ingress))
+			Expect(*actualSecretID).To(Equal(defaultSecret))
+		})
+
+		It("should install the default certificate only when a listener uses it", func() {
+			ingress := tests.NewIngressFixture()
+			cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}}
+			Expect(hasCertificate(*cb.getSslCertificates(cbCtx), defaultSecret.secretFullName())).To(BeFalse())
+
+			ingress.Spec.TLS = ingress.Spec.TLS[:1]
+			ingress.Spec.TLS[0].Hosts = []string{host1}
+			Expect(hasCertificate(*cb.getSslCertificates(cbCtx), defaultSecret.secretFullName())).To(BeTrue())
+		})
+	})
 })
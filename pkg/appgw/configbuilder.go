@@ -8,6 +8,7 @@ package appgw
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -48,6 +49,11 @@ func NewConfigBuilder(context *k8scontext.Context, appGwIdentifier *Identifier,
 
 // Build gets a pointer to updated ApplicationGatewayPropertiesFormat.
 func (c *appGwConfigBuilder) Build(cbCtx *ConfigBuilderContext) (*n.ApplicationGateway, error) {
+	c.excludeResources(cbCtx)
+	c.reportInvalidAnnotations(cbCtx)
+	c.reportMissingReferences(cbCtx)
+	c.reportHTTPSOnlyRulesWithoutTLS(cbCtx)
+
 	glog.V(5).Infof("-----Generating Probes-----")
 	err := c.HealthProbesCollection(cbCtx)
 	if err != nil {
@@ -89,16 +95,34 @@ func (c *appGwConfigBuilder) Build(cbCtx *ConfigBuilderContext) (*n.ApplicationG
 		return nil, errors.New("unable to generate request routing rules")
 	}
 
-	c.addTags()
+	c.configureGRPCBackends(cbCtx)
+	c.addTags(cbCtx)
+	c.configureWAFBody(cbCtx)
+	c.adjustForSku()
 
 	return &c.appGw, nil
 }
 
 type valFunc func(eventRecorder record.EventRecorder, config *n.ApplicationGatewayPropertiesFormat, envVariables environment.EnvVariables, ingressList []*v1beta1.Ingress, serviceList []*v1.Service) error
 
+// excludeResources removes the Ingresses and Istio Gateways violating the controller's policies, and the paths claimed by
+// older Ingresses, from the config builder context before the config is built. A policy, which cannot be parsed, is not
+// enforced; the other policies still are.
+func (c *appGwConfigBuilder) excludeResources(cbCtx *ConfigBuilderContext) {
+	if err := c.excludeDisallowedFrontendPorts(cbCtx); err != nil {
+		glog.Errorf("Unable to parse the allowed frontend ports; no frontend port is excluded: %s", err)
+	}
+	c.excludeForeignHostnames(cbCtx)
+	if err := c.excludeIngressesOverQuota(cbCtx); err != nil {
+		glog.Errorf("Unable to parse the namespace quotas; no Ingress is excluded over quota: %s", err)
+	}
+	c.mergeIngresses(cbCtx)
+}
+
 // PreBuildValidate runs all the validators that suggest misconfiguration in Kubernetes resources.
+// It does not change the config builder context; the policies excluding resources, such as namespace quotas, are
+// enforced by Build.
 func (c *appGwConfigBuilder) PreBuildValidate(cbCtx *ConfigBuilderContext) error {
-
 	validationFunctions := []valFunc{
 		validateServiceDefinition,
 	}
@@ -129,7 +153,7 @@ func (c *appGwConfigBuilder) runValidationFunctions(cbCtx *ConfigBuilderContext,
 // look for possible port number corresponding to a port name
 func (c *appGwConfigBuilder) resolvePortName(portName string, backendID *backendIdentifier) map[int32]interface{} {
 	resolvedPorts := make(map[int32]interface{})
-	endpoints, err := c.k8sContext.GetEndpointsByService(backendID.serviceKey())
+	endpoints, err := c.getEndpoints(*backendID)
 	if err != nil {
 		glog.Error("Could not fetch endpoint by service key from cache", err)
 		return resolvedPorts
@@ -221,10 +245,21 @@ func generateListenerID(rule *v1beta1.IngressRule,
 }
 
 // addTags will add certain tags to Application Gateway
-func (c *appGwConfigBuilder) addTags() {
+func (c *appGwConfigBuilder) addTags(cbCtx *ConfigBuilderContext) {
 	if c.appGw.Tags == nil {
 		c.appGw.Tags = make(map[string]*string)
 	}
 	// Identify the App Gateway as being exclusively managed by a Kubernetes Ingress.
 	c.appGw.Tags[managedByK8sIngress] = to.StringPtr(fmt.Sprintf("%s/%s/%s", version.Version, version.GitCommit, version.BuildDate))
+
+	// List the deleted Ingresses, whose config is retained, so they are visible in Azure as well.
+	if len(cbCtx.OrphanedIngresses) == 0 {
+		delete(c.appGw.Tags, orphanedK8sIngresses)
+		return
+	}
+	orphans := strings.Join(cbCtx.OrphanedIngresses, ",")
+	if len(orphans) > maxTagValueLength {
+		orphans = orphans[:maxTagValueLength-3] + "..."
+	}
+	c.appGw.Tags[orphanedK8sIngresses] = to.StringPtr(orphans)
 }
@@ -0,0 +1,98 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"testing"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/generator"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// Run with: go test -run=^$ -bench=Build -benchmem ./pkg/appgw/
+// scripts/benchmark.sh compares the results with the baseline in .pipelines/benchmarks.
+func BenchmarkBuild(b *testing.B) {
+	// The tests of the package log verbosely; the benchmarks measure the build, not the logging.
+	_ = flag.Set("v", "0")
+	for _, opts := range []generator.Options{
+		{Namespaces: 1, IngressesPerNamespace: 10, PathsPerIngress: 1, EndpointsPerService: 2},
+		{Namespaces: 2, IngressesPerNamespace: 10, PathsPerIngress: 2, EndpointsPerService: 3},
+		{Namespaces: 4, IngressesPerNamespace: 10, PathsPerIngress: 2, EndpointsPerService: 3},
+		{Namespaces: 10, IngressesPerNamespace: 100, PathsPerIngress: 2, EndpointsPerService: 3},
+		{Namespaces: 20, IngressesPerNamespace: 100, PathsPerIngress: 2, EndpointsPerService: 3},
+	} {
+		opts := opts
+		name := fmt.Sprintf("ingresses=%d/paths=%d/endpoints=%d", opts.Namespaces*opts.IngressesPerNamespace, opts.PathsPerIngress, opts.EndpointsPerService)
+		b.Run(name, func(b *testing.B) {
+			benchmarkBuild(b, generator.Generate(opts))
+		})
+	}
+}
+
+func benchmarkBuild(b *testing.B, cluster *generator.Cluster) {
+	k8sContext := &k8scontext.Context{
+		Caches: &k8scontext.CacheCollection{
+			Endpoints: cache.NewStore(cache.MetaNamespaceKeyFunc),
+			Secret:    cache.NewStore(cache.MetaNamespaceKeyFunc),
+			Service:   cache.NewStore(cache.MetaNamespaceKeyFunc),
+			Pods:      cache.NewStore(cache.MetaNamespaceKeyFunc),
+			Ingress:   cache.NewStore(cache.MetaNamespaceKeyFunc),
+		},
+		CertificateSecretStore: newSecretStoreFixture(nil),
+	}
+	for _, obj := range cluster.Services {
+		_ = k8sContext.Caches.Service.Add(obj)
+	}
+	for _, obj := range cluster.Endpoints {
+		_ = k8sContext.Caches.Endpoints.Add(obj)
+	}
+	for _, obj := range cluster.Pods {
+		_ = k8sContext.Caches.Pods.Add(obj)
+	}
+	for _, obj := range cluster.Ingresses {
+		_ = k8sContext.Caches.Ingress.Add(obj)
+	}
+
+	cbCtx := &ConfigBuilderContext{
+		IngressList:  cluster.Ingresses,
+		ServiceList:  cluster.Services,
+		EnvVariables: environment.GetFakeEnv(),
+	}
+	appGwIdentifier := Identifier{
+		SubscriptionID: tests.Subscription,
+		ResourceGroup:  tests.ResourceGroup,
+		AppGwName:      tests.AppGwName,
+	}
+
+	var appGw *n.ApplicationGateway
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		appGwConfig := newAppGwyConfigFixture()
+		original := n.ApplicationGateway{ApplicationGatewayPropertiesFormat: &appGwConfig}
+		configBuilder := NewConfigBuilder(k8sContext, &appGwIdentifier, &original, &record.FakeRecorder{})
+		var err error
+		if appGw, err = configBuilder.Build(cbCtx); err != nil {
+			b.Fatalf("Build() failed: %s", err)
+		}
+	}
+	b.StopTimer()
+
+	jsonConfig, err := json.Marshal(appGw)
+	if err != nil {
+		b.Fatalf("unable to marshal App Gateway config: %s", err)
+	}
+	b.Logf("App Gateway config: %d bytes", len(jsonConfig))
+}
@@ -2,3 +2,9 @@ package appgw
 
 // An App Gateway tag: Resources tagged with this are exclusively managed by a Kubernetes Ingress.
 const managedByK8sIngress = "managed-by-k8s-ingress"
+
+// An App Gateway tag: the deleted Ingresses, whose listeners and rules AGIC retains because of their orphan policy.
+const orphanedK8sIngresses = "orphaned-k8s-ingresses"
+
+// maxTagValueLength is the longest value of an Azure tag.
+const maxTagValueLength = 256
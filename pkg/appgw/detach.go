@@ -0,0 +1,285 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+)
+
+// ErrNoRulesAfterDetach is returned when every request routing rule of the App Gateway was created by AGIC; an App Gateway
+// must have at least one, so such an App Gateway is deleted rather than detached.
+var ErrNoRulesAfterDetach = errors.New("all request routing rules of the App Gateway were created by AGIC, and an App Gateway must have at least one")
+
+// agicNamePrefixes are the prefixes of the names of the App Gateway resources AGIC generates.
+var agicNamePrefixes = []string{
+	prefixHTTPSettings,
+	prefixProbe,
+	prefixPool,
+	prefixPort,
+	prefixListener,
+	prefixPathMap,
+	prefixRoutingRule,
+	prefixRedirect,
+}
+
+// isAGICName tells whether the name of an App Gateway resource is one AGIC generates.
+func isAGICName(name *string) bool {
+	if name == nil {
+		return false
+	}
+	switch *name {
+	case defaultBackendAddressPoolName, defaultBackendHTTPSettingsName, defaultProbeName:
+		return true
+	}
+	for _, prefix := range agicNamePrefixes {
+		if strings.HasPrefix(*name, agPrefix+prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// detachKey identifies a sub-resource by its collection and name, as in "backendAddressPools/pool-web".
+func detachKey(collection string, name *string) string {
+	if name == nil {
+		return ""
+	}
+	return strings.ToLower(collection + "/" + *name)
+}
+
+// detachRefKey is the detachKey of the sub-resource a reference points to.
+func detachRefKey(ref *n.SubResource) string {
+	if ref == nil || ref.ID == nil {
+		return ""
+	}
+	chunks := strings.Split(*ref.ID, "/")
+	if len(chunks) < 2 {
+		return ""
+	}
+	return detachKey(chunks[len(chunks)-2], &chunks[len(chunks)-1])
+}
+
+// detachGraph holds the sub-resources of the App Gateway, which of them AGIC created, and what each references.
+type detachGraph struct {
+	created map[string]bool
+	refs    map[string][]string
+}
+
+func (g detachGraph) add(key string, created bool, refs ...*n.SubResource) {
+	g.created[key] = created
+	for _, ref := range refs {
+		if refKey := detachRefKey(ref); refKey != "" {
+			g.refs[key] = append(g.refs[key], refKey)
+		}
+	}
+}
+
+// GetDetachedConfig returns the App Gateway config without the resources AGIC created, so AGIC can be removed from a shared
+// App Gateway. Resources named the way AGIC names them are removed, unless the prohibited targets keep them, or a resource
+// which is kept references them. Certificates are removed when only removed listeners used them. The tags AGIC adds to the
+// App Gateway are removed as well. The removed resources are returned, as in "httpListeners/fl-contoso.com-80".
+func GetDetachedConfig(appGw n.ApplicationGateway, prohibitedTargets []*ptv1.AzureIngressProhibitedTarget) (n.ApplicationGateway, []string, error) {
+	if appGw.ApplicationGatewayPropertiesFormat == nil {
+		return appGw, nil, brownfield.ErrNoGatewayProperties
+	}
+
+	graph := detachGraph{created: make(map[string]bool), refs: make(map[string][]string)}
+	existing := brownfield.NewExistingResources(appGw, prohibitedTargets, nil)
+	for _, pool := range existing.BackendPools {
+		graph.add(detachKey("backendAddressPools", pool.Name), isAGICName(pool.Name))
+	}
+	for _, probe := range existing.Probes {
+		graph.add(detachKey("probes", probe.Name), isAGICName(probe.Name))
+	}
+	for _, port := range existing.Ports {
+		graph.add(detachKey("frontendPorts", port.Name), isAGICName(port.Name))
+	}
+	for _, settings := range existing.HTTPSettings {
+		var probe *n.SubResource
+		if settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat != nil {
+			probe = settings.Probe
+		}
+		graph.add(detachKey("backendHttpSettingsCollection", settings.Name), isAGICName(settings.Name), probe)
+	}
+	certsOfAGICListeners := make(map[string]bool)
+	for _, listener := range existing.Listeners {
+		var port, cert *n.SubResource
+		if listener.ApplicationGatewayHTTPListenerPropertiesFormat != nil {
+			port, cert = listener.FrontendPort, listener.SslCertificate
+		}
+		graph.add(detachKey("httpListeners", listener.Name), isAGICName(listener.Name), port, cert)
+		if isAGICName(listener.Name) && cert != nil {
+			certsOfAGICListeners[detachRefKey(cert)] = true
+		}
+	}
+	for _, cert := range existing.Certificates {
+		key := detachKey("sslCertificates", cert.Name)
+		graph.add(key, certsOfAGICListeners[key])
+	}
+	if appGw.RedirectConfigurations != nil {
+		for _, redirect := range *appGw.RedirectConfigurations {
+			var target *n.SubResource
+			if redirect.ApplicationGatewayRedirectConfigurationPropertiesFormat != nil {
+				target = redirect.TargetListener
+			}
+			graph.add(detachKey("redirectConfigurations", redirect.Name), isAGICName(redirect.Name), target)
+		}
+	}
+	for _, pathMap := range existing.URLPathMaps {
+		var refs []*n.SubResource
+		if pathMap.ApplicationGatewayURLPathMapPropertiesFormat != nil {
+			refs = append(refs, pathMap.DefaultBackendAddressPool, pathMap.DefaultBackendHTTPSettings, pathMap.DefaultRedirectConfiguration)
+			if pathMap.PathRules != nil {
+				for _, pathRule := range *pathMap.PathRules {
+					if pathRule.ApplicationGatewayPathRulePropertiesFormat != nil {
+						refs = append(refs, pathRule.BackendAddressPool, pathRule.BackendHTTPSettings, pathRule.RedirectConfiguration)
+					}
+				}
+			}
+		}
+		graph.add(detachKey("urlPathMaps", pathMap.Name), isAGICName(pathMap.Name), refs...)
+	}
+	for _, rule := range existing.RoutingRules {
+		var refs []*n.SubResource
+		if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat != nil {
+			refs = append(refs, rule.HTTPListener, rule.BackendAddressPool, rule.BackendHTTPSettings, rule.URLPathMap, rule.RedirectConfiguration)
+		}
+		graph.add(detachKey("requestRoutingRules", rule.Name), isAGICName(rule.Name), refs...)
+	}
+
+	// What the prohibited targets keep, and what the kept resources reference, is not removed.
+	kept := make(map[string]bool)
+	var toVisit []string
+	keep := func(key string) {
+		if !kept[key] {
+			kept[key] = true
+			toVisit = append(toVisit, key)
+		}
+	}
+	for key, created := range graph.created {
+		if !created {
+			keep(key)
+		}
+	}
+	if len(prohibitedTargets) > 0 {
+		blacklist := existing.GetBlacklist()
+		for _, rule := range blacklist.Kept.RoutingRules {
+			keep(detachKey("requestRoutingRules", rule.Name))
+		}
+		for _, listener := range blacklist.Kept.Listeners {
+			keep(detachKey("httpListeners", listener.Name))
+		}
+		for _, pathMap := range blacklist.Kept.URLPathMaps {
+			keep(detachKey("urlPathMaps", pathMap.Name))
+		}
+	}
+	for len(toVisit) > 0 {
+		key := toVisit[0]
+		toVisit = toVisit[1:]
+		for _, ref := range graph.refs[key] {
+			keep(ref)
+		}
+	}
+
+	var removed []string
+	isKept := func(collection string, name *string) bool {
+		if name == nil || kept[detachKey(collection, name)] {
+			return true
+		}
+		removed = append(removed, collection+"/"+*name)
+		return false
+	}
+
+	var pools []n.ApplicationGatewayBackendAddressPool
+	for _, pool := range existing.BackendPools {
+		if isKept("backendAddressPools", pool.Name) {
+			pools = append(pools, pool)
+		}
+	}
+	var probes []n.ApplicationGatewayProbe
+	for _, probe := range existing.Probes {
+		if isKept("probes", probe.Name) {
+			probes = append(probes, probe)
+		}
+	}
+	var ports []n.ApplicationGatewayFrontendPort
+	for _, port := range existing.Ports {
+		if isKept("frontendPorts", port.Name) {
+			ports = append(ports, port)
+		}
+	}
+	var settingsCollection []n.ApplicationGatewayBackendHTTPSettings
+	for _, settings := range existing.HTTPSettings {
+		if isKept("backendHttpSettingsCollection", settings.Name) {
+			settingsCollection = append(settingsCollection, settings)
+		}
+	}
+	var listeners []n.ApplicationGatewayHTTPListener
+	for _, listener := range existing.Listeners {
+		if isKept("httpListeners", listener.Name) {
+			listeners = append(listeners, listener)
+		}
+	}
+	var certs []n.ApplicationGatewaySslCertificate
+	for _, cert := range existing.Certificates {
+		if isKept("sslCertificates", cert.Name) {
+			certs = append(certs, cert)
+		}
+	}
+	var redirects []n.ApplicationGatewayRedirectConfiguration
+	if appGw.RedirectConfigurations != nil {
+		for _, redirect := range *appGw.RedirectConfigurations {
+			if isKept("redirectConfigurations", redirect.Name) {
+				redirects = append(redirects, redirect)
+			}
+		}
+	}
+	var pathMaps []n.ApplicationGatewayURLPathMap
+	for _, pathMap := range existing.URLPathMaps {
+		if isKept("urlPathMaps", pathMap.Name) {
+			pathMaps = append(pathMaps, pathMap)
+		}
+	}
+	var rules []n.ApplicationGatewayRequestRoutingRule
+	for _, rule := range existing.RoutingRules {
+		if isKept("requestRoutingRules", rule.Name) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return appGw, nil, ErrNoRulesAfterDetach
+	}
+	sort.Strings(removed)
+
+	detached := appGw
+	properties := *appGw.ApplicationGatewayPropertiesFormat
+	properties.BackendAddressPools = &pools
+	properties.Probes = &probes
+	properties.FrontendPorts = &ports
+	properties.BackendHTTPSettingsCollection = &settingsCollection
+	properties.HTTPListeners = &listeners
+	properties.SslCertificates = &certs
+	properties.RedirectConfigurations = &redirects
+	properties.URLPathMaps = &pathMaps
+	properties.RequestRoutingRules = &rules
+	detached.ApplicationGatewayPropertiesFormat = &properties
+
+	tags := make(map[string]*string)
+	for key, value := range appGw.Tags {
+		if key != managedByK8sIngress && key != orphanedK8sIngresses {
+			tags[key] = value
+		}
+	}
+	detached.Tags = tags
+	return detached, removed, nil
+}
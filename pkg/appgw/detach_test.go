@@ -0,0 +1,173 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+)
+
+var _ = Describe("Test detaching AGIC from a shared App Gateway", func() {
+	appGwIdentifier := Identifier{
+		SubscriptionID: "--subscription--",
+		ResourceGroup:  "--resource-group--",
+		AppGwName:      "--app-gw-name--",
+	}
+	ref := func(id string) *n.SubResource {
+		return &n.SubResource{ID: to.StringPtr(id)}
+	}
+	newListener := func(name, port, host, cert string) n.ApplicationGatewayHTTPListener {
+		listener := n.ApplicationGatewayHTTPListener{
+			Name: to.StringPtr(name),
+			ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+				FrontendPort: ref(appGwIdentifier.frontendPortID(port)),
+				HostName:     to.StringPtr(host),
+			},
+		}
+		if cert != "" {
+			listener.SslCertificate = ref(appGwIdentifier.sslCertificateID(cert))
+		}
+		return listener
+	}
+	newRule := func(name, listener, pool, settings string) n.ApplicationGatewayRequestRoutingRule {
+		return n.ApplicationGatewayRequestRoutingRule{
+			Name: to.StringPtr(name),
+			ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+				HTTPListener:        ref(appGwIdentifier.listenerID(listener)),
+				BackendAddressPool:  ref(appGwIdentifier.addressPoolID(pool)),
+				BackendHTTPSettings: ref(appGwIdentifier.httpSettingsID(settings)),
+			},
+		}
+	}
+	newSettings := func(name, probe string) n.ApplicationGatewayBackendHTTPSettings {
+		return n.ApplicationGatewayBackendHTTPSettings{
+			Name: to.StringPtr(name),
+			ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{
+				Probe: ref(appGwIdentifier.probeID(probe)),
+			},
+		}
+	}
+
+	var appGw n.ApplicationGateway
+	BeforeEach(func() {
+		appGw = n.ApplicationGateway{
+			Tags: map[string]*string{
+				managedByK8sIngress: to.StringPtr("a/b/c"),
+				"team":              to.StringPtr("web"),
+			},
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				FrontendPorts: &[]n.ApplicationGatewayFrontendPort{
+					{Name: to.StringPtr("fp-80")},
+					{Name: to.StringPtr("fp-443")},
+				},
+				SslCertificates: &[]n.ApplicationGatewaySslCertificate{
+					{Name: to.StringPtr("default-bye-secret")},
+					{Name: to.StringPtr("uploaded")},
+				},
+				HTTPListeners: &[]n.ApplicationGatewayHTTPListener{
+					newListener("fl-bye.com-443", "fp-443", "bye.com", "default-bye-secret"),
+					newListener("fl-hello.com-80", "fp-80", "hello.com", ""),
+					newListener("contoso", "fp-80", "contoso.com", ""),
+				},
+				BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{
+					{Name: to.StringPtr("pool-default-web-80-bp-8080")},
+					{Name: to.StringPtr("pool-default-hello-80-bp-8080")},
+					{Name: to.StringPtr("defaultaddresspool")},
+					{Name: to.StringPtr("contoso-pool")},
+				},
+				Probes: &[]n.ApplicationGatewayProbe{
+					{Name: to.StringPtr("pb-default-web-80-bye")},
+					{Name: to.StringPtr("pb-default-hello-80-hello")},
+				},
+				BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHTTPSettings{
+					newSettings("bp-default-web-80-8080-bye", "pb-default-web-80-bye"),
+					newSettings("bp-default-hello-80-8080-hello", "pb-default-hello-80-hello"),
+					{Name: to.StringPtr("contoso-settings")},
+				},
+				RequestRoutingRules: &[]n.ApplicationGatewayRequestRoutingRule{
+					newRule("rr-bye.com-443", "fl-bye.com-443", "pool-default-web-80-bp-8080", "bp-default-web-80-8080-bye"),
+					newRule("rr-hello.com-80", "fl-hello.com-80", "pool-default-hello-80-bp-8080", "bp-default-hello-80-8080-hello"),
+					newRule("contoso", "contoso", "contoso-pool", "contoso-settings"),
+				},
+			},
+		}
+	})
+
+	namesOf := func(listeners []n.ApplicationGatewayHTTPListener) []string {
+		var names []string
+		for _, listener := range listeners {
+			names = append(names, *listener.Name)
+		}
+		return names
+	}
+
+	Context("test GetDetachedConfig", func() {
+		It("should remove the resources AGIC created, and keep the ones they share with other rules", func() {
+			detached, removed, err := GetDetachedConfig(appGw, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(Equal([]string{
+				"backendAddressPools/defaultaddresspool",
+				"backendAddressPools/pool-default-hello-80-bp-8080",
+				"backendAddressPools/pool-default-web-80-bp-8080",
+				"backendHttpSettingsCollection/bp-default-hello-80-8080-hello",
+				"backendHttpSettingsCollection/bp-default-web-80-8080-bye",
+				"frontendPorts/fp-443",
+				"httpListeners/fl-bye.com-443",
+				"httpListeners/fl-hello.com-80",
+				"probes/pb-default-hello-80-hello",
+				"probes/pb-default-web-80-bye",
+				"requestRoutingRules/rr-bye.com-443",
+				"requestRoutingRules/rr-hello.com-80",
+				"sslCertificates/default-bye-secret",
+			}))
+			Expect(namesOf(*detached.HTTPListeners)).To(Equal([]string{"contoso"}))
+			// The listener, which was not created by AGIC, uses the port AGIC created.
+			Expect(*detached.FrontendPorts).To(Equal([]n.ApplicationGatewayFrontendPort{{Name: to.StringPtr("fp-80")}}))
+			Expect(*detached.SslCertificates).To(Equal([]n.ApplicationGatewaySslCertificate{{Name: to.StringPtr("uploaded")}}))
+			Expect(detached.Tags).To(Equal(map[string]*string{"team": to.StringPtr("web")}))
+
+			// The given config is not modified.
+			Expect(*appGw.HTTPListeners).To(HaveLen(3))
+			Expect(appGw.Tags).To(HaveKey(managedByK8sIngress))
+		})
+
+		It("should keep the resources of the prohibited targets", func() {
+			prohibitedTargets := []*ptv1.AzureIngressProhibitedTarget{{
+				ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+				Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: "hello.com"},
+			}}
+			detached, removed, err := GetDetachedConfig(appGw, prohibitedTargets)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(namesOf(*detached.HTTPListeners)).To(Equal([]string{"fl-hello.com-80", "contoso"}))
+			Expect(*detached.RequestRoutingRules).To(HaveLen(2))
+			Expect(*detached.Probes).To(Equal([]n.ApplicationGatewayProbe{{Name: to.StringPtr("pb-default-hello-80-hello")}}))
+			Expect(removed).ToNot(ContainElement("backendAddressPools/pool-default-hello-80-bp-8080"))
+			Expect(removed).To(ContainElement("backendAddressPools/pool-default-web-80-bp-8080"))
+		})
+
+		It("should fail when no request routing rule would remain", func() {
+			*appGw.RequestRoutingRules = (*appGw.RequestRoutingRules)[:2]
+			_, _, err := GetDetachedConfig(appGw, nil)
+			Expect(err).To(Equal(ErrNoRulesAfterDetach))
+		})
+	})
+
+	Context("test isAGICName", func() {
+		It("should recognize the names AGIC generates", func() {
+			Expect(isAGICName(to.StringPtr("rr-bye.com-443"))).To(BeTrue())
+			Expect(isAGICName(to.StringPtr("sslr-fl-bye.com-443"))).To(BeTrue())
+			Expect(isAGICName(to.StringPtr("defaulthttpsetting"))).To(BeTrue())
+			Expect(isAGICName(to.StringPtr("contoso-rule"))).To(BeFalse())
+			Expect(isAGICName(to.StringPtr("fl"))).To(BeFalse())
+			Expect(isAGICName(nil)).To(BeFalse())
+		})
+	})
+})
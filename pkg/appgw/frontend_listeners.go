@@ -37,12 +37,16 @@ func (c *appGwConfigBuilder) getListeners(cbCtx *ConfigBuilderContext) *[]n.Appl
 		if config.Protocol == n.HTTPS {
 			sslCertificateID := c.appGwIdentifier.sslCertificateID(config.Secret.secretFullName())
 			listener.SslCertificate = resourceRef(sslCertificateID)
+			listener.RequireServerNameIndication = config.RequireServerNameIndication
 		}
 		listeners = append(listeners, listener)
 	}
 
-	if cbCtx.EnableBrownfieldDeployment {
-		er := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
+	// Existing listeners named by the Ingresses are kept as they are.
+	listeners = append(listeners, c.getReferencedListeners(cbCtx.IngressList)...)
+
+	if cbCtx.keepsExistingConfig() {
+		er := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), nil)
 
 		// Listeners we obtained from App Gateway - we segment them into ones AGIC is and is not allowed to change.
 		existingBlacklisted, existingNonBlacklisted := er.GetBlacklistedListeners()
@@ -70,8 +74,8 @@ func (c *appGwConfigBuilder) getListenerConfigs(ingressList []*v1beta1.Ingress)
 		}
 	}
 
-	// App Gateway must have at least one listener - the default one!
-	if len(allListeners) == 0 {
+	// App Gateway must have at least one listener - the default one, unless the Ingresses are routed through existing listeners!
+	if len(allListeners) == 0 && len(c.getReferencedListeners(ingressList)) == 0 {
 		allListeners[defaultFrontendListenerIdentifier()] = listenerAzConfig{
 			// Default protocol
 			Protocol: n.HTTP,
@@ -155,13 +159,18 @@ func (c *appGwConfigBuilder) groupListenersByListenerIdentifier(listeners *[]n.A
 	listenersByID := make(map[listenerIdentifier]*n.ApplicationGatewayHTTPListener)
 	// Update the listenerMap with the final listener lists
 	for idx, listener := range *listeners {
-		port := c.lookupFrontendPortByID(listener.FrontendPort.ID)
-		listenerID := listenerIdentifier{
-			HostName:     *listener.HostName,
-			FrontendPort: *port.Port,
-		}
-		listenersByID[listenerID] = &((*listeners)[idx])
+		listenersByID[c.getListenerIdentifier(listener)] = &((*listeners)[idx])
 	}
 
 	return listenersByID
 }
+
+// getListenerIdentifier returns the hostname and frontend port of the listener; existing listeners may have no hostname.
+func (c *appGwConfigBuilder) getListenerIdentifier(listener n.ApplicationGatewayHTTPListener) listenerIdentifier {
+	port := c.lookupFrontendPortByID(listener.FrontendPort.ID)
+	listenerID := listenerIdentifier{FrontendPort: *port.Port}
+	if listener.HostName != nil {
+		listenerID.HostName = *listener.HostName
+	}
+	return listenerID
+}
@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 	"k8s.io/api/extensions/v1beta1"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
@@ -89,6 +90,29 @@ var _ = Describe("Process ingress rules and parse frontend listener configs", fu
 			Expect(*listener.FrontendIPConfiguration.ID).To(Equal(tests.IPID1))
 		})
 	})
+	Context("ingress with the require-server-name-indication annotation", func() {
+		It("should set the SNI requirement on the HTTPS listeners with a hostname only", func() {
+			certs := newCertsFixture()
+			cb := newConfigBuilderFixture(&certs)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequireServerNameIndicationKey] = "false"
+			cbCtx := &ConfigBuilderContext{
+				IngressList:  []*v1beta1.Ingress{ingress},
+				EnvVariables: envVariables,
+			}
+
+			cb.appGw.FrontendPorts = cb.getFrontendPorts(cbCtx)
+			listeners := cb.getListeners(cbCtx)
+			Expect(*listeners).ToNot(BeEmpty())
+			for _, listener := range *listeners {
+				if listener.Protocol == n.HTTPS && *listener.HostName != "" {
+					Expect(listener.RequireServerNameIndication).To(Equal(to.BoolPtr(false)))
+				} else {
+					Expect(listener.RequireServerNameIndication).To(BeNil())
+				}
+			}
+		})
+	})
 	Context("create a new App Gateway HTTP Listener", func() {
 		It("should create a correct App Gwy listener", func() {
 			certs := newCertsFixture()
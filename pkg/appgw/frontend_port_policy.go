@@ -0,0 +1,131 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/knative/pkg/apis/istio/v1alpha3"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+type portRange struct {
+	from int32
+	to   int32
+}
+
+// portRanges is the set of frontend ports AGIC is allowed to open on the App Gateway. An empty set allows all ports.
+type portRanges []portRange
+
+// parsePortRanges parses a comma separated list of ports and port ranges, such as "80,443,8000-8999".
+func parsePortRanges(spec string) (portRanges, error) {
+	var ranges portRanges
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		from, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %s", item, err)
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 32); err != nil {
+				return nil, fmt.Errorf("invalid port range %q: %s", item, err)
+			}
+		}
+		if from < 1 || to > 65535 || from > to {
+			return nil, fmt.Errorf("invalid port range %q", item)
+		}
+		ranges = append(ranges, portRange{from: int32(from), to: int32(to)})
+	}
+	return ranges, nil
+}
+
+func (r portRanges) allows(port int32) bool {
+	if len(r) == 0 {
+		return true
+	}
+	for _, pr := range r {
+		if pr.from <= port && port <= pr.to {
+			return true
+		}
+	}
+	return false
+}
+
+// disallowed returns the sorted ports of the given set, which are outside of the allowed ranges.
+func (r portRanges) disallowed(ports map[int32]interface{}) []string {
+	var disallowed []int
+	for port := range ports {
+		if !r.allows(port) {
+			disallowed = append(disallowed, int(port))
+		}
+	}
+	sort.Ints(disallowed)
+	var result []string
+	for _, port := range disallowed {
+		result = append(result, strconv.Itoa(port))
+	}
+	return result
+}
+
+// excludeDisallowedFrontendPorts removes the Ingresses and Istio Gateways, which require a frontend port the controller
+// is not allowed to open, from the config builder context and emits a rejection event for each.
+// The cached Kubernetes objects are not modified.
+func (c *appGwConfigBuilder) excludeDisallowedFrontendPorts(cbCtx *ConfigBuilderContext) error {
+	allowed, err := parsePortRanges(cbCtx.EnvVariables.AllowedFrontendPorts)
+	if err != nil {
+		return err
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var ingressList []*v1beta1.Ingress
+	for _, ingress := range cbCtx.IngressList {
+		fePorts, _ := c.processIngressRules(ingress)
+		if disallowed := allowed.disallowed(fePorts); len(disallowed) > 0 {
+			logLine := fmt.Sprintf("Ingress %s/%s requires frontend port(s) %s, which are not allowed by the controller (allowed: %s); the Ingress is ignored",
+				ingress.Namespace, ingress.Name, strings.Join(disallowed, ","), cbCtx.EnvVariables.AllowedFrontendPorts)
+			glog.Warning(logLine)
+			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonFrontendPortNotAllowed, logLine)
+			continue
+		}
+		ingressList = append(ingressList, ingress)
+	}
+	cbCtx.IngressList = ingressList
+
+	if !cbCtx.EnableIstioIntegration {
+		return nil
+	}
+	var gateways []*v1alpha3.Gateway
+	for _, gateway := range cbCtx.IstioGateways {
+		fePorts := make(map[int32]interface{})
+		for _, server := range gateway.Spec.Servers {
+			fePorts[int32(server.Port.Number)] = nil
+		}
+		if disallowed := allowed.disallowed(fePorts); len(disallowed) > 0 {
+			logLine := fmt.Sprintf("Istio Gateway %s/%s requires frontend port(s) %s, which are not allowed by the controller (allowed: %s); the Gateway is ignored",
+				gateway.Namespace, gateway.Name, strings.Join(disallowed, ","), cbCtx.EnvVariables.AllowedFrontendPorts)
+			glog.Warning(logLine)
+			c.recorder.Event(gateway, v1.EventTypeWarning, events.ReasonFrontendPortNotAllowed, logLine)
+			continue
+		}
+		gateways = append(gateways, gateway)
+	}
+	cbCtx.IstioGateways = gateways
+	return nil
+}
@@ -0,0 +1,78 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test allowed frontend ports", func() {
+
+	Context("test parsePortRanges()", func() {
+		It("should parse ports and port ranges", func() {
+			ranges, err := parsePortRanges("80, 443,8000-8999")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ranges).To(Equal(portRanges{{80, 80}, {443, 443}, {8000, 8999}}))
+			Expect(ranges.allows(443)).To(BeTrue())
+			Expect(ranges.allows(8080)).To(BeTrue())
+			Expect(ranges.allows(8443)).To(BeTrue())
+			Expect(ranges.allows(9000)).To(BeFalse())
+		})
+
+		It("should allow all ports when empty", func() {
+			ranges, err := parsePortRanges("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ranges.allows(12345)).To(BeTrue())
+		})
+
+		It("should reject invalid ranges", func() {
+			for _, spec := range []string{"http", "0", "443-80", "80-70000"} {
+				_, err := parsePortRanges(spec)
+				Expect(err).To(HaveOccurred(), spec)
+			}
+		})
+	})
+
+	Context("test excludeDisallowedFrontendPorts()", func() {
+		// The fixture Ingress has TLS and SSL redirect, so it requires ports 80 and 443.
+		newContext := func(allowedPorts string) *ConfigBuilderContext {
+			env := environment.GetFakeEnv()
+			env.AllowedFrontendPorts = allowedPorts
+			return &ConfigBuilderContext{
+				IngressList:  []*v1beta1.Ingress{tests.NewIngressFixture()},
+				EnvVariables: env,
+			}
+		}
+
+		It("should keep Ingresses, which only use allowed ports", func() {
+			certs := newCertsFixture()
+			cb := newConfigBuilderFixture(&certs)
+			cbCtx := newContext("80,443")
+			Expect(cb.excludeDisallowedFrontendPorts(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(HaveLen(1))
+		})
+
+		It("should exclude Ingresses, which require a port that is not allowed, and emit an event", func() {
+			certs := newCertsFixture()
+			cb := newConfigBuilderFixture(&certs)
+			recorder := record.NewFakeRecorder(100)
+			cb.recorder = recorder
+			cbCtx := newContext("443")
+			Expect(cb.excludeDisallowedFrontendPorts(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(BeEmpty())
+			Expect(recorder.Events).To(Receive(ContainSubstring(events.ReasonFrontendPortNotAllowed)))
+		})
+	})
+})
@@ -33,6 +33,15 @@ func (c *appGwConfigBuilder) getFrontendPorts(cbCtx *ConfigBuilderContext) *[]n.
 		}
 	}
 
+	// Existing frontend ports of the listeners named by the Ingresses are kept as they are, and shared with the listeners AGIC creates.
+	referencedPorts := make(map[int32]n.ApplicationGatewayFrontendPort)
+	for _, listener := range c.getReferencedListeners(cbCtx.IngressList) {
+		if port := c.getFrontendPortOfReferencedListener(listener); port != nil {
+			referencedPorts[*port.Port] = *port
+			allPorts[*port.Port] = nil
+		}
+	}
+
 	// fallback to default listener as placeholder if no listener is available
 	if len(allPorts) == 0 {
 		port := defaultFrontendListenerIdentifier().FrontendPort
@@ -41,6 +50,10 @@ func (c *appGwConfigBuilder) getFrontendPorts(cbCtx *ConfigBuilderContext) *[]n.
 
 	var frontendPorts []n.ApplicationGatewayFrontendPort
 	for port := range allPorts {
+		if existing, exists := referencedPorts[port]; exists {
+			frontendPorts = append(frontendPorts, existing)
+			continue
+		}
 		frontendPortName := generateFrontendPortName(port)
 		frontendPorts = append(frontendPorts, n.ApplicationGatewayFrontendPort{
 			Etag: to.StringPtr("*"),
@@ -52,8 +65,8 @@ func (c *appGwConfigBuilder) getFrontendPorts(cbCtx *ConfigBuilderContext) *[]n.
 		})
 	}
 
-	if cbCtx.EnableBrownfieldDeployment {
-		er := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
+	if cbCtx.keepsExistingConfig() {
+		er := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), nil)
 
 		// Ports we obtained from App Gateway - we segment them into ones AGIC is and is not allowed to change.
 		existingBlacklisted, existingNonBlacklisted := er.GetBlacklistedPorts()
@@ -0,0 +1,52 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"context"
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// networkAPIVersion is the version of the App Gateway API used by AGIC, as the network SDK sends it to ARM. Its backend HTTP
+// settings only support HTTP/1.1 (protocols Http and Https); HTTP/2 is only available between clients and the App Gateway.
+var networkAPIVersion = getNetworkAPIVersion()
+
+func getNetworkAPIVersion() string {
+	req, err := n.NewApplicationGatewaysClient("-").GetPreparer(context.Background(), "-", "-")
+	if err != nil {
+		return "unknown"
+	}
+	return req.URL.Query().Get("api-version")
+}
+
+// configureGRPCBackends enables HTTP/2 on the App Gateway while an Ingress designates gRPC backends, so gRPC clients can connect,
+// and disables it otherwise; it is set on every build, so HTTP/2 is turned off once the last of these Ingresses is gone.
+// As the App Gateway proxies requests to backends over HTTP/1.1, gRPC cannot be served end to end: a warning event is
+// emitted on each of these Ingresses, rather than letting gRPC calls fail without explanation.
+func (c *appGwConfigBuilder) configureGRPCBackends(cbCtx *ConfigBuilderContext) {
+	enableHTTP2 := false
+	for _, ingress := range cbCtx.IngressList {
+		if isGRPC, err := annotations.IsGRPCBackend(ingress); err != nil || !isGRPC {
+			continue
+		}
+		enableHTTP2 = true
+
+		logLine := fmt.Sprintf("Ingress %s/%s designates gRPC backends, which App Gateway %s cannot serve end to end:"+
+			" HTTP/2 is enabled for clients, but App Gateway API %s proxies requests to backends over HTTP/1.1 only",
+			ingress.Namespace, ingress.Name, c.appGwIdentifier.AppGwName, networkAPIVersion)
+		glog.Warning(logLine)
+		c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonGRPCNotSupported, logLine)
+	}
+	c.appGw.EnableHTTP2 = to.BoolPtr(enableHTTP2)
+}
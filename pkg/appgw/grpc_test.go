@@ -0,0 +1,57 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test gRPC backends", func() {
+	var cb *appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var ingress *v1beta1.Ingress
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		cb = &appGwConfigBuilder{
+			appGw:           n.ApplicationGateway{ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{}},
+			appGwIdentifier: Identifier{AppGwName: tests.AppGwName},
+			recorder:        recorder,
+		}
+		ingress = tests.NewIngressFixture()
+	})
+
+	It("should enable HTTP/2 and report that gRPC cannot be served end to end", func() {
+		ingress.Annotations[annotations.GRPCBackendKey] = "true"
+		cb.configureGRPCBackends(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}})
+		Expect(*cb.appGw.EnableHTTP2).To(BeTrue())
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(events.ReasonGRPCNotSupported),
+			ContainSubstring(tests.Namespace+"/"+tests.Name))))
+	})
+
+	It("should disable HTTP/2 once no Ingress designates gRPC backends", func() {
+		cb.appGw.EnableHTTP2 = to.BoolPtr(true)
+		cb.configureGRPCBackends(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}})
+		Expect(*cb.appGw.EnableHTTP2).To(BeFalse())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should name the App Gateway API version of the network SDK", func() {
+		Expect(networkAPIVersion).To(Equal("2018-12-01"))
+	})
+})
@@ -20,6 +20,10 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 )
 
+// healthyStatusCodes is the range of status codes App Gateway considers healthy, unless a probe defines its own.
+// It has to be given along with a body to match, as the match replaces the default of App Gateway.
+const healthyStatusCodes = "200-399"
+
 func (c *appGwConfigBuilder) HealthProbesCollection(cbCtx *ConfigBuilderContext) error {
 	healthProbeCollection, _ := c.newProbesMap(cbCtx)
 	glog.V(5).Infof("Will create %d App Gateway probes.", len(healthProbeCollection))
@@ -28,8 +32,8 @@ func (c *appGwConfigBuilder) HealthProbesCollection(cbCtx *ConfigBuilderContext)
 		agicCreatedProbes = append(agicCreatedProbes, probe)
 	}
 
-	if cbCtx.EnableBrownfieldDeployment {
-		er := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
+	if cbCtx.keepsExistingConfig() {
+		er := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), nil)
 		existingBlacklisted, existingNonBlacklisted := er.GetBlacklistedProbes()
 		brownfield.LogProbes(existingBlacklisted, existingNonBlacklisted, agicCreatedProbes)
 		agicCreatedProbes = brownfield.MergeProbes(existingBlacklisted, agicCreatedProbes)
@@ -49,7 +53,21 @@ func (c *appGwConfigBuilder) newProbesMap(cbCtx *ConfigBuilderContext) (map[stri
 	healthProbeCollection[*defaultProbe.Name] = defaultProbe
 
 	for backendID := range newBackendIdsFiltered(cbCtx) {
-		probe := c.generateHealthProbe(backendID)
+		// Existing probes named by the Ingress, or used by the existing HTTP settings it names, are kept as they are.
+		if settings := c.getReferencedHTTPSettings(backendID); settings != nil {
+			if probe := c.getProbeOfReferencedHTTPSettings(settings); probe != nil {
+				healthProbeCollection[*probe.Name] = *probe
+			}
+			continue
+		}
+		if probe := c.getReferencedProbe(backendID); probe != nil {
+			glog.V(5).Infof("Using existing probe %s for backend: '%s'", *probe.Name, backendID.Name)
+			probesMap[backendID] = probe
+			healthProbeCollection[*probe.Name] = *probe
+			continue
+		}
+
+		probe := c.generateHealthProbe(backendID, cbCtx.BackendDefaults)
 
 		if probe != nil {
 			glog.V(5).Infof("Created probe %s for backend: '%s'", *probe.Name, backendID.Name)
@@ -63,14 +81,16 @@ func (c *appGwConfigBuilder) newProbesMap(cbCtx *ConfigBuilderContext) (map[stri
 	return healthProbeCollection, probesMap
 }
 
-func (c *appGwConfigBuilder) generateHealthProbe(backendID backendIdentifier) *n.ApplicationGatewayProbe {
+func (c *appGwConfigBuilder) generateHealthProbe(backendID backendIdentifier, defaults BackendDefaults) *n.ApplicationGatewayProbe {
 	// TODO(draychev): remove GetService
-	service := c.k8sContext.GetService(backendID.serviceKey())
+	service := c.getService(backendID)
 	if service == nil {
 		return nil
 	}
 	probe := defaultProbe(c.appGwIdentifier)
-	probe.Name = to.StringPtr(generateProbeName(backendID.Path.Backend.ServiceName, backendID.Path.Backend.ServicePort.String(), backendID.Ingress))
+	defaults.applyToProbe(&probe)
+	probePath, hasPathProbe := backendID.pathProbe()
+	probe.Name = to.StringPtr(backendID.probeName())
 	probe.ID = to.StringPtr(c.appGwIdentifier.probeID(*probe.Name))
 	if backendID.Rule != nil && len(backendID.Rule.Host) != 0 {
 		probe.Host = to.StringPtr(backendID.Rule.Host)
@@ -105,9 +125,34 @@ func (c *appGwConfigBuilder) generateHealthProbe(backendID backendIdentifier) *n
 		}
 	}
 
+	// A probe declared for this specific Ingress path takes precedence over the one inferred from the Service
+	if hasPathProbe {
+		probe.Path = to.StringPtr(probePath)
+	}
+
+	if matchBody, err := annotations.HealthProbeMatchBody(backendID.Ingress); err == nil {
+		probe.Match = &n.ApplicationGatewayProbeHealthResponseMatch{
+			Body:        to.StringPtr(matchBody),
+			StatusCodes: &[]string{healthyStatusCodes},
+		}
+	}
+
 	return &probe
 }
 
+// pathProbe returns the probe path declared for the Ingress path of this backend (if any) with the health-probe-paths annotation.
+func (id backendIdentifier) pathProbe() (string, bool) {
+	if id.Path == nil || id.Ingress == nil {
+		return "", false
+	}
+	probePaths, err := annotations.HealthProbePaths(id.Ingress)
+	if err != nil {
+		return "", false
+	}
+	probePath, exists := probePaths[id.Path.Path]
+	return probePath, exists
+}
+
 func (c *appGwConfigBuilder) getProbeForServiceContainer(service *v1.Service, backendID backendIdentifier) *v1.Probe {
 	allPorts := make(map[int32]interface{})
 	for _, sp := range service.Spec.Ports {
@@ -15,6 +15,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
 
@@ -126,4 +127,95 @@ var _ = Describe("configure App Gateway health probes", func() {
 			Expect(*actual).To(ContainElement(defaultProbe(cb.appGwIdentifier)))
 		})
 	})
+
+	Context("create per-path probes", func() {
+		cb := newConfigBuilderFixture(nil)
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+
+		pod := tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+		_ = cb.k8sContext.Caches.Pods.Add(pod)
+
+		be80 := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = []v1beta1.IngressRule{
+			tests.NewIngressRuleFixture(tests.Host, "/api", *be80),
+		}
+		ingress.Spec.Rules[0].HTTP.Paths = append(ingress.Spec.Rules[0].HTTP.Paths, v1beta1.HTTPIngressPath{
+			Path:    "/web",
+			Backend: *be80,
+		})
+		ingress.Annotations[annotations.HealthProbePathsKey] = "/api=/api/healthz"
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList: []*v1beta1.Ingress{ingress},
+			ServiceList: serviceList,
+		}
+
+		// !! Action !!
+		_ = cb.HealthProbesCollection(cbCtx)
+		actual := cb.appGw.Probes
+		_, settingsMap, _, _ := cb.getBackendsAndSettingsMap(cbCtx)
+
+		pathProbeName := generatePathProbeName(tests.ServiceName, "80", ingress, "/api")
+		serviceProbeName := generateProbeName(tests.ServiceName, "80", ingress)
+
+		It("should have created a probe for the path and one for the service", func() {
+			Expect(len(*actual)).To(Equal(3))
+			var pathProbe *n.ApplicationGatewayProbe
+			for idx := range *actual {
+				if *(*actual)[idx].Name == pathProbeName {
+					pathProbe = &(*actual)[idx]
+				}
+			}
+			Expect(pathProbe).ToNot(BeNil())
+			Expect(*pathProbe.Path).To(Equal("/api/healthz"))
+		})
+
+		It("should have created HTTP settings for the path referencing the path probe", func() {
+			Expect(len(settingsMap)).To(Equal(2))
+			for backendID, settings := range settingsMap {
+				if backendID.Path.Path == "/api" {
+					Expect(*settings.Name).To(Equal(generatePathHTTPSettingsName(backendID.serviceFullName(), "80", tests.ContainerPort, ingress.Name, "/api")))
+					Expect(*settings.Probe.ID).To(Equal(cb.appGwIdentifier.probeID(pathProbeName)))
+				} else {
+					Expect(*settings.Name).To(Equal(generateHTTPSettingsName(backendID.serviceFullName(), "80", tests.ContainerPort, ingress.Name)))
+					Expect(*settings.Probe.ID).To(Equal(cb.appGwIdentifier.probeID(serviceProbeName)))
+				}
+			}
+		})
+	})
+
+	Context("match the body of probe responses", func() {
+		cb := newConfigBuilderFixture(nil)
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.HealthProbeMatchBodyKey] = "OK"
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList: []*v1beta1.Ingress{ingress},
+			ServiceList: serviceList,
+		}
+
+		// !! Action !!
+		_ = cb.HealthProbesCollection(cbCtx)
+		actual := cb.appGw.Probes
+
+		It("should match the body with the probes of the Ingress only", func() {
+			Expect(len(*actual)).To(BeNumerically(">", 1))
+			for _, probe := range *actual {
+				if *probe.Name == defaultProbeName {
+					Expect(probe.Match).To(BeNil())
+					continue
+				}
+				Expect(*probe.Match.Body).To(Equal("OK"))
+				Expect(*probe.Match.StatusCodes).To(Equal([]string{healthyStatusCodes}))
+			}
+		})
+	})
 })
@@ -0,0 +1,88 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// getHostnameOwners returns the namespace owning each hostname. A hostname is owned by the namespace of the oldest Ingress using it;
+// ties are broken by namespace and name, so the owner does not change from one build to the next.
+func getHostnameOwners(ingressList []*v1beta1.Ingress) map[string]string {
+	owners := make(map[string]string)
+	for _, ingress := range oldestFirst(ingressList) {
+		for _, rule := range ingress.Spec.Rules {
+			// Rules without a host are catch-all and do not claim a hostname.
+			if rule.Host == "" {
+				continue
+			}
+			if _, exists := owners[rule.Host]; !exists {
+				owners[rule.Host] = ingress.Namespace
+			}
+		}
+	}
+	return owners
+}
+
+// oldestFirst returns a copy of the list of Ingresses sorted by creation time, then namespace and name.
+func oldestFirst(ingressList []*v1beta1.Ingress) []*v1beta1.Ingress {
+	sorted := make([]*v1beta1.Ingress, len(ingressList))
+	copy(sorted, ingressList)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ti, tj := sorted[i].CreationTimestamp, sorted[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// excludeForeignHostnames removes the rules of Ingresses, which use a hostname owned by another namespace, from the config
+// builder context and emits an event for each. Ingresses are copied before removing rules; the cached objects are not modified.
+// An Ingress left without rules is kept when it has a default backend.
+func (c *appGwConfigBuilder) excludeForeignHostnames(cbCtx *ConfigBuilderContext) {
+	if cbCtx.EnvVariables.EnableHostnameOwnership != "true" {
+		return
+	}
+
+	owners := getHostnameOwners(cbCtx.IngressList)
+	var ingressList []*v1beta1.Ingress
+	for _, ingress := range cbCtx.IngressList {
+		var rules []v1beta1.IngressRule
+		for _, rule := range ingress.Spec.Rules {
+			if owner, exists := owners[rule.Host]; exists && owner != ingress.Namespace {
+				logLine := fmt.Sprintf("Hostname %s of Ingress %s/%s is owned by namespace %s; the rule is ignored", rule.Host, ingress.Namespace, ingress.Name, owner)
+				glog.Warning(logLine)
+				c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonHostnameConflict, logLine)
+				continue
+			}
+			rules = append(rules, rule)
+		}
+
+		if len(rules) == len(ingress.Spec.Rules) {
+			ingressList = append(ingressList, ingress)
+			continue
+		}
+		if len(rules) == 0 && ingress.Spec.Backend == nil {
+			continue
+		}
+		pruned := ingress.DeepCopy()
+		pruned.Spec.Rules = rules
+		ingressList = append(ingressList, pruned)
+	}
+	cbCtx.IngressList = ingressList
+}
@@ -0,0 +1,112 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test hostname ownership", func() {
+
+	now := time.Now()
+	newIngress := func(namespace, name string, created time.Time, hosts ...string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Namespace = namespace
+		ingress.Name = name
+		ingress.CreationTimestamp = metav1.NewTime(created)
+		ingress.Spec.Rules = nil
+		for _, host := range hosts {
+			ingress.Spec.Rules = append(ingress.Spec.Rules, tests.NewIngressRuleFixture(host, tests.URLPath, *tests.NewIngressBackendFixture(tests.ServiceName, 80)))
+		}
+		return ingress
+	}
+
+	Context("test getHostnameOwners()", func() {
+		It("should assign each hostname to the namespace of the oldest Ingress", func() {
+			owners := getHostnameOwners([]*v1beta1.Ingress{
+				newIngress("b", "newer", now, "shared.com", "b.com"),
+				newIngress("a", "older", now.Add(-time.Hour), "shared.com", ""),
+			})
+			Expect(owners).To(Equal(map[string]string{
+				"shared.com": "a",
+				"b.com":      "b",
+			}))
+		})
+
+		It("should break ties by namespace", func() {
+			owners := getHostnameOwners([]*v1beta1.Ingress{
+				newIngress("z", "ingress", now, "shared.com"),
+				newIngress("m", "ingress", now, "shared.com"),
+			})
+			Expect(owners["shared.com"]).To(Equal("m"))
+		})
+	})
+
+	Context("test excludeForeignHostnames()", func() {
+		var cb appGwConfigBuilder
+		var recorder *record.FakeRecorder
+		var cbCtx *ConfigBuilderContext
+		var owner, intruder *v1beta1.Ingress
+
+		BeforeEach(func() {
+			certs := newCertsFixture()
+			cb = newConfigBuilderFixture(&certs)
+			recorder = record.NewFakeRecorder(100)
+			cb.recorder = recorder
+			owner = newIngress("a", "owner", now.Add(-time.Hour), "shared.com")
+			intruder = newIngress("b", "intruder", now, "shared.com", "b.com")
+			env := environment.GetFakeEnv()
+			env.EnableHostnameOwnership = "true"
+			cbCtx = &ConfigBuilderContext{
+				IngressList:  []*v1beta1.Ingress{owner, intruder},
+				EnvVariables: env,
+			}
+		})
+
+		It("should remove the rules for hostnames owned by another namespace", func() {
+			cb.excludeForeignHostnames(cbCtx)
+			Expect(cbCtx.IngressList).To(HaveLen(2))
+			Expect(cbCtx.IngressList[0]).To(Equal(owner))
+			Expect(cbCtx.IngressList[1].Spec.Rules).To(HaveLen(1))
+			Expect(cbCtx.IngressList[1].Spec.Rules[0].Host).To(Equal("b.com"))
+			Expect(recorder.Events).To(Receive(ContainSubstring(events.ReasonHostnameConflict)))
+
+			// The original Ingress is not modified.
+			Expect(intruder.Spec.Rules).To(HaveLen(2))
+		})
+
+		It("should keep an Ingress left without rules only when it has a default backend", func() {
+			withBackend := newIngress("c", "with-backend", now, "shared.com")
+			withBackend.Spec.Backend = tests.NewIngressBackendFixture(tests.ServiceName, 80)
+			withoutBackend := newIngress("d", "without-backend", now, "shared.com")
+			cbCtx.IngressList = []*v1beta1.Ingress{owner, withBackend, withoutBackend}
+			cb.excludeForeignHostnames(cbCtx)
+			Expect(cbCtx.IngressList).To(HaveLen(2))
+			Expect(cbCtx.IngressList[1].Name).To(Equal("with-backend"))
+			Expect(cbCtx.IngressList[1].Spec.Rules).To(BeEmpty())
+			Expect(cbCtx.IngressList[1].Spec.Backend).To(Equal(withBackend.Spec.Backend))
+		})
+
+		It("should do nothing unless enabled", func() {
+			cbCtx.EnvVariables.EnableHostnameOwnership = ""
+			cb.excludeForeignHostnames(cbCtx)
+			Expect(cbCtx.IngressList[1]).To(Equal(intruder))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
+})
@@ -24,6 +24,11 @@ func (agw Identifier) resourceID(provider string, resourceKind string, resourceP
 		agw.SubscriptionID, agw.ResourceGroup, provider, resourceKind, resourcePath)
 }
 
+// AppGwResourceID returns the resource ID of the App Gateway.
+func (agw Identifier) AppGwResourceID() string {
+	return agw.resourceID("Microsoft.Network", "applicationGateways", agw.AppGwName)
+}
+
 func (agw Identifier) gatewayResourceID(subResourceKind string, resourceName string) string {
 	resourcePath := fmt.Sprintf("%s/%s/%s", agw.AppGwName, subResourceKind, resourceName)
 	return agw.resourceID("Microsoft.Network", "applicationGateways", resourcePath)
@@ -6,10 +6,16 @@
 package appgw
 
 import (
+	"fmt"
+
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 )
 
 // processIngressRules creates the sets of front end listeners and ports, and a map of azure config per listener for the given ingress.
@@ -19,6 +25,11 @@ func (c *appGwConfigBuilder) processIngressRules(ingress *v1beta1.Ingress) (map[
 	ingressHostnameSecretIDMap := c.newHostToSecretMap(ingress)
 	listeners := make(map[listenerIdentifier]listenerAzConfig)
 
+	// Ingresses routed through an existing listener get no listeners and ports of their own.
+	if c.getReferencedListener(ingress) != nil {
+		return frontendPorts, listeners
+	}
+
 	for _, rule := range ingress.Spec.Rules {
 		if rule.HTTP == nil {
 			continue
@@ -26,7 +37,10 @@ func (c *appGwConfigBuilder) processIngressRules(ingress *v1beta1.Ingress) (map[
 
 		cert, secID := c.getCertificate(ingress, rule.Host, ingressHostnameSecretIDMap)
 		hasTLS := cert != nil
+		httpsOnly, _ := annotations.IsHTTPSOnly(ingress)
 		sslRedirect, _ := annotations.IsSslRedirect(ingress)
+		// Nothing answers HTTP for an Ingress annotated with https-only, so there is nothing to redirect.
+		sslRedirect = sslRedirect && !httpsOnly
 		// If a certificate is available we enable only HTTPS; unless ingress is annotated with ssl-redirect - then
 		// we enable HTTPS as well as HTTP, and redirect HTTP to HTTPS.
 		if hasTLS {
@@ -38,15 +52,20 @@ func (c *appGwConfigBuilder) processIngressRules(ingress *v1beta1.Ingress) (map[
 				redirect = generateSSLRedirectConfigurationName(listenerID)
 			}
 
-			listeners[listenerID] = listenerAzConfig{
+			listenerConfig := listenerAzConfig{
 				Protocol:                     n.HTTPS,
 				Secret:                       *secID,
 				SslRedirectConfigurationName: redirect,
 			}
+			// SNI selects among the listeners of a port by hostname, so listeners without a hostname never require it.
+			if requireSNI, err := annotations.RequireServerNameIndication(ingress); err == nil && rule.Host != "" {
+				listenerConfig.RequireServerNameIndication = to.BoolPtr(requireSNI)
+			}
+			listeners[listenerID] = listenerConfig
 		}
 
-		// Enable HTTP only if HTTPS is not configured OR if ingress annotated with 'ssl-redirect'
-		if sslRedirect || !hasTLS {
+		// Enable HTTP only if HTTPS is not configured OR if ingress annotated with 'ssl-redirect'; never if annotated with 'https-only'
+		if !httpsOnly && (sslRedirect || !hasTLS) {
 			listenerID := generateListenerID(&rule, n.HTTP, nil)
 			frontendPorts[listenerID.FrontendPort] = nil
 			listeners[listenerID] = listenerAzConfig{
@@ -57,3 +76,30 @@ func (c *appGwConfigBuilder) processIngressRules(ingress *v1beta1.Ingress) (map[
 	}
 	return frontendPorts, listeners
 }
+
+// reportHTTPSOnlyRulesWithoutTLS emits a warning on each Ingress annotated with https-only, which has rules without a TLS certificate.
+// These rules get no listener, so their paths are not served.
+func (c *appGwConfigBuilder) reportHTTPSOnlyRulesWithoutTLS(cbCtx *ConfigBuilderContext) {
+	for _, ingress := range cbCtx.IngressList {
+		if httpsOnly, _ := annotations.IsHTTPSOnly(ingress); !httpsOnly || c.getReferencedListener(ingress) != nil {
+			continue
+		}
+		ingressHostnameSecretIDMap := c.newHostToSecretMap(ingress)
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			if cert, _ := c.getCertificate(ingress, rule.Host, ingressHostnameSecretIDMap); cert != nil {
+				continue
+			}
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			logLine := fmt.Sprintf("Ingress %s/%s is annotated with %s, but has no TLS certificate for host %s; the rule is not served",
+				ingress.Namespace, ingress.Name, annotations.HTTPSOnlyKey, host)
+			glog.Warning(logLine)
+			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonHTTPSOnlyWithoutTLS, logLine)
+		}
+	}
+}
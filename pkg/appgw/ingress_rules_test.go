@@ -2,10 +2,12 @@ package appgw
 
 import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
 )
 
 // appgw_suite_test.go launches these Ginkgo tests
@@ -162,6 +164,39 @@ var _ = Describe("Process ingress rules, listeners, and ports", func() {
 			Expect(actualVal.SslRedirectConfigurationName).To(Equal(""))
 		})
 	})
+
+	Context("with the https-only annotation", func() {
+		It("should create no HTTP listener and no redirect, even with ssl-redirect", func() {
+			certs := newCertsFixture()
+			cb := newConfigBuilderFixture(&certs)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.SslRedirectKey] = "true"
+			ingress.Annotations[annotations.HTTPSOnlyKey] = "true"
+
+			frontendPorts, frontendListeners := cb.processIngressRules(ingress)
+			Expect(getInt32MapKeys(&frontendPorts)).To(ConsistOf(port443))
+			Expect(getMapKeys(&frontendListeners)).To(ConsistOf(expectedListener443))
+			Expect(frontendListeners[expectedListener443].SslRedirectConfigurationName).To(Equal(""))
+		})
+
+		It("should create no listener for rules without a certificate, and report them", func() {
+			cb := newConfigBuilderFixture(nil)
+			recorder := record.NewFakeRecorder(10)
+			cb.recorder = recorder
+			ingress := tests.NewIngressFixture()
+			ingress.Spec.TLS = nil
+			ingress.Annotations[annotations.HTTPSOnlyKey] = "true"
+
+			frontendPorts, frontendListeners := cb.processIngressRules(ingress)
+			Expect(frontendPorts).To(BeEmpty())
+			Expect(frontendListeners).To(BeEmpty())
+
+			cb.reportHTTPSOnlyRulesWithoutTLS(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}})
+			Expect(recorder.Events).To(Receive(And(
+				ContainSubstring(events.ReasonHTTPSOnlyWithoutTLS),
+				ContainSubstring("no TLS certificate for host "+tests.Host))))
+		})
+	})
 })
 
 func getMapKeys(m *map[listenerIdentifier]listenerAzConfig) []listenerIdentifier {
@@ -13,6 +13,7 @@ import (
 	"crypto/md5"
 	"fmt"
 	"regexp"
+	"strings"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -87,6 +88,8 @@ type listenerAzConfig struct {
 	Protocol                     n.ApplicationGatewayProtocol
 	Secret                       secretIdentifier
 	SslRedirectConfigurationName string
+	// RequireServerNameIndication is set on HTTPS listeners with a hostname only; nil leaves the App Gateway default.
+	RequireServerNameIndication *bool
 }
 
 // formatPropName ensures that the string generated is not longer than 80 characters.
@@ -132,6 +135,16 @@ func generateProbeName(serviceName string, servicePort string, ingress *v1beta1.
 	return formatPropName(fmt.Sprintf("%s%s-%s-%v-%v-%s", agPrefix, prefixProbe, ingress.Namespace, serviceName, servicePort, ingress.Name))
 }
 
+// generatePathHTTPSettingsName is used for Ingress paths, which declared their own health probe.
+func generatePathHTTPSettingsName(serviceName string, servicePort string, backendPortNo int32, ingress string, urlPath string) string {
+	return formatPropName(fmt.Sprintf("%s%s-%v-%v-%v-%s-%s", agPrefix, prefixHTTPSettings, serviceName, servicePort, backendPortNo, ingress, formatPath(urlPath)))
+}
+
+// generatePathProbeName is used for Ingress paths, which declared their own health probe.
+func generatePathProbeName(serviceName string, servicePort string, ingress *v1beta1.Ingress, urlPath string) string {
+	return formatPropName(fmt.Sprintf("%s%s-%s-%v-%v-%s-%s", agPrefix, prefixProbe, ingress.Namespace, serviceName, servicePort, ingress.Name, formatPath(urlPath)))
+}
+
 func generateAddressPoolName(serviceName string, servicePort string, backendPortNo int32) string {
 	return formatPropName(fmt.Sprintf("%s%s-%v-%v-bp-%v", agPrefix, prefixPool, serviceName, servicePort, backendPortNo))
 }
@@ -217,6 +230,15 @@ func defaultFrontendListenerIdentifier() listenerIdentifier {
 	}
 }
 
+var nonWordChars = regexp.MustCompile(`[^0-9a-zA-Z_]+`)
+
+// formatPath turns a URL path into a string, which can be used in an App Gateway property name. Paths, which only differ in
+// the characters replaced, such as "/api", "/api/" and "/api/*", are told apart by a short hash of the path.
+func formatPath(urlPath string) string {
+	hash := fmt.Sprintf("%x", md5.Sum([]byte(urlPath)))
+	return fmt.Sprintf("%s-%s", strings.Trim(nonWordChars.ReplaceAllString(urlPath, "-"), "-"), hash[:8])
+}
+
 // formatHostname formats the hostname, which could be an empty string.
 func formatHostname(hostName string) string {
 	// Hostname could be empty.
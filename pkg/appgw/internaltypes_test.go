@@ -213,6 +213,18 @@ var _ = Describe("Test string key generators", func() {
 		})
 	})
 
+	Context("test formatPath()", func() {
+		It("should give distinct names to paths, which only differ in the characters replaced", func() {
+			paths := []string{"/api", "/api/", "/api/*", "/a-b", "/a/b"}
+			names := make(map[string]interface{})
+			for _, path := range paths {
+				names[formatPath(path)] = nil
+			}
+			Expect(names).To(HaveLen(len(paths)))
+			Expect(formatPath("/api/*")).To(HavePrefix("api-"))
+		})
+	})
+
 	Context("test whether getResourceKey works correctly", func() {
 		It("should construct correct key", func() {
 			actual := getResourceKey(tests.Namespace, tests.Name)
@@ -0,0 +1,32 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/errors"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// reportInvalidAnnotations emits a warning on each Ingress with an annotation whose value cannot be parsed, naming the annotation,
+// the value and the accepted format. The setting of such an annotation is left to its default, as if the annotation was not there.
+func (c *appGwConfigBuilder) reportInvalidAnnotations(cbCtx *ConfigBuilderContext) {
+	for _, ingress := range cbCtx.IngressList {
+		for _, err := range annotations.Validate(ingress) {
+			if !errors.IsInvalidContent(err) {
+				continue
+			}
+			logLine := fmt.Sprintf("Ingress %s/%s: %s; the default is used", ingress.Namespace, ingress.Name, err)
+			glog.Warning(logLine)
+			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonInvalidAnnotation, logLine)
+		}
+	}
+}
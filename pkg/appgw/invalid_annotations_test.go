@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test reporting invalid annotations", func() {
+	var cb appGwConfigBuilder
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		cb.recorder = recorder
+	})
+
+	It("should report each invalid annotation with the accepted format", func() {
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.RequestTimeoutKey] = "thirty"
+		ingress.Annotations[annotations.SslRedirectKey] = "yes"
+		cb.reportInvalidAnnotations(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}})
+
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(events.ReasonInvalidAnnotation),
+			ContainSubstring(annotations.RequestTimeoutKey),
+			ContainSubstring(`"thirty"`),
+			ContainSubstring("expected a number of seconds between 1 and 86400"))))
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(annotations.SslRedirectKey),
+			ContainSubstring("expected true or false"))))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should not report valid annotations", func() {
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.RequestTimeoutKey] = "5m"
+		cb.reportInvalidAnnotations(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}})
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
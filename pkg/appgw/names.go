@@ -0,0 +1,271 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"strconv"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// ResourceNames holds the names of the App Gateway resources AGIC generates for a single path of an Ingress rule.
+type ResourceNames struct {
+	FrontendPort string `json:"frontendPort"`
+	Listener     string `json:"listener"`
+	RoutingRule  string `json:"requestRoutingRule"`
+
+	// URLPathMap and PathRule are empty for paths routed by a basic rule; PathRule is also empty for paths, which are the
+	// default backend of the URL path map ("", "/" and "/*").
+	URLPathMap string `json:"urlPathMap,omitempty"`
+	PathRule   string `json:"pathRule,omitempty"`
+
+	// BackendPool, HTTPSettings and Probe are empty for paths redirected to HTTPS.
+	BackendPool  string `json:"backendAddressPool,omitempty"`
+	HTTPSettings string `json:"backendHttpSettings,omitempty"`
+	Probe        string `json:"probe,omitempty"`
+}
+
+// PredictResourceNames returns the names of the App Gateway resources AGIC will generate for the path at index pathIdx of
+// the given Ingress rule, before the Ingress is deployed. frontendPort is the port of the listener (80 for HTTP, 443 for
+// HTTPS); backendPort is the port of the Pods the Service forwards traffic to (the resolved targetPort of the Service).
+// Existing resources named by the annotations of the Ingress are returned by their names; the names of the resources only
+// found in the config of those, such as the routing rule of an existing listener, are left empty. The prediction assumes
+// the path is not removed for conflicting with a path of an older Ingress, or a prohibited target; GetResourceNames reads
+// the names from the config once applied.
+func PredictResourceNames(ingress *v1beta1.Ingress, rule *v1beta1.IngressRule, pathIdx int, frontendPort int32, backendPort int32) ResourceNames {
+	path := &rule.HTTP.Paths[pathIdx]
+	backendID := generateBackendID(ingress, rule, path, &path.Backend)
+	names := ResourceNames{BackendPool: backendID.addressPoolName(backendPort)}
+
+	if listenerName, err := annotations.ListenerName(ingress); err == nil {
+		names.Listener = listenerName
+	} else {
+		listenerID := listenerIdentifier{
+			FrontendPort: frontendPort,
+			HostName:     rule.Host,
+		}
+		names.FrontendPort = generateFrontendPortName(frontendPort)
+		names.Listener = generateListenerName(listenerID)
+		names.RoutingRule = generateRequestRoutingRuleName(listenerID)
+		names.URLPathMap = generateURLPathMapName(listenerID)
+	}
+	if !isDefaultPath(path.Path) {
+		names.PathRule = generatePathRuleName(ingress.Namespace, ingress.Name, strconv.Itoa(pathIdx))
+	}
+
+	// The probe of existing HTTP settings is the one they use, whatever the Ingress names.
+	if httpSettingsName, err := annotations.BackendHTTPSettingsName(ingress); err == nil {
+		names.HTTPSettings = httpSettingsName
+	} else if probeName, err := annotations.HealthProbeName(ingress); err == nil {
+		names.HTTPSettings = backendID.httpSettingsName(backendPort)
+		names.Probe = probeName
+	} else {
+		names.HTTPSettings = backendID.httpSettingsName(backendPort)
+		names.Probe = backendID.probeName()
+	}
+	return names
+}
+
+// GetResourceNames returns the names of the App Gateway resources routing the path at index pathIdx of the given Ingress
+// rule, on the listener of the given frontend port (80 for HTTP, 443 for HTTPS). The names are read from the App Gateway
+// config built by AGIC, such as the last applied config, rather than predicted from the Ingress: paths conflicting with
+// those of older Ingresses are removed before the config is built, and annotations may name existing resources.
+// An error is returned when the config does not route the path.
+func GetResourceNames(appGw n.ApplicationGateway, ingress *v1beta1.Ingress, rule *v1beta1.IngressRule, pathIdx int, frontendPort int32) (ResourceNames, error) {
+	path := &rule.HTTP.Paths[pathIdx]
+	var names ResourceNames
+	if appGw.ApplicationGatewayPropertiesFormat == nil {
+		return names, fmt.Errorf("the App Gateway config is empty")
+	}
+
+	listener := findListener(appGw, ingress, rule.Host, frontendPort)
+	if listener == nil || listener.Name == nil {
+		return names, fmt.Errorf("there is no listener for host %q on frontend port %d", rule.Host, frontendPort)
+	}
+	names.Listener = *listener.Name
+	if listener.ApplicationGatewayHTTPListenerPropertiesFormat != nil && listener.FrontendPort != nil && listener.FrontendPort.ID != nil {
+		names.FrontendPort = utils.GetLastChunkOfSlashed(*listener.FrontendPort.ID)
+	}
+
+	routingRule := findRoutingRule(appGw, names.Listener)
+	if routingRule == nil {
+		return names, fmt.Errorf("there is no request routing rule for listener %s", names.Listener)
+	}
+	names.RoutingRule = *routingRule.Name
+
+	var backendPool, httpSettings *n.SubResource
+	if routingRule.RuleType != n.PathBasedRouting || routingRule.URLPathMap == nil || routingRule.URLPathMap.ID == nil {
+		if !isDefaultPath(path.Path) {
+			return names, fmt.Errorf("path %s is not routed by the basic request routing rule %s", path.Path, names.RoutingRule)
+		}
+		backendPool, httpSettings = routingRule.BackendAddressPool, routingRule.BackendHTTPSettings
+	} else {
+		names.URLPathMap = utils.GetLastChunkOfSlashed(*routingRule.URLPathMap.ID)
+		urlPathMap := findURLPathMap(appGw, names.URLPathMap)
+		if urlPathMap == nil {
+			return names, fmt.Errorf("there is no URL path map %s", names.URLPathMap)
+		}
+		if isDefaultPath(path.Path) {
+			backendPool, httpSettings = urlPathMap.DefaultBackendAddressPool, urlPathMap.DefaultBackendHTTPSettings
+		} else {
+			pathRule := findPathRule(urlPathMap, ingress, path.Path, pathIdx)
+			if pathRule == nil {
+				return names, fmt.Errorf("path %s of Ingress %s/%s is not in the URL path map %s", path.Path, ingress.Namespace, ingress.Name, names.URLPathMap)
+			}
+			names.PathRule = *pathRule.Name
+			backendPool, httpSettings = pathRule.BackendAddressPool, pathRule.BackendHTTPSettings
+		}
+	}
+
+	if backendPool != nil && backendPool.ID != nil {
+		names.BackendPool = utils.GetLastChunkOfSlashed(*backendPool.ID)
+	}
+	if httpSettings != nil && httpSettings.ID != nil {
+		names.HTTPSettings = utils.GetLastChunkOfSlashed(*httpSettings.ID)
+		names.Probe = findProbeOfHTTPSettings(appGw, names.HTTPSettings)
+	}
+	return names, nil
+}
+
+// findListener returns the existing listener named by the listener-name annotation of the Ingress, if any, or else the listener
+// of the hostname on the frontend port.
+func findListener(appGw n.ApplicationGateway, ingress *v1beta1.Ingress, host string, frontendPort int32) *n.ApplicationGatewayHTTPListener {
+	if appGw.HTTPListeners == nil {
+		return nil
+	}
+	listenerName, err := annotations.ListenerName(ingress)
+	for idx := range *appGw.HTTPListeners {
+		listener := &(*appGw.HTTPListeners)[idx]
+		if listener.Name == nil || listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil {
+			continue
+		}
+		if err == nil {
+			if *listener.Name == listenerName {
+				return listener
+			}
+			continue
+		}
+		listenerHost := ""
+		if listener.HostName != nil {
+			listenerHost = *listener.HostName
+		}
+		if listenerHost == host && listener.FrontendPort != nil && listener.FrontendPort.ID != nil &&
+			getFrontendPortNumber(appGw, utils.GetLastChunkOfSlashed(*listener.FrontendPort.ID)) == frontendPort {
+			return listener
+		}
+	}
+	return nil
+}
+
+func getFrontendPortNumber(appGw n.ApplicationGateway, name string) int32 {
+	if appGw.FrontendPorts == nil {
+		return 0
+	}
+	for _, port := range *appGw.FrontendPorts {
+		if port.Name != nil && *port.Name == name && port.ApplicationGatewayFrontendPortPropertiesFormat != nil && port.Port != nil {
+			return *port.Port
+		}
+	}
+	return 0
+}
+
+func findRoutingRule(appGw n.ApplicationGateway, listenerName string) *n.ApplicationGatewayRequestRoutingRule {
+	if appGw.RequestRoutingRules == nil {
+		return nil
+	}
+	for idx := range *appGw.RequestRoutingRules {
+		rule := &(*appGw.RequestRoutingRules)[idx]
+		if rule.Name != nil && rule.ApplicationGatewayRequestRoutingRulePropertiesFormat != nil && rule.HTTPListener != nil &&
+			rule.HTTPListener.ID != nil && utils.GetLastChunkOfSlashed(*rule.HTTPListener.ID) == listenerName {
+			return rule
+		}
+	}
+	return nil
+}
+
+func findURLPathMap(appGw n.ApplicationGateway, name string) *n.ApplicationGatewayURLPathMap {
+	if appGw.URLPathMaps == nil {
+		return nil
+	}
+	for idx := range *appGw.URLPathMaps {
+		urlPathMap := &(*appGw.URLPathMaps)[idx]
+		if urlPathMap.Name != nil && *urlPathMap.Name == name && urlPathMap.ApplicationGatewayURLPathMapPropertiesFormat != nil {
+			return urlPathMap
+		}
+	}
+	return nil
+}
+
+// findPathRule returns the path rule of the Ingress with the path, at index pathIdx of its rule. The path may be in the URL path
+// map for an older Ingress, which defined it first. Path rules are named after their Ingress and the index of the path, which
+// is lower than pathIdx when paths before it were removed for conflicting with those of older Ingresses.
+func findPathRule(urlPathMap *n.ApplicationGatewayURLPathMap, ingress *v1beta1.Ingress, urlPath string, pathIdx int) *n.ApplicationGatewayPathRule {
+	if urlPathMap.PathRules == nil {
+		return nil
+	}
+	names := make(map[string]interface{})
+	for idx := 0; idx <= pathIdx; idx++ {
+		names[generatePathRuleName(ingress.Namespace, ingress.Name, strconv.Itoa(idx))] = nil
+	}
+	for idx := range *urlPathMap.PathRules {
+		pathRule := &(*urlPathMap.PathRules)[idx]
+		if pathRule.Name == nil || pathRule.ApplicationGatewayPathRulePropertiesFormat == nil || pathRule.Paths == nil {
+			continue
+		}
+		if _, isIngressPathRule := names[*pathRule.Name]; !isIngressPathRule {
+			continue
+		}
+		for _, path := range *pathRule.Paths {
+			if path == urlPath {
+				return pathRule
+			}
+		}
+	}
+	return nil
+}
+
+// findProbeOfHTTPSettings returns the name of the probe used by the HTTP settings, or an empty one when they use none.
+func findProbeOfHTTPSettings(appGw n.ApplicationGateway, httpSettingsName string) string {
+	if appGw.BackendHTTPSettingsCollection == nil {
+		return ""
+	}
+	for _, settings := range *appGw.BackendHTTPSettingsCollection {
+		if settings.Name != nil && *settings.Name == httpSettingsName && settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat != nil &&
+			settings.Probe != nil && settings.Probe.ID != nil {
+			return utils.GetLastChunkOfSlashed(*settings.Probe.ID)
+		}
+	}
+	return ""
+}
+
+func (id backendIdentifier) addressPoolName(backendPort int32) string {
+	return generateAddressPoolName(id.serviceFullName(), id.Backend.ServicePort.String(), backendPort)
+}
+
+func (id backendIdentifier) httpSettingsName(backendPort int32) string {
+	if _, hasPathProbe := id.pathProbe(); hasPathProbe {
+		// Paths with a dedicated probe need dedicated HTTP settings, which reference that probe.
+		return generatePathHTTPSettingsName(id.serviceFullName(), id.Backend.ServicePort.String(), backendPort, id.Ingress.Name, id.Path.Path)
+	}
+	return generateHTTPSettingsName(id.serviceFullName(), id.Backend.ServicePort.String(), backendPort, id.Ingress.Name)
+}
+
+func (id backendIdentifier) probeName() string {
+	if _, hasPathProbe := id.pathProbe(); hasPathProbe {
+		return generatePathProbeName(id.Backend.ServiceName, id.Backend.ServicePort.String(), id.Ingress, id.Path.Path)
+	}
+	return generateProbeName(id.Backend.ServiceName, id.Backend.ServicePort.String(), id.Ingress)
+}
+
+// isDefaultPath tells whether an Ingress path catches all traffic, in which case it becomes the default backend of a URL path map.
+func isDefaultPath(urlPath string) bool {
+	return len(urlPath) == 0 || urlPath == "/*" || urlPath == "/"
+}
@@ -0,0 +1,169 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test resource name resolution", func() {
+	now := time.Now()
+	newIngress := func(name string, created time.Time, paths ...string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		ingress.CreationTimestamp = metav1.NewTime(created)
+		ingress.Spec.TLS = nil
+		delete(ingress.Annotations, annotations.SslRedirectKey)
+		rule := tests.NewIngressRuleFixture(tests.Host, paths[0], *tests.NewIngressBackendFixture(tests.ServiceName, 80))
+		for _, path := range paths[1:] {
+			rule.HTTP.Paths = append(rule.HTTP.Paths, v1beta1.HTTPIngressPath{Path: path, Backend: *tests.NewIngressBackendFixture(tests.ServiceName, 80)})
+		}
+		ingress.Spec.Rules = []v1beta1.IngressRule{rule}
+		return ingress
+	}
+	build := func(ingressList ...*v1beta1.Ingress) n.ApplicationGateway {
+		cb := newConfigBuilderFixture(nil)
+		_ = cb.k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = cb.k8sContext.Caches.Pods.Add(tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort))
+		appGw, err := cb.Build(&ConfigBuilderContext{
+			IngressList:  ingressList,
+			ServiceList:  []*v1.Service{tests.NewServiceFixture()},
+			EnvVariables: environment.GetFakeEnv(),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return *appGw
+	}
+
+	Context("test PredictResourceNames()", func() {
+		It("should return the names of all resources generated for an Ingress path", func() {
+			ingress := tests.NewIngressFixture()
+			names := PredictResourceNames(ingress, &ingress.Spec.Rules[0], 0, 443, 8080)
+			Expect(names).To(Equal(ResourceNames{
+				FrontendPort: agPrefix + "fp-443",
+				Listener:     agPrefix + "fl-bye.com-443",
+				RoutingRule:  agPrefix + "rr-bye.com-443",
+				URLPathMap:   agPrefix + "url-bye.com-443",
+				PathRule:     agPrefix + "pr---namespace-----name---0",
+				BackendPool:  agPrefix + "pool---namespace-----service-name---80-bp-8080",
+				HTTPSettings: agPrefix + "bp---namespace-----service-name---80-8080---name--",
+				Probe:        agPrefix + "pb---namespace-----service-name---80---name--",
+			}))
+		})
+
+		It("should predict the names of the config built for the Ingress", func() {
+			ingress := newIngress("shop", now, "/api", "/*")
+			appGw := build(ingress)
+			for pathIdx := range ingress.Spec.Rules[0].HTTP.Paths {
+				names, err := GetResourceNames(appGw, ingress, &ingress.Spec.Rules[0], pathIdx, 80)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(PredictResourceNames(ingress, &ingress.Spec.Rules[0], pathIdx, 80, 9876)).To(Equal(names))
+			}
+		})
+
+		It("should not return a path rule for a catch-all path", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Spec.Rules[0].HTTP.Paths[0].Path = "/*"
+			names := PredictResourceNames(ingress, &ingress.Spec.Rules[0], 0, 80, 8080)
+			Expect(names.PathRule).To(Equal(""))
+			Expect(names.Listener).To(Equal(agPrefix + "fl-bye.com-80"))
+		})
+
+		It("should return the names of the dedicated probe and HTTP settings of a path with its own health probe", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.HealthProbePathsKey] = tests.URLPath + "=/status"
+			names := PredictResourceNames(ingress, &ingress.Spec.Rules[0], 0, 80, 8080)
+			Expect(names.HTTPSettings).To(Equal(agPrefix + "bp---namespace-----service-name---80-8080---name---" + formatPath(tests.URLPath)))
+			Expect(names.Probe).To(Equal(agPrefix + "pb---namespace-----service-name---80---name---" + formatPath(tests.URLPath)))
+		})
+
+		It("should return the names of the existing resources named by annotations", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.ListenerNameKey] = "shared-listener"
+			ingress.Annotations[annotations.HealthProbeNameKey] = "shared-probe"
+			names := PredictResourceNames(ingress, &ingress.Spec.Rules[0], 0, 80, 8080)
+			Expect(names.Listener).To(Equal("shared-listener"))
+			Expect(names.FrontendPort).To(Equal(""))
+			Expect(names.RoutingRule).To(Equal(""))
+			Expect(names.URLPathMap).To(Equal(""))
+			Expect(names.HTTPSettings).To(Equal(agPrefix + "bp---namespace-----service-name---80-8080---name--"))
+			Expect(names.Probe).To(Equal("shared-probe"))
+
+			ingress.Annotations[annotations.BackendHTTPSettingsNameKey] = "shared-settings"
+			names = PredictResourceNames(ingress, &ingress.Spec.Rules[0], 0, 80, 8080)
+			Expect(names.HTTPSettings).To(Equal("shared-settings"))
+			Expect(names.Probe).To(Equal(""))
+		})
+	})
+
+	Context("test GetResourceNames()", func() {
+		It("should return the names of all resources the built config uses for an Ingress path", func() {
+			ingress := newIngress("shop", now, "/api")
+			names, err := GetResourceNames(build(ingress), ingress, &ingress.Spec.Rules[0], 0, 80)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal(ResourceNames{
+				FrontendPort: agPrefix + "fp-80",
+				Listener:     agPrefix + "fl-bye.com-80",
+				RoutingRule:  agPrefix + "rr-bye.com-80",
+				URLPathMap:   agPrefix + "url-bye.com-80",
+				PathRule:     agPrefix + "pr---namespace---shop-0",
+				BackendPool:  agPrefix + "pool---namespace-----service-name---80-bp-9876",
+				HTTPSettings: agPrefix + "bp---namespace-----service-name---80-9876-shop",
+				Probe:        agPrefix + "pb---namespace-----service-name---80-shop",
+			}))
+		})
+
+		It("should return the names of the default backend of the URL path map for a catch-all path", func() {
+			ingress := newIngress("shop", now, "/api", "/*")
+			names, err := GetResourceNames(build(ingress), ingress, &ingress.Spec.Rules[0], 1, 80)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names.URLPathMap).To(Equal(agPrefix + "url-bye.com-80"))
+			Expect(names.PathRule).To(Equal(""))
+			Expect(names.BackendPool).To(Equal(agPrefix + "pool---namespace-----service-name---80-bp-9876"))
+		})
+
+		It("should return the names of the path rule built for a path, which follows a path removed for a conflict", func() {
+			older := newIngress("older", now.Add(-time.Hour), "/api")
+			newer := newIngress("newer", now, "/api", "/cart")
+			appGw := build(older, newer)
+
+			names, err := GetResourceNames(appGw, newer, &newer.Spec.Rules[0], 1, 80)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names.PathRule).To(Equal(agPrefix + "pr---namespace---newer-0"))
+
+			_, err = GetResourceNames(appGw, newer, &newer.Spec.Rules[0], 0, 80)
+			Expect(err).To(MatchError("path /api of Ingress --namespace--/newer is not in the URL path map " + agPrefix + "url-bye.com-80"))
+		})
+
+		It("should return the names of the dedicated probe and HTTP settings of a path with its own health probe", func() {
+			ingress := newIngress("shop", now, "/api")
+			ingress.Annotations[annotations.HealthProbePathsKey] = "/api=/status"
+			names, err := GetResourceNames(build(ingress), ingress, &ingress.Spec.Rules[0], 0, 80)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names.HTTPSettings).To(Equal(agPrefix + "bp---namespace-----service-name---80-9876-shop-" + formatPath("/api")))
+			Expect(names.Probe).To(Equal(agPrefix + "pb---namespace-----service-name---80-shop-" + formatPath("/api")))
+		})
+
+		It("should fail when the config has no listener for the frontend port", func() {
+			ingress := newIngress("shop", now, "/api")
+			_, err := GetResourceNames(build(ingress), ingress, &ingress.Spec.Rules[0], 0, 443)
+			Expect(err).To(MatchError(`there is no listener for host "bye.com" on frontend port 443`))
+		})
+	})
+})
@@ -0,0 +1,143 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// defaultQuotaKey is the key of the quota applied to namespaces without a quota of their own.
+const defaultQuotaKey = "*"
+
+// namespaceQuota limits the App Gateway resources AGIC creates for the Ingresses of a namespace. Nil limits are unlimited.
+type namespaceQuota struct {
+	Listeners    *int `json:"listeners,omitempty"`
+	Paths        *int `json:"paths,omitempty"`
+	Certificates *int `json:"certificates,omitempty"`
+}
+
+// namespaceUsage is the count of App Gateway resources created for the Ingresses of a namespace.
+type namespaceUsage struct {
+	listeners    map[listenerIdentifier]interface{}
+	paths        int
+	certificates map[secretIdentifier]interface{}
+}
+
+// parseNamespaceQuotas parses the quotas keyed by namespace, such as {"*": {"listeners": 10}, "team-a": {"paths": 50}}.
+func parseNamespaceQuotas(spec string) (map[string]namespaceQuota, error) {
+	quotas := make(map[string]namespaceQuota)
+	if strings.TrimSpace(spec) == "" {
+		return quotas, nil
+	}
+	if err := json.Unmarshal([]byte(spec), &quotas); err != nil {
+		return nil, fmt.Errorf("invalid namespace quotas %q: %s", spec, err)
+	}
+	return quotas, nil
+}
+
+// exceeded returns the limits of the quota, which the usage is over.
+func (q namespaceQuota) exceeded(usage namespaceUsage) []string {
+	var exceeded []string
+	check := func(resource string, limit *int, used int) {
+		if limit != nil && used > *limit {
+			exceeded = append(exceeded, fmt.Sprintf("%d %s (quota %d)", used, resource, *limit))
+		}
+	}
+	check("listeners", q.Listeners, len(usage.listeners))
+	check("paths", q.Paths, usage.paths)
+	check("certificates", q.Certificates, len(usage.certificates))
+	return exceeded
+}
+
+// excludeIngressesOverQuota removes the Ingresses, which would take their namespace over its quota, from the config builder
+// context and emits an event for each. Ingresses are admitted oldest first, so newly created Ingresses cannot evict existing ones.
+// Quotas are enforced by Build rather than PreBuildValidate: a quota excludes Ingresses from the context, while
+// PreBuildValidate only reports, and failing it would block the Ingresses of all namespaces sharing the App Gateway.
+func (c *appGwConfigBuilder) excludeIngressesOverQuota(cbCtx *ConfigBuilderContext) error {
+	quotas, err := parseNamespaceQuotas(cbCtx.EnvVariables.NamespaceQuotas)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	usages := make(map[string]namespaceUsage)
+	excluded := make(map[*v1beta1.Ingress]interface{})
+	for _, ingress := range oldestFirst(cbCtx.IngressList) {
+		quota, exists := quotas[ingress.Namespace]
+		if !exists {
+			if quota, exists = quotas[defaultQuotaKey]; !exists {
+				continue
+			}
+		}
+
+		current, exists := usages[ingress.Namespace]
+		if !exists {
+			current = namespaceUsage{
+				listeners:    make(map[listenerIdentifier]interface{}),
+				certificates: make(map[secretIdentifier]interface{}),
+			}
+		}
+		usage := c.addIngressUsage(current, ingress)
+		if exceeded := quota.exceeded(usage); len(exceeded) > 0 {
+			logLine := fmt.Sprintf("Ingress %s/%s would take namespace %s to %s; the Ingress is ignored",
+				ingress.Namespace, ingress.Name, ingress.Namespace, strings.Join(exceeded, ", "))
+			glog.Warning(logLine)
+			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonNamespaceQuotaExceeded, logLine)
+			excluded[ingress] = nil
+			continue
+		}
+		usages[ingress.Namespace] = usage
+	}
+
+	var ingressList []*v1beta1.Ingress
+	for _, ingress := range cbCtx.IngressList {
+		if _, isExcluded := excluded[ingress]; !isExcluded {
+			ingressList = append(ingressList, ingress)
+		}
+	}
+	cbCtx.IngressList = ingressList
+	return nil
+}
+
+// addIngressUsage returns the usage of the namespace with the resources of the given Ingress added; the given usage is not modified.
+func (c *appGwConfigBuilder) addIngressUsage(usage namespaceUsage, ingress *v1beta1.Ingress) namespaceUsage {
+	result := namespaceUsage{
+		listeners:    make(map[listenerIdentifier]interface{}),
+		paths:        usage.paths,
+		certificates: make(map[secretIdentifier]interface{}),
+	}
+	for listenerID := range usage.listeners {
+		result.listeners[listenerID] = nil
+	}
+	for secretID := range usage.certificates {
+		result.certificates[secretID] = nil
+	}
+
+	_, listeners := c.processIngressRules(ingress)
+	for listenerID, azConfig := range listeners {
+		result.listeners[listenerID] = nil
+		if azConfig.Protocol == n.HTTPS {
+			result.certificates[azConfig.Secret] = nil
+		}
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP != nil {
+			result.paths += len(rule.HTTP.Paths)
+		}
+	}
+	return result
+}
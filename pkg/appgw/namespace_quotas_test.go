@@ -0,0 +1,109 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test namespace quotas", func() {
+
+	Context("test parseNamespaceQuotas()", func() {
+		It("should parse quotas", func() {
+			quotas, err := parseNamespaceQuotas(`{"*": {"listeners": 10}, "team-a": {"paths": 5, "certificates": 0}}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*quotas["*"].Listeners).To(Equal(10))
+			Expect(quotas["*"].Paths).To(BeNil())
+			Expect(*quotas["team-a"].Paths).To(Equal(5))
+			Expect(*quotas["team-a"].Certificates).To(Equal(0))
+		})
+
+		It("should fail on malformed quotas", func() {
+			_, err := parseNamespaceQuotas(`listeners=10`)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("test excludeIngressesOverQuota()", func() {
+		var cb appGwConfigBuilder
+		var recorder *record.FakeRecorder
+		var older, newer *v1beta1.Ingress
+
+		// Each fixture Ingress has 2 paths, an HTTP and an HTTPS listener for the same host, and 1 certificate.
+		newContext := func(quotas string) *ConfigBuilderContext {
+			env := environment.GetFakeEnv()
+			env.NamespaceQuotas = quotas
+			return &ConfigBuilderContext{
+				IngressList:  []*v1beta1.Ingress{newer, older},
+				EnvVariables: env,
+			}
+		}
+
+		BeforeEach(func() {
+			certs := newCertsFixture()
+			cb = newConfigBuilderFixture(&certs)
+			recorder = record.NewFakeRecorder(100)
+			cb.recorder = recorder
+			older = tests.NewIngressFixture()
+			older.Name = "older"
+			older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+			newer = tests.NewIngressFixture()
+			newer.Name = "newer"
+			newer.CreationTimestamp = metav1.NewTime(time.Now())
+		})
+
+		It("should keep all Ingresses within the quota", func() {
+			cbCtx := newContext(`{"*": {"listeners": 2, "paths": 4, "certificates": 1}}`)
+			Expect(cb.excludeIngressesOverQuota(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{newer, older}))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("should exclude the newest Ingress over the quota of its namespace", func() {
+			cbCtx := newContext(`{"` + tests.Namespace + `": {"paths": 3}}`)
+			Expect(cb.excludeIngressesOverQuota(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{older}))
+			Expect(recorder.Events).To(Receive(ContainSubstring(events.ReasonNamespaceQuotaExceeded)))
+		})
+
+		It("should count certificates", func() {
+			cbCtx := newContext(`{"*": {"certificates": 0}}`)
+			Expect(cb.excludeIngressesOverQuota(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(BeEmpty())
+		})
+
+		It("should not restrict namespaces without a quota", func() {
+			cbCtx := newContext(`{"other-namespace": {"paths": 0}}`)
+			Expect(cb.excludeIngressesOverQuota(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(HaveLen(2))
+		})
+
+		It("should enforce the quotas when the allowed frontend ports cannot be parsed", func() {
+			cbCtx := newContext(`{"*": {"certificates": 0}}`)
+			cbCtx.EnvVariables.AllowedFrontendPorts = "http"
+			cb.excludeResources(cbCtx)
+			Expect(cbCtx.IngressList).To(BeEmpty())
+		})
+
+		It("should leave the context to Build, when validating", func() {
+			cbCtx := newContext(`{"*": {"certificates": 0}}`)
+			Expect(cb.PreBuildValidate(cbCtx)).To(Succeed())
+			Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{newer, older}))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// taintMatcher matches the taints with the key, and with the value unless any value matches.
+type taintMatcher struct {
+	key      string
+	value    string
+	anyValue bool
+}
+
+// nodeExclusion matches the nodes, whose Pods are left out of the backend pools, such as spot nodes being drained or infrastructure nodes.
+type nodeExclusion struct {
+	selector labels.Selector
+	taints   []taintMatcher
+}
+
+// parseNodeExclusion parses a label selector, such as "agentpool=infra", and a comma separated list of taints as "key" or "key=value".
+// It returns nil when neither is given.
+func parseNodeExclusion(selector, taints string) (*nodeExclusion, error) {
+	if strings.TrimSpace(selector) == "" && strings.TrimSpace(taints) == "" {
+		return nil, nil
+	}
+	exclusion := &nodeExclusion{selector: labels.Nothing()}
+	if strings.TrimSpace(selector) != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, err
+		}
+		exclusion.selector = parsed
+	}
+	for _, taint := range strings.Split(taints, ",") {
+		taint = strings.TrimSpace(taint)
+		if taint == "" {
+			continue
+		}
+		chunks := strings.SplitN(taint, "=", 2)
+		if chunks[0] == "" {
+			return nil, fmt.Errorf("malformed taint %q", taint)
+		}
+		matcher := taintMatcher{key: chunks[0], anyValue: len(chunks) == 1}
+		if !matcher.anyValue {
+			matcher.value = chunks[1]
+		}
+		exclusion.taints = append(exclusion.taints, matcher)
+	}
+	return exclusion, nil
+}
+
+// matches tells whether the node has the labels of the selector, or any of the taints.
+func (e *nodeExclusion) matches(node *v1.Node) bool {
+	if e.selector.Matches(labels.Set(node.Labels)) {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		for _, matcher := range e.taints {
+			if taint.Key == matcher.key && (matcher.anyValue || taint.Value == matcher.value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getNodeExclusion returns the nodes excluded by the controller settings, or nil when none are.
+func (c *appGwConfigBuilder) getNodeExclusion(cbCtx *ConfigBuilderContext) *nodeExclusion {
+	exclusion, err := parseNodeExclusion(cbCtx.EnvVariables.ExcludeNodesSelector, cbCtx.EnvVariables.ExcludeNodesTaints)
+	if err != nil {
+		glog.Errorf("Unable to parse the nodes to exclude from the backend pools; keeping all nodes: %s", err)
+		return nil
+	}
+	return exclusion
+}
+
+// dropAddressesOnNodes returns the subset without the addresses of the Pods on the nodes, which are excluded.
+// Addresses without a node, or on nodes missing from the cache, are kept.
+func (c *appGwConfigBuilder) dropAddressesOnNodes(serviceKey string, subset v1.EndpointSubset, nodes string, excluded func(*v1.Node) bool) v1.EndpointSubset {
+	var kept []v1.EndpointAddress
+	for _, address := range subset.Addresses {
+		if address.NodeName != nil {
+			if node := c.k8sContext.GetNode(*address.NodeName); node != nil && excluded(node) {
+				continue
+			}
+		}
+		kept = append(kept, address)
+	}
+	if dropped := len(subset.Addresses) - len(kept); dropped > 0 {
+		glog.V(3).Infof("Excluded %d backend addresses of service %s on %s", dropped, serviceKey, nodes)
+	}
+
+	filtered := subset
+	filtered.Addresses = kept
+	return filtered
+}
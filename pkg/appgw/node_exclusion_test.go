@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test excluding nodes from backend pools", func() {
+	newNode := func(labels map[string]string, taints ...v1.Taint) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Spec:       v1.NodeSpec{Taints: taints},
+		}
+	}
+	spotTaint := v1.Taint{Key: "kubernetes.azure.com/scalesetpriority", Value: "spot", Effect: v1.TaintEffectNoSchedule}
+
+	It("should exclude nothing without settings", func() {
+		exclusion, err := parseNodeExclusion("", " ")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exclusion).To(BeNil())
+	})
+
+	It("should exclude the nodes matching the selector", func() {
+		exclusion, err := parseNodeExclusion("agentpool in (infra, system)", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exclusion.matches(newNode(map[string]string{"agentpool": "infra"}))).To(BeTrue())
+		Expect(exclusion.matches(newNode(map[string]string{"agentpool": "apps"}, spotTaint))).To(BeFalse())
+	})
+
+	It("should exclude the nodes with the taints", func() {
+		exclusion, err := parseNodeExclusion("", "kubernetes.azure.com/scalesetpriority=spot, node.kubernetes.io/unschedulable")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exclusion.matches(newNode(nil, spotTaint))).To(BeTrue())
+		Expect(exclusion.matches(newNode(nil, v1.Taint{Key: "node.kubernetes.io/unschedulable", Effect: v1.TaintEffectNoSchedule}))).To(BeTrue())
+		Expect(exclusion.matches(newNode(nil, v1.Taint{Key: "kubernetes.azure.com/scalesetpriority", Value: "regular"}))).To(BeFalse())
+		Expect(exclusion.matches(newNode(map[string]string{"agentpool": "infra"}))).To(BeFalse())
+	})
+
+	It("should fail on malformed settings", func() {
+		_, err := parseNodeExclusion("agentpool in infra", "")
+		Expect(err).To(HaveOccurred())
+		_, err = parseNodeExclusion("", "=spot")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,151 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// pathKey identifies a path in the URL path map shared by the Ingresses using a hostname on a frontend port, or routed through
+// an existing listener. All paths matching everything ("", "/" and "/*") set the default backend of the URL path map, and are
+// one and the same path.
+type pathKey struct {
+	host         string
+	frontendPort int32
+	listener     string
+	path         string
+}
+
+// pathClaim is the Ingress whose path is kept, and the path as written in that Ingress.
+type pathClaim struct {
+	ingress *v1beta1.Ingress
+	path    string
+}
+
+// mergeIngresses orders the Ingresses oldest first, so the paths of all Ingresses using a hostname are merged into the URL path map
+// of its listener in the same order from one build to the next. A path defined by more than one Ingress is kept in the oldest one,
+// and removed from the others; a warning naming both Ingresses is emitted on each. App Gateway does not allow duplicate paths.
+// Ingresses are copied before removing paths; the cached objects are not modified.
+func (c *appGwConfigBuilder) mergeIngresses(cbCtx *ConfigBuilderContext) {
+	claims := make(map[pathKey]pathClaim)
+	var ingressList []*v1beta1.Ingress
+	for _, ingress := range oldestFirst(cbCtx.IngressList) {
+		listener := ""
+		if c.getReferencedListener(ingress) != nil {
+			listener, _ = annotations.ListenerName(ingress)
+		}
+		_, listenerConfigs := c.processIngressRules(ingress)
+
+		pruned := false
+		var rules []v1beta1.IngressRule
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				rules = append(rules, rule)
+				continue
+			}
+			// The path is in the URL path map of each listener of the rule; an existing listener has a single one.
+			frontendPorts := frontendPortsOfHost(listenerConfigs, rule.Host)
+			if listener != "" {
+				frontendPorts = []int32{0}
+			}
+			var paths []v1beta1.HTTPIngressPath
+			for _, path := range rule.HTTP.Paths {
+				var keys []pathKey
+				for _, frontendPort := range frontendPorts {
+					key := pathKey{host: rule.Host, frontendPort: frontendPort, listener: listener, path: path.Path}
+					if listener != "" {
+						key.host = ""
+					}
+					if isDefaultPath(path.Path) {
+						key.path = "/*"
+					}
+					keys = append(keys, key)
+				}
+				if claim, exists := claimOf(claims, keys); exists {
+					c.reportPathConflict(claim, ingress, rule.Host, path.Path)
+					continue
+				}
+				for _, key := range keys {
+					claims[key] = pathClaim{ingress: ingress, path: path.Path}
+				}
+				paths = append(paths, path)
+			}
+			if len(paths) == len(rule.HTTP.Paths) {
+				rules = append(rules, rule)
+				continue
+			}
+			pruned = true
+			// A rule left without paths would still get a listener; it is removed along with its paths.
+			if len(paths) == 0 {
+				continue
+			}
+			rule = *rule.DeepCopy()
+			rule.HTTP.Paths = paths
+			rules = append(rules, rule)
+		}
+
+		if !pruned {
+			ingressList = append(ingressList, ingress)
+			continue
+		}
+		if len(rules) == 0 && ingress.Spec.Backend == nil {
+			continue
+		}
+		copied := ingress.DeepCopy()
+		copied.Spec.Rules = rules
+		ingressList = append(ingressList, copied)
+	}
+	cbCtx.IngressList = ingressList
+}
+
+// frontendPortsOfHost returns the frontend ports of the listeners of the hostname.
+func frontendPortsOfHost(listenerConfigs map[listenerIdentifier]listenerAzConfig, host string) []int32 {
+	var frontendPorts []int32
+	for listenerID := range listenerConfigs {
+		if listenerID.HostName == host {
+			frontendPorts = append(frontendPorts, listenerID.FrontendPort)
+		}
+	}
+	return frontendPorts
+}
+
+// claimOf returns the claim of any of the keys of a path.
+func claimOf(claims map[pathKey]pathClaim, keys []pathKey) (pathClaim, bool) {
+	for _, key := range keys {
+		if claim, exists := claims[key]; exists {
+			return claim, true
+		}
+	}
+	return pathClaim{}, false
+}
+
+// reportPathConflict emits a warning on both the Ingress keeping the path, and the Ingress from which it is removed.
+func (c *appGwConfigBuilder) reportPathConflict(claim pathClaim, ingress *v1beta1.Ingress, host, path string) {
+	if host == "" {
+		host = "*"
+	}
+	if claim.ingress.Namespace == ingress.Namespace && claim.ingress.Name == ingress.Name {
+		logLine := fmt.Sprintf("Path %s of host %s is defined more than once by Ingress %s/%s; only the first one is used", path, host, ingress.Namespace, ingress.Name)
+		glog.Warning(logLine)
+		c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonPathConflict, logLine)
+		return
+	}
+
+	logLine := fmt.Sprintf("Path %s of host %s of Ingress %s/%s conflicts with path %s of the older Ingress %s/%s; the path is ignored",
+		path, host, ingress.Namespace, ingress.Name, claim.path, claim.ingress.Namespace, claim.ingress.Name)
+	glog.Warning(logLine)
+	c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonPathConflict, logLine)
+	c.recorder.Event(claim.ingress, v1.EventTypeWarning, events.ReasonPathConflict,
+		fmt.Sprintf("Path %s of host %s of Ingress %s/%s conflicts with path %s of the newer Ingress %s/%s; the path of Ingress %s/%s is used",
+			claim.path, host, claim.ingress.Namespace, claim.ingress.Name, path, ingress.Namespace, ingress.Name, claim.ingress.Namespace, claim.ingress.Name))
+}
@@ -0,0 +1,144 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test merging Ingresses sharing a host", func() {
+	var cb appGwConfigBuilder
+	var recorder *record.FakeRecorder
+
+	now := time.Now()
+	newIngress := func(namespace, name string, created time.Time, host string, paths ...string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Namespace = namespace
+		ingress.Name = name
+		ingress.CreationTimestamp = metav1.NewTime(created)
+		rule := tests.NewIngressRuleFixture(host, paths[0], *tests.NewIngressBackendFixture(tests.ServiceName, 80))
+		for _, path := range paths[1:] {
+			rule.HTTP.Paths = append(rule.HTTP.Paths, v1beta1.HTTPIngressPath{Path: path, Backend: *tests.NewIngressBackendFixture(tests.ServiceName, 80)})
+		}
+		ingress.Spec.Rules = []v1beta1.IngressRule{rule}
+		return ingress
+	}
+	pathsOf := func(ingress *v1beta1.Ingress) []string {
+		var paths []string
+		for _, rule := range ingress.Spec.Rules {
+			for _, path := range rule.HTTP.Paths {
+				paths = append(paths, path.Path)
+			}
+		}
+		return paths
+	}
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		cb.recorder = recorder
+	})
+
+	It("should order the Ingresses oldest first, and keep distinct paths of the same host", func() {
+		newer := newIngress("b", "newer", now, "shared.com", "/b")
+		older := newIngress("a", "older", now.Add(-time.Hour), "shared.com", "/a")
+		cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{newer, older}}
+		cb.mergeIngresses(cbCtx)
+
+		Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{older, newer}))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should keep a conflicting path in the oldest Ingress, and report both Ingresses", func() {
+		newer := newIngress("b", "newer", now, "shared.com", "/api", "/b")
+		older := newIngress("a", "older", now.Add(-time.Hour), "shared.com", "/api")
+		cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{newer, older}}
+		cb.mergeIngresses(cbCtx)
+
+		Expect(cbCtx.IngressList).To(HaveLen(2))
+		Expect(cbCtx.IngressList[0]).To(Equal(older))
+		Expect(pathsOf(cbCtx.IngressList[1])).To(Equal([]string{"/b"}))
+		// The cached Ingress is not modified.
+		Expect(pathsOf(newer)).To(Equal([]string{"/api", "/b"}))
+
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(events.ReasonPathConflict),
+			ContainSubstring("Ingress b/newer"),
+			ContainSubstring("older Ingress a/older"))))
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(events.ReasonPathConflict),
+			ContainSubstring("Ingress a/older"),
+			ContainSubstring("newer Ingress b/newer"))))
+	})
+
+	It("should treat all paths matching everything as the same path", func() {
+		newer := newIngress("b", "newer", now, "shared.com", "/*")
+		older := newIngress("a", "older", now.Add(-time.Hour), "shared.com", "/")
+		cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{newer, older}}
+		cb.mergeIngresses(cbCtx)
+
+		Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{older}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Path /* of host shared.com of Ingress b/newer conflicts with path / of the older Ingress a/older")))
+	})
+
+	It("should not report the same path of different hosts", func() {
+		first := newIngress("a", "first", now, "a.com", "/api")
+		second := newIngress("a", "second", now, "b.com", "/api")
+		cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{first, second}}
+		cb.mergeIngresses(cbCtx)
+
+		Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{first, second}))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should not report the same path of a host on different frontend ports", func() {
+		secret := secretIdentifier{Namespace: "a", Name: "tls"}
+		cb = newConfigBuilderFixture(&map[string]interface{}{secret.secretKey(): []byte("abc")})
+		cb.recorder = recorder
+		withTLS := func(ingress *v1beta1.Ingress) *v1beta1.Ingress {
+			ingress.Spec.TLS = []v1beta1.IngressTLS{{Hosts: []string{"shared.com"}, SecretName: secret.Name}}
+			return ingress
+		}
+
+		// The HTTPS listener on port 443 and the HTTP listener on port 80 each have a URL path map of their own.
+		https := withTLS(newIngress("a", "https", now.Add(-time.Hour), "shared.com", "/api"))
+		delete(https.Annotations, annotations.SslRedirectKey)
+		http := newIngress("b", "http", now, "shared.com", "/api")
+		http.Spec.TLS = nil
+		cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{https, http}}
+		cb.mergeIngresses(cbCtx)
+		Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{https, http}))
+		Expect(recorder.Events).To(BeEmpty())
+
+		// With ssl-redirect the HTTPS Ingress has a listener on port 80 as well.
+		https.Annotations[annotations.SslRedirectKey] = "true"
+		cbCtx = &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{https, http}}
+		cb.mergeIngresses(cbCtx)
+		Expect(cbCtx.IngressList).To(Equal([]*v1beta1.Ingress{https}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Path /api of host shared.com of Ingress b/http conflicts with path /api of the older Ingress a/https")))
+	})
+
+	It("should keep the first of the paths defined twice by an Ingress", func() {
+		ingress := newIngress("a", "twice", now, "a.com", "/api", "/api")
+		cbCtx := &ConfigBuilderContext{IngressList: []*v1beta1.Ingress{ingress}}
+		cb.mergeIngresses(cbCtx)
+
+		Expect(pathsOf(cbCtx.IngressList[0])).To(Equal([]string{"/api"}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("is defined more than once by Ingress a/twice")))
+	})
+})
@@ -0,0 +1,116 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// podSelector returns the labels of the Pods of the backend, when the Ingress selects them with the backend-pod-selectors
+// annotation rather than with a Service; it returns nil otherwise.
+func podSelector(backendID backendIdentifier) map[string]string {
+	if backendID.Ingress == nil {
+		return nil
+	}
+	selectors, err := annotations.BackendPodSelectors(backendID.Ingress)
+	if err != nil {
+		return nil
+	}
+	return selectors[backendID.Name]
+}
+
+// getService returns the Service of the backend. A backend selecting its Pods has no Service; a Service with the selector
+// and the port of the backend stands in for it, so its ports and probes are resolved the way the ones of a Service are.
+func (c *appGwConfigBuilder) getService(backendID backendIdentifier) *v1.Service {
+	selector := podSelector(backendID)
+	if selector == nil {
+		return c.k8sContext.GetService(backendID.serviceKey())
+	}
+
+	port := v1.ServicePort{Protocol: v1.ProtocolTCP, TargetPort: backendID.Backend.ServicePort}
+	if backendID.Backend.ServicePort.Type == intstr.Int {
+		port.Port = backendID.Backend.ServicePort.IntVal
+	} else {
+		port.Name = backendID.Backend.ServicePort.StrVal
+	}
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: backendID.Namespace, Name: backendID.Name},
+		Spec: v1.ServiceSpec{
+			Selector: selector,
+			Ports:    []v1.ServicePort{port},
+		},
+	}
+}
+
+// getEndpoints returns the Endpoints of the Service of the backend, or, for a backend selecting its Pods, the ready Pods matching
+// the selector, in the namespace of the Ingress.
+func (c *appGwConfigBuilder) getEndpoints(backendID backendIdentifier) (*v1.Endpoints, error) {
+	selector := podSelector(backendID)
+	if selector == nil {
+		return c.k8sContext.GetEndpointsByService(backendID.serviceKey())
+	}
+
+	// The Pods are grouped by the port of the backend, which differs among them when the port is a container port name.
+	subsets := make(map[int32]*v1.EndpointSubset)
+	for _, pod := range c.k8sContext.ListPodsByServiceSelector(selector) {
+		if pod.Namespace != backendID.Namespace || pod.Status.PodIP == "" || !isPodReady(pod) {
+			continue
+		}
+		address := v1.EndpointAddress{
+			IP:        pod.Status.PodIP,
+			TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
+		}
+		if pod.Spec.NodeName != "" {
+			nodeName := pod.Spec.NodeName
+			address.NodeName = &nodeName
+		}
+		for _, port := range getPodPorts(pod, backendID.Backend.ServicePort) {
+			if _, exists := subsets[port.Port]; !exists {
+				subsets[port.Port] = &v1.EndpointSubset{Ports: []v1.EndpointPort{port}}
+			}
+			subsets[port.Port].Addresses = append(subsets[port.Port].Addresses, address)
+		}
+	}
+
+	endpoints := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: backendID.Namespace, Name: backendID.Name}}
+	for _, subset := range subsets {
+		endpoints.Subsets = append(endpoints.Subsets, *subset)
+	}
+	sort.Slice(endpoints.Subsets, func(i, j int) bool { return endpoints.Subsets[i].Ports[0].Port < endpoints.Subsets[j].Ports[0].Port })
+	return endpoints, nil
+}
+
+// getPodPorts returns the port of the Pod the backend targets: the port number itself, or the TCP container ports with the name.
+func getPodPorts(pod *v1.Pod, servicePort intstr.IntOrString) []v1.EndpointPort {
+	if servicePort.Type == intstr.Int {
+		return []v1.EndpointPort{{Port: servicePort.IntVal, Protocol: v1.ProtocolTCP}}
+	}
+	var ports []v1.EndpointPort
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == servicePort.StrVal && (port.Protocol == "" || port.Protocol == v1.ProtocolTCP) {
+				ports = append(ports, v1.EndpointPort{Name: port.Name, Port: port.ContainerPort, Protocol: v1.ProtocolTCP})
+			}
+		}
+	}
+	return ports
+}
+
+// isPodReady tells whether the Pod is ready to serve, as the Endpoints controller does for the Pods of a Service.
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test backends selecting Pods by label", func() {
+	var cb *appGwConfigBuilder
+	var pods cache.Store
+
+	newPod := func(name, ip string, ready bool, labels map[string]string, ports ...v1.ContainerPort) *v1.Pod {
+		status := v1.ConditionFalse
+		if ready {
+			status = v1.ConditionTrue
+		}
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: tests.Namespace, Name: name, Labels: labels},
+			Spec: v1.PodSpec{
+				NodeName:   "aks-nodepool1-0",
+				Containers: []v1.Container{{Name: "web", Ports: ports}},
+			},
+			Status: v1.PodStatus{
+				PodIP:      ip,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+			},
+		}
+	}
+	newBackendID := func(selectors string, port intstr.IntOrString) backendIdentifier {
+		ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{
+			Namespace:   tests.Namespace,
+			Name:        tests.Name,
+			Annotations: map[string]string{annotations.BackendPodSelectorsKey: selectors},
+		}}
+		return backendIdentifier{
+			serviceIdentifier: serviceIdentifier{Namespace: tests.Namespace, Name: "web-0"},
+			Ingress:           ingress,
+			Backend:           &v1beta1.IngressBackend{ServiceName: "web-0", ServicePort: port},
+		}
+	}
+
+	BeforeEach(func() {
+		pods = cache.NewStore(cache.MetaNamespaceKeyFunc)
+		cb = &appGwConfigBuilder{
+			k8sContext: &k8scontext.Context{Caches: &k8scontext.CacheCollection{Pods: pods}},
+		}
+	})
+
+	Context("test podSelector", func() {
+		It("should return the selector of the backend only", func() {
+			Expect(podSelector(newBackendID("web-0: app=web,statefulset.kubernetes.io/pod-name=web-0", intstr.FromInt(80)))).To(Equal(
+				map[string]string{"app": "web", "statefulset.kubernetes.io/pod-name": "web-0"}))
+			Expect(podSelector(newBackendID("web-1: app=web", intstr.FromInt(80)))).To(BeNil())
+			Expect(podSelector(backendIdentifier{})).To(BeNil())
+		})
+	})
+
+	Context("test getService", func() {
+		It("should stand in a Service with the selector and the port of the backend", func() {
+			service := cb.getService(newBackendID("web-0: app=web", intstr.FromInt(8080)))
+			Expect(service.Namespace).To(Equal(tests.Namespace))
+			Expect(service.Name).To(Equal("web-0"))
+			Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "web"}))
+			Expect(service.Spec.Ports).To(Equal([]v1.ServicePort{{Protocol: v1.ProtocolTCP, Port: 8080, TargetPort: intstr.FromInt(8080)}}))
+
+			service = cb.getService(newBackendID("web-0: app=web", intstr.FromString("http")))
+			Expect(service.Spec.Ports).To(Equal([]v1.ServicePort{{Protocol: v1.ProtocolTCP, Name: "http", TargetPort: intstr.FromString("http")}}))
+		})
+	})
+
+	Context("test getEndpoints", func() {
+		It("should return the ready Pods matching the selector in the namespace of the Ingress", func() {
+			httpPort := v1.ContainerPort{Name: "http", ContainerPort: 8080}
+			_ = pods.Add(newPod("web-0", "10.0.0.1", true, map[string]string{"app": "web"}, httpPort))
+			_ = pods.Add(newPod("web-1", "10.0.0.2", false, map[string]string{"app": "web"}, httpPort))
+			_ = pods.Add(newPod("web-2", "", true, map[string]string{"app": "web"}, httpPort))
+			_ = pods.Add(newPod("api-0", "10.0.0.3", true, map[string]string{"app": "api"}, httpPort))
+			other := newPod("web-0", "10.0.1.1", true, map[string]string{"app": "web"}, httpPort)
+			other.Namespace = "other"
+			_ = pods.Add(other)
+
+			endpoints, err := cb.getEndpoints(newBackendID("web-0: app=web", intstr.FromString("http")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoints.Subsets).To(Equal([]v1.EndpointSubset{{
+				Addresses: []v1.EndpointAddress{{
+					IP:        "10.0.0.1",
+					NodeName:  to.StringPtr("aks-nodepool1-0"),
+					TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: tests.Namespace, Name: "web-0"},
+				}},
+				Ports: []v1.EndpointPort{{Name: "http", Port: 8080, Protocol: v1.ProtocolTCP}},
+			}}))
+		})
+
+		It("should add a Pod once it becomes ready", func() {
+			pod := newPod("web-0", "10.0.0.1", false, map[string]string{"app": "web"})
+			_ = pods.Add(pod)
+			backendID := newBackendID("web-0: app=web", intstr.FromInt(8080))
+
+			endpoints, err := cb.getEndpoints(backendID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoints.Subsets).To(BeEmpty())
+
+			pod = pod.DeepCopy()
+			pod.Status.Conditions[0].Status = v1.ConditionTrue
+			_ = pods.Update(pod)
+
+			endpoints, err = cb.getEndpoints(backendID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoints.Subsets).To(HaveLen(1))
+			Expect(endpoints.Subsets[0].Addresses[0].IP).To(Equal("10.0.0.1"))
+		})
+
+		It("should group the Pods by the port the container port name resolves to", func() {
+			_ = pods.Add(newPod("web-0", "10.0.0.1", true, map[string]string{"app": "web"}, v1.ContainerPort{Name: "http", ContainerPort: 8081}))
+			_ = pods.Add(newPod("web-1", "10.0.0.2", true, map[string]string{"app": "web"}, v1.ContainerPort{Name: "http", ContainerPort: 8080}))
+			_ = pods.Add(newPod("web-2", "10.0.0.3", true, map[string]string{"app": "web"}, v1.ContainerPort{Name: "metrics", ContainerPort: 9090}))
+
+			endpoints, err := cb.getEndpoints(newBackendID("web-0: app=web", intstr.FromString("http")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpoints.Subsets).To(HaveLen(2))
+			Expect(endpoints.Subsets[0].Ports[0].Port).To(Equal(int32(8080)))
+			Expect(endpoints.Subsets[0].Addresses[0].IP).To(Equal("10.0.0.2"))
+			Expect(endpoints.Subsets[1].Ports[0].Port).To(Equal(int32(8081)))
+			Expect(endpoints.Subsets[1].Addresses[0].IP).To(Equal("10.0.0.1"))
+		})
+	})
+
+	Context("test getPodPorts", func() {
+		It("should return the port number, or the TCP container ports with the name", func() {
+			pod := newPod("web-0", "10.0.0.1", true, nil,
+				v1.ContainerPort{Name: "http", ContainerPort: 8080},
+				v1.ContainerPort{Name: "dns", ContainerPort: 53, Protocol: v1.ProtocolUDP})
+			Expect(getPodPorts(pod, intstr.FromInt(80))).To(Equal([]v1.EndpointPort{{Port: 80, Protocol: v1.ProtocolTCP}}))
+			Expect(getPodPorts(pod, intstr.FromString("http"))).To(Equal([]v1.EndpointPort{{Name: "http", Port: 8080, Protocol: v1.ProtocolTCP}}))
+			Expect(getPodPorts(pod, intstr.FromString("dns"))).To(BeEmpty())
+		})
+	})
+
+	Context("test isPodReady", func() {
+		It("should tell whether the Pod has the Ready condition", func() {
+			Expect(isPodReady(newPod("web-0", "10.0.0.1", true, nil))).To(BeTrue())
+			Expect(isPodReady(newPod("web-0", "10.0.0.1", false, nil))).To(BeFalse())
+			Expect(isPodReady(&v1.Pod{})).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,154 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// getReferencedHTTPSettings returns the existing HTTP settings named by the backend-http-settings-name annotation of the Ingress
+// of the backend, or nil when the Ingress names none, or the App Gateway has none by that name.
+// The HTTP settings are kept as they are, so they are looked up in the App Gateway config being built.
+func (c *appGwConfigBuilder) getReferencedHTTPSettings(backendID backendIdentifier) *n.ApplicationGatewayBackendHTTPSettings {
+	name, err := annotations.BackendHTTPSettingsName(backendID.Ingress)
+	if err != nil || c.appGw.ApplicationGatewayPropertiesFormat == nil || c.appGw.BackendHTTPSettingsCollection == nil {
+		return nil
+	}
+	for _, settings := range *c.appGw.BackendHTTPSettingsCollection {
+		if settings.Name != nil && *settings.Name == name {
+			return &settings
+		}
+	}
+	return nil
+}
+
+// getReferencedProbe returns the existing probe named by the health-probe-name annotation of the Ingress of the backend,
+// or nil when the Ingress names none, or the App Gateway has none by that name.
+func (c *appGwConfigBuilder) getReferencedProbe(backendID backendIdentifier) *n.ApplicationGatewayProbe {
+	name, err := annotations.HealthProbeName(backendID.Ingress)
+	if err != nil {
+		return nil
+	}
+	return c.findProbe(func(probe n.ApplicationGatewayProbe) bool { return probe.Name != nil && *probe.Name == name })
+}
+
+// getProbeOfReferencedHTTPSettings returns the existing probe used by the existing HTTP settings of the backend, or nil when there is none.
+// It is kept along with the HTTP settings.
+func (c *appGwConfigBuilder) getProbeOfReferencedHTTPSettings(settings *n.ApplicationGatewayBackendHTTPSettings) *n.ApplicationGatewayProbe {
+	if settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat == nil || settings.Probe == nil || settings.Probe.ID == nil {
+		return nil
+	}
+	probeID := *settings.Probe.ID
+	return c.findProbe(func(probe n.ApplicationGatewayProbe) bool { return probe.ID != nil && *probe.ID == probeID })
+}
+
+func (c *appGwConfigBuilder) findProbe(matches func(n.ApplicationGatewayProbe) bool) *n.ApplicationGatewayProbe {
+	if c.appGw.ApplicationGatewayPropertiesFormat == nil || c.appGw.Probes == nil {
+		return nil
+	}
+	for _, probe := range *c.appGw.Probes {
+		if matches(probe) {
+			return &probe
+		}
+	}
+	return nil
+}
+
+// getReferencedListener returns the existing listener named by the listener-name annotation of the Ingress,
+// or nil when the Ingress names none, or the App Gateway has none by that name.
+// The listener is kept as it is, along with its frontend port and certificate; AGIC only manages the rule attached to it.
+func (c *appGwConfigBuilder) getReferencedListener(ingress *v1beta1.Ingress) *n.ApplicationGatewayHTTPListener {
+	name, err := annotations.ListenerName(ingress)
+	if err != nil || c.appGw.ApplicationGatewayPropertiesFormat == nil || c.appGw.HTTPListeners == nil {
+		return nil
+	}
+	for _, listener := range *c.appGw.HTTPListeners {
+		if listener.Name != nil && *listener.Name == name {
+			return &listener
+		}
+	}
+	return nil
+}
+
+// getReferencedListeners returns the unique existing listeners named by the Ingresses.
+func (c *appGwConfigBuilder) getReferencedListeners(ingressList []*v1beta1.Ingress) []n.ApplicationGatewayHTTPListener {
+	var listeners []n.ApplicationGatewayHTTPListener
+	seen := make(map[string]interface{})
+	for _, ingress := range ingressList {
+		listener := c.getReferencedListener(ingress)
+		if listener == nil {
+			continue
+		}
+		if _, exists := seen[*listener.Name]; exists {
+			continue
+		}
+		seen[*listener.Name] = nil
+		listeners = append(listeners, *listener)
+	}
+	return listeners
+}
+
+// getFrontendPortOfReferencedListener returns the existing frontend port used by the existing listener, or nil when there is none.
+func (c *appGwConfigBuilder) getFrontendPortOfReferencedListener(listener n.ApplicationGatewayHTTPListener) *n.ApplicationGatewayFrontendPort {
+	if listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil || listener.FrontendPort == nil || listener.FrontendPort.ID == nil ||
+		c.appGw.FrontendPorts == nil {
+		return nil
+	}
+	for _, port := range *c.appGw.FrontendPorts {
+		if port.ID != nil && *port.ID == *listener.FrontendPort.ID && port.ApplicationGatewayFrontendPortPropertiesFormat != nil && port.Port != nil {
+			return &port
+		}
+	}
+	return nil
+}
+
+// getCertificateOfReferencedListener returns the existing certificate used by the existing listener, or nil when there is none.
+func (c *appGwConfigBuilder) getCertificateOfReferencedListener(listener n.ApplicationGatewayHTTPListener) *n.ApplicationGatewaySslCertificate {
+	if listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil || listener.SslCertificate == nil || listener.SslCertificate.ID == nil ||
+		c.appGw.SslCertificates == nil {
+		return nil
+	}
+	for _, cert := range *c.appGw.SslCertificates {
+		if cert.ID != nil && *cert.ID == *listener.SslCertificate.ID {
+			return &cert
+		}
+	}
+	return nil
+}
+
+// reportMissingReferences emits a warning on each Ingress, which names HTTP settings, a probe or a listener the App Gateway does not have.
+// AGIC creates the HTTP settings, probes and listeners of these Ingresses as if they named none.
+// It must be given the App Gateway before the config builder replaces its HTTP settings, probes and listeners.
+func (c *appGwConfigBuilder) reportMissingReferences(cbCtx *ConfigBuilderContext) {
+	for backendID := range newBackendIdsFiltered(cbCtx) {
+		if name, err := annotations.BackendHTTPSettingsName(backendID.Ingress); err == nil && c.getReferencedHTTPSettings(backendID) == nil {
+			c.reportMissingReference(backendID.Ingress, annotations.BackendHTTPSettingsNameKey, "HTTP settings", name)
+		}
+		if name, err := annotations.HealthProbeName(backendID.Ingress); err == nil && c.getReferencedProbe(backendID) == nil {
+			c.reportMissingReference(backendID.Ingress, annotations.HealthProbeNameKey, "probe", name)
+		}
+	}
+	for _, ingress := range cbCtx.IngressList {
+		if name, err := annotations.ListenerName(ingress); err == nil && c.getReferencedListener(ingress) == nil {
+			c.reportMissingReference(ingress, annotations.ListenerNameKey, "listener", name)
+		}
+	}
+}
+
+func (c *appGwConfigBuilder) reportMissingReference(ingress *v1beta1.Ingress, annotation, kind, name string) {
+	message := fmt.Sprintf("Annotation %s of Ingress %s/%s names %s %s, which App Gateway %s does not have; AGIC creates its own instead",
+		annotation, ingress.Namespace, ingress.Name, kind, name, c.appGwIdentifier.AppGwName)
+	glog.Warning(message)
+	c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonReferencedResourceNotFound, message)
+}
@@ -0,0 +1,204 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test existing HTTP settings and probes referenced by Ingresses", func() {
+	var cb appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var ingress *v1beta1.Ingress
+	var tunedProbe n.ApplicationGatewayProbe
+	var tunedSettings n.ApplicationGatewayBackendHTTPSettings
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		_ = cb.k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))
+		_ = cb.k8sContext.Caches.Pods.Add(tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort))
+
+		tunedProbe = n.ApplicationGatewayProbe{
+			Name: to.StringPtr("tuned-probe"),
+			ID:   to.StringPtr(cb.appGwIdentifier.probeID("tuned-probe")),
+			ApplicationGatewayProbePropertiesFormat: &n.ApplicationGatewayProbePropertiesFormat{
+				Protocol: n.HTTPS,
+				Path:     to.StringPtr("/status"),
+				Interval: to.Int32Ptr(7),
+			},
+		}
+		tunedSettings = n.ApplicationGatewayBackendHTTPSettings{
+			Name: to.StringPtr("tuned-settings"),
+			ID:   to.StringPtr(cb.appGwIdentifier.httpSettingsID("tuned-settings")),
+			ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{
+				Protocol:       n.HTTPS,
+				Port:           to.Int32Ptr(8443),
+				RequestTimeout: to.Int32Ptr(300),
+				Probe:          resourceRef(*tunedProbe.ID),
+			},
+		}
+		cb.appGw.Probes = &[]n.ApplicationGatewayProbe{tunedProbe}
+		cb.appGw.BackendHTTPSettingsCollection = &[]n.ApplicationGatewayBackendHTTPSettings{tunedSettings}
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = []v1beta1.IngressRule{
+			tests.NewIngressRuleFixture(tests.Host, "/api", *tests.NewIngressBackendFixture(tests.ServiceName, 80)),
+		}
+		cbCtx = &ConfigBuilderContext{
+			IngressList: []*v1beta1.Ingress{ingress},
+			ServiceList: []*v1.Service{tests.NewServiceFixture()},
+		}
+	})
+
+	It("keeps the HTTP settings named by the Ingress, and their probe, as they are", func() {
+		ingress.Annotations[annotations.BackendHTTPSettingsNameKey] = "tuned-settings"
+		cb.reportMissingReferences(cbCtx)
+		Expect(cb.HealthProbesCollection(cbCtx)).To(Succeed())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).To(Succeed())
+
+		Expect(*cb.appGw.Probes).To(ConsistOf(defaultProbe(cb.appGwIdentifier), tunedProbe))
+		Expect(*cb.appGw.BackendHTTPSettingsCollection).To(ConsistOf(
+			defaultBackendHTTPSettings(cb.appGwIdentifier, defaultProbeName), tunedSettings))
+
+		_, settingsMap, _, _ := cb.getBackendsAndSettingsMap(cbCtx)
+		Expect(settingsMap).ToNot(BeEmpty())
+		for _, settings := range settingsMap {
+			Expect(*settings.Name).To(Equal("tuned-settings"))
+		}
+		Expect(cb.recorder.(*record.FakeRecorder).Events).To(BeEmpty())
+	})
+
+	It("links the generated HTTP settings to the probe named by the Ingress", func() {
+		ingress.Annotations[annotations.HealthProbeNameKey] = "tuned-probe"
+		Expect(cb.HealthProbesCollection(cbCtx)).To(Succeed())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).To(Succeed())
+
+		Expect(*cb.appGw.Probes).To(ConsistOf(defaultProbe(cb.appGwIdentifier), tunedProbe))
+		for _, settings := range *cb.appGw.BackendHTTPSettingsCollection {
+			Expect(*settings.Name).ToNot(Equal("tuned-settings"))
+			if *settings.Name != defaultBackendHTTPSettingsName {
+				Expect(*settings.Probe.ID).To(Equal(*tunedProbe.ID))
+			}
+		}
+	})
+
+	It("warns about names the App Gateway does not have, and creates its own", func() {
+		ingress.Annotations[annotations.BackendHTTPSettingsNameKey] = "missing-settings"
+		cb.reportMissingReferences(cbCtx)
+		Expect(cb.recorder.(*record.FakeRecorder).Events).To(Receive(And(
+			ContainSubstring(events.ReasonReferencedResourceNotFound),
+			ContainSubstring("missing-settings"))))
+
+		Expect(cb.HealthProbesCollection(cbCtx)).To(Succeed())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).To(Succeed())
+		Expect(*cb.appGw.Probes).ToNot(ContainElement(tunedProbe))
+		Expect(*cb.appGw.BackendHTTPSettingsCollection).ToNot(ContainElement(tunedSettings))
+		Expect(len(*cb.appGw.BackendHTTPSettingsCollection)).To(BeNumerically(">", 1))
+	})
+})
+
+var _ = Describe("Test existing listeners referenced by Ingresses", func() {
+	var cb appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var ingress *v1beta1.Ingress
+	var opsPort n.ApplicationGatewayFrontendPort
+	var opsCert n.ApplicationGatewaySslCertificate
+	var opsListener n.ApplicationGatewayHTTPListener
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		_ = cb.k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))
+		_ = cb.k8sContext.Caches.Pods.Add(tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort))
+
+		opsPort = n.ApplicationGatewayFrontendPort{
+			Name: to.StringPtr("ops-port"),
+			ID:   to.StringPtr(cb.appGwIdentifier.frontendPortID("ops-port")),
+			ApplicationGatewayFrontendPortPropertiesFormat: &n.ApplicationGatewayFrontendPortPropertiesFormat{
+				Port: to.Int32Ptr(443),
+			},
+		}
+		opsCert = n.ApplicationGatewaySslCertificate{
+			Name: to.StringPtr("ops-cert"),
+			ID:   to.StringPtr(cb.appGwIdentifier.sslCertificateID("ops-cert")),
+		}
+		opsListener = n.ApplicationGatewayHTTPListener{
+			Name: to.StringPtr("ops-listener"),
+			ID:   to.StringPtr(cb.appGwIdentifier.listenerID("ops-listener")),
+			ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+				FrontendIPConfiguration: resourceRef(tests.IPID1),
+				FrontendPort:            resourceRef(*opsPort.ID),
+				SslCertificate:          resourceRef(*opsCert.ID),
+				Protocol:                n.HTTPS,
+			},
+		}
+		cb.appGw.FrontendPorts = &[]n.ApplicationGatewayFrontendPort{opsPort}
+		cb.appGw.SslCertificates = &[]n.ApplicationGatewaySslCertificate{opsCert}
+		cb.appGw.HTTPListeners = &[]n.ApplicationGatewayHTTPListener{opsListener}
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = []v1beta1.IngressRule{
+			tests.NewIngressRuleFixture(tests.Host, "/api", *tests.NewIngressBackendFixture(tests.ServiceName, 80)),
+		}
+		cbCtx = &ConfigBuilderContext{
+			IngressList: []*v1beta1.Ingress{ingress},
+			ServiceList: []*v1.Service{tests.NewServiceFixture()},
+		}
+	})
+
+	build := func() {
+		cb.reportMissingReferences(cbCtx)
+		Expect(cb.HealthProbesCollection(cbCtx)).To(Succeed())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).To(Succeed())
+		Expect(cb.BackendAddressPools(cbCtx)).To(Succeed())
+		Expect(cb.Listeners(cbCtx)).To(Succeed())
+		Expect(cb.RequestRoutingRules(cbCtx)).To(Succeed())
+	}
+
+	It("attaches the paths of the Ingress to the listener it names, and keeps the listener as it is", func() {
+		ingress.Annotations[annotations.ListenerNameKey] = "ops-listener"
+		build()
+
+		Expect(*cb.appGw.HTTPListeners).To(ConsistOf(opsListener))
+		Expect(*cb.appGw.FrontendPorts).To(ConsistOf(opsPort))
+		Expect(*cb.appGw.SslCertificates).To(ConsistOf(opsCert))
+
+		Expect(*cb.appGw.RequestRoutingRules).To(HaveLen(1))
+		rule := (*cb.appGw.RequestRoutingRules)[0]
+		Expect(*rule.HTTPListener.ID).To(Equal(*opsListener.ID))
+		Expect(rule.RuleType).To(Equal(n.PathBasedRouting))
+
+		Expect(*cb.appGw.URLPathMaps).To(HaveLen(1))
+		pathRules := *(*cb.appGw.URLPathMaps)[0].PathRules
+		Expect(pathRules).To(HaveLen(1))
+		Expect(*pathRules[0].Paths).To(Equal([]string{"/api"}))
+	})
+
+	It("warns about a listener the App Gateway does not have, and creates its own", func() {
+		ingress.Annotations[annotations.ListenerNameKey] = "missing-listener"
+		build()
+		Expect(cb.recorder.(*record.FakeRecorder).Events).To(Receive(And(
+			ContainSubstring(events.ReasonReferencedResourceNotFound),
+			ContainSubstring("missing-listener"))))
+
+		Expect(*cb.appGw.HTTPListeners).To(HaveLen(1))
+		Expect(*(*cb.appGw.HTTPListeners)[0].Name).ToNot(Equal("ops-listener"))
+		Expect(*cb.appGw.FrontendPorts).ToNot(ContainElement(opsPort))
+	})
+})
@@ -22,8 +22,8 @@ import (
 func (c *appGwConfigBuilder) RequestRoutingRules(cbCtx *ConfigBuilderContext) error {
 	requestRoutingRules, pathMaps := c.getRules(cbCtx)
 
-	if cbCtx.EnableBrownfieldDeployment {
-		rCtx := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
+	if cbCtx.keepsExistingConfig() {
+		rCtx := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), nil)
 		{
 			// PathMaps we obtained from App Gateway - we segment them into ones AGIC is and is not allowed to change.
 			existingBlacklisted, existingNonBlacklisted := rCtx.GetBlacklistedPathMaps()
@@ -39,8 +39,8 @@ func (c *appGwConfigBuilder) RequestRoutingRules(cbCtx *ConfigBuilderContext) er
 	sort.Sort(sorter.ByPathMap(pathMaps))
 	c.appGw.URLPathMaps = &pathMaps
 
-	if cbCtx.EnableBrownfieldDeployment {
-		rCtx := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
+	if cbCtx.keepsExistingConfig() {
+		rCtx := brownfield.NewExistingResources(c.appGw, cbCtx.existingTargets(), nil)
 		{
 			// RoutingRules we obtained from App Gateway - we segment them into ones AGIC is and is not allowed to change.
 			existingBlacklisted, existingNonBlacklisted := rCtx.GetBlacklistedRoutingRules()
@@ -103,6 +103,22 @@ func (c *appGwConfigBuilder) getURLPathMaps(cbCtx *ConfigBuilderContext) map[lis
 			}
 		}
 
+		// The paths of all rules of an Ingress routed through an existing listener go to the path map of that listener.
+		// The listener decides which requests reach them; AGIC does not set up redirection for these Ingresses.
+		if listener := c.getReferencedListener(ingress); listener != nil {
+			listenerID := c.getListenerIdentifier(*listener)
+			for ruleIdx := range ingress.Spec.Rules {
+				rule := &ingress.Spec.Rules[ruleIdx]
+				if rule.HTTP == nil {
+					continue
+				}
+				urlPathMaps[listenerID] = c.pathMaps(ingress, backendPools, backendHTTPSettingsMap, rule,
+					listenerID, urlPathMaps[listenerID],
+					defaultAddressPoolID, defaultHTTPSettingsID)
+			}
+			continue
+		}
+
 		for ruleIdx := range ingress.Spec.Rules {
 			rule := &ingress.Spec.Rules[ruleIdx]
 			if rule.HTTP == nil {
@@ -119,13 +135,13 @@ func (c *appGwConfigBuilder) getURLPathMaps(cbCtx *ConfigBuilderContext) map[lis
 			if httpAvailable {
 				if wildcardRule != nil && len(rule.Host) != 0 {
 					// only add wildcard rules when host is specified
-					urlPathMaps[listenerHTTPID] = c.pathMaps(ingress, cbCtx, wildcardRule,
+					urlPathMaps[listenerHTTPID] = c.pathMaps(ingress, backendPools, backendHTTPSettingsMap, wildcardRule,
 						listenerHTTPID, urlPathMaps[listenerHTTPID],
 						defaultAddressPoolID, defaultHTTPSettingsID)
 				}
 
 				// need to eliminate non-unique paths
-				urlPathMaps[listenerHTTPID] = c.pathMaps(ingress, cbCtx, rule,
+				urlPathMaps[listenerHTTPID] = c.pathMaps(ingress, backendPools, backendHTTPSettingsMap, rule,
 					listenerHTTPID, urlPathMaps[listenerHTTPID],
 					defaultAddressPoolID, defaultHTTPSettingsID)
 
@@ -138,13 +154,13 @@ func (c *appGwConfigBuilder) getURLPathMaps(cbCtx *ConfigBuilderContext) map[lis
 			if httpsAvailable {
 				if wildcardRule != nil && len(rule.Host) != 0 {
 					// only add wildcard rules when host is specified
-					urlPathMaps[listenerHTTPSID] = c.pathMaps(ingress, cbCtx, wildcardRule,
+					urlPathMaps[listenerHTTPSID] = c.pathMaps(ingress, backendPools, backendHTTPSettingsMap, wildcardRule,
 						listenerHTTPSID, urlPathMaps[listenerHTTPSID],
 						defaultAddressPoolID, defaultHTTPSettingsID)
 				}
 
 				// need to eliminate non-unique paths
-				urlPathMaps[listenerHTTPSID] = c.pathMaps(ingress, cbCtx, rule,
+				urlPathMaps[listenerHTTPSID] = c.pathMaps(ingress, backendPools, backendHTTPSettingsMap, rule,
 					listenerHTTPSID, urlPathMaps[listenerHTTPSID],
 					defaultAddressPoolID, defaultHTTPSettingsID)
 			}
@@ -206,7 +222,10 @@ func (c *appGwConfigBuilder) getRules(cbCtx *ConfigBuilderContext) ([]n.Applicat
 	return requestRoutingRules, pathMap
 }
 
-func (c *appGwConfigBuilder) pathMaps(ingress *v1beta1.Ingress, cbCtx *ConfigBuilderContext, rule *v1beta1.IngressRule,
+// pathMaps adds the paths of the rule to the path map of the listener. The backend pools and HTTP settings are generated
+// once for all the rules, rather than for each rule, which made the build slower with every Ingress added.
+func (c *appGwConfigBuilder) pathMaps(ingress *v1beta1.Ingress, backendPools map[backendIdentifier]*n.ApplicationGatewayBackendAddressPool,
+	backendHTTPSettingsMap map[backendIdentifier]*n.ApplicationGatewayBackendHTTPSettings, rule *v1beta1.IngressRule,
 	listenerID listenerIdentifier, urlPathMap *n.ApplicationGatewayURLPathMap,
 	defaultAddressPoolID string, defaultHTTPSettingsID string) *n.ApplicationGatewayURLPathMap {
 	if urlPathMap == nil {
@@ -225,8 +244,6 @@ func (c *appGwConfigBuilder) pathMaps(ingress *v1beta1.Ingress, cbCtx *ConfigBui
 		urlPathMap.PathRules = &[]n.ApplicationGatewayPathRule{}
 	}
 
-	backendPools := c.newBackendPoolMap(cbCtx)
-	_, backendHTTPSettingsMap, _, _ := c.getBackendsAndSettingsMap(cbCtx)
 	for pathIdx := range rule.HTTP.Paths {
 		path := &rule.HTTP.Paths[pathIdx]
 		backendID := generateBackendID(ingress, rule, path, &path.Backend)
@@ -240,7 +257,7 @@ func (c *appGwConfigBuilder) pathMaps(ingress *v1beta1.Ingress, cbCtx *ConfigBui
 		backendPoolSubResource := n.SubResource{ID: to.StringPtr(c.appGwIdentifier.addressPoolID(*backendPool.Name))}
 		backendHTTPSettingsSubResource := n.SubResource{ID: to.StringPtr(c.appGwIdentifier.httpSettingsID(*backendHTTPSettings.Name))}
 
-		if len(path.Path) == 0 || path.Path == "/*" || path.Path == "/" {
+		if isDefaultPath(path.Path) {
 			// this backend should be a default backend, catches all traffic
 			// check if it is a host-specific default backend
 			if rule.Host == listenerID.HostName {
@@ -0,0 +1,82 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"strconv"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+)
+
+// scaleLimits are the limits of an App Gateway SKU on the resources AGIC generates.
+// See https://docs.microsoft.com/en-us/azure/azure-subscription-service-limits#application-gateway-limits
+type scaleLimits struct {
+	listeners           int
+	pathRulesPerPathMap int
+	certificates        int
+	addressesPerPool    int
+}
+
+var v1ScaleLimits = scaleLimits{
+	listeners:           100,
+	pathRulesPerPathMap: 100,
+	certificates:        100,
+	addressesPerPool:    100,
+}
+
+var v2ScaleLimits = scaleLimits{
+	listeners:           100,
+	pathRulesPerPathMap: 100,
+	certificates:        100,
+	addressesPerPool:    1200,
+}
+
+func getScaleLimits(sku *n.ApplicationGatewaySku) scaleLimits {
+	if getSkuCapabilities(sku).isV2 {
+		return v2ScaleLimits
+	}
+	return v1ScaleLimits
+}
+
+// GetScaleLimitWarnings returns a warning for each resource count of the App Gateway config, which is at or above the given
+// percentage of the limit of the App Gateway's SKU. No warnings are returned when the threshold is zero or not a number.
+func GetScaleLimitWarnings(appGw *n.ApplicationGateway, thresholdPercent string) []string {
+	threshold, err := strconv.Atoi(thresholdPercent)
+	if err != nil || threshold <= 0 || appGw == nil || appGw.ApplicationGatewayPropertiesFormat == nil {
+		return nil
+	}
+	limits := getScaleLimits(appGw.Sku)
+
+	var warnings []string
+	check := func(resource string, count, limit int) {
+		if count*100 >= limit*threshold {
+			warnings = append(warnings, fmt.Sprintf("App Gateway has %d %s; the limit of its SKU is %d", count, resource, limit))
+		}
+	}
+
+	if appGw.HTTPListeners != nil {
+		check("listeners", len(*appGw.HTTPListeners), limits.listeners)
+	}
+	if appGw.SslCertificates != nil {
+		check("certificates", len(*appGw.SslCertificates), limits.certificates)
+	}
+	if appGw.URLPathMaps != nil {
+		for _, pathMap := range *appGw.URLPathMaps {
+			if pathMap.ApplicationGatewayURLPathMapPropertiesFormat != nil && pathMap.PathRules != nil {
+				check(fmt.Sprintf("path rules in URL path map %s", *pathMap.Name), len(*pathMap.PathRules), limits.pathRulesPerPathMap)
+			}
+		}
+	}
+	if appGw.BackendAddressPools != nil {
+		for _, pool := range *appGw.BackendAddressPools {
+			if pool.ApplicationGatewayBackendAddressPoolPropertiesFormat != nil && pool.BackendAddresses != nil {
+				check(fmt.Sprintf("backend addresses in pool %s", *pool.Name), len(*pool.BackendAddresses), limits.addressesPerPool)
+			}
+		}
+	}
+	return warnings
+}
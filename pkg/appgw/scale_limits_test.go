@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test scale limit warnings", func() {
+	newAppGw := func(tier n.ApplicationGatewayTier, listenerCount, addressCount int) *n.ApplicationGateway {
+		listeners := make([]n.ApplicationGatewayHTTPListener, listenerCount)
+		addresses := make([]n.ApplicationGatewayBackendAddress, addressCount)
+		return &n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				Sku:           &n.ApplicationGatewaySku{Tier: tier},
+				HTTPListeners: &listeners,
+				BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{{
+					Name: to.StringPtr("pool"),
+					ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+						BackendAddresses: &addresses,
+					},
+				}},
+			},
+		}
+	}
+
+	It("should not warn below the threshold", func() {
+		Expect(GetScaleLimitWarnings(newAppGw(n.ApplicationGatewayTierStandard, 79, 10), "80")).To(BeEmpty())
+	})
+
+	It("should warn at the threshold", func() {
+		warnings := GetScaleLimitWarnings(newAppGw(n.ApplicationGatewayTierStandard, 80, 10), "80")
+		Expect(warnings).To(ConsistOf("App Gateway has 80 listeners; the limit of its SKU is 100"))
+	})
+
+	It("should use the limits of the SKU", func() {
+		Expect(GetScaleLimitWarnings(newAppGw(n.ApplicationGatewayTierStandard, 1, 90), "80")).To(HaveLen(1))
+		Expect(GetScaleLimitWarnings(newAppGw(n.ApplicationGatewayTierStandardV2, 1, 90), "80")).To(BeEmpty())
+		Expect(GetScaleLimitWarnings(newAppGw(n.ApplicationGatewayTierWAFV2, 1, 1000), "80")).To(ConsistOf(
+			"App Gateway has 1000 backend addresses in pool pool; the limit of its SKU is 1200"))
+	})
+
+	It("should not warn when disabled", func() {
+		Expect(GetScaleLimitWarnings(newAppGw(n.ApplicationGatewayTierStandard, 100, 100), "0")).To(BeEmpty())
+	})
+})
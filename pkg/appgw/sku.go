@@ -0,0 +1,62 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+)
+
+// skuCapabilities are the features of an App Gateway, which depend on its SKU.
+type skuCapabilities struct {
+	known bool
+	isV2  bool
+	isWAF bool
+}
+
+func getSkuCapabilities(sku *n.ApplicationGatewaySku) skuCapabilities {
+	if sku == nil || sku.Tier == "" {
+		return skuCapabilities{}
+	}
+	return skuCapabilities{
+		known: true,
+		isV2:  sku.Tier == n.ApplicationGatewayTierStandardV2 || sku.Tier == n.ApplicationGatewayTierWAFV2,
+		isWAF: sku.Tier == n.ApplicationGatewayTierWAF || sku.Tier == n.ApplicationGatewayTierWAFV2,
+	}
+}
+
+// adjustForSku removes the properties, which the SKU of the App Gateway does not support, from the generated config;
+// ARM would otherwise reject the config as a whole. Nothing is removed when the SKU is not known.
+func (c *appGwConfigBuilder) adjustForSku() {
+	if c.appGw.ApplicationGatewayPropertiesFormat == nil {
+		return
+	}
+	capabilities := getSkuCapabilities(c.appGw.Sku)
+	if !capabilities.known {
+		return
+	}
+
+	if !capabilities.isV2 {
+		if c.appGw.AutoscaleConfiguration != nil {
+			glog.Warningf("App Gateway SKU %s does not support autoscaling; the autoscale configuration is omitted", c.appGw.Sku.Tier)
+			c.appGw.AutoscaleConfiguration = nil
+		}
+		if c.appGw.Zones != nil && len(*c.appGw.Zones) > 0 {
+			glog.Warningf("App Gateway SKU %s does not support availability zones; the zones are omitted", c.appGw.Sku.Tier)
+			c.appGw.Zones = nil
+		}
+	}
+
+	if !capabilities.isWAF && c.appGw.WebApplicationFirewallConfiguration != nil {
+		glog.Warningf("App Gateway SKU %s does not include a web application firewall; the firewall configuration is omitted", c.appGw.Sku.Tier)
+		c.appGw.WebApplicationFirewallConfiguration = nil
+	}
+
+	if !(capabilities.isV2 && capabilities.isWAF) && c.appGw.FirewallPolicy != nil {
+		glog.Warningf("App Gateway SKU %s does not support firewall policies, which require WAF_v2; the firewall policy is omitted", c.appGw.Sku.Tier)
+		c.appGw.FirewallPolicy = nil
+	}
+}
@@ -0,0 +1,70 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test SKU capabilities", func() {
+	newBuilder := func(tier n.ApplicationGatewayTier) *appGwConfigBuilder {
+		return &appGwConfigBuilder{
+			appGw: n.ApplicationGateway{
+				Zones: &[]string{"1", "2"},
+				ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+					Sku:                                 &n.ApplicationGatewaySku{Tier: tier},
+					AutoscaleConfiguration:              &n.ApplicationGatewayAutoscaleConfiguration{MinCapacity: to.Int32Ptr(2)},
+					WebApplicationFirewallConfiguration: &n.ApplicationGatewayWebApplicationFirewallConfiguration{Enabled: to.BoolPtr(true)},
+					FirewallPolicy:                      &n.SubResource{ID: to.StringPtr("--policy--")},
+				},
+			},
+		}
+	}
+
+	It("should detect the SKU", func() {
+		Expect(getSkuCapabilities(&n.ApplicationGatewaySku{Tier: n.ApplicationGatewayTierWAFV2})).To(Equal(skuCapabilities{known: true, isV2: true, isWAF: true}))
+		Expect(getSkuCapabilities(&n.ApplicationGatewaySku{Tier: n.ApplicationGatewayTierStandard})).To(Equal(skuCapabilities{known: true}))
+		Expect(getSkuCapabilities(nil).known).To(BeFalse())
+	})
+
+	It("should keep all properties on WAF_v2", func() {
+		cb := newBuilder(n.ApplicationGatewayTierWAFV2)
+		cb.adjustForSku()
+		Expect(cb.appGw.AutoscaleConfiguration).ToNot(BeNil())
+		Expect(cb.appGw.Zones).ToNot(BeNil())
+		Expect(cb.appGw.WebApplicationFirewallConfiguration).ToNot(BeNil())
+		Expect(cb.appGw.FirewallPolicy).ToNot(BeNil())
+	})
+
+	It("should omit v2 and firewall properties on Standard", func() {
+		cb := newBuilder(n.ApplicationGatewayTierStandard)
+		cb.adjustForSku()
+		Expect(cb.appGw.AutoscaleConfiguration).To(BeNil())
+		Expect(cb.appGw.Zones).To(BeNil())
+		Expect(cb.appGw.WebApplicationFirewallConfiguration).To(BeNil())
+		Expect(cb.appGw.FirewallPolicy).To(BeNil())
+	})
+
+	It("should omit firewall policies on WAF v1", func() {
+		cb := newBuilder(n.ApplicationGatewayTierWAF)
+		cb.adjustForSku()
+		Expect(cb.appGw.AutoscaleConfiguration).To(BeNil())
+		Expect(cb.appGw.WebApplicationFirewallConfiguration).ToNot(BeNil())
+		Expect(cb.appGw.FirewallPolicy).To(BeNil())
+	})
+
+	It("should not change the config when the SKU is not known", func() {
+		cb := newBuilder("")
+		cb.appGw.Sku = nil
+		cb.adjustForSku()
+		Expect(cb.appGw.AutoscaleConfiguration).ToNot(BeNil())
+	})
+})
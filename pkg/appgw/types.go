@@ -24,9 +24,34 @@ type ConfigBuilderContext struct {
 	IstioGateways        []*v1alpha3.Gateway
 	IstioVirtualServices []*v1alpha3.VirtualService
 
+	// BackendDefaults apply to the HTTP settings and probes of all Ingresses, unless overridden by their annotations.
+	BackendDefaults BackendDefaults
+
+	// OrphanedIngresses are the deleted Ingresses, as "namespace/name", whose config is retained because of their orphan policy.
+	OrphanedIngresses []string
+
+	// RetainedTargets are the hostnames and paths of the OrphanedIngresses. Their existing config is kept like the one of
+	// prohibited targets, but the Ingresses are not pruned of them: an Ingress taking a hostname or path over replaces its config.
+	RetainedTargets []*ptv1.AzureIngressProhibitedTarget
+
 	// Feature flag toggling Brownfield Deployment across the entire AGIC code base.
 	EnableBrownfieldDeployment bool
 
 	// Feature flag toggling Istio Integration across the entire AGIC code base.
 	EnableIstioIntegration bool
 }
+
+// keepsExistingConfig is whether part of the existing config is kept: the one of the prohibited targets of a brownfield
+// deployment, or the one retained for the OrphanedIngresses.
+func (cbCtx *ConfigBuilderContext) keepsExistingConfig() bool {
+	return cbCtx.EnableBrownfieldDeployment || len(cbCtx.RetainedTargets) > 0
+}
+
+// existingTargets returns the targets, whose existing config is kept.
+func (cbCtx *ConfigBuilderContext) existingTargets() []*ptv1.AzureIngressProhibitedTarget {
+	if !cbCtx.EnableBrownfieldDeployment {
+		return cbCtx.RetainedTargets
+	}
+	targets := append([]*ptv1.AzureIngressProhibitedTarget{}, cbCtx.ProhibitedTargets...)
+	return append(targets, cbCtx.RetainedTargets...)
+}
@@ -60,7 +60,7 @@ func validateServiceDefinition(eventRecorder record.EventRecorder, config *n.App
 
 	serviceSet := newServiceSet(&serviceList)
 	for be := range backendIDs {
-		if _, exists := serviceSet[be.serviceKey()]; !exists {
+		if _, exists := serviceSet[be.serviceKey()]; !exists && podSelector(be) == nil {
 			logLine := fmt.Sprintf("Ingress %s/%s references non existent Service %s. Please correct the Service section of your Kubernetes YAML", be.Ingress.Namespace, be.Ingress.Name, be.serviceKey())
 			eventRecorder.Event(be.Ingress, v1.EventTypeWarning, events.ReasonIngressServiceTargetMatch, logLine)
 			// NOTE: we could and should return errors.New(logLine)
@@ -0,0 +1,57 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/errors"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+const (
+	// virtualKubeletLabel and virtualKubeletTaint mark the nodes of virtual-kubelet, such as the virtual nodes of AKS backed by ACI.
+	virtualKubeletLabel      = "type"
+	virtualKubeletLabelValue = "virtual-kubelet"
+	virtualKubeletTaint      = "virtual-kubelet.io/provider"
+)
+
+// isVirtualNode tells whether the node is a virtual-kubelet node, whose Pods usually are not routable from the App Gateway subnet.
+func isVirtualNode(node *v1.Node) bool {
+	if node.Labels[virtualKubeletLabel] == virtualKubeletLabelValue {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == virtualKubeletTaint {
+			return true
+		}
+	}
+	return false
+}
+
+// excludesVirtualNodePods tells whether the Pods on virtual nodes are left out of the backend pools of the Service:
+// the annotation of the Service takes precedence over the default of the controller.
+func (c *appGwConfigBuilder) excludesVirtualNodePods(serviceKey string, cbCtx *ConfigBuilderContext) bool {
+	defaultExclude := cbCtx.EnvVariables.ExcludeVirtualNodePods == "true"
+	service := c.k8sContext.GetService(serviceKey)
+	if service == nil {
+		return defaultExclude
+	}
+	exclude, err := annotations.ExcludeVirtualNodePods(service)
+	if err != nil {
+		if !errors.IsMissingAnnotations(err) {
+			logLine := fmt.Sprintf("Service %s: %s; using the default %t", serviceKey, err, defaultExclude)
+			glog.Warning(logLine)
+			c.recorder.Event(service, v1.EventTypeWarning, events.ReasonInvalidAnnotation, logLine)
+		}
+		return defaultExclude
+	}
+	return exclude
+}
@@ -0,0 +1,106 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test excluding Pods on virtual nodes", func() {
+	serviceKey := tests.Namespace + "/" + tests.ServiceName
+
+	var cb *appGwConfigBuilder
+	var service *v1.Service
+	var services cache.Store
+	var recorder *record.FakeRecorder
+	subset := v1.EndpointSubset{
+		Addresses: []v1.EndpointAddress{
+			{IP: "10.0.0.1", NodeName: to.StringPtr("aks-nodepool1-0")},
+			{IP: "10.0.0.2", NodeName: to.StringPtr("virtual-node-aci-linux")},
+			{IP: "10.0.0.3"},
+		},
+	}
+
+	BeforeEach(func() {
+		nodes := cache.NewStore(cache.MetaNamespaceKeyFunc)
+		_ = nodes.Add(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "aks-nodepool1-0"}})
+		_ = nodes.Add(&v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   "virtual-node-aci-linux",
+			Labels: map[string]string{"type": "virtual-kubelet"},
+		}})
+		services = cache.NewStore(cache.MetaNamespaceKeyFunc)
+		service = &v1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        tests.ServiceName,
+			Namespace:   tests.Namespace,
+			Annotations: map[string]string{},
+		}}
+		recorder = record.NewFakeRecorder(100)
+		cb = &appGwConfigBuilder{
+			k8sContext: &k8scontext.Context{Caches: &k8scontext.CacheCollection{Nodes: nodes, Service: services}},
+			recorder:   recorder,
+		}
+	})
+
+	Context("test isVirtualNode", func() {
+		It("should detect virtual nodes by label or taint", func() {
+			Expect(isVirtualNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"type": "virtual-kubelet"}}})).To(BeTrue())
+			Expect(isVirtualNode(&v1.Node{Spec: v1.NodeSpec{Taints: []v1.Taint{{Key: "virtual-kubelet.io/provider", Value: "azure"}}}})).To(BeTrue())
+			Expect(isVirtualNode(&v1.Node{})).To(BeFalse())
+		})
+	})
+
+	Context("test dropAddressesOnNodes", func() {
+		It("should drop the addresses on virtual nodes only", func() {
+			filtered := cb.dropAddressesOnNodes(serviceKey, subset, "virtual nodes", isVirtualNode)
+			Expect(filtered.Addresses).To(Equal([]v1.EndpointAddress{subset.Addresses[0], subset.Addresses[2]}))
+		})
+	})
+
+	Context("test excludesVirtualNodePods", func() {
+		newContext := func(exclude string) *ConfigBuilderContext {
+			env := environment.GetFakeEnv()
+			env.ExcludeVirtualNodePods = exclude
+			return &ConfigBuilderContext{EnvVariables: env}
+		}
+
+		It("should use the default of the controller without annotation", func() {
+			_ = services.Add(service)
+			Expect(cb.excludesVirtualNodePods(serviceKey, newContext("true"))).To(BeTrue())
+			Expect(cb.excludesVirtualNodePods(serviceKey, newContext("false"))).To(BeFalse())
+			Expect(cb.excludesVirtualNodePods(serviceKey, newContext(""))).To(BeFalse())
+		})
+
+		It("should prefer the annotation of the Service", func() {
+			service.Annotations[annotations.ExcludeVirtualNodePodsKey] = "false"
+			_ = services.Add(service)
+			Expect(cb.excludesVirtualNodePods(serviceKey, newContext("true"))).To(BeFalse())
+		})
+
+		It("should ignore an invalid annotation, and report it on the Service", func() {
+			service.Annotations[annotations.ExcludeVirtualNodePodsKey] = "maybe"
+			_ = services.Add(service)
+			Expect(cb.excludesVirtualNodePods(serviceKey, newContext("true"))).To(BeTrue())
+			Expect(recorder.Events).To(Receive(And(
+				ContainSubstring(events.ReasonInvalidAnnotation),
+				ContainSubstring(annotations.ExcludeVirtualNodePodsKey),
+				ContainSubstring("expected true or false"))))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strconv"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// maxWAFv1FileUploadLimit is the largest file upload limit, in MB, of a WAF (v1) App Gateway.
+const maxWAFv1FileUploadLimit = 100
+
+// wafBodySettings are the request body settings of the web application firewall; unset values leave those of the App Gateway as they are.
+type wafBodySettings struct {
+	requestBodyCheck   *bool
+	maxRequestBodySize *int32
+	fileUploadLimit    *int32
+}
+
+// largest keeps the largest of the sizes; the firewall configuration is shared by all sites, so the App Gateway gets the
+// largest size among the Ingresses, rather than the one of the last Ingress.
+func largest(current *int32, size int32) *int32 {
+	if current == nil || size > *current {
+		return to.Int32Ptr(size)
+	}
+	return current
+}
+
+// getWAFBodySettings returns the request body settings of the environment, with the sizes raised to the largest ones of the Ingresses.
+func getWAFBodySettings(env environment.EnvVariables, ingressList []*v1beta1.Ingress) wafBodySettings {
+	var settings wafBodySettings
+	if checkBody, err := strconv.ParseBool(env.WAFRequestBodyCheck); err == nil {
+		settings.requestBodyCheck = to.BoolPtr(checkBody)
+	}
+	if size, err := strconv.ParseInt(env.WAFMaxRequestBodySize, 10, 32); err == nil {
+		settings.maxRequestBodySize = to.Int32Ptr(int32(size))
+	}
+	if limit, err := strconv.ParseInt(env.WAFFileUploadLimit, 10, 32); err == nil {
+		settings.fileUploadLimit = to.Int32Ptr(int32(limit))
+	}
+
+	for _, ingress := range ingressList {
+		if size, err := annotations.WAFMaxRequestBodySize(ingress); err == nil {
+			settings.maxRequestBodySize = largest(settings.maxRequestBodySize, size)
+		}
+		if limit, err := annotations.WAFFileUploadLimit(ingress); err == nil {
+			settings.fileUploadLimit = largest(settings.fileUploadLimit, limit)
+		}
+	}
+	return settings
+}
+
+// configureWAFBody sets the request body inspection, maximum request body size and file upload limit of the web application firewall.
+// The App Gateway API AGIC uses has a single firewall configuration, so the settings apply to all sites of the App Gateway.
+func (c *appGwConfigBuilder) configureWAFBody(cbCtx *ConfigBuilderContext) {
+	settings := getWAFBodySettings(cbCtx.EnvVariables, cbCtx.IngressList)
+	if settings.requestBodyCheck == nil && settings.maxRequestBodySize == nil && settings.fileUploadLimit == nil {
+		return
+	}
+	if c.appGw.ApplicationGatewayPropertiesFormat == nil || c.appGw.WebApplicationFirewallConfiguration == nil {
+		glog.Warning("The App Gateway has no web application firewall configuration; the request body and file upload settings are ignored")
+		return
+	}
+
+	waf := c.appGw.WebApplicationFirewallConfiguration
+	if settings.requestBodyCheck != nil {
+		waf.RequestBodyCheck = settings.requestBodyCheck
+	}
+	if settings.maxRequestBodySize != nil {
+		// MaxRequestBodySize is the deprecated size in bytes; it is cleared so it does not contradict the size in KB.
+		waf.MaxRequestBodySizeInKb = settings.maxRequestBodySize
+		waf.MaxRequestBodySize = nil
+	}
+	if settings.fileUploadLimit != nil {
+		if c.appGw.Sku != nil && c.appGw.Sku.Tier == n.ApplicationGatewayTierWAF && *settings.fileUploadLimit > maxWAFv1FileUploadLimit {
+			glog.Warningf("App Gateway SKU %s allows file uploads up to %d MB; the file upload limit of %d MB is lowered", c.appGw.Sku.Tier, maxWAFv1FileUploadLimit, *settings.fileUploadLimit)
+			settings.fileUploadLimit = to.Int32Ptr(maxWAFv1FileUploadLimit)
+		}
+		waf.FileUploadLimitInMb = settings.fileUploadLimit
+	}
+}
@@ -0,0 +1,94 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test the request body settings of the web application firewall", func() {
+	newIngress := func(bodySize, uploadLimit string) *v1beta1.Ingress {
+		ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+		if bodySize != "" {
+			ingress.Annotations[annotations.WAFMaxRequestBodySizeKey] = bodySize
+		}
+		if uploadLimit != "" {
+			ingress.Annotations[annotations.WAFFileUploadLimitKey] = uploadLimit
+		}
+		return ingress
+	}
+	newBuilder := func(tier n.ApplicationGatewayTier) *appGwConfigBuilder {
+		return &appGwConfigBuilder{
+			appGw: n.ApplicationGateway{
+				ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+					Sku: &n.ApplicationGatewaySku{Tier: tier},
+					WebApplicationFirewallConfiguration: &n.ApplicationGatewayWebApplicationFirewallConfiguration{
+						Enabled:             to.BoolPtr(true),
+						MaxRequestBodySize:  to.Int32Ptr(131072),
+						FileUploadLimitInMb: to.Int32Ptr(100),
+					},
+				},
+			},
+		}
+	}
+
+	It("should take the largest sizes of the environment and the Ingresses", func() {
+		env := environment.EnvVariables{WAFRequestBodyCheck: "false", WAFMaxRequestBodySize: "64", WAFFileUploadLimit: "200"}
+		settings := getWAFBodySettings(env, []*v1beta1.Ingress{newIngress("32", ""), newIngress("128", "50"), newIngress("huge", "")})
+		Expect(settings).To(Equal(wafBodySettings{
+			requestBodyCheck:   to.BoolPtr(false),
+			maxRequestBodySize: to.Int32Ptr(128),
+			fileUploadLimit:    to.Int32Ptr(200),
+		}))
+
+		Expect(getWAFBodySettings(environment.EnvVariables{}, []*v1beta1.Ingress{newIngress("", "")})).To(Equal(wafBodySettings{}))
+	})
+
+	It("should set the request body settings of the firewall configuration", func() {
+		cb := newBuilder(n.ApplicationGatewayTierWAFV2)
+		cb.configureWAFBody(&ConfigBuilderContext{
+			EnvVariables: environment.EnvVariables{WAFRequestBodyCheck: "true"},
+			IngressList:  []*v1beta1.Ingress{newIngress("100", "300")},
+		})
+		waf := cb.appGw.WebApplicationFirewallConfiguration
+		Expect(waf.RequestBodyCheck).To(Equal(to.BoolPtr(true)))
+		Expect(waf.MaxRequestBodySizeInKb).To(Equal(to.Int32Ptr(100)))
+		Expect(waf.MaxRequestBodySize).To(BeNil())
+		Expect(waf.FileUploadLimitInMb).To(Equal(to.Int32Ptr(300)))
+	})
+
+	It("should leave the firewall configuration as it is without settings", func() {
+		cb := newBuilder(n.ApplicationGatewayTierWAFV2)
+		cb.configureWAFBody(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{newIngress("", "")}})
+		waf := cb.appGw.WebApplicationFirewallConfiguration
+		Expect(waf.RequestBodyCheck).To(BeNil())
+		Expect(waf.MaxRequestBodySize).To(Equal(to.Int32Ptr(131072)))
+		Expect(waf.MaxRequestBodySizeInKb).To(BeNil())
+	})
+
+	It("should lower the file upload limit to the one of WAF v1", func() {
+		cb := newBuilder(n.ApplicationGatewayTierWAF)
+		cb.configureWAFBody(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{newIngress("", "500")}})
+		Expect(cb.appGw.WebApplicationFirewallConfiguration.FileUploadLimitInMb).To(Equal(to.Int32Ptr(maxWAFv1FileUploadLimit)))
+	})
+
+	It("should ignore the settings without a firewall configuration", func() {
+		cb := newBuilder(n.ApplicationGatewayTierStandardV2)
+		cb.appGw.WebApplicationFirewallConfiguration = nil
+		cb.configureWAFBody(&ConfigBuilderContext{IngressList: []*v1beta1.Ingress{newIngress("100", "")}})
+		Expect(cb.appGw.WebApplicationFirewallConfiguration).To(BeNil())
+	})
+})
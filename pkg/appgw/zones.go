@@ -0,0 +1,71 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+)
+
+// zoneLabels are the node labels holding the availability zone of the node, such as "westus2-1"; the newer label first.
+var zoneLabels = []string{
+	"topology.kubernetes.io/zone",
+	"failure-domain.beta.kubernetes.io/zone",
+}
+
+// getNodeZone returns the availability zone of the node, such as "1", or an empty string when it is not in a zone.
+// Nodes outside of availability zones carry their fault domain, such as "0", in the same labels, without a region.
+func getNodeZone(node *v1.Node) string {
+	for _, label := range zoneLabels {
+		value, exists := node.Labels[label]
+		if !exists {
+			continue
+		}
+		if idx := strings.LastIndex(value, "-"); idx >= 0 {
+			return value[idx+1:]
+		}
+		return ""
+	}
+	return ""
+}
+
+// keepAddressesInZones returns the subset with only the addresses of the Pods on nodes in the availability zones of the App Gateway,
+// so traffic does not cross zones. All addresses are kept when the App Gateway is not pinned to zones, or when no Pod runs in its
+// zones: crossing zones is better than having no backends.
+func (c *appGwConfigBuilder) keepAddressesInZones(subset v1.EndpointSubset) v1.EndpointSubset {
+	if c.appGw.Zones == nil || len(*c.appGw.Zones) == 0 {
+		return subset
+	}
+	appGwZones := make(map[string]interface{})
+	for _, zone := range *c.appGw.Zones {
+		appGwZones[zone] = nil
+	}
+
+	var inZone []v1.EndpointAddress
+	for _, address := range subset.Addresses {
+		if address.NodeName == nil {
+			continue
+		}
+		node := c.k8sContext.GetNode(*address.NodeName)
+		if node == nil {
+			continue
+		}
+		if _, exists := appGwZones[getNodeZone(node)]; exists {
+			inZone = append(inZone, address)
+		}
+	}
+	if len(inZone) == 0 {
+		glog.V(3).Infof("None of the %d backend addresses is on a node in the zones %s of the App Gateway; keeping all of them",
+			len(subset.Addresses), strings.Join(*c.appGw.Zones, ","))
+		return subset
+	}
+
+	filtered := subset
+	filtered.Addresses = inZone
+	return filtered
+}
@@ -0,0 +1,81 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+)
+
+// appgw_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test zone aware backend pools", func() {
+	newNode := func(name, zone string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": zone},
+		}}
+	}
+
+	var cb *appGwConfigBuilder
+	subset := v1.EndpointSubset{
+		Addresses: []v1.EndpointAddress{
+			{IP: "10.0.0.1", NodeName: to.StringPtr("node-1")},
+			{IP: "10.0.0.2", NodeName: to.StringPtr("node-2")},
+			{IP: "10.0.0.3"},
+		},
+	}
+
+	BeforeEach(func() {
+		nodes := cache.NewStore(cache.MetaNamespaceKeyFunc)
+		_ = nodes.Add(newNode("node-1", "westus2-1"))
+		_ = nodes.Add(newNode("node-2", "westus2-2"))
+		cb = &appGwConfigBuilder{
+			appGw:      n.ApplicationGateway{ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{}},
+			k8sContext: &k8scontext.Context{Caches: &k8scontext.CacheCollection{Nodes: nodes}},
+		}
+	})
+
+	Context("test getNodeZone", func() {
+		It("should return the zone without the region", func() {
+			Expect(getNodeZone(newNode("node", "westus2-3"))).To(Equal("3"))
+		})
+
+		It("should prefer the topology label", func() {
+			node := newNode("node", "westus2-3")
+			node.Labels["topology.kubernetes.io/zone"] = "westus2-1"
+			Expect(getNodeZone(node)).To(Equal("1"))
+		})
+
+		It("should not return fault domains", func() {
+			Expect(getNodeZone(newNode("node", "0"))).To(Equal(""))
+			Expect(getNodeZone(&v1.Node{})).To(Equal(""))
+		})
+	})
+
+	Context("test keepAddressesInZones", func() {
+		It("should keep the addresses on nodes in the zones of the App Gateway", func() {
+			cb.appGw.Zones = &[]string{"1"}
+			Expect(cb.keepAddressesInZones(subset).Addresses).To(Equal([]v1.EndpointAddress{subset.Addresses[0]}))
+		})
+
+		It("should keep all addresses when the App Gateway is not zonal", func() {
+			Expect(cb.keepAddressesInZones(subset)).To(Equal(subset))
+		})
+
+		It("should keep all addresses when none is in the zones of the App Gateway", func() {
+			cb.appGw.Zones = &[]string{"3"}
+			Expect(cb.keepAddressesInZones(subset)).To(Equal(subset))
+		})
+	})
+})
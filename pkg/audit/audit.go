@@ -0,0 +1,225 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// CorrelationIDHeader is the ARM response header identifying the request in Azure's logs.
+const CorrelationIDHeader = "x-ms-correlation-request-id"
+
+// keysIgnoredInDiff are properties, which differ between the config read from ARM and the generated config,
+// even when the App Gateway is not changed; and the secrets of certificates.
+var keysIgnoredInDiff = map[string]interface{}{
+	"etag":              nil,
+	"type":              nil,
+	"provisioningstate": nil,
+	"data":              nil,
+	"password":          nil,
+	"publiccertdata":    nil,
+}
+
+// Object identifies the Kubernetes object, which triggered an App Gateway update.
+type Object struct {
+	Event     string `json:"event"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// Entry is a record of an App Gateway update applied by AGIC, or observed in observe-only mode.
+type Entry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Trigger       Object    `json:"trigger"`
+	Added         []string  `json:"added,omitempty"`
+	Modified      []string  `json:"modified,omitempty"`
+	Removed       []string  `json:"removed,omitempty"`
+	CorrelationID string    `json:"correlationID,omitempty"`
+	Error         string    `json:"error,omitempty"`
+
+	// ObserveOnly is set for updates, which were not applied because AGIC runs in observe-only mode.
+	ObserveOnly bool `json:"observeOnly,omitempty"`
+}
+
+// Sink receives every entry added to the audit log; Write must not block.
+type Sink interface {
+	Write(entry Entry)
+}
+
+// Log keeps the most recent entries in memory and serves them as JSON over HTTP.
+type Log struct {
+	lock    sync.Mutex
+	size    int
+	entries []Entry
+	sinks   []Sink
+}
+
+// NewLog creates an audit log retaining up to size entries.
+func NewLog(size int) *Log {
+	return &Log{size: size}
+}
+
+// AddSink sends the entries added from now on to the sink as well.
+func (l *Log) AddSink(sink Sink) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.sinks = append(l.sinks, sink)
+}
+
+// Add records an entry; every entry is also written to the controller's log, and to the sinks.
+func (l *Log) Add(entry Entry) {
+	if content, err := json.Marshal(entry); err == nil {
+		glog.V(1).Infof("Audit: %s", content)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.size {
+		l.entries = l.entries[len(l.entries)-l.size:]
+	}
+	for _, sink := range l.sinks {
+		sink.Write(entry)
+	}
+}
+
+// Entries returns a copy of the retained entries, oldest first.
+func (l *Log) Entries() []Entry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entries := make([]Entry, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
+
+// ServeHTTP responds with the retained entries as a JSON array.
+func (l *Log) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	content, err := json.MarshalIndent(l.Entries(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(content)
+}
+
+// NewObject describes the Kubernetes object carried by the event.
+func NewObject(event events.Event) Object {
+	object := Object{Event: events.EventTypeLookup[event.Type]}
+	if event.Value == nil {
+		return object
+	}
+	objType := reflect.TypeOf(event.Value)
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	object.Kind = objType.Name()
+	if accessor, err := meta.Accessor(event.Value); err == nil {
+		object.Namespace = accessor.GetNamespace()
+		object.Name = accessor.GetName()
+	}
+	return object
+}
+
+// Snapshot is the normalized JSON of each named sub-resource of an App Gateway, keyed by "collection/name"
+// (for instance "httpListeners/fl-www.contoso.com-80").
+type Snapshot map[string]string
+
+// NewSnapshot captures the sub-resources of the App Gateway. The config builder modifies the App Gateway it is given,
+// so the existing config must be captured before building.
+func NewSnapshot(appGw *n.ApplicationGateway) (Snapshot, error) {
+	snapshot := make(Snapshot)
+	if appGw == nil || appGw.ApplicationGatewayPropertiesFormat == nil {
+		return snapshot, nil
+	}
+	content, err := json.Marshal(appGw.ApplicationGatewayPropertiesFormat)
+	if err != nil {
+		return nil, err
+	}
+	var properties map[string]interface{}
+	if err := json.Unmarshal(content, &properties); err != nil {
+		return nil, err
+	}
+
+	for collection, value := range properties {
+		items, isSlice := value.([]interface{})
+		if !isSlice {
+			continue
+		}
+		for _, item := range items {
+			resource, isMap := item.(map[string]interface{})
+			if !isMap {
+				continue
+			}
+			name, hasName := resource["name"].(string)
+			if !hasName {
+				continue
+			}
+			normalized, err := json.Marshal(withoutIgnoredKeys(resource))
+			if err != nil {
+				return nil, err
+			}
+			snapshot[fmt.Sprintf("%s/%s", collection, name)] = string(normalized)
+		}
+	}
+	return snapshot, nil
+}
+
+// Diff returns the sub-resources, which are added, modified or removed going from the existing to the updated snapshot.
+func Diff(existing, updated Snapshot) (added, modified, removed []string) {
+	for name, updatedJSON := range updated {
+		existingJSON, exists := existing[name]
+		if !exists {
+			added = append(added, name)
+		} else if existingJSON != updatedJSON {
+			modified = append(modified, name)
+		}
+	}
+	for name := range existing {
+		if _, exists := updated[name]; !exists {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(modified)
+	sort.Strings(removed)
+	return added, modified, removed
+}
+
+func withoutIgnoredKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range v {
+			if _, ignored := keysIgnoredInDiff[strings.ToLower(key)]; ignored {
+				continue
+			}
+			result[key] = withoutIgnoredKeys(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for idx, item := range v {
+			result[idx] = withoutIgnoredKeys(item)
+		}
+		return result
+	}
+	return value
+}
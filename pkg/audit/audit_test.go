@@ -0,0 +1,92 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test the audit log", func() {
+
+	newAppGw := func(listeners ...n.ApplicationGatewayHTTPListener) *n.ApplicationGateway {
+		return &n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				HTTPListeners: &listeners,
+			},
+		}
+	}
+
+	newListener := func(name, hostName string) n.ApplicationGatewayHTTPListener {
+		return n.ApplicationGatewayHTTPListener{
+			Name: to.StringPtr(name),
+			Etag: to.StringPtr("--etag--"),
+			ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+				HostName: to.StringPtr(hostName),
+			},
+		}
+	}
+
+	Context("test Diff()", func() {
+		It("should list added, modified and removed sub-resources", func() {
+			existing, err := NewSnapshot(newAppGw(
+				newListener("kept", "kept.com"),
+				newListener("changed", "old.com"),
+				newListener("deleted", "deleted.com"),
+			))
+			Expect(err).ToNot(HaveOccurred())
+
+			unchanged := newListener("kept", "kept.com")
+			unchanged.Etag = to.StringPtr("*")
+			updated, err := NewSnapshot(newAppGw(
+				unchanged,
+				newListener("changed", "new.com"),
+				newListener("created", "created.com"),
+			))
+			Expect(err).ToNot(HaveOccurred())
+
+			added, modified, removed := Diff(existing, updated)
+			Expect(added).To(Equal([]string{"httpListeners/created"}))
+			Expect(modified).To(Equal([]string{"httpListeners/changed"}))
+			Expect(removed).To(Equal([]string{"httpListeners/deleted"}))
+		})
+	})
+
+	Context("test NewObject()", func() {
+		It("should describe the object of the event", func() {
+			object := NewObject(events.Event{Type: events.Update, Value: tests.NewIngressFixture()})
+			Expect(object).To(Equal(Object{Event: "Update", Kind: "Ingress", Namespace: tests.Namespace, Name: tests.Name}))
+		})
+	})
+
+	Context("test Log", func() {
+		It("should retain the most recent entries and serve them as JSON", func() {
+			log := NewLog(2)
+			log.Add(Entry{CorrelationID: "1"})
+			log.Add(Entry{CorrelationID: "2"})
+			log.Add(Entry{CorrelationID: "3"})
+
+			recorder := httptest.NewRecorder()
+			log.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/audit", nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var entries []Entry
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].CorrelationID).To(Equal("2"))
+			Expect(entries[1].CorrelationID).To(Equal("3"))
+		})
+	})
+})
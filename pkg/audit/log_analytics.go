@@ -0,0 +1,88 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// LogAnalyticsLogType is the name of the custom log of the workspace; Log Analytics appends "_CL" to it, as in "AGICAudit_CL".
+const LogAnalyticsLogType = "AGICAudit"
+
+// logAnalyticsEndpoint returns the URL of the HTTP Data Collector API of the workspace.
+var logAnalyticsEndpoint = func(workspaceID string) string {
+	return fmt.Sprintf("https://%s.ods.opinsights.azure.com/api/logs?api-version=2016-04-01", workspaceID)
+}
+
+// logAnalyticsRecord is an entry as sent to Log Analytics, with the App Gateway it applies to; the workspace may collect
+// the entries of the controllers of many clusters.
+type logAnalyticsRecord struct {
+	Entry
+	AppGateway string `json:"appGateway"`
+}
+
+// LogAnalyticsSink sends the entries of the audit log to a Log Analytics workspace, with the HTTP Data Collector API.
+// Entries are sent in the background, so a slow or unavailable workspace does not delay the updates of the App Gateway.
+type LogAnalyticsSink struct {
+	workspaceID string
+	sharedKey   []byte
+	appGwID     string
+
+	client *http.Client
+	*sinkQueue
+}
+
+// NewLogAnalyticsSink creates a sink sending the entries about the given App Gateway to the workspace.
+// The shared key is the primary or secondary key of the workspace, as base64.
+func NewLogAnalyticsSink(workspaceID, sharedKey, appGwID string) (*LogAnalyticsSink, error) {
+	key, err := base64.StdEncoding.DecodeString(sharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("the shared key of Log Analytics workspace %s is not base64: %s", workspaceID, err)
+	}
+	sink := &LogAnalyticsSink{
+		workspaceID: workspaceID,
+		sharedKey:   key,
+		appGwID:     appGwID,
+		client:      &http.Client{Timeout: sinkTimeout},
+	}
+	sink.sinkQueue = newSinkQueue(fmt.Sprintf("Log Analytics workspace %s", workspaceID), sink.send)
+	return sink, nil
+}
+
+func (s *LogAnalyticsSink) send(entry Entry) error {
+	body, err := json.Marshal([]logAnalyticsRecord{{Entry: entry, AppGateway: s.appGwID}})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, logAnalyticsEndpoint(s.workspaceID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	date := time.Now().UTC().Format(http.TimeFormat)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Log-Type", LogAnalyticsLogType)
+	request.Header.Set("x-ms-date", date)
+	request.Header.Set("time-generated-field", "timestamp")
+	request.Header.Set("x-ms-AzureResourceId", s.appGwID)
+	request.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", s.workspaceID, s.signature(date, len(body))))
+
+	return doRequest(s.client, request)
+}
+
+// signature is the HMAC-SHA256 of the request, which authenticates it with the shared key of the workspace.
+func (s *LogAnalyticsSink) signature(date string, contentLength int) string {
+	stringToSign := fmt.Sprintf("POST\n%d\napplication/json\nx-ms-date:%s\n/api/logs", contentLength, date)
+	mac := hmac.New(sha256.New, s.sharedKey)
+	_, _ = mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,102 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test sending the audit log to Log Analytics", func() {
+	const workspaceID = "--workspace--"
+	const appGwID = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.Network/applicationGateways/--appgw--"
+	sharedKey := base64.StdEncoding.EncodeToString([]byte("--key--"))
+
+	var requests chan *http.Request
+	var bodies chan []byte
+	var server *httptest.Server
+	var defaultEndpoint func(string) string
+
+	BeforeEach(func() {
+		requests = make(chan *http.Request, 10)
+		bodies = make(chan []byte, 10)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests <- r
+			bodies <- body
+		}))
+		defaultEndpoint = logAnalyticsEndpoint
+		logAnalyticsEndpoint = func(id string) string {
+			return server.URL + "/api/logs?workspace=" + id
+		}
+	})
+
+	AfterEach(func() {
+		logAnalyticsEndpoint = defaultEndpoint
+		server.Close()
+	})
+
+	It("should refuse a shared key, which is not base64", func() {
+		_, err := NewLogAnalyticsSink(workspaceID, "not base64!", appGwID)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should send the entries added to the audit log, signed with the shared key", func() {
+		sink, err := NewLogAnalyticsSink(workspaceID, sharedKey, appGwID)
+		Expect(err).ToNot(HaveOccurred())
+		stop := make(chan struct{})
+		defer close(stop)
+		go sink.Run(stop)
+
+		log := NewLog(10)
+		log.AddSink(sink)
+		log.Add(Entry{
+			Timestamp: time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC),
+			Trigger:   Object{Event: "Update", Kind: "Ingress", Namespace: "ns", Name: "web"},
+			Added:     []string{"httpListeners/fl-web"},
+		})
+
+		var request *http.Request
+		Eventually(requests).Should(Receive(&request))
+		var body []byte
+		Eventually(bodies).Should(Receive(&body))
+
+		Expect(request.URL.Query().Get("workspace")).To(Equal(workspaceID))
+		Expect(request.Header.Get("Log-Type")).To(Equal(LogAnalyticsLogType))
+		Expect(request.Header.Get("time-generated-field")).To(Equal("timestamp"))
+		Expect(request.Header.Get("x-ms-AzureResourceId")).To(Equal(appGwID))
+
+		mac := hmac.New(sha256.New, []byte("--key--"))
+		_, _ = mac.Write([]byte(fmt.Sprintf("POST\n%d\napplication/json\nx-ms-date:%s\n/api/logs", len(body), request.Header.Get("x-ms-date"))))
+		Expect(request.Header.Get("Authorization")).To(Equal(fmt.Sprintf("SharedKey %s:%s", workspaceID, base64.StdEncoding.EncodeToString(mac.Sum(nil)))))
+
+		var records []map[string]interface{}
+		Expect(json.Unmarshal(body, &records)).To(Succeed())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0]["appGateway"]).To(Equal(appGwID))
+		Expect(records[0]["timestamp"]).To(Equal("2019-07-01T12:00:00Z"))
+		Expect(records[0]["added"]).To(Equal([]interface{}{"httpListeners/fl-web"}))
+	})
+
+	It("should drop entries when the queue is full, rather than block", func() {
+		sink, err := NewLogAnalyticsSink(workspaceID, sharedKey, appGwID)
+		Expect(err).ToNot(HaveOccurred())
+		for i := 0; i < sinkQueueSize+1; i++ {
+			sink.Write(Entry{})
+		}
+		Expect(sink.entries).To(HaveLen(sinkQueueSize))
+	})
+})
@@ -0,0 +1,77 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// sinkQueueSize is the number of entries waiting to be sent, past which new entries are dropped.
+	sinkQueueSize = 100
+
+	// sinkTimeout limits how long sending an entry may take.
+	sinkTimeout = 30 * time.Second
+)
+
+// sinkQueue sends the entries to a remote destination in the background, so a slow or unavailable destination does not
+// delay the updates of the App Gateway.
+type sinkQueue struct {
+	destination string
+	send        func(Entry) error
+	entries     chan Entry
+}
+
+func newSinkQueue(destination string, send func(Entry) error) *sinkQueue {
+	return &sinkQueue{
+		destination: destination,
+		send:        send,
+		entries:     make(chan Entry, sinkQueueSize),
+	}
+}
+
+// Write queues the entry to be sent; it is dropped when the queue is full.
+func (q *sinkQueue) Write(entry Entry) {
+	select {
+	case q.entries <- entry:
+	default:
+		glog.Errorf("Dropping audit entry of %s/%s; %s is not keeping up", entry.Trigger.Namespace, entry.Trigger.Name, q.destination)
+	}
+}
+
+// Run sends the queued entries until the stop channel is closed. Failed entries are logged, and not retried.
+func (q *sinkQueue) Run(stopChannel <-chan struct{}) {
+	for {
+		select {
+		case entry := <-q.entries:
+			if err := q.send(entry); err != nil {
+				glog.Errorf("Unable to send audit entry to %s: %s", q.destination, err)
+			}
+		case <-stopChannel:
+			return
+		}
+	}
+}
+
+// doRequest sends the request, and returns an error with the body of the response unless it succeeded.
+func doRequest(client *http.Client, request *http.Request) error {
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("unexpected status %d: %s", response.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	// EventGridEventType is the type of the Event Grid events sent after each App Gateway update.
+	EventGridEventType = "Microsoft.AGIC.AppGatewayUpdated"
+
+	eventGridDataVersion = "1.0"
+	eventGridKeyHeader   = "aeg-sas-key"
+)
+
+// Notification is the payload posted to the webhook after each App Gateway update, and the data of the Event Grid event.
+type Notification struct {
+	Entry
+	AppGateway string `json:"appGateway"`
+	Succeeded  bool   `json:"succeeded"`
+}
+
+// eventGridEvent is an event in the Event Grid schema.
+type eventGridEvent struct {
+	ID          string       `json:"id"`
+	EventType   string       `json:"eventType"`
+	Subject     string       `json:"subject"`
+	EventTime   time.Time    `json:"eventTime"`
+	Data        Notification `json:"data"`
+	DataVersion string       `json:"dataVersion"`
+}
+
+// WebhookSink posts a notification of every entry of the audit log to a webhook, or publishes it to an Event Grid topic
+// when the key of the topic is given, so pipelines can react to the updates AGIC applies.
+type WebhookSink struct {
+	url          string
+	eventGridKey string
+	appGwID      string
+
+	client *http.Client
+	*sinkQueue
+}
+
+// NewWebhookSink creates a sink notifying the given URL of the updates of the App Gateway; with an Event Grid key the URL
+// is the endpoint of an Event Grid topic.
+func NewWebhookSink(url, eventGridKey, appGwID string) *WebhookSink {
+	sink := &WebhookSink{
+		url:          url,
+		eventGridKey: eventGridKey,
+		appGwID:      appGwID,
+		client:       &http.Client{Timeout: sinkTimeout},
+	}
+	destination := "webhook " + url
+	if eventGridKey != "" {
+		destination = "Event Grid topic " + url
+	}
+	sink.sinkQueue = newSinkQueue(destination, sink.send)
+	return sink
+}
+
+func (s *WebhookSink) send(entry Entry) error {
+	notification := Notification{Entry: entry, AppGateway: s.appGwID, Succeeded: entry.Error == ""}
+	var payload interface{} = notification
+	if s.eventGridKey != "" {
+		payload = []eventGridEvent{{
+			ID:          newEventID(),
+			EventType:   EventGridEventType,
+			Subject:     s.appGwID,
+			EventTime:   entry.Timestamp,
+			Data:        notification,
+			DataVersion: eventGridDataVersion,
+		}}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.eventGridKey != "" {
+		request.Header.Set(eventGridKeyHeader, s.eventGridKey)
+	}
+	return doRequest(s.client, request)
+}
+
+// newEventID returns a random ID, which identifies the event to Event Grid.
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
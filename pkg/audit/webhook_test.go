@@ -0,0 +1,87 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test notifying a webhook of the App Gateway updates", func() {
+	const appGwID = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.Network/applicationGateways/--appgw--"
+	timestamp := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	var requests chan *http.Request
+	var bodies chan []byte
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		requests = make(chan *http.Request, 10)
+		bodies = make(chan []byte, 10)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			requests <- r
+			bodies <- body
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should post the outcome, changes and correlation ID of the update", func() {
+		sink := NewWebhookSink(server.URL, "", appGwID)
+		Expect(sink.send(Entry{
+			Timestamp:     timestamp,
+			Modified:      []string{"httpListeners/fl-web"},
+			CorrelationID: "--correlation--",
+			Error:         "unable to deploy App Gateway config",
+		})).To(Succeed())
+
+		var request *http.Request
+		Expect(requests).To(Receive(&request))
+		Expect(request.Header.Get(eventGridKeyHeader)).To(BeEmpty())
+		var notification Notification
+		Expect(json.Unmarshal(<-bodies, &notification)).To(Succeed())
+		Expect(notification.AppGateway).To(Equal(appGwID))
+		Expect(notification.Succeeded).To(BeFalse())
+		Expect(notification.Modified).To(Equal([]string{"httpListeners/fl-web"}))
+		Expect(notification.CorrelationID).To(Equal("--correlation--"))
+	})
+
+	It("should publish an event to the Event Grid topic, with its key", func() {
+		sink := NewWebhookSink(server.URL, "--key--", appGwID)
+		Expect(sink.send(Entry{Timestamp: timestamp, CorrelationID: "--correlation--"})).To(Succeed())
+
+		var request *http.Request
+		Expect(requests).To(Receive(&request))
+		Expect(request.Header.Get(eventGridKeyHeader)).To(Equal("--key--"))
+		var events []eventGridEvent
+		Expect(json.Unmarshal(<-bodies, &events)).To(Succeed())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].ID).ToNot(BeEmpty())
+		Expect(events[0].EventType).To(Equal(EventGridEventType))
+		Expect(events[0].Subject).To(Equal(appGwID))
+		Expect(events[0].EventTime).To(Equal(timestamp))
+		Expect(events[0].Data.Succeeded).To(BeTrue())
+		Expect(events[0].Data.CorrelationID).To(Equal("--correlation--"))
+	})
+
+	It("should fail when the webhook does not accept the notification", func() {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}))
+		defer failing.Close()
+		err := NewWebhookSink(failing.URL, "", appGwID).send(Entry{})
+		Expect(err).To(MatchError("unexpected status 403: forbidden"))
+	})
+})
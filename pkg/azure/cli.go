@@ -0,0 +1,120 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// cliTokenRefreshWithin is how long before expiry a token obtained from the Azure CLI is replaced.
+	cliTokenRefreshWithin = 5 * time.Minute
+
+	// cliExpiresOnFormat is the layout of the local time in the "expiresOn" field of "az account get-access-token".
+	cliExpiresOnFormat = "2006-01-02 15:04:05.999999"
+)
+
+type cliCredential struct{}
+
+func (c cliCredential) Name() string {
+	return "Azure CLI"
+}
+
+func (c cliCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	provider := &cliTokenProvider{
+		resource: resource,
+		getToken: getTokenFromCLI,
+	}
+	// Fetch a token right away to make sure the CLI is installed and logged in.
+	if err := provider.EnsureFresh(); err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(provider), nil
+}
+
+// cliToken is the output of "az account get-access-token".
+type cliToken struct {
+	AccessToken string `json:"accessToken"`
+	ExpiresOn   string `json:"expiresOn"`
+}
+
+// cliTokenProvider obtains tokens from the Azure CLI and replaces them before they expire.
+// Unlike the token cache of older CLI versions, "az account get-access-token" works with all CLI versions.
+type cliTokenProvider struct {
+	resource string
+	getToken func(resource string) (cliToken, error)
+
+	lock      sync.Mutex
+	token     string
+	expiresOn time.Time
+}
+
+func (p *cliTokenProvider) OAuthToken() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.token
+}
+
+func (p *cliTokenProvider) EnsureFresh() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.token != "" && time.Now().Add(cliTokenRefreshWithin).Before(p.expiresOn) {
+		return nil
+	}
+	return p.refresh()
+}
+
+func (p *cliTokenProvider) Refresh() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.refresh()
+}
+
+func (p *cliTokenProvider) RefreshExchange(resource string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.resource = resource
+	return p.refresh()
+}
+
+func (p *cliTokenProvider) refresh() error {
+	token, err := p.getToken(p.resource)
+	if err != nil {
+		return err
+	}
+	expiresOn, err := time.ParseInLocation(cliExpiresOnFormat, token.ExpiresOn, time.Local)
+	if err != nil {
+		return fmt.Errorf("unable to parse expiry of Azure CLI token %q: %s", token.ExpiresOn, err)
+	}
+	glog.V(5).Infof("Obtained a token from Azure CLI, which expires on %s", expiresOn)
+	p.token = token.AccessToken
+	p.expiresOn = expiresOn
+	return nil
+}
+
+func getTokenFromCLI(resource string) (cliToken, error) {
+	var token cliToken
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "az", "account", "get-access-token", "--resource", resource, "--output", "json").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return token, fmt.Errorf("az account get-access-token failed (run \"az login\" first): %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return token, err
+	}
+	err = json.Unmarshal(output, &token)
+	return token, err
+}
@@ -0,0 +1,76 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+var _ = Describe("Test Azure CLI authentication", func() {
+
+	newProvider := func(expiresIn time.Duration) (*cliTokenProvider, *int) {
+		calls := 0
+		provider := &cliTokenProvider{
+			resource: "https://management.azure.com/",
+			getToken: func(resource string) (cliToken, error) {
+				calls++
+				return cliToken{
+					AccessToken: fmt.Sprintf("--token-%d--", calls),
+					ExpiresOn:   time.Now().Add(expiresIn).Format(cliExpiresOnFormat),
+				}, nil
+			},
+		}
+		return provider, &calls
+	}
+
+	Context("test cliTokenProvider", func() {
+		It("should reuse a token, which is not about to expire", func() {
+			provider, calls := newProvider(time.Hour)
+			Expect(provider.EnsureFresh()).To(Succeed())
+			Expect(provider.EnsureFresh()).To(Succeed())
+			Expect(*calls).To(Equal(1))
+			Expect(provider.OAuthToken()).To(Equal("--token-1--"))
+		})
+
+		It("should replace a token, which is about to expire", func() {
+			provider, calls := newProvider(time.Minute)
+			Expect(provider.EnsureFresh()).To(Succeed())
+			Expect(provider.EnsureFresh()).To(Succeed())
+			Expect(*calls).To(Equal(2))
+			Expect(provider.OAuthToken()).To(Equal("--token-2--"))
+		})
+
+		It("should fail on a token with malformed expiry", func() {
+			provider := &cliTokenProvider{
+				getToken: func(resource string) (cliToken, error) {
+					return cliToken{AccessToken: "--token--", ExpiresOn: "tomorrow"}, nil
+				},
+			}
+			Expect(provider.EnsureFresh()).ToNot(Succeed())
+			Expect(provider.OAuthToken()).To(Equal(""))
+		})
+	})
+
+	Context("test NewDefaultCredentialChain()", func() {
+		It("should only use the Azure CLI when enabled", func() {
+			env := environment.GetFakeEnv()
+			env.UseAzureCLIAuth = "true"
+			Expect(NewDefaultCredentialChain(env)).To(Equal([]Credential{cliCredential{}}))
+		})
+
+		It("should try the Azure CLI last", func() {
+			chain := NewDefaultCredentialChain(environment.GetFakeEnv())
+			Expect(len(chain)).To(Equal(5))
+			Expect(chain[4]).To(Equal(cliCredential{}))
+		})
+	})
+})
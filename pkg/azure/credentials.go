@@ -0,0 +1,231 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	az "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+const (
+	// FederatedTokenFileVarName is the path to the projected service account token used by Azure Workload Identity.
+	FederatedTokenFileVarName = "AZURE_FEDERATED_TOKEN_FILE"
+
+	// AuthorityHostVarName is the Azure Active Directory endpoint set by Azure Workload Identity.
+	AuthorityHostVarName = "AZURE_AUTHORITY_HOST"
+
+	// managedIdentityProbeTimeout bounds the time spent checking whether a managed identity endpoint is reachable.
+	managedIdentityProbeTimeout = 10 * time.Second
+
+	clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	// MonitoringResource is the audience of the tokens of the Azure Monitor custom metrics API.
+	MonitoringResource = "https://monitoring.azure.com/"
+)
+
+// ErrCredentialUnavailable is returned by a Credential, which is not configured in the current environment.
+var ErrCredentialUnavailable = errors.New("credential is not configured in this environment")
+
+// Credential is a method of authenticating with Azure Resource Manager.
+type Credential interface {
+	// Name describes the authentication method.
+	Name() string
+
+	// Authorizer creates an authorizer for the given resource, such as Azure Resource Manager.
+	// ErrCredentialUnavailable is returned when the method is not configured.
+	Authorizer(resource string) (autorest.Authorizer, error)
+}
+
+// NewDefaultCredentialChain returns the authentication methods AGIC tries, in order:
+// service principal file, environment variables, workload identity, managed identity and Azure CLI.
+// When UseAzureCLIAuth is enabled only the Azure CLI is used; this is meant for running AGIC outside of the cluster.
+func NewDefaultCredentialChain(env environment.EnvVariables) []Credential {
+	if env.UseAzureCLIAuth == "true" {
+		return []Credential{cliCredential{}}
+	}
+	return []Credential{
+		fileCredential{authLocation: env.AuthLocation},
+		environmentCredential{},
+		workloadIdentityCredential{
+			tokenFile:     os.Getenv(FederatedTokenFileVarName),
+			clientID:      os.Getenv(auth.ClientID),
+			tenantID:      os.Getenv(auth.TenantID),
+			authorityHost: getAuthorityHost(),
+		},
+		managedIdentityCredential{clientID: os.Getenv(auth.ClientID)},
+		cliCredential{},
+	}
+}
+
+// GetAuthorizer returns an ARM authorizer from the first credential of the chain, which is available in this environment.
+// As with auth.NewAuthorizerFromEnvironment, the resource is the Resource Manager of the Azure cloud named by AZURE_ENVIRONMENT,
+// the public cloud by default, unless AZURE_AD_RESOURCE overrides it.
+func GetAuthorizer(chain []Credential) (autorest.Authorizer, Credential, error) {
+	settings, err := auth.GetSettingsFromEnvironment()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get the Azure cloud from %s: %s", auth.EnvironmentName, err)
+	}
+	return GetAuthorizerForResource(chain, settings.Values[auth.Resource])
+}
+
+// getAuthorityHost returns the Azure Active Directory endpoint set by Azure Workload Identity, or the one of the Azure cloud
+// named by AZURE_ENVIRONMENT. An unknown cloud is reported by GetAuthorizer.
+func getAuthorityHost() string {
+	if authorityHost := os.Getenv(AuthorityHostVarName); authorityHost != "" {
+		return authorityHost
+	}
+	if settings, err := auth.GetSettingsFromEnvironment(); err == nil {
+		return settings.Environment.ActiveDirectoryEndpoint
+	}
+	return ""
+}
+
+// GetAuthorizerForResource returns an authorizer for the given resource from the first credential of the chain, which is available.
+func GetAuthorizerForResource(chain []Credential, resource string) (autorest.Authorizer, Credential, error) {
+	var reasons []string
+	for _, credential := range chain {
+		authorizer, err := credential.Authorizer(resource)
+		if err == nil {
+			glog.Infof("Authenticating with Azure Resource Manager using %s", credential.Name())
+			return authorizer, credential, nil
+		}
+		glog.V(3).Infof("Skipping %s: %s", credential.Name(), err)
+		reasons = append(reasons, fmt.Sprintf("%s: %s", credential.Name(), err))
+	}
+	return nil, nil, fmt.Errorf("no Azure credential is available; %s", strings.Join(reasons, "; "))
+}
+
+type fileCredential struct {
+	authLocation string
+}
+
+func (c fileCredential) Name() string {
+	return fmt.Sprintf("service principal file (%s)", environment.AuthLocationVarName)
+}
+
+func (c fileCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	if c.authLocation == "" {
+		return nil, ErrCredentialUnavailable
+	}
+	return auth.NewAuthorizerFromFileWithResource(resource)
+}
+
+type environmentCredential struct{}
+
+func (c environmentCredential) Name() string {
+	return fmt.Sprintf("environment variables (%s)", auth.ClientID)
+}
+
+func (c environmentCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	settings, err := auth.GetSettingsFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	settings.Values[auth.Resource] = resource
+	if config, err := settings.GetClientCredentials(); err == nil {
+		return config.Authorizer()
+	}
+	if config, err := settings.GetClientCertificate(); err == nil {
+		return config.Authorizer()
+	}
+	return nil, ErrCredentialUnavailable
+}
+
+type workloadIdentityCredential struct {
+	tokenFile     string
+	clientID      string
+	tenantID      string
+	authorityHost string
+}
+
+func (c workloadIdentityCredential) Name() string {
+	return "workload identity"
+}
+
+func (c workloadIdentityCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	if c.tokenFile == "" || c.clientID == "" || c.tenantID == "" {
+		return nil, ErrCredentialUnavailable
+	}
+	authorityHost := c.authorityHost
+	if authorityHost == "" {
+		authorityHost = az.PublicCloud.ActiveDirectoryEndpoint
+	}
+	oauthConfig, err := adal.NewOAuthConfig(authorityHost, c.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	secret := &federatedTokenSecret{tokenFile: c.tokenFile}
+	token, err := adal.NewServicePrincipalTokenWithSecret(*oauthConfig, c.clientID, resource, secret)
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+// federatedTokenSecret exchanges the projected service account token for an Azure AD token.
+// The file is read on every refresh, since Kubernetes rotates the token.
+type federatedTokenSecret struct {
+	tokenFile string
+}
+
+func (s *federatedTokenSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, values *url.Values) error {
+	assertion, err := ioutil.ReadFile(s.tokenFile)
+	if err != nil {
+		return err
+	}
+	values.Set("client_assertion_type", clientAssertionType)
+	values.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	return nil
+}
+
+type managedIdentityCredential struct {
+	clientID string
+}
+
+func (c managedIdentityCredential) Name() string {
+	if c.clientID != "" {
+		return fmt.Sprintf("managed identity (client ID %s)", c.clientID)
+	}
+	return "managed identity"
+}
+
+func (c managedIdentityCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	msiEndpoint, err := adal.GetMSIVMEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	var token *adal.ServicePrincipalToken
+	if c.clientID == "" {
+		token, err = adal.NewServicePrincipalTokenFromMSI(msiEndpoint, resource)
+	} else {
+		token, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(msiEndpoint, resource, c.clientID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Creating the token does not reach out to the endpoint; fetch one to make sure a managed identity is available.
+	ctx, cancel := context.WithTimeout(context.Background(), managedIdentityProbeTimeout)
+	defer cancel()
+	if err := token.RefreshWithContext(ctx); err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
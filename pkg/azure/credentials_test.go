@@ -0,0 +1,138 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+
+	"github.com/Azure/go-autorest/autorest"
+	az "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeCredential struct {
+	name string
+	err  error
+}
+
+func (c fakeCredential) Name() string {
+	return c.name
+}
+
+func (c fakeCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return autorest.NullAuthorizer{}, nil
+}
+
+// recordingCredential records the resource it authorizes for.
+type recordingCredential struct {
+	resource *string
+}
+
+func (c recordingCredential) Name() string {
+	return "recording"
+}
+
+func (c recordingCredential) Authorizer(resource string) (autorest.Authorizer, error) {
+	*c.resource = resource
+	return autorest.NullAuthorizer{}, nil
+}
+
+var _ = Describe("Test Azure credential chain", func() {
+
+	Context("test GetAuthorizer()", func() {
+		It("should use the first available credential", func() {
+			chain := []Credential{
+				fakeCredential{name: "first", err: ErrCredentialUnavailable},
+				fakeCredential{name: "second"},
+				fakeCredential{name: "third"},
+			}
+			authorizer, credential, err := GetAuthorizer(chain)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(authorizer).To(Equal(autorest.NullAuthorizer{}))
+			Expect(credential.Name()).To(Equal("second"))
+		})
+
+		It("should authorize with the Resource Manager of the cloud named by AZURE_ENVIRONMENT", func() {
+			defer os.Unsetenv(auth.EnvironmentName)
+			var resource string
+			chain := []Credential{recordingCredential{resource: &resource}}
+
+			_, _, err := GetAuthorizer(chain)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource).To(Equal(az.PublicCloud.ResourceManagerEndpoint))
+
+			Expect(os.Setenv(auth.EnvironmentName, "AzureChinaCloud")).To(Succeed())
+			_, _, err = GetAuthorizer(chain)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resource).To(Equal(az.ChinaCloud.ResourceManagerEndpoint))
+			Expect(getAuthorityHost()).To(Equal(az.ChinaCloud.ActiveDirectoryEndpoint))
+
+			Expect(os.Setenv(auth.EnvironmentName, "--unknown--")).To(Succeed())
+			_, _, err = GetAuthorizer(chain)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should list the reason each credential was skipped when none is available", func() {
+			chain := []Credential{
+				fakeCredential{name: "first", err: ErrCredentialUnavailable},
+				fakeCredential{name: "second", err: errors.New("token expired")},
+			}
+			_, credential, err := GetAuthorizer(chain)
+			Expect(credential).To(BeNil())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("first: " + ErrCredentialUnavailable.Error()))
+			Expect(err.Error()).To(ContainSubstring("second: token expired"))
+		})
+	})
+
+	Context("test credentials, which are not configured", func() {
+		It("should not use a service principal file without AZURE_AUTH_LOCATION", func() {
+			_, err := fileCredential{}.Authorizer(az.PublicCloud.ResourceManagerEndpoint)
+			Expect(err).To(Equal(ErrCredentialUnavailable))
+		})
+
+		It("should not use workload identity without a federated token", func() {
+			_, err := workloadIdentityCredential{clientID: "--client--", tenantID: "--tenant--"}.Authorizer(az.PublicCloud.ResourceManagerEndpoint)
+			Expect(err).To(Equal(ErrCredentialUnavailable))
+		})
+	})
+
+	Context("test workload identity", func() {
+		It("should create an authorizer when fully configured", func() {
+			credential := workloadIdentityCredential{
+				tokenFile: "/var/run/secrets/azure/tokens/azure-identity-token",
+				clientID:  "--client--",
+				tenantID:  "--tenant--",
+			}
+			authorizer, err := credential.Authorizer(MonitoringResource)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(authorizer).ToNot(BeNil())
+		})
+
+		It("should send the federated token as client assertion", func() {
+			tokenFile, err := ioutil.TempFile("", "federated-token")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(tokenFile.Name())
+			_, err = tokenFile.WriteString("--jwt--\n")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tokenFile.Close()).To(Succeed())
+
+			values := url.Values{}
+			secret := &federatedTokenSecret{tokenFile: tokenFile.Name()}
+			Expect(secret.SetAuthenticationValues(nil, &values)).To(Succeed())
+			Expect(values.Get("client_assertion")).To(Equal("--jwt--"))
+			Expect(values.Get("client_assertion_type")).To(Equal(clientAssertionType))
+		})
+	})
+})
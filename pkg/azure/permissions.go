@@ -0,0 +1,103 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+)
+
+const (
+	// AppGwReadAction is the ARM action required to GET an App Gateway.
+	AppGwReadAction = "Microsoft.Network/applicationGateways/read"
+
+	// AppGwWriteAction is the ARM action required to PUT an App Gateway.
+	AppGwWriteAction = "Microsoft.Network/applicationGateways/write"
+)
+
+// PermissionsNotListedError is returned when the App Gateway is reachable, but the permissions of the identity on it cannot be
+// listed, as when the identity is not allowed to perform Microsoft.Authorization/permissions/read. AGIC may run regardless;
+// a missing write permission then surfaces on the first update.
+type PermissionsNotListedError struct {
+	appGwName string
+	err       error
+}
+
+func (e PermissionsNotListedError) Error() string {
+	return fmt.Sprintf("unable to list the permissions on App Gateway %s, so the write permission is not checked: %s", e.appGwName, e.err)
+}
+
+// CheckAppGwPermissions gets the App Gateway, and lists the ARM permissions the authenticated identity has on it. It returns an
+// error when the App Gateway cannot be read, or naming the actions AGIC requires, which are not granted, or a
+// PermissionsNotListedError when the permissions cannot be listed.
+// ARM does not offer a dry run of an App Gateway update, so this is how a missing write permission is detected before the first update.
+// When readOnly is set, AGIC never updates the App Gateway and only the read permission is required.
+func CheckAppGwPermissions(appGwClient n.ApplicationGatewaysClient, subscriptionID, resourceGroup, appGwName string, readOnly bool) error {
+	// Getting the App Gateway proves the read permission, which does not depend on listing the permissions.
+	if _, err := appGwClient.Get(context.Background(), resourceGroup, appGwName); err != nil {
+		return fmt.Errorf("unable to get App Gateway %s; the identity AGIC authenticated with needs at least the Reader role on it: %s", appGwName, err)
+	}
+
+	client := authorization.NewPermissionsClient(subscriptionID)
+	client.Authorizer = appGwClient.Authorizer
+
+	var permissions []authorization.Permission
+	iter, err := client.ListForResourceComplete(context.Background(), resourceGroup, "Microsoft.Network", "", "applicationGateways", appGwName)
+	for ; err == nil && iter.NotDone(); err = iter.Next() {
+		permissions = append(permissions, iter.Value())
+	}
+	if err != nil {
+		return PermissionsNotListedError{appGwName: appGwName, err: err}
+	}
+
+	required, role := []string{AppGwReadAction, AppGwWriteAction}, "Contributor"
+	if readOnly {
+		required, role = []string{AppGwReadAction}, "Reader"
+	}
+	var missing []string
+	for _, action := range required {
+		if !isActionAllowed(permissions, action) {
+			missing = append(missing, action)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the identity AGIC authenticated with is not allowed to perform %s on App Gateway %s; grant it the %s role on the App Gateway",
+			strings.Join(missing, ", "), appGwName, role)
+	}
+	glog.V(3).Infof("Identity is allowed to perform %s on App Gateway %s", strings.Join(required, ", "), appGwName)
+	return nil
+}
+
+// isActionAllowed tells whether any of the permissions grants the action and does not exclude it with NotActions.
+func isActionAllowed(permissions []authorization.Permission, action string) bool {
+	for _, permission := range permissions {
+		if permission.Actions == nil || !matchesAny(*permission.Actions, action) {
+			continue
+		}
+		if permission.NotActions != nil && matchesAny(*permission.NotActions, action) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// matchesAny tells whether the action matches any of the patterns; patterns may contain "*" wildcards and are case insensitive.
+func matchesAny(patterns []string, action string) bool {
+	for _, pattern := range patterns {
+		expr := "(?i)^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$"
+		if matched, _ := regexp.MatchString(expr, action); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test ARM permission checks", func() {
+
+	permission := func(actions []string, notActions []string) authorization.Permission {
+		return authorization.Permission{Actions: &actions, NotActions: &notActions}
+	}
+
+	Context("test isActionAllowed()", func() {
+		It("should allow actions granted by the Contributor role", func() {
+			permissions := []authorization.Permission{
+				permission([]string{"*"}, []string{"Microsoft.Authorization/*/Delete", "Microsoft.Authorization/*/Write"}),
+			}
+			Expect(isActionAllowed(permissions, AppGwReadAction)).To(BeTrue())
+			Expect(isActionAllowed(permissions, AppGwWriteAction)).To(BeTrue())
+		})
+
+		It("should not allow writes with the Reader role", func() {
+			permissions := []authorization.Permission{
+				permission([]string{"*/read"}, nil),
+			}
+			Expect(isActionAllowed(permissions, AppGwReadAction)).To(BeTrue())
+			Expect(isActionAllowed(permissions, AppGwWriteAction)).To(BeFalse())
+		})
+
+		It("should match wildcards case insensitively and honor NotActions", func() {
+			permissions := []authorization.Permission{
+				permission([]string{"microsoft.network/*"}, []string{"Microsoft.Network/applicationGateways/write"}),
+			}
+			Expect(isActionAllowed(permissions, AppGwReadAction)).To(BeTrue())
+			Expect(isActionAllowed(permissions, AppGwWriteAction)).To(BeFalse())
+
+			permissions = append(permissions, permission([]string{"Microsoft.Network/applicationGateways/*"}, nil))
+			Expect(isActionAllowed(permissions, AppGwWriteAction)).To(BeTrue())
+		})
+
+		It("should not allow anything without permissions", func() {
+			Expect(isActionAllowed(nil, AppGwReadAction)).To(BeFalse())
+			Expect(isActionAllowed([]authorization.Permission{{}}, AppGwReadAction)).To(BeFalse())
+		})
+	})
+
+	Context("test CheckAppGwPermissions()", func() {
+		It("should fail when the App Gateway cannot be read", func() {
+			client := n.NewApplicationGatewaysClient("--subscription--")
+			client.Authorizer = autorest.NullAuthorizer{}
+			client.Sender = autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+				return newResponse(req, http.StatusForbidden, nil, []byte(`{"error": {"code": "AuthorizationFailed"}}`)), nil
+			})
+			err := CheckAppGwPermissions(client, "--subscription--", "--resource-group--", "appgw", false)
+			Expect(err).To(HaveOccurred())
+			_, notListed := err.(PermissionsNotListedError)
+			Expect(notListed).To(BeFalse())
+		})
+
+		It("should name the App Gateway when the permissions cannot be listed", func() {
+			err := PermissionsNotListedError{appGwName: "appgw", err: errors.New("403 Forbidden")}
+			Expect(err.Error()).To(Equal("unable to list the permissions on App Gateway appgw, so the write permission is not checked: 403 Forbidden"))
+		})
+	})
+})
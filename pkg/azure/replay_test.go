@@ -0,0 +1,131 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test record and replay of ARM interactions", func() {
+
+	const appGwJSON = `{
+  "name": "appgw",
+  "location": "westus2",
+  "properties": {
+    "provisioningState": "Succeeded",
+    "backendAddressPools": [{"name": "defaultaddresspool", "properties": {}}]
+  }
+}`
+
+	var dir string
+	var gatewayFile string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "agic-replay")
+		Expect(err).ToNot(HaveOccurred())
+		gatewayFile = filepath.Join(dir, "appgw.json")
+		Expect(ioutil.WriteFile(gatewayFile, []byte(appGwJSON), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	newClient := func(sender autorest.Sender) n.ApplicationGatewaysClient {
+		client := n.NewApplicationGatewaysClient("--subscription--")
+		client.Authorizer = autorest.NullAuthorizer{}
+		client.Sender = sender
+		return client
+	}
+
+	update := func(client n.ApplicationGatewaysClient, appGw n.ApplicationGateway) {
+		appGw.ProvisioningState = to.StringPtr("Updating")
+		future, err := client.CreateOrUpdate(context.Background(), "--resource-group--", "appgw", appGw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(future.WaitForCompletionRef(context.Background(), client.Client)).To(Succeed())
+	}
+
+	Context("test NewFileGatewaySender()", func() {
+		It("should serve and update the App Gateway from the file", func() {
+			sender, err := NewFileGatewaySender(gatewayFile)
+			Expect(err).ToNot(HaveOccurred())
+			client := newClient(sender)
+
+			appGw, err := client.Get(context.Background(), "--resource-group--", "appgw")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*(*appGw.BackendAddressPools)[0].Name).To(Equal("defaultaddresspool"))
+
+			(*appGw.BackendAddressPools)[0].Name = to.StringPtr("pool")
+			update(client, appGw)
+
+			appGw, err = client.Get(context.Background(), "--resource-group--", "appgw")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*(*appGw.BackendAddressPools)[0].Name).To(Equal("pool"))
+			Expect(*appGw.ProvisioningState).To(Equal("Succeeded"))
+		})
+
+		It("should fail when the file does not exist", func() {
+			_, err := NewFileGatewaySender(filepath.Join(dir, "missing.json"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("test NewRecordingSender() and NewReplayingSender()", func() {
+		It("should replay the recorded interactions in order", func() {
+			fileSender, err := NewFileGatewaySender(gatewayFile)
+			Expect(err).ToNot(HaveOccurred())
+			recordDir := filepath.Join(dir, "recording")
+			recorder, err := NewRecordingSender(fileSender, recordDir)
+			Expect(err).ToNot(HaveOccurred())
+
+			client := newClient(recorder)
+			appGw, err := client.Get(context.Background(), "--resource-group--", "appgw")
+			Expect(err).ToNot(HaveOccurred())
+			(*appGw.BackendAddressPools)[0].Name = to.StringPtr("pool")
+			update(client, appGw)
+			_, err = client.Get(context.Background(), "--resource-group--", "appgw")
+			Expect(err).ToNot(HaveOccurred())
+
+			recorded, err := filepath.Glob(filepath.Join(recordDir, "*.json"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorded).To(HaveLen(3))
+
+			replayer, err := NewReplayingSender(recordDir)
+			Expect(err).ToNot(HaveOccurred())
+			client = newClient(replayer)
+
+			appGw, err = client.Get(context.Background(), "--resource-group--", "appgw")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*(*appGw.BackendAddressPools)[0].Name).To(Equal("defaultaddresspool"))
+			update(client, appGw)
+			appGw, err = client.Get(context.Background(), "--resource-group--", "appgw")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*(*appGw.BackendAddressPools)[0].Name).To(Equal("pool"))
+
+			// Every recorded interaction has been replayed.
+			req, err := http.NewRequest(http.MethodGet, "https://management.azure.com/", nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = replayer.Do(req)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail on a directory without recordings", func() {
+			_, err := NewReplayingSender(dir + "/empty")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,75 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+)
+
+// GetSubnetID returns the ID of the subnet the App Gateway is deployed in.
+func GetSubnetID(appGw n.ApplicationGateway) (string, error) {
+	if appGw.ApplicationGatewayPropertiesFormat != nil && appGw.GatewayIPConfigurations != nil {
+		for _, ipConfig := range *appGw.GatewayIPConfigurations {
+			if ipConfig.ApplicationGatewayIPConfigurationPropertiesFormat != nil && ipConfig.Subnet != nil && ipConfig.Subnet.ID != nil {
+				return *ipConfig.Subnet.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("the App Gateway has no gateway IP configuration with a subnet")
+}
+
+// ParseSubnetID returns the resource group, virtual network and subnet names of a subnet ID.
+func ParseSubnetID(id string) (resourceGroup, vnetName, subnetName string, err error) {
+	segments := parseResourceID(id)
+	resourceGroup, vnetName, subnetName = segments["resourcegroups"], segments["virtualnetworks"], segments["subnets"]
+	if resourceGroup == "" || vnetName == "" || subnetName == "" {
+		return "", "", "", fmt.Errorf("malformed subnet ID %s", id)
+	}
+	return resourceGroup, vnetName, subnetName, nil
+}
+
+// ParseRouteTableID returns the resource group and name of a route table ID.
+func ParseRouteTableID(id string) (resourceGroup, routeTableName string, err error) {
+	segments := parseResourceID(id)
+	resourceGroup, routeTableName = segments["resourcegroups"], segments["routetables"]
+	if resourceGroup == "" || routeTableName == "" {
+		return "", "", fmt.Errorf("malformed route table ID %s", id)
+	}
+	return resourceGroup, routeTableName, nil
+}
+
+// ParseNetworkSecurityGroupID returns the resource group and name of a network security group ID.
+func ParseNetworkSecurityGroupID(id string) (resourceGroup, nsgName string, err error) {
+	segments := parseResourceID(id)
+	resourceGroup, nsgName = segments["resourcegroups"], segments["networksecuritygroups"]
+	if resourceGroup == "" || nsgName == "" {
+		return "", "", fmt.Errorf("malformed network security group ID %s", id)
+	}
+	return resourceGroup, nsgName, nil
+}
+
+// ParsePublicIPAddressID returns the resource group and name of a public IP address ID.
+func ParsePublicIPAddressID(id string) (resourceGroup, publicIPName string, err error) {
+	segments := parseResourceID(id)
+	resourceGroup, publicIPName = segments["resourcegroups"], segments["publicipaddresses"]
+	if resourceGroup == "" || publicIPName == "" {
+		return "", "", fmt.Errorf("malformed public IP address ID %s", id)
+	}
+	return resourceGroup, publicIPName, nil
+}
+
+// parseResourceID maps the lower case keys of an ARM resource ID, such as "resourcegroups", to the values following them.
+func parseResourceID(id string) map[string]string {
+	segments := make(map[string]string)
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+	for idx := 0; idx+1 < len(parts); idx += 2 {
+		segments[strings.ToLower(parts[idx])] = parts[idx+1]
+	}
+	return segments
+}
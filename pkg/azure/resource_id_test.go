@@ -0,0 +1,52 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test the parsing of ARM resource IDs", func() {
+	Context("test ParseSubnetID()", func() {
+		It("should parse the names of the subnet", func() {
+			resourceGroup, vnet, subnet, err := ParseSubnetID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/appgw")
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]string{resourceGroup, vnet, subnet}).To(Equal([]string{"rg", "vnet", "appgw"}))
+		})
+
+		It("should fail on other resources", func() {
+			_, _, _, err := ParseSubnetID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/routeTables/rt")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("test ParseNetworkSecurityGroupID()", func() {
+		It("should parse the name of the network security group", func() {
+			resourceGroup, nsg, err := ParseNetworkSecurityGroupID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/appgw-nsg")
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]string{resourceGroup, nsg}).To(Equal([]string{"rg", "appgw-nsg"}))
+		})
+
+		It("should fail on other resources", func() {
+			_, _, err := ParseNetworkSecurityGroupID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/routeTables/rt")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("test ParsePublicIPAddressID()", func() {
+		It("should parse the name of the public IP address", func() {
+			resourceGroup, publicIP, err := ParsePublicIPAddressID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/appgw-ip")
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]string{resourceGroup, publicIP}).To(Equal([]string{"rg", "appgw-ip"}))
+		})
+
+		It("should fail on other resources", func() {
+			_, _, err := ParsePublicIPAddressID("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/routeTables/rt")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
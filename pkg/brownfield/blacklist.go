@@ -0,0 +1,79 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package brownfield
+
+import (
+	"encoding/json"
+	"errors"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+)
+
+// ErrNoGatewayProperties is returned when the given App Gateway JSON has no "properties" section.
+var ErrNoGatewayProperties = errors.New("no properties found in App Gateway config")
+
+// Resources is a collection of App Gateway sub-resources, grouped by type.
+type Resources struct {
+	BackendPools []n.ApplicationGatewayBackendAddressPool  `json:"backendAddressPools,omitempty"`
+	HTTPSettings []n.ApplicationGatewayBackendHTTPSettings `json:"backendHttpSettingsCollection,omitempty"`
+	Listeners    []n.ApplicationGatewayHTTPListener        `json:"httpListeners,omitempty"`
+	Ports        []n.ApplicationGatewayFrontendPort        `json:"frontendPorts,omitempty"`
+	Probes       []n.ApplicationGatewayProbe               `json:"probes,omitempty"`
+	RoutingRules []n.ApplicationGatewayRequestRoutingRule  `json:"requestRoutingRules,omitempty"`
+	URLPathMaps  []n.ApplicationGatewayURLPathMap          `json:"urlPathMaps,omitempty"`
+}
+
+// Blacklist is the outcome of applying a list of AzureIngressProhibitedTarget CRDs to an existing App Gateway config.
+type Blacklist struct {
+	// Kept holds the blacklisted resources. AGIC is not allowed to change these and will retain them as they are.
+	Kept Resources `json:"kept"`
+
+	// Removed holds the resources AGIC manages. These are overwritten or removed when AGIC applies a new config.
+	Removed Resources `json:"removed"`
+}
+
+// GetBlacklist splits all existing resources into ones AGIC will keep and ones AGIC will remove or overwrite.
+// The split is computed with the exact same logic AGIC uses when it runs in a brownfield deployment.
+func (er ExistingResources) GetBlacklist() Blacklist {
+	var bl Blacklist
+	bl.Kept.BackendPools, bl.Removed.BackendPools = er.GetBlacklistedPools()
+	bl.Kept.HTTPSettings, bl.Removed.HTTPSettings = er.GetBlacklistedHTTPSettings()
+	bl.Kept.Listeners, bl.Removed.Listeners = er.GetBlacklistedListeners()
+	bl.Kept.Ports, bl.Removed.Ports = er.GetBlacklistedPorts()
+	bl.Kept.Probes, bl.Removed.Probes = er.GetBlacklistedProbes()
+	bl.Kept.RoutingRules, bl.Removed.RoutingRules = er.GetBlacklistedRoutingRules()
+	bl.Kept.URLPathMaps, bl.Removed.URLPathMaps = er.GetBlacklistedPathMaps()
+	return bl
+}
+
+// GetBlacklistFromJSON computes the Blacklist for an App Gateway config given in the JSON format returned by ARM
+// (for instance the output of "az network application-gateway show") and a list of prohibited targets.
+func GetBlacklistFromJSON(appGwJSON []byte, prohibitedTargets []*ptv1.AzureIngressProhibitedTarget) (*Blacklist, error) {
+	var appGw n.ApplicationGateway
+	if err := json.Unmarshal(appGwJSON, &appGw); err != nil {
+		return nil, err
+	}
+	if appGw.ApplicationGatewayPropertiesFormat == nil {
+		return nil, ErrNoGatewayProperties
+	}
+	bl := NewExistingResources(appGw, prohibitedTargets, nil).GetBlacklist()
+	return &bl, nil
+}
+
+// ProtectedListenersAndRules returns the names of the listeners and request routing rules kept by the blacklist,
+// prefixed with their collection (for instance "httpListeners/fl-bye.com-80").
+func (bl Blacklist) ProtectedListenersAndRules() map[string]interface{} {
+	protected := make(map[string]interface{})
+	for _, listener := range bl.Kept.Listeners {
+		protected["httpListeners/"+*listener.Name] = nil
+	}
+	for _, rule := range bl.Kept.RoutingRules {
+		protected["requestRoutingRules/"+*rule.Name] = nil
+	}
+	return protected
+}
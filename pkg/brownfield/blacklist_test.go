@@ -0,0 +1,89 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package brownfield
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
+)
+
+var _ = Describe("Test blacklist computation", func() {
+
+	appGw := fixtures.GetAppGateway()
+	prohibitedTargets := fixtures.GetAzureIngressProhibitedTargets() // Host: "bye.com", Paths: [/fox, /bar]
+
+	Context("Test GetBlacklist()", func() {
+		It("should split all resources the same way as the per-resource functions", func() {
+			er := NewExistingResources(appGw, prohibitedTargets, nil)
+			bl := er.GetBlacklist()
+
+			blacklistedListeners, nonBlacklistedListeners := er.GetBlacklistedListeners()
+			Expect(bl.Kept.Listeners).To(Equal(blacklistedListeners))
+			Expect(bl.Removed.Listeners).To(Equal(nonBlacklistedListeners))
+
+			blacklistedRules, nonBlacklistedRules := er.GetBlacklistedRoutingRules()
+			Expect(bl.Kept.RoutingRules).To(Equal(blacklistedRules))
+			Expect(bl.Removed.RoutingRules).To(Equal(nonBlacklistedRules))
+
+			blacklistedProbes, nonBlacklistedProbes := er.GetBlacklistedProbes()
+			Expect(bl.Kept.Probes).To(Equal(blacklistedProbes))
+			Expect(bl.Removed.Probes).To(Equal(nonBlacklistedProbes))
+
+			Expect(len(bl.Kept.Listeners)).To(Equal(3))
+			Expect(len(bl.Removed.Listeners)).To(Equal(1))
+		})
+	})
+
+	Context("Test ProtectedListenersAndRules()", func() {
+		It("should name the kept listeners and routing rules", func() {
+			bl := NewExistingResources(appGw, prohibitedTargets, nil).GetBlacklist()
+			protected := bl.ProtectedListenersAndRules()
+			Expect(protected).ToNot(BeEmpty())
+			for _, listener := range bl.Kept.Listeners {
+				Expect(protected).To(HaveKey("httpListeners/" + *listener.Name))
+			}
+			for _, rule := range bl.Kept.RoutingRules {
+				Expect(protected).To(HaveKey("requestRoutingRules/" + *rule.Name))
+			}
+		})
+
+		It("should protect nothing without prohibited targets", func() {
+			bl := NewExistingResources(appGw, nil, nil).GetBlacklist()
+			Expect(bl.ProtectedListenersAndRules()).To(BeEmpty())
+		})
+	})
+
+	Context("Test GetBlacklistFromJSON()", func() {
+		It("should compute the blacklist for an App Gateway in ARM JSON format", func() {
+			appGwJSON, err := json.Marshal(appGw)
+			Expect(err).ToNot(HaveOccurred())
+
+			bl, err := GetBlacklistFromJSON(appGwJSON, prohibitedTargets)
+			Expect(err).ToNot(HaveOccurred())
+
+			expected := NewExistingResources(appGw, prohibitedTargets, nil).GetBlacklist()
+			Expect(getListenerNames(bl.Kept.Listeners)).To(Equal(getListenerNames(expected.Kept.Listeners)))
+			Expect(getListenerNames(bl.Removed.Listeners)).To(Equal(getListenerNames(expected.Removed.Listeners)))
+			Expect(getRuleNames(bl.Kept.RoutingRules)).To(Equal(getRuleNames(expected.Kept.RoutingRules)))
+			Expect(getSettingNames(bl.Kept.HTTPSettings)).To(Equal(getSettingNames(expected.Kept.HTTPSettings)))
+			Expect(getProbeNames(bl.Kept.Probes)).To(Equal(getProbeNames(expected.Kept.Probes)))
+		})
+
+		It("should fail on malformed JSON", func() {
+			_, err := GetBlacklistFromJSON([]byte("{"), prohibitedTargets)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail when App Gateway has no properties", func() {
+			_, err := GetBlacklistFromJSON([]byte(`{"name": "appgw"}`), prohibitedTargets)
+			Expect(err).To(Equal(ErrNoGatewayProperties))
+		})
+	})
+})
@@ -0,0 +1,47 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package brownfield
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+)
+
+// ParseProhibitedTargets reads the prohibited targets of a ConfigMap, the alternative to AzureIngressProhibitedTarget CRDs for
+// clusters where installing CRDs is not allowed. Each key is the name of a prohibited target, and its value is the spec of an
+// AzureIngressProhibitedTarget, in YAML or JSON. Values which cannot be parsed are left out, and returned as errors.
+func ParseProhibitedTargets(configMap *v1.ConfigMap) ([]*ptv1.AzureIngressProhibitedTarget, []error) {
+	if configMap == nil {
+		return nil, nil
+	}
+	var names []string
+	for name := range configMap.Data {
+		names = append(names, name)
+	}
+	// Sorted, so the targets are in the same order from one sync to the next.
+	sort.Strings(names)
+
+	var targets []*ptv1.AzureIngressProhibitedTarget
+	var errs []error
+	for _, name := range names {
+		var spec ptv1.AzureIngressProhibitedTargetSpec
+		if err := yaml.UnmarshalStrict([]byte(configMap.Data[name]), &spec); err != nil {
+			errs = append(errs, fmt.Errorf("prohibited target %s is not a valid AzureIngressProhibitedTarget spec: %s", name, err))
+			continue
+		}
+		targets = append(targets, &ptv1.AzureIngressProhibitedTarget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: configMap.Namespace, Name: name},
+			Spec:       spec,
+		})
+	}
+	return targets, errs
+}
@@ -0,0 +1,61 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package brownfield
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+)
+
+var _ = Describe("Test reading prohibited targets from a ConfigMap", func() {
+	newConfigMap := func(data map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "agic", Name: "prohibited-targets"},
+			Data:       data,
+		}
+	}
+
+	It("should read each key as a prohibited target in YAML or JSON, ordered by name", func() {
+		targets, errs := ParseProhibitedTargets(newConfigMap(map[string]string{
+			"legacy": "hostname: legacy.contoso.com\npaths:\n- /admin/*\n- /api/*\n",
+			"blog":   `{"hostname": "blog.contoso.com"}`,
+		}))
+		Expect(errs).To(BeEmpty())
+		Expect(targets).To(Equal([]*ptv1.AzureIngressProhibitedTarget{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "agic", Name: "blog"},
+				Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: "blog.contoso.com"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "agic", Name: "legacy"},
+				Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: "legacy.contoso.com", Paths: []string{"/admin/*", "/api/*"}},
+			},
+		}))
+	})
+
+	It("should leave out and report the values which are not a valid spec", func() {
+		targets, errs := ParseProhibitedTargets(newConfigMap(map[string]string{
+			"typo":   "hostnam: www.contoso.com",
+			"broken": "paths: /api/*",
+			"valid":  "hostname: www.contoso.com",
+		}))
+		Expect(targets).To(HaveLen(1))
+		Expect(targets[0].Name).To(Equal("valid"))
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0].Error()).To(ContainSubstring("prohibited target broken is not a valid AzureIngressProhibitedTarget spec"))
+		Expect(errs[1].Error()).To(ContainSubstring("prohibited target typo"))
+	})
+
+	It("should have no prohibited targets without the ConfigMap", func() {
+		targets, errs := ParseProhibitedTargets(nil)
+		Expect(targets).To(BeEmpty())
+		Expect(errs).To(BeEmpty())
+	})
+})
@@ -7,6 +7,7 @@ package brownfield
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/golang/glog"
@@ -66,3 +67,35 @@ func GetTargetBlacklist(prohibitedTargets []*ptv1.AzureIngressProhibitedTarget)
 	}
 	return &target
 }
+
+// GetProhibitedTargetStatus returns the status the Ingress Controller writes on the prohibited target: what it prohibits or,
+// when its paths are not of the form "/.../*", that it was not accepted. Such paths are still prohibited, exactly as written.
+func GetProhibitedTargetStatus(prohibitedTarget *ptv1.AzureIngressProhibitedTarget) ptv1.AzureIngressProhibitedTargetStatus {
+	status := ptv1.AzureIngressProhibitedTargetStatus{ObservedGeneration: prohibitedTarget.Generation}
+
+	var invalidPaths []string
+	for _, path := range prohibitedTarget.Spec.Paths {
+		if !strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/*") {
+			invalidPaths = append(invalidPaths, path)
+		}
+	}
+	if len(invalidPaths) > 0 {
+		status.Message = fmt.Sprintf("paths [%s] must begin with / and end with /*; until fixed, they are prohibited exactly as written",
+			strings.Join(invalidPaths, ", "))
+		return status
+	}
+
+	status.Accepted = true
+	hostname, paths := prohibitedTarget.Spec.Hostname, strings.Join(prohibitedTarget.Spec.Paths, ", ")
+	switch {
+	case hostname == "" && paths == "":
+		status.Message = "prohibits all App Gateway config"
+	case paths == "":
+		status.Message = fmt.Sprintf("prohibits all paths of hostname %s", hostname)
+	case hostname == "":
+		status.Message = fmt.Sprintf("prohibits paths [%s] of all hostnames", paths)
+	default:
+		status.Message = fmt.Sprintf("prohibits paths [%s] of hostname %s", paths, hostname)
+	}
+	return status
+}
@@ -8,7 +8,9 @@ package brownfield
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
 )
@@ -86,4 +88,28 @@ var _ = Describe("Test blacklisting targets", func() {
 			Expect(targetNoHost.IsBlacklisted(&blacklist)).To(BeFalse())
 		})
 	})
+
+	Context("test GetProhibitedTargetStatus", func() {
+		newTarget := func(hostname string, paths ...string) *ptv1.AzureIngressProhibitedTarget {
+			return &ptv1.AzureIngressProhibitedTarget{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: hostname, Paths: paths},
+			}
+		}
+
+		It("should accept valid targets and describe what they prohibit", func() {
+			Expect(GetProhibitedTargetStatus(newTarget(""))).To(Equal(ptv1.AzureIngressProhibitedTargetStatus{
+				ObservedGeneration: 3, Accepted: true, Message: "prohibits all App Gateway config"}))
+			Expect(GetProhibitedTargetStatus(newTarget(tests.Host)).Message).To(Equal("prohibits all paths of hostname " + tests.Host))
+			Expect(GetProhibitedTargetStatus(newTarget("", "/foo/*")).Message).To(Equal("prohibits paths [/foo/*] of all hostnames"))
+			Expect(GetProhibitedTargetStatus(newTarget(tests.Host, "/foo/*", "/bar/*")).Message).To(Equal("prohibits paths [/foo/*, /bar/*] of hostname " + tests.Host))
+		})
+
+		It("should not accept paths which do not end with /*", func() {
+			status := GetProhibitedTargetStatus(newTarget(tests.Host, "/foo/*", "/bar", "baz/*"))
+			Expect(status.Accepted).To(BeFalse())
+			Expect(status.ObservedGeneration).To(Equal(int64(3)))
+			Expect(status.Message).To(HavePrefix("paths [/bar, baz/*] must begin with / and end with /*"))
+		})
+	})
 })
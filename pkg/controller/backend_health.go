@@ -0,0 +1,134 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// unhealthyBackend is a backend server, which the App Gateway found unhealthy, with a description of the failing probe.
+type unhealthyBackend struct {
+	address string
+	message string
+}
+
+// MonitorBackendHealth gets the health of the backends from the App Gateway at every interval until the controller stops,
+// and emits a warning event on each Pod, which the App Gateway found unhealthy.
+func (c *AppGwIngressController) MonitorBackendHealth(interval time.Duration) {
+	wait.Until(c.reportBackendHealth, interval, c.stopChannel)
+}
+
+// reportBackendHealth emits a warning event on the Pods, which the App Gateway found unhealthy; other backends are only logged.
+func (c *AppGwIngressController) reportBackendHealth() {
+	appGw, err := c.getAppGw()
+	if err != nil {
+		glog.Error("Unable to get App Gateway to report the health of its backends: ", err)
+		return
+	}
+	health, err := c.getBackendHealth()
+	if err != nil {
+		glog.Error("Unable to get the health of the backends of the App Gateway: ", err)
+		return
+	}
+
+	for _, backend := range getUnhealthyBackends(health, appGw) {
+		pod := c.k8sContext.GetPodByIP(backend.address)
+		if pod == nil {
+			glog.V(5).Infof("Backend %s is unhealthy, and is not a Pod: %s", backend.address, backend.message)
+			continue
+		}
+		glog.V(3).Infof("Pod %s/%s is unhealthy: %s", pod.Namespace, pod.Name, backend.message)
+		c.recorder.Event(pod, v1.EventTypeWarning, events.ReasonBackendUnhealthy, backend.message)
+	}
+}
+
+// getBackendHealth requests the health of the backends, and waits for the App Gateway to probe them.
+func (c *AppGwIngressController) getBackendHealth() (n.ApplicationGatewayBackendHealth, error) {
+	future, err := c.appGwClient.BackendHealth(c.ctx, c.appGwIdentifier.ResourceGroup, c.appGwIdentifier.AppGwName, "")
+	if err != nil {
+		return n.ApplicationGatewayBackendHealth{}, err
+	}
+	if err := future.WaitForCompletionRef(c.ctx, c.appGwClient.Client); err != nil {
+		return n.ApplicationGatewayBackendHealth{}, err
+	}
+	return future.Result(c.appGwClient)
+}
+
+// getUnhealthyBackends returns the backend servers, which are down, with the port and path of the probe, and the log of the probe.
+// The HTTP settings and probes are looked up in the App Gateway, as the backend health only references them.
+func getUnhealthyBackends(health n.ApplicationGatewayBackendHealth, appGw n.ApplicationGateway) []unhealthyBackend {
+	var backends []unhealthyBackend
+	if health.BackendAddressPools == nil {
+		return backends
+	}
+	for _, pool := range *health.BackendAddressPools {
+		if pool.BackendHTTPSettingsCollection == nil {
+			continue
+		}
+		for _, settingsHealth := range *pool.BackendHTTPSettingsCollection {
+			if settingsHealth.Servers == nil {
+				continue
+			}
+			target := describeProbeTarget(settingsHealth.BackendHTTPSettings, appGw)
+			for _, server := range *settingsHealth.Servers {
+				if server.Health != n.Down || server.Address == nil {
+					continue
+				}
+				message := fmt.Sprintf("App Gateway probe to %s failed", target)
+				if server.HealthProbeLog != nil && *server.HealthProbeLog != "" {
+					message = fmt.Sprintf("%s: %s", message, strings.TrimSpace(*server.HealthProbeLog))
+				}
+				backends = append(backends, unhealthyBackend{address: *server.Address, message: message})
+			}
+		}
+	}
+	return backends
+}
+
+// describeProbeTarget returns the port and path probed with the referenced HTTP settings, such as ":8080/healthz".
+// Without a custom probe, the App Gateway probes "/" on the port of the HTTP settings.
+func describeProbeTarget(reference *n.ApplicationGatewayBackendHTTPSettings, appGw n.ApplicationGateway) string {
+	settings := findHTTPSettingsByID(reference, appGw)
+	if settings == nil || settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat == nil {
+		return "the backend"
+	}
+	port := ""
+	if settings.Port != nil {
+		port = fmt.Sprintf(":%d", *settings.Port)
+	}
+	path := "/"
+	if settings.Probe != nil && settings.Probe.ID != nil && appGw.Probes != nil {
+		for _, probe := range *appGw.Probes {
+			if probe.ID != nil && strings.EqualFold(*probe.ID, *settings.Probe.ID) &&
+				probe.ApplicationGatewayProbePropertiesFormat != nil && probe.Path != nil {
+				path = *probe.Path
+			}
+		}
+	}
+	return port + path
+}
+
+// findHTTPSettingsByID returns the HTTP settings of the App Gateway with the ID of the reference, or nil.
+func findHTTPSettingsByID(reference *n.ApplicationGatewayBackendHTTPSettings, appGw n.ApplicationGateway) *n.ApplicationGatewayBackendHTTPSettings {
+	if reference == nil || reference.ID == nil || appGw.ApplicationGatewayPropertiesFormat == nil || appGw.BackendHTTPSettingsCollection == nil {
+		return nil
+	}
+	for idx, settings := range *appGw.BackendHTTPSettingsCollection {
+		if settings.ID != nil && strings.EqualFold(*settings.ID, *reference.ID) {
+			return &(*appGw.BackendHTTPSettingsCollection)[idx]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test the reporting of the backend health", func() {
+	const settingsID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/appgw/backendHttpSettingsCollection/bp-80"
+	const probeID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/appgw/probes/pb-80"
+
+	appGw := n.ApplicationGateway{
+		ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+			BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHTTPSettings{
+				{
+					ID: to.StringPtr(settingsID),
+					ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{
+						Port:  to.Int32Ptr(8080),
+						Probe: &n.SubResource{ID: to.StringPtr(probeID)},
+					},
+				},
+			},
+			Probes: &[]n.ApplicationGatewayProbe{
+				{ID: to.StringPtr(probeID), ApplicationGatewayProbePropertiesFormat: &n.ApplicationGatewayProbePropertiesFormat{Path: to.StringPtr("/healthz")}},
+			},
+		},
+	}
+
+	newBackendHealth := func(settingsID string, servers ...n.ApplicationGatewayBackendHealthServer) n.ApplicationGatewayBackendHealth {
+		return n.ApplicationGatewayBackendHealth{
+			BackendAddressPools: &[]n.ApplicationGatewayBackendHealthPool{
+				{
+					BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHealthHTTPSettings{
+						{
+							BackendHTTPSettings: &n.ApplicationGatewayBackendHTTPSettings{ID: to.StringPtr(settingsID)},
+							Servers:             &servers,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("should describe the servers which are down with their probe", func() {
+		health := newBackendHealth(settingsID,
+			n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.244.0.5"), Health: n.Up},
+			n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.244.0.6"), Health: n.Down, HealthProbeLog: to.StringPtr("Received invalid status code: 500 ")},
+		)
+		Expect(getUnhealthyBackends(health, appGw)).To(Equal([]unhealthyBackend{
+			{address: "10.244.0.6", message: "App Gateway probe to :8080/healthz failed: Received invalid status code: 500"},
+		}))
+	})
+
+	It("should describe the servers of unknown HTTP settings without their probe", func() {
+		health := newBackendHealth(settingsID+"-unknown", n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.244.0.6"), Health: n.Down})
+		Expect(getUnhealthyBackends(health, appGw)).To(Equal([]unhealthyBackend{
+			{address: "10.244.0.6", message: "App Gateway probe to the backend failed"},
+		}))
+	})
+
+	It("should probe / without a custom probe", func() {
+		settings := &n.ApplicationGatewayBackendHTTPSettings{
+			ID: to.StringPtr(settingsID),
+			ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{Port: to.Int32Ptr(80)},
+		}
+		withoutProbe := n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHTTPSettings{*settings},
+			},
+		}
+		Expect(describeProbeTarget(settings, withoutProbe)).To(Equal(":80/"))
+	})
+})
@@ -6,60 +6,219 @@
 package controller
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2019-03-01/insights"
 	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/audit"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
 )
 
+const (
+	// auditLogSize is the number of App Gateway updates retained in the audit log.
+	auditLogSize = 100
+
+	// abandonTimeout is how long Stop waits for the event being processed to end, once its ARM requests are cancelled.
+	abandonTimeout = 5 * time.Second
+)
+
 // AppGwIngressController configures the application gateway based on the ingress rules defined.
 type AppGwIngressController struct {
 	appGwClient     n.ApplicationGatewaysClient
 	appGwIdentifier appgw.Identifier
 
+	// diagnosticSettingsClient is nil unless AGIC manages the diagnostic settings of the App Gateway.
+	diagnosticSettingsClient *insights.DiagnosticSettingsClient
+
+	// publicIPAddressesClient gets the public IP address of the App Gateway, which is published in the status of the Ingresses.
+	publicIPAddressesClient n.PublicIPAddressesClient
+	frontendIP              *frontendIP
+
+	// nsgClients is nil unless AGIC manages the security rules of the frontend ports of the App Gateway.
+	nsgClients *nsgClients
+
 	k8sContext *k8scontext.Context
+	kubeClient kubernetes.Interface
 	worker     *worker.Worker
 
 	configCache *[]byte
 
 	recorder record.EventRecorder
+	auditLog *audit.Log
+
+	lastApplied *lastAppliedStore
+	orphans     *orphanStore
+	dryRuns     *dryRunReports
+	protected   *protectedResources
+	readiness   *readiness
+	health      *health
+
+	// customMetrics are accumulated even when they are not published to Azure Monitor.
+	customMetrics *customMetrics
+
+	// dataPlaneMetrics are the metrics of the App Gateway last read from Azure Monitor, exported on /metrics.
+	dataPlaneMetrics *dataPlaneMetricsCache
+
+	// watchdog abandons the ARM requests of a stalled event, and counts the recoveries exported on /metrics.
+	watchdog *watchdog
+
+	// twoPhase tracks the new backends staged before the generated config is applied.
+	twoPhase *twoPhaseApply
+
+	// armGetTimeout limits how long getting the App Gateway may take; zero means no limit.
+	armGetTimeout time.Duration
+
+	// eventTimeout limits how long the ARM requests of an event may take, all together; zero means no limit.
+	eventTimeout time.Duration
+
+	// ctx is cancelled to abandon the ARM requests in progress when the controller does not stop in time.
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	stopChannel chan struct{}
+	stopOnce    *sync.Once
+	stopped     chan struct{}
 }
 
 // NewAppGwIngressController constructs a controller object.
-func NewAppGwIngressController(appGwClient n.ApplicationGatewaysClient, appGwIdentifier appgw.Identifier, k8sContext *k8scontext.Context, recorder record.EventRecorder) *AppGwIngressController {
+func NewAppGwIngressController(appGwClient n.ApplicationGatewaysClient, appGwIdentifier appgw.Identifier, k8sContext *k8scontext.Context, kubeClient kubernetes.Interface, recorder record.EventRecorder, armGetTimeout time.Duration, workerOptions worker.Options) *AppGwIngressController {
 	controller := &AppGwIngressController{
-		appGwClient:     appGwClient,
-		appGwIdentifier: appGwIdentifier,
-		k8sContext:      k8sContext,
-		recorder:        recorder,
-		configCache:     to.ByteSlicePtr([]byte{}),
+		appGwClient:      appGwClient,
+		appGwIdentifier:  appGwIdentifier,
+		k8sContext:       k8sContext,
+		kubeClient:       kubeClient,
+		recorder:         recorder,
+		configCache:      to.ByteSlicePtr([]byte{}),
+		auditLog:         audit.NewLog(auditLogSize),
+		protected:        &protectedResources{},
+		dryRuns:          &dryRunReports{},
+		readiness:        &readiness{},
+		health:           newHealth(),
+		customMetrics:    newCustomMetrics(),
+		dataPlaneMetrics: newDataPlaneMetricsCache(),
+		watchdog:         newWatchdog(),
+		twoPhase:         newTwoPhaseApply(DefaultTwoPhaseApplyTimeout),
+		armGetTimeout:    armGetTimeout,
+		stopChannel:      make(chan struct{}),
+		stopOnce:         &sync.Once{},
+		stopped:          make(chan struct{}),
 	}
+	controller.ctx, controller.cancel = context.WithCancel(context.Background())
+
+	controller.publicIPAddressesClient = n.NewPublicIPAddressesClient(appGwIdentifier.SubscriptionID)
+	controller.publicIPAddressesClient.Authorizer = appGwClient.Authorizer
+	controller.frontendIP = &frontendIP{}
 
-	controller.worker = worker.NewWorker(controller)
+	controller.worker = worker.NewWorker(controller, workerOptions)
 	return controller
 }
 
 // Start function runs the k8scontext and continues to listen to the
-// event channel and enqueue events before stopChannel is closed
+// event channel and enqueue events before stopChannel is closed. It returns once Stop completed.
 func (c *AppGwIngressController) Start(envVariables environment.EnvVariables) {
+	// The last applied config is kept in the namespace of AGIC; without it there is nowhere to keep it.
+	c.lastApplied = newLastAppliedStore(c.kubeClient, envVariables.AGICPodNamespace)
+	c.checkLastApplied()
+	c.orphans = newOrphanStore(c.kubeClient, c.k8sContext, envVariables.AGICPodNamespace)
+
+	if getDiagnosticSettings(envVariables) != nil {
+		client := insights.NewDiagnosticSettingsClient(c.appGwIdentifier.SubscriptionID)
+		client.Authorizer = c.appGwClient.Authorizer
+		c.diagnosticSettingsClient = &client
+	}
+
+	if envVariables.AuditWorkspaceID != "" {
+		if sink, err := audit.NewLogAnalyticsSink(envVariables.AuditWorkspaceID, envVariables.AuditWorkspaceKey, c.appGwIdentifier.AppGwResourceID()); err != nil {
+			glog.Error("Audit entries are not sent to Log Analytics: ", err)
+		} else {
+			go sink.Run(c.stopChannel)
+			c.auditLog.AddSink(sink)
+		}
+	}
+
+	if envVariables.NotificationWebhookURL != "" {
+		sink := audit.NewWebhookSink(envVariables.NotificationWebhookURL, envVariables.NotificationEventGridKey, c.appGwIdentifier.AppGwResourceID())
+		go sink.Run(c.stopChannel)
+		c.auditLog.AddSink(sink)
+	}
+
+	if envVariables.ManageNSGRules == "true" {
+		c.nsgClients = newNSGClients(c.appGwIdentifier.SubscriptionID, c.appGwClient)
+	}
+
 	// Starts k8scontext which contains all the informers
 	// This will start individual go routines for informers
 	c.k8sContext.Run(c.stopChannel, false, envVariables)
+	// Run returns once the informers synced, or when the controller is stopped, when readiness no longer matters.
+	c.readiness.markInformersSynced()
 
 	// Starts Worker
 	// This will start worker to process events from k8sContext
 	c.worker.Run(c.k8sContext.UpdateChannel, c.stopChannel)
 
-	select {}
+	<-c.stopped
+}
+
+// SetTwoPhaseApplyTimeout sets how long the new backends staged by a two-phase apply may take to be healthy, before the
+// generated config is applied anyway.
+func (c *AppGwIngressController) SetTwoPhaseApplyTimeout(timeout time.Duration) {
+	c.twoPhase = newTwoPhaseApply(timeout)
 }
 
-// Stop function terminates the k8scontext and signal the stopchannel
-func (c *AppGwIngressController) Stop() {
-	close(c.stopChannel)
+// SetEventTimeout sets how long the ARM requests of an event may take, all together, before they are cancelled and the
+// event fails; zero means no limit.
+func (c *AppGwIngressController) SetEventTimeout(timeout time.Duration) {
+	c.eventTimeout = timeout
+}
+
+// AuditLog returns the record of the App Gateway updates applied by the controller.
+func (c *AppGwIngressController) AuditLog() *audit.Log {
+	return c.auditLog
+}
+
+// Stop function terminates the k8scontext and signal the stopchannel. New events are no longer processed;
+// an App Gateway update in progress is given the timeout to complete, so its result is recorded and the last applied
+// config is stored. Past the timeout the update is abandoned: ARM completes it regardless, and the next AGIC reconciles.
+func (c *AppGwIngressController) Stop(timeout time.Duration) {
+	c.stopOnce.Do(func() {
+		defer close(c.stopped)
+		defer c.cancel()
+		close(c.stopChannel)
+
+		select {
+		case <-c.worker.Done():
+			glog.Info("Stopped processing events")
+			return
+		case <-time.After(timeout):
+		}
+
+		glog.Warningf("Processing of the current event did not complete within %s; abandoning the App Gateway update in progress, which ARM will complete", timeout)
+		c.cancel()
+		select {
+		case <-c.worker.Done():
+		case <-time.After(abandonTimeout):
+			glog.Error("Processing of the current event did not end after abandoning its ARM requests")
+		}
+	})
+}
+
+// getAppGw gets the App Gateway from ARM, within the configured timeout.
+func (c *AppGwIngressController) getAppGw() (n.ApplicationGateway, error) {
+	ctx := c.ctx
+	if c.armGetTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.armGetTimeout)
+		defer cancel()
+	}
+	return c.appGwClient.Get(ctx, c.appGwIdentifier.ResourceGroup, c.appGwIdentifier.AppGwName)
 }
@@ -0,0 +1,72 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/eapache/channels"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
+)
+
+var _ = Describe("Test the ARM timeouts", func() {
+	// unresponsiveARM never responds; requests only end when their context is done.
+	unresponsiveARM := autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	It("should give up getting the App Gateway after the timeout", func() {
+		appGwClient := n.NewApplicationGatewaysClient("--subscription--")
+		appGwClient.Sender = unresponsiveARM
+		identifier := appgw.Identifier{SubscriptionID: "--subscription--", ResourceGroup: "--group--", AppGwName: "--name--"}
+		c := NewAppGwIngressController(appGwClient, identifier, nil, nil, nil, 50*time.Millisecond, worker.DefaultOptions())
+
+		done := make(chan error)
+		go func() {
+			_, err := c.getAppGw()
+			done <- err
+		}()
+		Eventually(done, 5*time.Second).Should(Receive(HaveOccurred()))
+	})
+})
+
+var _ = Describe("Test stopping the controller", func() {
+	It("should abandon the ARM requests of the event being processed after the timeout", func() {
+		// The request getting the App Gateway never completes, unless abandoned.
+		requested := make(chan struct{})
+		appGwClient := n.NewApplicationGatewaysClient("--subscription--")
+		appGwClient.Sender = autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			close(requested)
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+		identifier := appgw.Identifier{SubscriptionID: "--subscription--", ResourceGroup: "--group--", AppGwName: "--name--"}
+		c := NewAppGwIngressController(appGwClient, identifier, nil, nil, nil, 0, worker.DefaultOptions())
+
+		eventChannel := channels.NewRingChannel(1)
+		c.worker.Run(eventChannel, c.stopChannel)
+		eventChannel.In() <- events.Event{Type: events.Create}
+		Eventually(requested, 5*time.Second).Should(BeClosed())
+
+		stopped := make(chan struct{})
+		go func() {
+			c.Stop(50 * time.Millisecond)
+			close(stopped)
+		}()
+		Consistently(stopped, 30*time.Millisecond).ShouldNot(BeClosed())
+		Eventually(stopped, 5*time.Second).Should(BeClosed())
+		Expect(c.worker.Done()).To(BeClosed())
+	})
+})
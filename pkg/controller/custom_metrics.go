@@ -0,0 +1,226 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// customMetricsNamespace groups the metrics published by AGIC among the metrics of the App Gateway in Azure Monitor.
+	customMetricsNamespace = "AGIC"
+
+	syncDurationMetric     = "SyncDurationMs"
+	syncFailuresMetric     = "SyncFailures"
+	managedResourcesMetric = "ManagedResources"
+
+	// resourceTypeDimension tells the kinds of resources counted by the managed resources metric apart.
+	resourceTypeDimension = "ResourceType"
+)
+
+// customMetricsEndpoint returns the regional endpoint of the Azure Monitor custom metrics API for the location of the App Gateway.
+var customMetricsEndpoint = func(location string) string {
+	return fmt.Sprintf("https://%s.monitoring.azure.com", location)
+}
+
+// customMetrics accumulates the outcome of the events processed by the worker between two publications to Azure Monitor.
+// Processing events and publishing the metrics happen in different goroutines, hence the mutex.
+type customMetrics struct {
+	mutex sync.Mutex
+
+	// appGwID and location are those of the App Gateway last retrieved from ARM; nothing is published until then.
+	appGwID  string
+	location string
+
+	syncCount    int
+	syncFailures int
+	syncMin      float64
+	syncMax      float64
+	syncSum      float64
+
+	// managedResources are the number of resources of each type in the last App Gateway config built by AGIC.
+	managedResources map[string]int
+}
+
+func newCustomMetrics() *customMetrics {
+	return &customMetrics{}
+}
+
+// observeAppGw records the App Gateway the metrics are published on.
+func (m *customMetrics) observeAppGw(appGw *n.ApplicationGateway) {
+	if appGw.ID == nil || appGw.Location == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.appGwID = *appGw.ID
+	m.location = *appGw.Location
+}
+
+// observeSync records how long processing an event took, and whether it failed.
+func (m *customMetrics) observeSync(duration time.Duration, err error) {
+	milliseconds := float64(duration) / float64(time.Millisecond)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.syncCount == 0 || milliseconds < m.syncMin {
+		m.syncMin = milliseconds
+	}
+	if m.syncCount == 0 || milliseconds > m.syncMax {
+		m.syncMax = milliseconds
+	}
+	m.syncCount++
+	m.syncSum += milliseconds
+	if err != nil {
+		m.syncFailures++
+	}
+}
+
+// observeConfig records the number of listeners, routing rules, backend pools, HTTP settings, probes and certificates of the
+// App Gateway config built by AGIC.
+func (m *customMetrics) observeConfig(appGw *n.ApplicationGateway) {
+	if appGw.ApplicationGatewayPropertiesFormat == nil {
+		return
+	}
+	counts := map[string]int{}
+	if appGw.HTTPListeners != nil {
+		counts["HTTPListeners"] = len(*appGw.HTTPListeners)
+	}
+	if appGw.RequestRoutingRules != nil {
+		counts["RequestRoutingRules"] = len(*appGw.RequestRoutingRules)
+	}
+	if appGw.BackendAddressPools != nil {
+		counts["BackendAddressPools"] = len(*appGw.BackendAddressPools)
+	}
+	if appGw.BackendHTTPSettingsCollection != nil {
+		counts["BackendHTTPSettings"] = len(*appGw.BackendHTTPSettingsCollection)
+	}
+	if appGw.Probes != nil {
+		counts["Probes"] = len(*appGw.Probes)
+	}
+	if appGw.SslCertificates != nil {
+		counts["SslCertificates"] = len(*appGw.SslCertificates)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.managedResources = counts
+}
+
+// customMetric is the body of a request of the Azure Monitor custom metrics API.
+type customMetric struct {
+	Time string           `json:"time"`
+	Data customMetricData `json:"data"`
+}
+
+type customMetricData struct {
+	BaseData customMetricBaseData `json:"baseData"`
+}
+
+type customMetricBaseData struct {
+	Metric    string               `json:"metric"`
+	Namespace string               `json:"namespace"`
+	DimNames  []string             `json:"dimNames,omitempty"`
+	Series    []customMetricSeries `json:"series"`
+}
+
+type customMetricSeries struct {
+	DimValues []string `json:"dimValues,omitempty"`
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	Sum       float64  `json:"sum"`
+	Count     int      `json:"count"`
+}
+
+// collect returns the metrics accumulated since the last call, and resets them; the managed resources are published every time.
+func (m *customMetrics) collect(now time.Time) (appGwID, location string, metrics []customMetric) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	timestamp := now.UTC().Format(time.RFC3339)
+	newMetric := func(name string, dimNames []string, series ...customMetricSeries) customMetric {
+		return customMetric{
+			Time: timestamp,
+			Data: customMetricData{BaseData: customMetricBaseData{
+				Metric:    name,
+				Namespace: customMetricsNamespace,
+				DimNames:  dimNames,
+				Series:    series,
+			}},
+		}
+	}
+
+	if m.syncCount > 0 {
+		metrics = append(metrics, newMetric(syncDurationMetric, nil, customMetricSeries{Min: m.syncMin, Max: m.syncMax, Sum: m.syncSum, Count: m.syncCount}))
+	}
+	failures := float64(m.syncFailures)
+	metrics = append(metrics, newMetric(syncFailuresMetric, nil, customMetricSeries{Min: failures, Max: failures, Sum: failures, Count: 1}))
+
+	if len(m.managedResources) > 0 {
+		var resourceTypes []string
+		for resourceType := range m.managedResources {
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+		sort.Strings(resourceTypes)
+		var series []customMetricSeries
+		for _, resourceType := range resourceTypes {
+			count := float64(m.managedResources[resourceType])
+			series = append(series, customMetricSeries{DimValues: []string{resourceType}, Min: count, Max: count, Sum: count, Count: 1})
+		}
+		metrics = append(metrics, newMetric(managedResourcesMetric, []string{resourceTypeDimension}, series...))
+	}
+
+	m.syncCount, m.syncFailures = 0, 0
+	m.syncMin, m.syncMax, m.syncSum = 0, 0, 0
+	return m.appGwID, m.location, metrics
+}
+
+// PublishCustomMetrics publishes the sync duration, the number of failed syncs and the number of resources managed by AGIC
+// as custom metrics of the App Gateway in Azure Monitor at every interval, until the controller stops.
+// The authorizer must be one for the Azure Monitor resource, with the "Monitoring Metrics Publisher" role on the App Gateway.
+func (c *AppGwIngressController) PublishCustomMetrics(authorizer autorest.Authorizer, interval time.Duration) {
+	client := autorest.NewClientWithUserAgent("")
+	client.Authorizer = authorizer
+	wait.Until(func() { c.publishCustomMetrics(client) }, interval, c.stopChannel)
+}
+
+// publishCustomMetrics sends the metrics accumulated since the last publication; failures are only logged.
+func (c *AppGwIngressController) publishCustomMetrics(client autorest.Client) {
+	appGwID, location, metrics := c.customMetrics.collect(time.Now())
+	if appGwID == "" {
+		glog.V(5).Info("Not publishing custom metrics until the App Gateway is retrieved")
+		return
+	}
+	for _, metric := range metrics {
+		if err := postCustomMetric(client, customMetricsEndpoint(location), appGwID, metric); err != nil {
+			glog.Errorf("Unable to publish custom metric %s to Azure Monitor: %s", metric.Data.BaseData.Metric, err)
+		}
+	}
+}
+
+// postCustomMetric posts the metric to the custom metrics API of the given resource.
+func postCustomMetric(client autorest.Client, endpoint, resourceID string, metric customMetric) error {
+	request, err := autorest.Prepare(&http.Request{},
+		autorest.AsPost(),
+		autorest.AsContentType("application/json"),
+		autorest.WithBaseURL(endpoint),
+		autorest.WithPath(resourceID+"/metrics"),
+		autorest.WithJSON(metric))
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	return autorest.Respond(response, autorest.WithErrorUnlessStatusCode(http.StatusOK), autorest.ByClosing())
+}
@@ -0,0 +1,111 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test the custom metrics published to Azure Monitor", func() {
+	const appGwID = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.Network/applicationGateways/--appgw--"
+	now := time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	var metrics *customMetrics
+	BeforeEach(func() {
+		metrics = newCustomMetrics()
+		metrics.observeAppGw(&n.ApplicationGateway{ID: to.StringPtr(appGwID), Location: to.StringPtr("westus2")})
+	})
+
+	It("should aggregate the syncs since the last publication", func() {
+		metrics.observeSync(100*time.Millisecond, nil)
+		metrics.observeSync(300*time.Millisecond, errors.New("unable to deploy App Gateway config"))
+		metrics.observeSync(200*time.Millisecond, nil)
+
+		id, location, collected := metrics.collect(now)
+		Expect(id).To(Equal(appGwID))
+		Expect(location).To(Equal("westus2"))
+		Expect(collected).To(HaveLen(2))
+		Expect(collected[0].Time).To(Equal("2019-07-01T12:00:00Z"))
+		Expect(collected[0].Data.BaseData.Metric).To(Equal(syncDurationMetric))
+		Expect(collected[0].Data.BaseData.Namespace).To(Equal(customMetricsNamespace))
+		Expect(collected[0].Data.BaseData.Series).To(Equal([]customMetricSeries{{Min: 100, Max: 300, Sum: 600, Count: 3}}))
+		Expect(collected[1].Data.BaseData.Metric).To(Equal(syncFailuresMetric))
+		Expect(collected[1].Data.BaseData.Series).To(Equal([]customMetricSeries{{Min: 1, Max: 1, Sum: 1, Count: 1}}))
+
+		// The syncs are reset; no failures are still published.
+		_, _, collected = metrics.collect(now)
+		Expect(collected).To(HaveLen(1))
+		Expect(collected[0].Data.BaseData.Series).To(Equal([]customMetricSeries{{Count: 1}}))
+	})
+
+	It("should publish the number of managed resources by type", func() {
+		metrics.observeConfig(&n.ApplicationGateway{ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+			HTTPListeners:       &[]n.ApplicationGatewayHTTPListener{{}, {}},
+			BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{{}},
+		}})
+
+		_, _, collected := metrics.collect(now)
+		Expect(collected).To(HaveLen(2))
+		Expect(collected[1].Data.BaseData.Metric).To(Equal(managedResourcesMetric))
+		Expect(collected[1].Data.BaseData.DimNames).To(Equal([]string{resourceTypeDimension}))
+		Expect(collected[1].Data.BaseData.Series).To(Equal([]customMetricSeries{
+			{DimValues: []string{"BackendAddressPools"}, Min: 1, Max: 1, Sum: 1, Count: 1},
+			{DimValues: []string{"HTTPListeners"}, Min: 2, Max: 2, Sum: 2, Count: 1},
+		}))
+	})
+
+	It("should post the metrics to the App Gateway resource", func() {
+		var paths []string
+		var posted []customMetric
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			var metric customMetric
+			Expect(json.Unmarshal(body, &metric)).To(Succeed())
+			paths = append(paths, r.URL.Path)
+			posted = append(posted, metric)
+		}))
+		defer server.Close()
+
+		defaultEndpoint := customMetricsEndpoint
+		defer func() { customMetricsEndpoint = defaultEndpoint }()
+		var requestedLocation string
+		customMetricsEndpoint = func(location string) string {
+			requestedLocation = location
+			return server.URL
+		}
+
+		controller := &AppGwIngressController{customMetrics: metrics}
+		metrics.observeSync(time.Second, nil)
+		controller.publishCustomMetrics(autorest.NewClientWithUserAgent(""))
+
+		Expect(requestedLocation).To(Equal("westus2"))
+		Expect(paths).To(Equal([]string{appGwID + "/metrics", appGwID + "/metrics"}))
+		Expect(posted[0].Data.BaseData.Metric).To(Equal(syncDurationMetric))
+		Expect(posted[1].Data.BaseData.Metric).To(Equal(syncFailuresMetric))
+	})
+
+	It("should not publish anything before the App Gateway is retrieved", func() {
+		controller := &AppGwIngressController{customMetrics: newCustomMetrics()}
+		defaultEndpoint := customMetricsEndpoint
+		defer func() { customMetricsEndpoint = defaultEndpoint }()
+		customMetricsEndpoint = func(location string) string {
+			Fail("no metric should be published")
+			return ""
+		}
+		controller.publishCustomMetrics(autorest.NewClientWithUserAgent(""))
+	})
+})
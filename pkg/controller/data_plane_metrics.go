@@ -0,0 +1,225 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// azureMonitorMetricsAPIVersion is the version of the Azure Monitor API the metrics of the App Gateway are read with.
+	azureMonitorMetricsAPIVersion = "2018-01-01"
+
+	// dataPlaneMetricsTimespan is how far back the metrics are read; Azure Monitor makes them available a few minutes late,
+	// so the last value within the timespan is exported.
+	dataPlaneMetricsTimespan = 10 * time.Minute
+)
+
+// dataPlaneMetric is a metric of the App Gateway in Azure Monitor, and the gauge it is exported as on /metrics.
+type dataPlaneMetric struct {
+	azureName   string
+	aggregation string
+
+	// dimension is the Azure Monitor dimension the metric is split by, if any; its values become labels.
+	dimension string
+
+	name string
+	help string
+}
+
+// dataPlaneMetrics are the metrics of the App Gateway exported on /metrics.
+var dataPlaneMetrics = []dataPlaneMetric{
+	{azureName: "Throughput", aggregation: "Average", name: "appgw_throughput_bytes_per_second",
+		help: "Bytes per second served by the App Gateway."},
+	{azureName: "ComputeUnits", aggregation: "Average", name: "appgw_compute_units",
+		help: "Compute units consumed by the App Gateway."},
+	{azureName: "TotalRequests", aggregation: "Total", dimension: "BackendSettingsPool", name: "appgw_requests",
+		help: "Requests served by the App Gateway in a minute, by backend pool and HTTP settings."},
+	{azureName: "FailedRequests", aggregation: "Total", dimension: "BackendSettingsPool", name: "appgw_failed_requests",
+		help: "Requests the App Gateway failed in a minute, by backend pool and HTTP settings."},
+	{azureName: "HealthyHostCount", aggregation: "Average", dimension: "BackendSettingsPool", name: "appgw_healthy_host_count",
+		help: "Backend servers the App Gateway probes as healthy, by backend pool and HTTP settings."},
+	{azureName: "UnhealthyHostCount", aggregation: "Average", dimension: "BackendSettingsPool", name: "appgw_unhealthy_host_count",
+		help: "Backend servers the App Gateway probes as unhealthy, by backend pool and HTTP settings."},
+	{azureName: "ApplicationGatewayTotalTime", aggregation: "Average", dimension: "Listener", name: "appgw_total_time_milliseconds",
+		help: "Milliseconds the App Gateway takes to serve a request, by listener."},
+}
+
+// dataPlaneSample is the last value of a metric, with its Prometheus labels, as in `listener="fl-contoso.com-80"`.
+type dataPlaneSample struct {
+	labels string
+	value  float64
+}
+
+// dataPlaneMetricsCache keeps the values read from Azure Monitor until the next read; it is read by the HTTP server, hence the mutex.
+type dataPlaneMetricsCache struct {
+	mutex   sync.RWMutex
+	samples map[string][]dataPlaneSample
+}
+
+func newDataPlaneMetricsCache() *dataPlaneMetricsCache {
+	return &dataPlaneMetricsCache{samples: make(map[string][]dataPlaneSample)}
+}
+
+// set replaces the values of the metric; without values, the metric is not exported.
+func (m *dataPlaneMetricsCache) set(name string, samples []dataPlaneSample) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(samples) == 0 {
+		delete(m.samples, name)
+		return
+	}
+	m.samples[name] = samples
+}
+
+// write writes the metrics, which have values, in the Prometheus text format.
+func (m *dataPlaneMetricsCache) write(w io.Writer) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, metric := range dataPlaneMetrics {
+		samples, exists := m.samples[metric.name]
+		if !exists {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n", metric.name, metric.help)
+		_, _ = fmt.Fprintf(w, "# TYPE %s gauge\n", metric.name)
+		for _, sample := range samples {
+			if sample.labels == "" {
+				_, _ = fmt.Fprintf(w, "%s %g\n", metric.name, sample.value)
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "%s{%s} %g\n", metric.name, sample.labels, sample.value)
+		}
+	}
+}
+
+// azureMetricsResponse is the part of the response of the Azure Monitor metrics API the values are read from.
+type azureMetricsResponse struct {
+	Value []struct {
+		Timeseries []azureMetricTimeseries `json:"timeseries"`
+	} `json:"value"`
+}
+
+type azureMetricTimeseries struct {
+	Metadatavalues []struct {
+		Value string `json:"value"`
+	} `json:"metadatavalues"`
+	Data []struct {
+		Average *float64 `json:"average"`
+		Total   *float64 `json:"total"`
+	} `json:"data"`
+}
+
+// lastValue returns the last value of the aggregation in the timeseries; the last minutes have none until Azure Monitor gets them.
+func (t azureMetricTimeseries) lastValue(aggregation string) (float64, bool) {
+	for idx := len(t.Data) - 1; idx >= 0; idx-- {
+		value := t.Data[idx].Average
+		if aggregation == "Total" {
+			value = t.Data[idx].Total
+		}
+		if value != nil {
+			return *value, true
+		}
+	}
+	return 0, false
+}
+
+// prometheusLabels returns the labels of the value of a dimension. BackendSettingsPool values are "<pool>~<HTTP settings>".
+func prometheusLabels(dimension, value string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+	switch dimension {
+	case "":
+		return ""
+	case "BackendSettingsPool":
+		chunks := strings.SplitN(value, "~", 2)
+		if len(chunks) == 2 {
+			return fmt.Sprintf(`pool="%s",http_settings="%s"`, escape(chunks[0]), escape(chunks[1]))
+		}
+		return fmt.Sprintf(`pool="%s"`, escape(value))
+	case "Listener":
+		return fmt.Sprintf(`listener="%s"`, escape(value))
+	}
+	return fmt.Sprintf(`%s="%s"`, strings.ToLower(dimension), escape(value))
+}
+
+// queryDataPlaneMetric reads the metric of the resource from Azure Monitor, and returns its last value for each value of its dimension.
+func queryDataPlaneMetric(client autorest.Client, baseURI, resourceID string, metric dataPlaneMetric, now time.Time) ([]dataPlaneSample, error) {
+	parameters := map[string]interface{}{
+		"api-version": azureMonitorMetricsAPIVersion,
+		"metricnames": metric.azureName,
+		"aggregation": metric.aggregation,
+		"interval":    "PT1M",
+		"timespan":    fmt.Sprintf("%s/%s", now.Add(-dataPlaneMetricsTimespan).UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339)),
+	}
+	if metric.dimension != "" {
+		parameters["$filter"] = fmt.Sprintf("%s eq '*'", metric.dimension)
+	}
+	request, err := autorest.Prepare(&http.Request{},
+		autorest.AsGet(),
+		autorest.WithBaseURL(baseURI),
+		autorest.WithPath(resourceID+"/providers/microsoft.insights/metrics"),
+		autorest.WithQueryParameters(parameters))
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	var result azureMetricsResponse
+	if err := autorest.Respond(response,
+		autorest.WithErrorUnlessStatusCode(http.StatusOK),
+		autorest.ByUnmarshallingJSON(&result),
+		autorest.ByClosing()); err != nil {
+		return nil, err
+	}
+
+	var samples []dataPlaneSample
+	for _, value := range result.Value {
+		for _, timeseries := range value.Timeseries {
+			lastValue, exists := timeseries.lastValue(metric.aggregation)
+			if !exists {
+				continue
+			}
+			dimensionValue := ""
+			if len(timeseries.Metadatavalues) > 0 {
+				dimensionValue = timeseries.Metadatavalues[0].Value
+			}
+			samples = append(samples, dataPlaneSample{labels: prometheusLabels(metric.dimension, dimensionValue), value: lastValue})
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].labels < samples[j].labels })
+	return samples, nil
+}
+
+// ExportDataPlaneMetrics reads the throughput, requests, failed requests, backend health, total time and compute units of the
+// App Gateway from Azure Monitor at every interval until the controller stops, and exports them on /metrics.
+func (c *AppGwIngressController) ExportDataPlaneMetrics(interval time.Duration) {
+	wait.Until(func() { c.readDataPlaneMetrics(c.appGwClient.Client, c.appGwClient.BaseURI) }, interval, c.stopChannel)
+}
+
+// readDataPlaneMetrics reads each metric; a metric, which cannot be read, is not exported until it can be, rather than exporting a stale value.
+func (c *AppGwIngressController) readDataPlaneMetrics(client autorest.Client, baseURI string) {
+	resourceID := c.appGwIdentifier.AppGwResourceID()
+	now := time.Now()
+	for _, metric := range dataPlaneMetrics {
+		samples, err := queryDataPlaneMetric(client, baseURI, resourceID, metric, now)
+		if err != nil {
+			glog.Errorf("Unable to read metric %s of the App Gateway from Azure Monitor: %s", metric.azureName, err)
+		}
+		c.dataPlaneMetrics.set(metric.name, samples)
+	}
+}
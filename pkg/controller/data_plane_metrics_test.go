@@ -0,0 +1,97 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+)
+
+var _ = Describe("Test exporting the metrics of the App Gateway", func() {
+	appGwIdentifier := appgw.Identifier{SubscriptionID: "--subscription--", ResourceGroup: "--group--", AppGwName: "--appgw--"}
+
+	var server *httptest.Server
+	var queries []url.Values
+	var controller *AppGwIngressController
+	BeforeEach(func() {
+		queries = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal(appGwIdentifier.AppGwResourceID() + "/providers/microsoft.insights/metrics"))
+			queries = append(queries, r.URL.Query())
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Query().Get("metricnames") {
+			case "Throughput":
+				_, _ = w.Write([]byte(`{"value": [{"timeseries": [{"metadatavalues": [], "data": [
+					{"timeStamp": "2019-07-01T11:58:00Z", "average": 2048},
+					{"timeStamp": "2019-07-01T11:59:00Z"}
+				]}]}]}`))
+			case "UnhealthyHostCount":
+				_, _ = w.Write([]byte(`{"value": [{"timeseries": [
+					{"metadatavalues": [{"name": {"value": "backendsettingspool"}, "value": "pool-web~bp-web"}], "data": [{"average": 2}]},
+					{"metadatavalues": [{"name": {"value": "backendsettingspool"}, "value": "pool-api~bp-api"}], "data": [{"average": 0}]}
+				]}]}`))
+			case "ComputeUnits":
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				_, _ = w.Write([]byte(`{"value": []}`))
+			}
+		}))
+		controller = &AppGwIngressController{appGwIdentifier: appGwIdentifier, health: newHealth(), dataPlaneMetrics: newDataPlaneMetricsCache()}
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	metrics := func() string {
+		recorder := httptest.NewRecorder()
+		controller.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		return recorder.Body.String()
+	}
+
+	It("should query each metric with its aggregation and dimension", func() {
+		controller.readDataPlaneMetrics(autorest.NewClientWithUserAgent(""), server.URL)
+		Expect(queries).To(HaveLen(len(dataPlaneMetrics)))
+		Expect(queries[0].Get("metricnames")).To(Equal("Throughput"))
+		Expect(queries[0].Get("aggregation")).To(Equal("Average"))
+		Expect(queries[0].Get("api-version")).To(Equal(azureMonitorMetricsAPIVersion))
+		Expect(queries[0].Get("$filter")).To(BeEmpty())
+		Expect(queries[2].Get("metricnames")).To(Equal("TotalRequests"))
+		Expect(queries[2].Get("aggregation")).To(Equal("Total"))
+		Expect(queries[2].Get("$filter")).To(Equal("BackendSettingsPool eq '*'"))
+	})
+
+	It("should export the last value of the metrics, labeled by their dimension", func() {
+		controller.readDataPlaneMetrics(autorest.NewClientWithUserAgent(""), server.URL)
+		Expect(metrics()).To(HaveSuffix(
+			"# HELP appgw_throughput_bytes_per_second Bytes per second served by the App Gateway.\n" +
+				"# TYPE appgw_throughput_bytes_per_second gauge\n" +
+				"appgw_throughput_bytes_per_second 2048\n" +
+				"# HELP appgw_unhealthy_host_count Backend servers the App Gateway probes as unhealthy, by backend pool and HTTP settings.\n" +
+				"# TYPE appgw_unhealthy_host_count gauge\n" +
+				"appgw_unhealthy_host_count{pool=\"pool-api\",http_settings=\"bp-api\"} 0\n" +
+				"appgw_unhealthy_host_count{pool=\"pool-web\",http_settings=\"bp-web\"} 2\n"))
+	})
+
+	It("should stop exporting a metric, which cannot be read", func() {
+		controller.dataPlaneMetrics.set("appgw_compute_units", []dataPlaneSample{{value: 3}})
+		Expect(metrics()).To(ContainSubstring("\nappgw_compute_units 3\n"))
+		controller.readDataPlaneMetrics(autorest.NewClientWithUserAgent(""), server.URL)
+		Expect(metrics()).ToNot(ContainSubstring("appgw_compute_units"))
+	})
+
+	It("should label the values of the dimensions", func() {
+		Expect(prometheusLabels("", "")).To(Equal(""))
+		Expect(prometheusLabels("Listener", "fl-contoso.com-80")).To(Equal(`listener="fl-contoso.com-80"`))
+		Expect(prometheusLabels("BackendSettingsPool", `pool"web`)).To(Equal(`pool="pool\"web"`))
+	})
+})
@@ -0,0 +1,103 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2019-03-01/insights"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// diagnosticSettingsName is the name of the diagnostic setting of the App Gateway managed by AGIC; other diagnostic settings are left alone.
+const diagnosticSettingsName = "agic-managed"
+
+// getDiagnosticSettings returns the diagnostic setting of the App Gateway configured for AGIC,
+// or nil when neither a Log Analytics workspace nor a storage account is configured.
+func getDiagnosticSettings(envVariables environment.EnvVariables) *insights.DiagnosticSettingsResource {
+	if envVariables.DiagnosticsWorkspaceID == "" && envVariables.DiagnosticsStorageAccountID == "" {
+		return nil
+	}
+	var logs []insights.LogSettings
+	for _, category := range strings.Split(envVariables.DiagnosticsLogs, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			logs = append(logs, insights.LogSettings{Category: to.StringPtr(category), Enabled: to.BoolPtr(true)})
+		}
+	}
+	settings := &insights.DiagnosticSettings{Logs: &logs}
+	if envVariables.DiagnosticsWorkspaceID != "" {
+		settings.WorkspaceID = to.StringPtr(envVariables.DiagnosticsWorkspaceID)
+	}
+	if envVariables.DiagnosticsStorageAccountID != "" {
+		settings.StorageAccountID = to.StringPtr(envVariables.DiagnosticsStorageAccountID)
+	}
+	return &insights.DiagnosticSettingsResource{DiagnosticSettings: settings}
+}
+
+// diagnosticSettingsMatch tells whether the existing diagnostic setting sends the same logs to the same destinations.
+// Resource IDs are compared ignoring case, as ARM returns them with a casing of its own.
+func diagnosticSettingsMatch(existing, desired insights.DiagnosticSettingsResource) bool {
+	if existing.DiagnosticSettings == nil {
+		return false
+	}
+	return strings.EqualFold(to.String(existing.WorkspaceID), to.String(desired.WorkspaceID)) &&
+		strings.EqualFold(to.String(existing.StorageAccountID), to.String(desired.StorageAccountID)) &&
+		strings.Join(enabledLogs(existing.DiagnosticSettings), ",") == strings.Join(enabledLogs(desired.DiagnosticSettings), ",")
+}
+
+// enabledLogs returns the sorted categories of the enabled logs.
+func enabledLogs(settings *insights.DiagnosticSettings) []string {
+	var categories []string
+	if settings.Logs == nil {
+		return categories
+	}
+	for _, log := range *settings.Logs {
+		if to.Bool(log.Enabled) && log.Category != nil {
+			categories = append(categories, *log.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// reconcileDiagnosticSettings creates or updates the diagnostic setting of the App Gateway, when it differs from the one configured for AGIC.
+// Failures are reported without failing the update of the App Gateway itself.
+func (c AppGwIngressController) reconcileDiagnosticSettings(envVariables environment.EnvVariables, appGwID string) {
+	desired := getDiagnosticSettings(envVariables)
+	if desired == nil || c.diagnosticSettingsClient == nil {
+		return
+	}
+
+	existing, err := c.diagnosticSettingsClient.Get(c.ctx, appGwID, diagnosticSettingsName)
+	if err != nil && (existing.Response.Response == nil || existing.StatusCode != http.StatusNotFound) {
+		glog.Error("Unable to get the diagnostic settings of the App Gateway:", err)
+		return
+	}
+	if err == nil && diagnosticSettingsMatch(existing, *desired) {
+		glog.V(5).Infof("Diagnostic setting %s of the App Gateway is up to date", diagnosticSettingsName)
+		return
+	}
+
+	if envVariables.ObserveOnly == "true" {
+		glog.Infof("Observe-only mode; AGIC would send the logs [%s] of the App Gateway to workspace %q and storage account %q with diagnostic setting %s",
+			strings.Join(enabledLogs(desired.DiagnosticSettings), ", "), envVariables.DiagnosticsWorkspaceID, envVariables.DiagnosticsStorageAccountID, diagnosticSettingsName)
+		return
+	}
+
+	if _, err := c.diagnosticSettingsClient.CreateOrUpdate(c.ctx, appGwID, *desired, diagnosticSettingsName); err != nil {
+		glog.Error("Unable to update the diagnostic settings of the App Gateway:", err)
+		c.recordAGICPodEvent(envVariables, v1.EventTypeWarning, events.ReasonDiagnosticSettingsFailure, err.Error())
+		return
+	}
+	glog.Infof("Updated diagnostic setting %s of the App Gateway", diagnosticSettingsName)
+}
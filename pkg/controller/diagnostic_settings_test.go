@@ -0,0 +1,105 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2019-03-01/insights"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
+)
+
+var _ = Describe("Test the diagnostic settings of the App Gateway", func() {
+	const (
+		appGwID     = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.Network/applicationGateways/--name--"
+		workspaceID = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.OperationalInsights/workspaces/--workspace--"
+	)
+
+	var env environment.EnvVariables
+	var c *AppGwIngressController
+	var requests []*http.Request
+	var bodies []string
+	var existing *insights.DiagnosticSettingsResource
+
+	BeforeEach(func() {
+		env = environment.GetFakeEnv()
+		env.DiagnosticsWorkspaceID = workspaceID
+		env.DiagnosticsLogs = "ApplicationGatewayAccessLog, ApplicationGatewayFirewallLog"
+		requests, bodies, existing = nil, nil, nil
+
+		identifier := appgw.Identifier{SubscriptionID: "--subscription--", ResourceGroup: "--group--", AppGwName: "--name--"}
+		c = NewAppGwIngressController(n.NewApplicationGatewaysClient("--subscription--"), identifier, nil, nil, nil, 0, worker.DefaultOptions())
+		client := insights.NewDiagnosticSettingsClient("--subscription--")
+		client.Sender = autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+			requests = append(requests, req)
+			body := ""
+			if req.Body != nil {
+				content, _ := ioutil.ReadAll(req.Body)
+				body = string(content)
+			}
+			bodies = append(bodies, body)
+			response := &http.Response{Request: req, StatusCode: http.StatusOK, Header: http.Header{}}
+			switch {
+			case req.Method == http.MethodGet && existing == nil:
+				response.StatusCode = http.StatusNotFound
+				response.Body = ioutil.NopCloser(strings.NewReader(`{"code":"ResourceNotFound"}`))
+			case req.Method == http.MethodGet:
+				content, _ := json.Marshal(existing)
+				response.Body = ioutil.NopCloser(strings.NewReader(string(content)))
+			default:
+				response.Body = ioutil.NopCloser(strings.NewReader(body))
+			}
+			return response, nil
+		})
+		c.diagnosticSettingsClient = &client
+	})
+
+	It("should not manage diagnostic settings without a destination", func() {
+		Expect(getDiagnosticSettings(environment.GetFakeEnv())).To(BeNil())
+	})
+
+	It("should create the diagnostic setting when it does not exist", func() {
+		c.reconcileDiagnosticSettings(env, appGwID)
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].Method).To(Equal(http.MethodPut))
+		Expect(requests[1].URL.Path).To(HaveSuffix("/providers/microsoft.insights/diagnosticSettings/" + diagnosticSettingsName))
+		Expect(bodies[1]).To(ContainSubstring(workspaceID))
+		Expect(bodies[1]).To(ContainSubstring("ApplicationGatewayFirewallLog"))
+		Expect(bodies[1]).ToNot(ContainSubstring("ApplicationGatewayPerformanceLog"))
+	})
+
+	It("should leave a matching diagnostic setting alone", func() {
+		existing = getDiagnosticSettings(env)
+		existing.WorkspaceID = to.StringPtr(strings.ToLower(workspaceID))
+		c.reconcileDiagnosticSettings(env, appGwID)
+		Expect(requests).To(HaveLen(1))
+	})
+
+	It("should update a diagnostic setting sending other logs", func() {
+		existing = getDiagnosticSettings(env)
+		(*existing.Logs)[1].Enabled = to.BoolPtr(false)
+		c.reconcileDiagnosticSettings(env, appGwID)
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].Method).To(Equal(http.MethodPut))
+	})
+
+	It("should not update the diagnostic setting in observe-only mode", func() {
+		env.ObserveOnly = "true"
+		c.reconcileDiagnosticSettings(env, appGwID)
+		Expect(requests).To(HaveLen(1))
+	})
+})
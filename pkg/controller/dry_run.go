@@ -0,0 +1,144 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/audit"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// dryRunReports remembers the last report of each Ingress in dry run, so the report is emitted again only when it changes.
+type dryRunReports struct {
+	sync.Mutex
+	messages map[string]string
+}
+
+// splitDryRunIngresses returns the Ingresses, whose config is applied, and the ones in dry run.
+func splitDryRunIngresses(ingresses []*v1beta1.Ingress) ([]*v1beta1.Ingress, []*v1beta1.Ingress) {
+	var applied, dryRun []*v1beta1.Ingress
+	for _, ingress := range ingresses {
+		if isDryRun, _ := annotations.IsDryRun(ingress); isDryRun {
+			dryRun = append(dryRun, ingress)
+			continue
+		}
+		applied = append(applied, ingress)
+	}
+	return applied, dryRun
+}
+
+// objectRecorder forwards the events about a single object, so the builds of a dry run do not emit the events about the
+// other Ingresses once more.
+type objectRecorder struct {
+	record.EventRecorder
+	object runtime.Object
+}
+
+func (r objectRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if object == r.object {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r objectRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if object == r.object {
+		r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r objectRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
+	if object == r.object {
+		r.EventRecorder.PastEventf(object, timestamp, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r objectRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if object == r.object {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
+
+// dryRun builds the config of the App Gateway with the Ingress in dry run added to the applied ones, and returns the report of
+// what the Ingress would change in the generated config. The validations of the build emit their events on the Ingress.
+func (c AppGwIngressController) dryRun(cbCtx *appgw.ConfigBuilderContext, ingress *v1beta1.Ingress, existingAppGw *n.ApplicationGateway, generated audit.Snapshot) (string, string) {
+	appGw, err := copyAppGw(existingAppGw)
+	if err != nil {
+		return v1.EventTypeWarning, fmt.Sprintf("Dry run failed; unable to copy the App Gateway config: %s", err)
+	}
+
+	dryRunCtx := *cbCtx
+	dryRunCtx.IngressList = append(append([]*v1beta1.Ingress{}, cbCtx.IngressList...), ingress)
+	configBuilder := appgw.NewConfigBuilder(c.k8sContext, &c.appGwIdentifier, appGw, objectRecorder{EventRecorder: c.recorder, object: ingress})
+	if err := configBuilder.PreBuildValidate(&dryRunCtx); err != nil {
+		glog.V(3).Infof("Dry run of Ingress %s/%s: %s", ingress.Namespace, ingress.Name, err)
+	}
+	dryRunAppGw, err := configBuilder.Build(&dryRunCtx)
+	if err != nil {
+		return v1.EventTypeWarning, fmt.Sprintf("Dry run failed; the App Gateway config cannot be generated with the Ingress: %s", err)
+	}
+	if err := configBuilder.PostBuildValidate(&dryRunCtx); err != nil {
+		glog.V(3).Infof("Dry run of Ingress %s/%s: %s", ingress.Namespace, ingress.Name, err)
+	}
+
+	updated, err := audit.NewSnapshot(dryRunAppGw)
+	if err != nil {
+		return v1.EventTypeWarning, fmt.Sprintf("Dry run failed; unable to capture the App Gateway config: %s", err)
+	}
+	added, modified, removed := audit.Diff(generated, updated)
+	if len(added) == 0 && len(modified) == 0 && len(removed) == 0 {
+		return v1.EventTypeNormal, fmt.Sprintf("Dry run; App Gateway %s is not updated with the Ingress, which would not change its config", c.appGwIdentifier.AppGwName)
+	}
+	return v1.EventTypeNormal, fmt.Sprintf("Dry run; App Gateway %s is not updated with the Ingress, which would add [%s]; modify [%s]; remove [%s]",
+		c.appGwIdentifier.AppGwName, strings.Join(added, ", "), strings.Join(modified, ", "), strings.Join(removed, ", "))
+}
+
+// reportDryRuns emits an event on each Ingress in dry run with the App Gateway config it would add to the generated one,
+// when the report differs from the last one. existingAppGw is the App Gateway as it was before the config was generated.
+func (c AppGwIngressController) reportDryRuns(cbCtx *appgw.ConfigBuilderContext, dryRunIngresses []*v1beta1.Ingress, existingAppGw, generatedAppGw *n.ApplicationGateway) {
+	c.dryRuns.Lock()
+	defer c.dryRuns.Unlock()
+	lastMessages := c.dryRuns.messages
+	c.dryRuns.messages = make(map[string]string)
+	if len(dryRunIngresses) == 0 || existingAppGw == nil {
+		return
+	}
+
+	generated, err := audit.NewSnapshot(generatedAppGw)
+	if err != nil {
+		glog.Error("Unable to capture the generated App Gateway config for the dry runs:", err)
+		return
+	}
+	for _, ingress := range dryRunIngresses {
+		// The Ingress is pruned like the applied ones, without modifying the one in the cache.
+		ingress = ingress.DeepCopy()
+		if cbCtx.EnableBrownfieldDeployment {
+			ingress.Spec.Rules = brownfield.PruneIngressRules(ingress, cbCtx.ProhibitedTargets)
+		}
+
+		eventType, message := c.dryRun(cbCtx, ingress, existingAppGw, generated)
+		key := fmt.Sprintf("%s/%s", ingress.Namespace, ingress.Name)
+		c.dryRuns.messages[key] = message
+		if lastMessages[key] == message {
+			continue
+		}
+		glog.V(3).Infof("Ingress %s: %s", key, message)
+		c.recorder.Event(ingress, eventType, events.ReasonDryRun, message)
+	}
+}
@@ -0,0 +1,60 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+var _ = Describe("Test the dry run of Ingresses", func() {
+	newIngress := func(name, dryRun string) *v1beta1.Ingress {
+		ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name, Annotations: map[string]string{}}}
+		if dryRun != "" {
+			ingress.Annotations[annotations.DryRunKey] = dryRun
+		}
+		return ingress
+	}
+
+	Context("test splitDryRunIngresses", func() {
+		It("should leave the Ingresses in dry run out of the applied ones", func() {
+			shop, blog, wiki, docs := newIngress("shop", "true"), newIngress("blog", ""), newIngress("wiki", "false"), newIngress("docs", "yes")
+			applied, dryRun := splitDryRunIngresses([]*v1beta1.Ingress{shop, blog, wiki, docs})
+			Expect(applied).To(Equal([]*v1beta1.Ingress{blog, wiki, docs}))
+			Expect(dryRun).To(Equal([]*v1beta1.Ingress{shop}))
+		})
+	})
+
+	Context("test objectRecorder", func() {
+		It("should only forward the events of its object", func() {
+			shop, blog := newIngress("shop", "true"), newIngress("blog", "")
+			fakeRecorder := record.NewFakeRecorder(10)
+			recorder := objectRecorder{EventRecorder: fakeRecorder, object: shop}
+
+			recorder.Event(blog, v1.EventTypeWarning, events.ReasonBackendPortTargetMatch, "blog")
+			recorder.Eventf(shop, v1.EventTypeWarning, events.ReasonBackendPortTargetMatch, "%s", "shop")
+			recorder.Eventf(blog, v1.EventTypeWarning, events.ReasonBackendPortTargetMatch, "%s", "blog")
+
+			Expect(fakeRecorder.Events).To(HaveLen(1))
+			Expect(<-fakeRecorder.Events).To(Equal("Warning BackendPortTargetMatch shop"))
+		})
+	})
+
+	Context("test reportDryRuns", func() {
+		It("should forget the reports once no Ingress is in dry run", func() {
+			controller := AppGwIngressController{dryRuns: &dryRunReports{messages: map[string]string{"web/shop": "Dry run"}}}
+			controller.reportDryRuns(nil, nil, nil, nil)
+			Expect(controller.dryRuns.messages).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,116 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// frontendIP remembers the IP address of the App Gateway last published in the status of the Ingresses.
+// It is refreshed by the worker and by the periodic check, hence the mutex.
+type frontendIP struct {
+	mutex   sync.Mutex
+	address string
+}
+
+// swap stores the address and returns the previous one, which is empty until the first address is known.
+func (f *frontendIP) swap(address string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	previous := f.address
+	f.address = address
+	return previous
+}
+
+// MonitorFrontendIP refreshes the frontend IP address of the App Gateway at every interval until the controller stops,
+// so a change made outside of AGIC, such as the re-allocation of the public IP address, is published in the status of the Ingresses.
+func (c *AppGwIngressController) MonitorFrontendIP(envVariables environment.EnvVariables, interval time.Duration) {
+	wait.Until(func() {
+		appGw, err := c.getAppGw()
+		if err != nil {
+			glog.Error("Unable to get App Gateway to check its frontend IP address: ", err)
+			return
+		}
+		c.refreshFrontendIP(envVariables, &appGw)
+	}, interval, c.stopChannel)
+}
+
+// refreshFrontendIP publishes the frontend IP address of the App Gateway, the private one with USE_PRIVATE_IP, in the status
+// of the Ingresses. When the address changed, events are emitted on the AGIC Pod and on the Ingresses updated.
+// In observe-only mode nothing is published.
+func (c *AppGwIngressController) refreshFrontendIP(envVariables environment.EnvVariables, appGw *n.ApplicationGateway) {
+	if envVariables.ObserveOnly == "true" || c.frontendIP == nil {
+		return
+	}
+	usePrivateIP, _ := strconv.ParseBool(envVariables.UsePrivateIP)
+	address, err := c.getFrontendIPAddress(appGw, usePrivateIP)
+	if err != nil {
+		glog.Error("Unable to get the frontend IP address of the App Gateway: ", err)
+		return
+	}
+	if address == "" {
+		return
+	}
+
+	previous := c.frontendIP.swap(address)
+	changed := previous != "" && previous != address
+	if changed {
+		message := fmt.Sprintf("The frontend IP address of App Gateway %s changed from %s to %s; updating the status of the Ingresses",
+			c.appGwIdentifier.AppGwName, previous, address)
+		glog.Warning(message)
+		c.recordAGICPodEvent(envVariables, v1.EventTypeWarning, events.ReasonFrontendIPChanged, message)
+	}
+
+	for _, ingress := range c.k8sContext.PublishIngressAddress(address) {
+		glog.V(3).Infof("Published address %s in the status of Ingress %s/%s", address, ingress.Namespace, ingress.Name)
+		if changed {
+			c.recorder.Eventf(ingress, v1.EventTypeNormal, events.ReasonFrontendIPChanged,
+				"The frontend IP address of the App Gateway changed from %s to %s", previous, address)
+		}
+	}
+}
+
+// getFrontendIPAddress returns the private or public IP address of the frontend of the App Gateway, or an empty string when it has none.
+func (c *AppGwIngressController) getFrontendIPAddress(appGw *n.ApplicationGateway, usePrivateIP bool) (string, error) {
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.FrontendIPConfigurations == nil {
+		return "", nil
+	}
+	for _, ip := range *appGw.FrontendIPConfigurations {
+		if ip.ApplicationGatewayFrontendIPConfigurationPropertiesFormat == nil {
+			continue
+		}
+		if usePrivateIP && ip.PrivateIPAddress != nil {
+			return *ip.PrivateIPAddress, nil
+		}
+		if !usePrivateIP && ip.PublicIPAddress != nil && ip.PublicIPAddress.ID != nil {
+			resourceGroup, name, err := azure.ParsePublicIPAddressID(*ip.PublicIPAddress.ID)
+			if err != nil {
+				return "", err
+			}
+			publicIP, err := c.publicIPAddressesClient.Get(c.ctx, resourceGroup, name, "")
+			if err != nil {
+				return "", fmt.Errorf("unable to get public IP address %s: %s", *ip.PublicIPAddress.ID, err)
+			}
+			if publicIP.PublicIPAddressPropertiesFormat == nil || publicIP.IPAddress == nil {
+				return "", nil
+			}
+			return *publicIP.IPAddress, nil
+		}
+	}
+	return "", nil
+}
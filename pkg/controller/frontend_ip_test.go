@@ -0,0 +1,109 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istio_fake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test the publishing of the frontend IP address", func() {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "agic", Namespace: "agic-namespace"}}
+
+	var controller *AppGwIngressController
+	var kubeClient *testclient.Clientset
+	var recorder *record.FakeRecorder
+	var env environment.EnvVariables
+	var stopChannel chan struct{}
+
+	newIngress := func() *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.IngressClassKey] = annotations.ApplicationGatewayIngressClass
+		return ingress
+	}
+
+	newAppGw := func(privateIP string) *n.ApplicationGateway {
+		return &n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				FrontendIPConfigurations: &[]n.ApplicationGatewayFrontendIPConfiguration{
+					{ApplicationGatewayFrontendIPConfigurationPropertiesFormat: &n.ApplicationGatewayFrontendIPConfigurationPropertiesFormat{
+						PrivateIPAddress: to.StringPtr(privateIP),
+					}},
+				},
+			},
+		}
+	}
+
+	getAddresses := func(ingress *v1beta1.Ingress) []v1.LoadBalancerIngress {
+		stored, err := kubeClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).Get(ingress.Name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return stored.Status.LoadBalancer.Ingress
+	}
+
+	BeforeEach(func() {
+		stopChannel = make(chan struct{})
+		ingress := newIngress()
+		kubeClient = testclient.NewSimpleClientset(pod, ingress)
+		k8sContext := k8scontext.NewContext(kubeClient, fake.NewSimpleClientset(), istio_fake.NewSimpleClientset(),
+			dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), []string{ingress.Namespace}, 1000*time.Second)
+		k8sContext.Run(stopChannel, true, environment.GetFakeEnv())
+
+		recorder = record.NewFakeRecorder(10)
+		controller = &AppGwIngressController{
+			k8sContext:      k8sContext,
+			kubeClient:      kubeClient,
+			recorder:        recorder,
+			frontendIP:      &frontendIP{},
+			appGwIdentifier: appgw.Identifier{SubscriptionID: "--subscription--", ResourceGroup: "--group--", AppGwName: "--name--"},
+		}
+		env = environment.GetFakeEnv()
+		env.UsePrivateIP = "true"
+		env.AGICPodName, env.AGICPodNamespace = pod.Name, pod.Namespace
+	})
+
+	AfterEach(func() {
+		close(stopChannel)
+	})
+
+	It("should publish the address in the status of the Ingresses, and report when it changes", func() {
+		ingress := newIngress()
+
+		controller.refreshFrontendIP(env, newAppGw("10.1.0.4"))
+		Expect(getAddresses(ingress)).To(Equal([]v1.LoadBalancerIngress{{IP: "10.1.0.4"}}))
+		Expect(recorder.Events).To(BeEmpty())
+
+		controller.refreshFrontendIP(env, newAppGw("10.1.0.5"))
+		Expect(getAddresses(ingress)).To(Equal([]v1.LoadBalancerIngress{{IP: "10.1.0.5"}}))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring(events.ReasonFrontendIPChanged), ContainSubstring("from 10.1.0.4 to 10.1.0.5; updating"))))
+		Expect(recorder.Events).To(Receive(And(ContainSubstring(events.ReasonFrontendIPChanged), ContainSubstring("Normal"))))
+	})
+
+	It("should not publish anything in observe-only mode", func() {
+		env.ObserveOnly = "true"
+		controller.refreshFrontendIP(env, newAppGw("10.1.0.4"))
+		Expect(getAddresses(newIngress())).To(BeEmpty())
+	})
+})
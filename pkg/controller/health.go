@@ -0,0 +1,116 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+)
+
+// lastSuccessfulSyncMetric is the Unix time of the last event after which the App Gateway matched the cluster.
+const lastSuccessfulSyncMetric = "last_successful_sync_timestamp"
+
+// HealthStatus is the body of the /healthz response.
+type HealthStatus struct {
+	LastSuccessfulSync *time.Time `json:"lastSuccessfulSync,omitempty"`
+	LastError          string     `json:"lastError,omitempty"`
+	LastErrorTime      *time.Time `json:"lastErrorTime,omitempty"`
+	AppliedConfigHash  string     `json:"appliedConfigHash,omitempty"`
+	ProvisioningState  string     `json:"provisioningState,omitempty"`
+}
+
+// health tracks the outcome of the events processed by the worker; it is read by the HTTP server, hence the mutex.
+type health struct {
+	mutex  sync.RWMutex
+	status HealthStatus
+	now    func() time.Time
+}
+
+func newHealth() *health {
+	return &health{now: time.Now}
+}
+
+// observeAppGw records the provisioning state of the App Gateway, as last retrieved from ARM.
+func (h *health) observeAppGw(appGw *n.ApplicationGateway) {
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.ProvisioningState == nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.status.ProvisioningState = *appGw.ProvisioningState
+}
+
+// markSynced records that the App Gateway matches the cluster, with the given config; a nil config keeps the previous hash.
+func (h *health) markSynced(appGw *n.ApplicationGateway) {
+	hash := ""
+	if appGw != nil {
+		var err error
+		if _, hash, err = redactedConfig(appGw); err != nil {
+			glog.Error("Unable to hash the applied App Gateway config for the health status:", err)
+		}
+	}
+	now := h.now()
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.status.LastSuccessfulSync = &now
+	if hash != "" {
+		h.status.AppliedConfigHash = hash
+	}
+}
+
+// recordError records the error of the last failed event; it is kept after later successful events, along with its time.
+func (h *health) recordError(err error) {
+	now := h.now()
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.status.LastError = err.Error()
+	h.status.LastErrorTime = &now
+}
+
+func (h *health) get() HealthStatus {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.status
+}
+
+// HealthHandler serves /healthz: the time of the last successful sync, the last error, the hash of the applied config
+// and the provisioning state of the App Gateway, as JSON. It always succeeds while AGIC is running.
+func (c *AppGwIngressController) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.health.get()); err != nil {
+			glog.Error("Unable to write the health status:", err)
+		}
+	})
+}
+
+// MetricsHandler serves /metrics in the Prometheus text format; the last successful sync is zero until the first one.
+// Alerting on its age tells when AGIC stopped keeping the App Gateway up to date. The recoveries of the watchdog and the
+// metrics of the App Gateway follow, once they are read from Azure Monitor.
+func (c *AppGwIngressController) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var lastSync float64
+		if status := c.health.get(); status.LastSuccessfulSync != nil {
+			lastSync = float64(status.LastSuccessfulSync.UnixNano()) / float64(time.Second)
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = fmt.Fprintf(w, "# HELP %s Unix time of the last sync after which the App Gateway matched the cluster.\n", lastSuccessfulSyncMetric)
+		_, _ = fmt.Fprintf(w, "# TYPE %s gauge\n", lastSuccessfulSyncMetric)
+		_, _ = fmt.Fprintf(w, "%s %.3f\n", lastSuccessfulSyncMetric, lastSync)
+		if c.watchdog != nil {
+			c.watchdog.write(w)
+		}
+		if c.dataPlaneMetrics != nil {
+			c.dataPlaneMetrics.write(w)
+		}
+	})
+}
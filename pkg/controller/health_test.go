@@ -0,0 +1,72 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test the health endpoints", func() {
+	var controller *AppGwIngressController
+	now := time.Date(2019, 7, 1, 12, 0, 0, 500000000, time.UTC)
+
+	healthz := func() HealthStatus {
+		recorder := httptest.NewRecorder()
+		controller.HealthHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+		var status HealthStatus
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &status)).To(Succeed())
+		return status
+	}
+
+	metrics := func() string {
+		recorder := httptest.NewRecorder()
+		controller.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		return recorder.Body.String()
+	}
+
+	BeforeEach(func() {
+		controller = &AppGwIngressController{health: newHealth()}
+		controller.health.now = func() time.Time { return now }
+	})
+
+	It("should report no sync before the first one", func() {
+		Expect(healthz()).To(Equal(HealthStatus{}))
+		Expect(metrics()).To(ContainSubstring("\nlast_successful_sync_timestamp 0.000\n"))
+	})
+
+	It("should report the last sync, error, config hash and provisioning state", func() {
+		appGw := n.ApplicationGateway{
+			ID: to.StringPtr("--id--"),
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				ProvisioningState: to.StringPtr("Updating"),
+			},
+		}
+		controller.health.observeAppGw(&appGw)
+		controller.health.recordError(errors.New("unable to deploy App Gateway config"))
+		controller.health.markSynced(&appGw)
+		_, hash, err := redactedConfig(&appGw)
+		Expect(err).ToNot(HaveOccurred())
+
+		status := healthz()
+		Expect(status.LastSuccessfulSync.Equal(now)).To(BeTrue())
+		Expect(status.LastError).To(Equal("unable to deploy App Gateway config"))
+		Expect(status.LastErrorTime.Equal(now)).To(BeTrue())
+		Expect(status.AppliedConfigHash).To(Equal(hash))
+		Expect(status.ProvisioningState).To(Equal("Updating"))
+		Expect(metrics()).To(ContainSubstring("\nlast_successful_sync_timestamp 1561982400.500\n"))
+
+		controller.health.markSynced(nil)
+		Expect(healthz().AppliedConfigHash).To(Equal(hash))
+	})
+})
@@ -10,12 +10,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
 	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
 
@@ -23,6 +26,25 @@ var keysToDeleteForCache = []string{
 	"etag",
 }
 
+// unorderedCollections are the App Gateway collections, whose order has no meaning. These are sorted by name, then by ID,
+// before configs are compared or serialized, so semantically identical configs are identical byte for byte.
+// Path rules are not listed: their order is significant. Nor are listeners and request routing rules: the v1 SKUs evaluate
+// them in order, so reordering them is a change.
+var unorderedCollections = map[string]interface{}{
+	"authenticationCertificates":    nil,
+	"backendAddressPools":           nil,
+	"backendHttpSettingsCollection": nil,
+	"backendIPConfigurations":       nil,
+	"frontendIPConfigurations":      nil,
+	"frontendPorts":                 nil,
+	"gatewayIPConfigurations":       nil,
+	"probes":                        nil,
+	"redirectConfigurations":        nil,
+	"sslCertificates":               nil,
+	"trustedRootCertificates":       nil,
+	"urlPathMaps":                   nil,
+}
+
 func (c *AppGwIngressController) updateCache(appGw *n.ApplicationGateway) {
 	jsonConfig, err := appGw.MarshalJSON()
 	if err != nil {
@@ -31,7 +53,7 @@ func (c *AppGwIngressController) updateCache(appGw *n.ApplicationGateway) {
 		return
 	}
 	var sanitized []byte
-	if sanitized, err = deleteKeyFromJSON(jsonConfig, keysToDeleteForCache...); err != nil {
+	if sanitized, err = sanitizeJSON(jsonConfig, keysToDeleteForCache...); err != nil {
 		// Ran into an error; Wipe the existing cache
 		glog.Error("Failed stripping ETag key from App Gwy config. Wiping cache.", err)
 		c.configCache = nil
@@ -53,7 +75,7 @@ func (c *AppGwIngressController) configIsSame(appGw *n.ApplicationGateway) bool
 	// The JSON stored in the cache and the newly marshaled JSON will have different ETags even if configs are the same.
 	// We need to strip ETags from all nested structures in order to have a fair comparison.
 	var sanitized []byte
-	if sanitized, err = deleteKeyFromJSON(jsonConfig, keysToDeleteForCache...); err != nil {
+	if sanitized, err = sanitizeJSON(jsonConfig, keysToDeleteForCache...); err != nil {
 		// Ran into an error; Don't use cache; Refresh cache w/ new JSON
 		glog.Error("Failed stripping ETag key from App Gwy config. Will not use cache.", err)
 		return false
@@ -73,7 +95,7 @@ func (c *AppGwIngressController) dumpSanitizedJSON(appGw *n.ApplicationGateway,
 		"sslCertificates",
 	}
 	var sanitized []byte
-	if sanitized, err = deleteKeyFromJSON(jsonConfig, keysToDelete...); err != nil {
+	if sanitized, err = sanitizeJSON(jsonConfig, keysToDelete...); err != nil {
 		return nil, err
 	}
 
@@ -87,6 +109,19 @@ func (c *AppGwIngressController) dumpSanitizedJSON(appGw *n.ApplicationGateway,
 	return prettyJSON, err
 }
 
+// recordAGICPodEvent attaches an event about the controller itself to the AGIC Pod, when the Pod is known.
+func (c *AppGwIngressController) recordAGICPodEvent(env environment.EnvVariables, eventType, reason, message string) {
+	if c.kubeClient == nil || env.AGICPodName == "" || env.AGICPodNamespace == "" {
+		return
+	}
+	pod, err := c.kubeClient.CoreV1().Pods(env.AGICPodNamespace).Get(env.AGICPodName, metav1.GetOptions{})
+	if err != nil {
+		glog.Error("Unable to find the AGIC Pod to attach the event to: ", err)
+		return
+	}
+	c.recorder.Event(pod, eventType, reason, message)
+}
+
 func isMap(v interface{}) bool {
 	return v != nil && reflect.ValueOf(v).Type().Kind() == reflect.Map
 }
@@ -137,3 +172,50 @@ func deleteKeyFromJSON(jsonWithEtag []byte, keysToDelete ...string) ([]byte, err
 	}
 	return json.Marshal(m)
 }
+
+// sanitizeJSON assumes the []byte passed is JSON of an App Gateway. It deletes the given keys and sorts the unordered collections.
+func sanitizeJSON(jsonConfig []byte, keysToDelete ...string) ([]byte, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(jsonConfig, &m); err != nil {
+		glog.Error("Could not unmarshal config App Gwy JSON to sanitize it.", err)
+		return nil, err
+	}
+	for _, keyToDelete := range keysToDelete {
+		deleteKey(&m, keyToDelete)
+	}
+	sortUnorderedCollections(m)
+	return json.Marshal(m)
+}
+
+// sortUnorderedCollections recursively sorts the elements of the unordered collections found in the given JSON value.
+func sortUnorderedCollections(v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			sortUnorderedCollections(item)
+			if _, unordered := unorderedCollections[key]; !unordered {
+				continue
+			}
+			if collection, ok := item.([]interface{}); ok {
+				sort.SliceStable(collection, func(i, j int) bool {
+					return getCollectionItemKey(collection[i]) < getCollectionItemKey(collection[j])
+				})
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			sortUnorderedCollections(item)
+		}
+	}
+}
+
+// getCollectionItemKey returns the name and the ID of an element of a collection; references to other resources only have an ID.
+func getCollectionItemKey(item interface{}) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	name, _ := m["name"].(string)
+	id, _ := m["id"].(string)
+	return name + "\x00" + id
+}
@@ -10,6 +10,12 @@ import (
 	"github.com/Azure/go-autorest/autorest/to"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 )
 
 var _ = Describe("configure App Gateway", func() {
@@ -78,6 +84,73 @@ var _ = Describe("configure App Gateway", func() {
 		})
 	})
 
+	Context("ensure configIsSame ignores the order of unordered collections", func() {
+		newProbe := func(name string) n.ApplicationGatewayProbe {
+			return n.ApplicationGatewayProbe{Name: to.StringPtr(name)}
+		}
+		newListener := func(name string) n.ApplicationGatewayHTTPListener {
+			return n.ApplicationGatewayHTTPListener{Name: to.StringPtr(name)}
+		}
+		newPathRule := func(name string) n.ApplicationGatewayPathRule {
+			return n.ApplicationGatewayPathRule{Name: to.StringPtr(name)}
+		}
+		newConfig := func(probes []n.ApplicationGatewayProbe, listeners []n.ApplicationGatewayHTTPListener, pathRules []n.ApplicationGatewayPathRule) *n.ApplicationGateway {
+			return &n.ApplicationGateway{
+				ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+					Probes:        &probes,
+					HTTPListeners: &listeners,
+					URLPathMaps: &[]n.ApplicationGatewayURLPathMap{{
+						Name: to.StringPtr("url-path-map"),
+						ApplicationGatewayURLPathMapPropertiesFormat: &n.ApplicationGatewayURLPathMapPropertiesFormat{
+							PathRules: &pathRules,
+						},
+					}},
+				},
+			}
+		}
+		listeners := []n.ApplicationGatewayHTTPListener{newListener("a"), newListener("b")}
+		probes := []n.ApplicationGatewayProbe{newProbe("a"), newProbe("b")}
+		pathRules := []n.ApplicationGatewayPathRule{newPathRule("a"), newPathRule("b")}
+
+		It("should consider configs with reordered probes the same", func() {
+			c := AppGwIngressController{configCache: to.ByteSlicePtr([]byte{})}
+			c.updateCache(newConfig(probes, listeners, pathRules))
+			Expect(c.configIsSame(newConfig([]n.ApplicationGatewayProbe{newProbe("b"), newProbe("a")}, listeners, pathRules))).To(BeTrue())
+		})
+
+		It("should consider configs with reordered listeners different, as the v1 SKUs evaluate them in order", func() {
+			c := AppGwIngressController{configCache: to.ByteSlicePtr([]byte{})}
+			c.updateCache(newConfig(probes, listeners, pathRules))
+			Expect(c.configIsSame(newConfig(probes, []n.ApplicationGatewayHTTPListener{newListener("b"), newListener("a")}, pathRules))).To(BeFalse())
+		})
+
+		It("should consider configs with reordered path rules different", func() {
+			c := AppGwIngressController{configCache: to.ByteSlicePtr([]byte{})}
+			c.updateCache(newConfig(probes, listeners, pathRules))
+			Expect(c.configIsSame(newConfig(probes, listeners, []n.ApplicationGatewayPathRule{newPathRule("b"), newPathRule("a")}))).To(BeFalse())
+		})
+	})
+
+	Context("ensure recordAGICPodEvent works as expected", func() {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "agic", Namespace: "agic-namespace"}}
+
+		It("should attach the event to the AGIC Pod", func() {
+			recorder := record.NewFakeRecorder(10)
+			c := AppGwIngressController{kubeClient: testclient.NewSimpleClientset(pod), recorder: recorder}
+			env := environment.GetFakeEnv()
+			env.AGICPodName, env.AGICPodNamespace = pod.Name, pod.Namespace
+			c.recordAGICPodEvent(env, v1.EventTypeWarning, "Reason", "message")
+			Expect(recorder.Events).To(Receive(Equal("Warning Reason message")))
+		})
+
+		It("should not emit events when the AGIC Pod is not known", func() {
+			recorder := record.NewFakeRecorder(10)
+			c := AppGwIngressController{kubeClient: testclient.NewSimpleClientset(pod), recorder: recorder}
+			c.recordAGICPodEvent(environment.GetFakeEnv(), v1.EventTypeWarning, "Reason", "message")
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
+
 	Context("ensure isMap works as expected", func() {
 		It("should deal with nil values", func() {
 			Expect(isMap(nil)).To(BeFalse())
@@ -0,0 +1,19 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// markKnativeIngressesReady reports the Knative Ingresses as ready once the App Gateway has their config.
+// In observe-only mode nothing is applied, so they are left alone.
+func (c AppGwIngressController) markKnativeIngressesReady(envVariables environment.EnvVariables) {
+	if envVariables.EnableKnativeIntegration != "true" || envVariables.ObserveOnly == "true" {
+		return
+	}
+	c.k8sContext.MarkKnativeIngressesReady()
+}
@@ -0,0 +1,188 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LastAppliedConfigMapName is the name of the ConfigMap, in AGIC's namespace, with the last App Gateway config applied by AGIC.
+	LastAppliedConfigMapName = "appgw-last-applied-config"
+
+	lastAppliedHashKey      = "hash"
+	lastAppliedConfigKey    = "appGateway.json"
+	lastAppliedGzipKey      = "appGateway.json.gz"
+	lastAppliedTruncatedKey = "truncated"
+	lastAppliedIDKey        = "appGatewayID"
+	lastAppliedTimeKey      = "appliedAt"
+
+	// lastAppliedMaxConfigSize keeps the ConfigMap, with its other keys, below the 1 MiB limit of Kubernetes objects.
+	lastAppliedMaxConfigSize = 900 * 1024
+
+	// lastAppliedMaxCompressedSize accounts for the base64 encoding of the binary data of ConfigMaps.
+	lastAppliedMaxCompressedSize = lastAppliedMaxConfigSize / 4 * 3
+)
+
+// keysToDeleteForLastApplied are removed from the persisted config: ETags change on every update, and certificates are secrets.
+var keysToDeleteForLastApplied = []string{
+	"etag",
+	"data",
+	"password",
+}
+
+// lastAppliedStore persists the last App Gateway config applied by AGIC in a ConfigMap, so a replacement AGIC Pod can
+// detect changes made to the App Gateway in the meantime, and operators can restore the App Gateway.
+type lastAppliedStore struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+}
+
+// newLastAppliedStore returns nil when the namespace of AGIC is not known.
+func newLastAppliedStore(kubeClient kubernetes.Interface, namespace string) *lastAppliedStore {
+	if kubeClient == nil || namespace == "" {
+		return nil
+	}
+	return &lastAppliedStore{kubeClient: kubeClient, namespace: namespace}
+}
+
+// redactedConfig returns the App Gateway JSON without ETags and secrets, and its hash.
+func redactedConfig(appGw *n.ApplicationGateway) ([]byte, string, error) {
+	jsonConfig, err := appGw.MarshalJSON()
+	if err != nil {
+		return nil, "", err
+	}
+	redacted, err := sanitizeJSON(jsonConfig, keysToDeleteForLastApplied...)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := sha256.Sum256(redacted)
+	return redacted, hex.EncodeToString(hash[:]), nil
+}
+
+// gzipConfig compresses the config of a large App Gateway.
+func gzipConfig(config []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(config); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// save stores the App Gateway config as returned by ARM after a successful update. A config too large for a ConfigMap
+// is stored compressed in its binary data; when even that is too large only the hash is stored, with a truncation marker.
+func (s *lastAppliedStore) save(appGw *n.ApplicationGateway) error {
+	redacted, hash, err := redactedConfig(appGw)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		lastAppliedHashKey: hash,
+		lastAppliedTimeKey: time.Now().UTC().Format(time.RFC3339),
+	}
+	var binaryData map[string][]byte
+	if appGw.ID != nil {
+		data[lastAppliedIDKey] = *appGw.ID
+	}
+	if len(redacted) <= lastAppliedMaxConfigSize {
+		data[lastAppliedConfigKey] = string(redacted)
+	} else if compressed, err := gzipConfig(redacted); err != nil {
+		return err
+	} else if len(compressed) <= lastAppliedMaxCompressedSize {
+		binaryData = map[string][]byte{lastAppliedGzipKey: compressed}
+	} else {
+		glog.Warningf("App Gateway config is %d bytes compressed, too large for ConfigMap %s/%s; storing only its hash",
+			len(compressed), s.namespace, LastAppliedConfigMapName)
+		data[lastAppliedTruncatedKey] = "true"
+	}
+
+	configMaps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	existing, err := configMaps.Get(LastAppliedConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: LastAppliedConfigMapName, Namespace: s.namespace},
+			Data:       data,
+			BinaryData: binaryData,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	existing.Data = data
+	existing.BinaryData = binaryData
+	_, err = configMaps.Update(existing)
+	return err
+}
+
+// hasDiverged tells whether the App Gateway differs from the last config AGIC applied.
+// It is false when no config has been stored yet.
+func (s *lastAppliedStore) hasDiverged(appGw *n.ApplicationGateway) (bool, error) {
+	configMap, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(LastAppliedConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, hash, err := redactedConfig(appGw)
+	if err != nil {
+		return false, err
+	}
+	lastHash := configMap.Data[lastAppliedHashKey]
+	if lastHash != hash {
+		glog.V(5).Infof("Hash of App Gateway config %s differs from last applied %s (applied at %s)", hash, lastHash, configMap.Data[lastAppliedTimeKey])
+		return true, nil
+	}
+	return false, nil
+}
+
+// checkLastApplied warns when the App Gateway was changed since AGIC last updated it. It runs only on startup: changes
+// made while AGIC runs are overwritten by its next update without a warning.
+func (c *AppGwIngressController) checkLastApplied() {
+	if c.lastApplied == nil {
+		return
+	}
+	appGw, err := c.getAppGw()
+	if err != nil {
+		glog.Error("Unable to get App Gateway to compare with the last applied config:", err)
+		return
+	}
+	diverged, err := c.lastApplied.hasDiverged(&appGw)
+	if err != nil {
+		glog.Error("Unable to compare App Gateway with the last applied config:", err)
+		return
+	}
+	if diverged {
+		glog.Warningf("App Gateway %s was modified since AGIC last updated it; the last applied config is in ConfigMap %s/%s. AGIC will now reconcile the App Gateway.",
+			c.appGwIdentifier.AppGwName, c.lastApplied.namespace, LastAppliedConfigMapName)
+	}
+}
+
+// saveLastApplied stores the config of the App Gateway resulting from a successful update.
+func (c *AppGwIngressController) saveLastApplied(appGw *n.ApplicationGateway) {
+	if c.lastApplied == nil {
+		return
+	}
+	if err := c.lastApplied.save(appGw); err != nil {
+		glog.Errorf("Unable to store the last applied config in ConfigMap %s/%s: %s", c.lastApplied.namespace, LastAppliedConfigMapName, err)
+	}
+}
@@ -0,0 +1,139 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Test the last applied config", func() {
+
+	newAppGw := func(etag, hostName string) *n.ApplicationGateway {
+		return &n.ApplicationGateway{
+			ID:   to.StringPtr("--app-gw-id--"),
+			Etag: to.StringPtr(etag),
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				HTTPListeners: &[]n.ApplicationGatewayHTTPListener{{
+					Name: to.StringPtr("listener"),
+					Etag: to.StringPtr(etag),
+					ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+						HostName: to.StringPtr(hostName),
+					},
+				}},
+				SslCertificates: &[]n.ApplicationGatewaySslCertificate{{
+					Name: to.StringPtr("cert"),
+					ApplicationGatewaySslCertificatePropertiesFormat: &n.ApplicationGatewaySslCertificatePropertiesFormat{
+						Data:     to.StringPtr("--secret-data--"),
+						Password: to.StringPtr("--secret-password--"),
+					},
+				}},
+			},
+		}
+	}
+
+	It("should not have diverged before anything was stored", func() {
+		store := newLastAppliedStore(testclient.NewSimpleClientset(), "agic")
+		Expect(store.hasDiverged(newAppGw("1", "a.com"))).To(BeFalse())
+	})
+
+	It("should store the redacted config and detect changes", func() {
+		client := testclient.NewSimpleClientset()
+		store := newLastAppliedStore(client, "agic")
+		Expect(store.save(newAppGw("1", "a.com"))).To(Succeed())
+
+		configMap, err := client.CoreV1().ConfigMaps("agic").Get(LastAppliedConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMap.Data[lastAppliedIDKey]).To(Equal("--app-gw-id--"))
+		Expect(configMap.Data[lastAppliedConfigKey]).To(ContainSubstring("a.com"))
+		Expect(configMap.Data[lastAppliedConfigKey]).ToNot(ContainSubstring("--secret-"))
+
+		// ETags change with every update of the App Gateway.
+		Expect(store.hasDiverged(newAppGw("2", "a.com"))).To(BeFalse())
+		Expect(store.hasDiverged(newAppGw("2", "b.com"))).To(BeTrue())
+
+		// The ConfigMap is updated in place.
+		Expect(store.save(newAppGw("2", "b.com"))).To(Succeed())
+		Expect(store.hasDiverged(newAppGw("3", "b.com"))).To(BeFalse())
+	})
+
+	// withListeners adds listeners with host names of the given length; random host names do not compress.
+	withListeners := func(appGw *n.ApplicationGateway, count, hostNameLength int, random bool) *n.ApplicationGateway {
+		for idx := 0; idx < count; idx++ {
+			hostName := bytes.Repeat([]byte("a"), hostNameLength)
+			if random {
+				randomBytes := make([]byte, hostNameLength/2)
+				_, _ = rand.Read(randomBytes)
+				hostName = []byte(hex.EncodeToString(randomBytes))
+			}
+			*appGw.HTTPListeners = append(*appGw.HTTPListeners, n.ApplicationGatewayHTTPListener{
+				Name: to.StringPtr("listener"),
+				ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					HostName: to.StringPtr(string(hostName)),
+				},
+			})
+		}
+		return appGw
+	}
+
+	It("should compress a config too large for a ConfigMap", func() {
+		client := testclient.NewSimpleClientset()
+		store := newLastAppliedStore(client, "agic")
+		appGw := withListeners(newAppGw("1", "a.com"), 1000, 1000, false)
+		Expect(store.save(appGw)).To(Succeed())
+
+		configMap, err := client.CoreV1().ConfigMaps("agic").Get(LastAppliedConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMap.Data).ToNot(HaveKey(lastAppliedConfigKey))
+		Expect(configMap.Data).ToNot(HaveKey(lastAppliedTruncatedKey))
+		Expect(len(configMap.BinaryData[lastAppliedGzipKey])).To(BeNumerically("<=", lastAppliedMaxCompressedSize))
+
+		reader, err := gzip.NewReader(bytes.NewReader(configMap.BinaryData[lastAppliedGzipKey]))
+		Expect(err).ToNot(HaveOccurred())
+		config, err := ioutil.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		redacted, _, err := redactedConfig(appGw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(Equal(redacted))
+		Expect(store.hasDiverged(appGw)).To(BeFalse())
+
+		// A smaller config replaces the compressed one.
+		Expect(store.save(newAppGw("2", "a.com"))).To(Succeed())
+		configMap, err = client.CoreV1().ConfigMaps("agic").Get(LastAppliedConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMap.Data).To(HaveKey(lastAppliedConfigKey))
+		Expect(configMap.BinaryData).To(BeEmpty())
+	})
+
+	It("should store only the hash of a config too large even when compressed", func() {
+		client := testclient.NewSimpleClientset()
+		store := newLastAppliedStore(client, "agic")
+		appGw := withListeners(newAppGw("1", "a.com"), 1000, 2000, true)
+		Expect(store.save(appGw)).To(Succeed())
+
+		configMap, err := client.CoreV1().ConfigMaps("agic").Get(LastAppliedConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMap.Data[lastAppliedTruncatedKey]).To(Equal("true"))
+		Expect(configMap.Data).ToNot(HaveKey(lastAppliedConfigKey))
+		Expect(configMap.BinaryData).To(BeEmpty())
+		Expect(store.hasDiverged(appGw)).To(BeFalse())
+		Expect(store.hasDiverged(newAppGw("2", "a.com"))).To(BeTrue())
+	})
+
+	It("should not store anything without a namespace", func() {
+		Expect(newLastAppliedStore(testclient.NewSimpleClientset(), "")).To(BeNil())
+	})
+})
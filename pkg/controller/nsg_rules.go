@@ -0,0 +1,212 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+const (
+	// nsgRulePrefix starts the names of the security rules managed by AGIC; other rules of the network security group are left alone.
+	nsgRulePrefix = "agic-managed-allow-"
+
+	// nsgRuleDescription marks the security rules managed by AGIC to the operators looking at the network security group.
+	nsgRuleDescription = "Managed by AGIC: allows traffic to a frontend port of the App Gateway"
+
+	// nsgRuleMinPriority and nsgRuleMaxPriority bound the priorities AGIC gives to the security rules it creates.
+	nsgRuleMinPriority = 3000
+	nsgRuleMaxPriority = 3999
+)
+
+// nsgClients are the clients of the subnet of the App Gateway and of its network security group.
+type nsgClients struct {
+	subnets        n.SubnetsClient
+	securityGroups n.SecurityGroupsClient
+	securityRules  n.SecurityRulesClient
+}
+
+func newNSGClients(subscriptionID string, appGwClient n.ApplicationGatewaysClient) *nsgClients {
+	clients := &nsgClients{
+		subnets:        n.NewSubnetsClient(subscriptionID),
+		securityGroups: n.NewSecurityGroupsClient(subscriptionID),
+		securityRules:  n.NewSecurityRulesClient(subscriptionID),
+	}
+	clients.subnets.Authorizer = appGwClient.Authorizer
+	clients.securityGroups.Authorizer = appGwClient.Authorizer
+	clients.securityRules.Authorizer = appGwClient.Authorizer
+	return clients
+}
+
+// getFrontendPorts returns the sorted port numbers of the frontend ports of the App Gateway.
+func getFrontendPorts(appGw *n.ApplicationGateway) []int32 {
+	var ports []int32
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.FrontendPorts == nil {
+		return ports
+	}
+	for _, port := range *appGw.FrontendPorts {
+		if port.ApplicationGatewayFrontendPortPropertiesFormat != nil && port.Port != nil {
+			ports = append(ports, *port.Port)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
+
+// nsgRuleName returns the name of the security rule AGIC manages for a frontend port.
+func nsgRuleName(port int32) string {
+	return fmt.Sprintf("%s%d", nsgRulePrefix, port)
+}
+
+// newNSGRule returns the security rule allowing inbound TCP traffic to the frontend port. The destination is any address,
+// as the frontend IP addresses of the App Gateway are not in its subnet.
+func newNSGRule(port int32, priority int32) n.SecurityRule {
+	return n.SecurityRule{
+		Name: to.StringPtr(nsgRuleName(port)),
+		SecurityRulePropertiesFormat: &n.SecurityRulePropertiesFormat{
+			Description:              to.StringPtr(nsgRuleDescription),
+			Protocol:                 n.SecurityRuleProtocolTCP,
+			SourcePortRange:          to.StringPtr("*"),
+			SourceAddressPrefix:      to.StringPtr("*"),
+			DestinationPortRange:     to.StringPtr(strconv.Itoa(int(port))),
+			DestinationAddressPrefix: to.StringPtr("*"),
+			Access:                   n.SecurityRuleAccessAllow,
+			Direction:                n.SecurityRuleDirectionInbound,
+			Priority:                 to.Int32Ptr(priority),
+		},
+	}
+}
+
+// planNSGRules returns the security rules to create for the frontend ports without one, and the names of the rules AGIC manages
+// for frontend ports, which the App Gateway no longer has. New rules take the lowest inbound priorities not yet in use.
+func planNSGRules(ports []int32, existing []n.SecurityRule) (toCreate []n.SecurityRule, toDelete []string, err error) {
+	wanted := make(map[string]int32)
+	for _, port := range ports {
+		wanted[nsgRuleName(port)] = port
+	}
+
+	managed := make(map[string]interface{})
+	usedPriorities := make(map[int32]interface{})
+	for _, rule := range existing {
+		if rule.Name == nil {
+			continue
+		}
+		if rule.SecurityRulePropertiesFormat != nil && rule.Priority != nil && rule.Direction == n.SecurityRuleDirectionInbound {
+			usedPriorities[*rule.Priority] = nil
+		}
+		if !strings.HasPrefix(*rule.Name, nsgRulePrefix) {
+			continue
+		}
+		managed[*rule.Name] = nil
+		if _, exists := wanted[*rule.Name]; !exists {
+			toDelete = append(toDelete, *rule.Name)
+		}
+	}
+	sort.Strings(toDelete)
+
+	priority := int32(nsgRuleMinPriority)
+	for _, port := range ports {
+		if _, exists := managed[nsgRuleName(port)]; exists {
+			continue
+		}
+		for {
+			if _, used := usedPriorities[priority]; !used {
+				break
+			}
+			priority++
+		}
+		if priority > nsgRuleMaxPriority {
+			return nil, nil, fmt.Errorf("no priority between %d and %d is free for the security rule of frontend port %d", nsgRuleMinPriority, nsgRuleMaxPriority, port)
+		}
+		toCreate = append(toCreate, newNSGRule(port, priority))
+		usedPriorities[priority] = nil
+	}
+	return toCreate, toDelete, nil
+}
+
+// reconcileNSGRules allows traffic to the frontend ports of the App Gateway in the network security group of its subnet,
+// and removes the rules AGIC created for ports the App Gateway no longer has. Subnets without a network security group are left alone.
+// Failures are reported without failing the update of the App Gateway itself.
+func (c AppGwIngressController) reconcileNSGRules(envVariables environment.EnvVariables, appGw *n.ApplicationGateway) {
+	if c.nsgClients == nil {
+		return
+	}
+	if err := c.updateNSGRules(appGw); err != nil {
+		glog.Error("Unable to update the security rules of the frontend ports of the App Gateway:", err)
+		c.recordAGICPodEvent(envVariables, v1.EventTypeWarning, events.ReasonNSGRuleFailure, err.Error())
+	}
+}
+
+func (c AppGwIngressController) updateNSGRules(appGw *n.ApplicationGateway) error {
+	subnetID, err := azure.GetSubnetID(*appGw)
+	if err != nil {
+		return err
+	}
+	subnetResourceGroup, vnetName, subnetName, err := azure.ParseSubnetID(subnetID)
+	if err != nil {
+		return err
+	}
+	subnet, err := c.nsgClients.subnets.Get(c.ctx, subnetResourceGroup, vnetName, subnetName, "")
+	if err != nil {
+		return fmt.Errorf("unable to get subnet %s: %s", subnetID, err)
+	}
+	if subnet.SubnetPropertiesFormat == nil || subnet.NetworkSecurityGroup == nil || subnet.NetworkSecurityGroup.ID == nil {
+		glog.V(5).Infof("Subnet %s of the App Gateway has no network security group", subnetID)
+		return nil
+	}
+	nsgID := *subnet.NetworkSecurityGroup.ID
+	nsgResourceGroup, nsgName, err := azure.ParseNetworkSecurityGroupID(nsgID)
+	if err != nil {
+		return err
+	}
+	nsg, err := c.nsgClients.securityGroups.Get(c.ctx, nsgResourceGroup, nsgName, "")
+	if err != nil {
+		return fmt.Errorf("unable to get network security group %s: %s", nsgID, err)
+	}
+	var existing []n.SecurityRule
+	if nsg.SecurityGroupPropertiesFormat != nil && nsg.SecurityRules != nil {
+		existing = *nsg.SecurityRules
+	}
+
+	toCreate, toDelete, err := planNSGRules(getFrontendPorts(appGw), existing)
+	if err != nil {
+		return err
+	}
+
+	// Operations on the same network security group conflict, so each one is awaited.
+	for _, rule := range toCreate {
+		future, err := c.nsgClients.securityRules.CreateOrUpdate(c.ctx, nsgResourceGroup, nsgName, *rule.Name, rule)
+		if err == nil {
+			err = future.WaitForCompletionRef(c.ctx, c.nsgClients.securityRules.Client)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to create security rule %s in network security group %s: %s", *rule.Name, nsgID, err)
+		}
+		glog.Infof("Created security rule %s allowing frontend port %s in network security group %s", *rule.Name, *rule.DestinationPortRange, nsgID)
+	}
+	for _, name := range toDelete {
+		future, err := c.nsgClients.securityRules.Delete(c.ctx, nsgResourceGroup, nsgName, name)
+		if err == nil {
+			err = future.WaitForCompletionRef(c.ctx, c.nsgClients.securityRules.Client)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to delete security rule %s from network security group %s: %s", name, nsgID, err)
+		}
+		glog.Infof("Deleted security rule %s from network security group %s", name, nsgID)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
+)
+
+var _ = Describe("Test the security rules of the frontend ports", func() {
+	const (
+		subnetID = "/subscriptions/--subscription--/resourceGroups/--vnet-group--/providers/Microsoft.Network/virtualNetworks/--vnet--/subnets/--subnet--"
+		nsgID    = "/subscriptions/--subscription--/resourceGroups/--nsg-group--/providers/Microsoft.Network/networkSecurityGroups/--nsg--"
+	)
+
+	rule := func(name string, priority int32) n.SecurityRule {
+		return n.SecurityRule{
+			Name: to.StringPtr(name),
+			SecurityRulePropertiesFormat: &n.SecurityRulePropertiesFormat{
+				Priority:  to.Int32Ptr(priority),
+				Direction: n.SecurityRuleDirectionInbound,
+			},
+		}
+	}
+
+	Context("test planNSGRules()", func() {
+		It("should create the missing rules with free priorities, and delete the ones of removed ports", func() {
+			existing := []n.SecurityRule{
+				rule("allow-ssh", 3000),
+				rule(nsgRuleName(80), 3001),
+				rule(nsgRuleName(8080), 3002),
+			}
+			toCreate, toDelete, err := planNSGRules([]int32{80, 443, 8443}, existing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(toDelete).To(Equal([]string{nsgRuleName(8080)}))
+			Expect(toCreate).To(HaveLen(2))
+			Expect(*toCreate[0].Name).To(Equal(nsgRuleName(443)))
+			Expect(*toCreate[0].Priority).To(Equal(int32(3003)))
+			Expect(*toCreate[0].DestinationPortRange).To(Equal("443"))
+			Expect(toCreate[0].Access).To(Equal(n.SecurityRuleAccessAllow))
+			Expect(*toCreate[1].Name).To(Equal(nsgRuleName(8443)))
+			Expect(*toCreate[1].Priority).To(Equal(int32(3004)))
+		})
+
+		It("should leave the rules alone when they match the frontend ports", func() {
+			toCreate, toDelete, err := planNSGRules([]int32{80}, []n.SecurityRule{rule(nsgRuleName(80), 3000)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(toCreate).To(BeEmpty())
+			Expect(toDelete).To(BeEmpty())
+		})
+
+		It("should fail when no priority is free", func() {
+			var existing []n.SecurityRule
+			for priority := int32(nsgRuleMinPriority); priority <= nsgRuleMaxPriority; priority++ {
+				existing = append(existing, rule("other", priority))
+			}
+			_, _, err := planNSGRules([]int32{80}, existing)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("test reconcileNSGRules()", func() {
+		var c *AppGwIngressController
+		var requests []*http.Request
+		var subnet n.Subnet
+		var nsg n.SecurityGroup
+
+		appGw := &n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				GatewayIPConfigurations: &[]n.ApplicationGatewayIPConfiguration{{
+					ApplicationGatewayIPConfigurationPropertiesFormat: &n.ApplicationGatewayIPConfigurationPropertiesFormat{
+						Subnet: &n.SubResource{ID: to.StringPtr(subnetID)},
+					},
+				}},
+				FrontendPorts: &[]n.ApplicationGatewayFrontendPort{{
+					ApplicationGatewayFrontendPortPropertiesFormat: &n.ApplicationGatewayFrontendPortPropertiesFormat{Port: to.Int32Ptr(443)},
+				}},
+			},
+		}
+
+		BeforeEach(func() {
+			requests = nil
+			subnet = n.Subnet{SubnetPropertiesFormat: &n.SubnetPropertiesFormat{NetworkSecurityGroup: &n.SecurityGroup{ID: to.StringPtr(nsgID)}}}
+			nsg = n.SecurityGroup{SecurityGroupPropertiesFormat: &n.SecurityGroupPropertiesFormat{
+				SecurityRules: &[]n.SecurityRule{rule(nsgRuleName(80), 3000)},
+			}}
+
+			identifier := appgw.Identifier{SubscriptionID: "--subscription--", ResourceGroup: "--group--", AppGwName: "--name--"}
+			c = NewAppGwIngressController(n.NewApplicationGatewaysClient("--subscription--"), identifier, nil, nil, nil, 0, worker.DefaultOptions())
+			c.nsgClients = newNSGClients("--subscription--", c.appGwClient)
+			sender := autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+				requests = append(requests, req)
+				response := &http.Response{Request: req, StatusCode: http.StatusOK, Header: http.Header{}}
+				var content []byte
+				switch {
+				case req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/subnets/"):
+					content, _ = json.Marshal(subnet)
+				case req.Method == http.MethodGet:
+					content, _ = json.Marshal(nsg)
+				case req.Method == http.MethodPut:
+					content = []byte(`{"properties":{"provisioningState":"Succeeded"}}`)
+				}
+				response.Body = ioutil.NopCloser(strings.NewReader(string(content)))
+				return response, nil
+			})
+			c.nsgClients.subnets.Sender = sender
+			c.nsgClients.securityGroups.Sender = sender
+			c.nsgClients.securityRules.Sender = sender
+		})
+
+		It("should allow the frontend ports, and remove the rules of removed ports", func() {
+			c.reconcileNSGRules(environment.GetFakeEnv(), appGw)
+			Expect(requests).To(HaveLen(4))
+			Expect(requests[1].URL.Path).To(HaveSuffix("/resourceGroups/--nsg-group--/providers/Microsoft.Network/networkSecurityGroups/--nsg--"))
+			Expect(requests[2].Method).To(Equal(http.MethodPut))
+			Expect(requests[2].URL.Path).To(HaveSuffix("/securityRules/" + nsgRuleName(443)))
+			Expect(requests[3].Method).To(Equal(http.MethodDelete))
+			Expect(requests[3].URL.Path).To(HaveSuffix("/securityRules/" + nsgRuleName(80)))
+		})
+
+		It("should leave subnets without a network security group alone", func() {
+			subnet.NetworkSecurityGroup = nil
+			c.reconcileNSGRules(environment.GetFakeEnv(), appGw)
+			Expect(requests).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,48 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/audit"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// reportObservedChange logs, records in the audit log and emits an event on the AGIC Pod with the changes AGIC would apply
+// to the App Gateway, instead of applying them, in observe-only mode.
+func (c AppGwIngressController) reportObservedChange(event events.Event, envVars environment.EnvVariables, existing audit.Snapshot, generatedAppGw *n.ApplicationGateway) {
+	updated, err := audit.NewSnapshot(generatedAppGw)
+	if err != nil {
+		glog.Error("Unable to capture the generated App Gateway config:", err)
+		return
+	}
+	added, modified, removed := audit.Diff(existing, updated)
+	if len(added) == 0 && len(modified) == 0 && len(removed) == 0 {
+		glog.V(3).Info("Observe-only mode: the App Gateway matches the generated config")
+		return
+	}
+
+	c.auditLog.Add(audit.Entry{
+		Timestamp:   time.Now(),
+		Trigger:     audit.NewObject(event),
+		Added:       added,
+		Modified:    modified,
+		Removed:     removed,
+		ObserveOnly: true,
+	})
+	message := fmt.Sprintf("Observe-only mode; App Gateway %s was not updated. AGIC would add [%s]; modify [%s]; remove [%s]",
+		c.appGwIdentifier.AppGwName, strings.Join(added, ", "), strings.Join(modified, ", "), strings.Join(removed, ", "))
+	glog.Info(message)
+	c.recordAGICPodEvent(envVars, v1.EventTypeNormal, events.ReasonObservedConfigChange, message)
+}
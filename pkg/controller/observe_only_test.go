@@ -0,0 +1,74 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/audit"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+var _ = Describe("Test the observe-only mode", func() {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "agic", Namespace: "agic-namespace"}}
+
+	var controller AppGwIngressController
+	var recorder *record.FakeRecorder
+	var env environment.EnvVariables
+	var existing audit.Snapshot
+
+	newAppGw := func(listenerNames ...string) *n.ApplicationGateway {
+		var listeners []n.ApplicationGatewayHTTPListener
+		for _, name := range listenerNames {
+			listeners = append(listeners, n.ApplicationGatewayHTTPListener{Name: to.StringPtr(name)})
+		}
+		return &n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{HTTPListeners: &listeners},
+		}
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		controller = AppGwIngressController{
+			kubeClient: testclient.NewSimpleClientset(pod),
+			recorder:   recorder,
+			auditLog:   audit.NewLog(10),
+		}
+		env = environment.GetFakeEnv()
+		env.AGICPodName, env.AGICPodNamespace = pod.Name, pod.Namespace
+
+		var err error
+		existing, err = audit.NewSnapshot(newAppGw("kept", "removed"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should report the changes AGIC would apply", func() {
+		controller.reportObservedChange(events.Event{Type: events.Update}, env, existing, newAppGw("kept", "added"))
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(events.ReasonObservedConfigChange),
+			ContainSubstring("add [httpListeners/added]"),
+			ContainSubstring("remove [httpListeners/removed]"))))
+
+		entries := controller.auditLog.Entries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ObserveOnly).To(BeTrue())
+		Expect(entries[0].Added).To(Equal([]string{"httpListeners/added"}))
+	})
+
+	It("should not report anything when the App Gateway matches the generated config", func() {
+		controller.reportObservedChange(events.Event{Type: events.Update}, env, existing, newAppGw("kept", "removed"))
+		Expect(recorder.Events).To(BeEmpty())
+		Expect(controller.auditLog.Entries()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,188 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+)
+
+// orphanTarget is a hostname of the Ingress, and its paths; no paths stand for the whole hostname.
+type orphanTarget struct {
+	Hostname string   `json:"hostname,omitempty"`
+	Paths    []string `json:"paths,omitempty"`
+}
+
+// orphanRecord is the entry of an Ingress in the ConfigMap of orphaned Ingresses.
+type orphanRecord struct {
+	Targets []orphanTarget `json:"targets"`
+
+	// OrphanedAt is when AGIC found the Ingress deleted; it is empty while the Ingress exists.
+	OrphanedAt string `json:"orphanedAt,omitempty"`
+}
+
+// orphanStore keeps the entries of the Ingresses with the retain orphan policy in a ConfigMap, so their config is retained after
+// they are deleted, including while AGIC is restarted. The ConfigMap is read from the cache of its informer.
+type orphanStore struct {
+	kubeClient kubernetes.Interface
+	k8sContext *k8scontext.Context
+	namespace  string
+}
+
+// newOrphanStore returns nil when the namespace of AGIC is not known.
+func newOrphanStore(kubeClient kubernetes.Interface, k8sContext *k8scontext.Context, namespace string) *orphanStore {
+	if kubeClient == nil || k8sContext == nil || namespace == "" {
+		return nil
+	}
+	return &orphanStore{kubeClient: kubeClient, k8sContext: k8sContext, namespace: namespace}
+}
+
+// orphanKey is the key of the entry of the Ingress in the ConfigMap; namespaces have no dots, so the key is not ambiguous.
+func orphanKey(namespace, name string) string {
+	return namespace + "." + name
+}
+
+// getOrphanTargets returns the hostnames and paths of the Ingress, as they are retained once it is deleted.
+// A rule with a default path is the default of its whole hostname; the blank hostname gets "/*", since a blank hostname
+// without paths would prohibit all App Gateway config.
+func getOrphanTargets(ingress *v1beta1.Ingress) []orphanTarget {
+	var targets []orphanTarget
+	for _, rule := range ingress.Spec.Rules {
+		target := orphanTarget{Hostname: rule.Host}
+		wholeHost := rule.HTTP == nil
+		if rule.HTTP != nil {
+			for _, path := range rule.HTTP.Paths {
+				if path.Path == "" || path.Path == "/" || path.Path == "/*" {
+					wholeHost = true
+					break
+				}
+				target.Paths = append(target.Paths, path.Path)
+			}
+		}
+		if wholeHost {
+			target.Paths = nil
+			if target.Hostname == "" {
+				target.Paths = []string{"/*"}
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// getOrphans updates the entries of the ConfigMap with the Ingresses, and returns the names ("namespace/name") and the targets
+// of the entries of the deleted Ingresses. Entries of Ingresses, which no longer have the retain orphan policy, are removed.
+func getOrphans(data map[string]string, ingresses []*v1beta1.Ingress, now time.Time) ([]string, []*ptv1.AzureIngressProhibitedTarget) {
+	existing := make(map[string]interface{})
+	for _, ingress := range ingresses {
+		key := orphanKey(ingress.Namespace, ingress.Name)
+		existing[key] = nil
+		if policy, _ := annotations.OrphanPolicy(ingress); policy != annotations.OrphanPolicyRetain {
+			delete(data, key)
+			continue
+		}
+		record, _ := json.Marshal(orphanRecord{Targets: getOrphanTargets(ingress)})
+		data[key] = string(record)
+	}
+
+	var keys []string
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var names []string
+	var prohibitedTargets []*ptv1.AzureIngressProhibitedTarget
+	for _, key := range keys {
+		if _, exists := existing[key]; exists {
+			continue
+		}
+		var record orphanRecord
+		if err := json.Unmarshal([]byte(data[key]), &record); err != nil {
+			glog.Warningf("Entry %s of ConfigMap %s is not valid, and is ignored: %s", key, k8scontext.OrphanedIngressesConfigMapName, err)
+			continue
+		}
+		if record.OrphanedAt == "" {
+			record.OrphanedAt = now.UTC().Format(time.RFC3339)
+			updated, _ := json.Marshal(record)
+			data[key] = string(updated)
+		}
+		names = append(names, strings.Replace(key, ".", "/", 1))
+		for idx, target := range record.Targets {
+			prohibitedTargets = append(prohibitedTargets, &ptv1.AzureIngressProhibitedTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("orphan-%s-%d", key, idx)},
+				Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: target.Hostname, Paths: target.Paths},
+			})
+		}
+	}
+	return names, prohibitedTargets
+}
+
+// sync updates the ConfigMap with the Ingresses, and returns the deleted Ingresses whose config is retained, with their targets.
+// The entries are read from the cache, which holds the last known state of the ConfigMap; a failed update is retried on the next sync.
+func (s *orphanStore) sync(ingresses []*v1beta1.Ingress) ([]string, []*ptv1.AzureIngressProhibitedTarget) {
+	configMap := s.k8sContext.GetOrphanedIngressesConfigMap()
+	notFound := configMap == nil
+	if notFound {
+		configMap = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: k8scontext.OrphanedIngressesConfigMapName, Namespace: s.namespace}}
+	}
+
+	data := make(map[string]string)
+	for key, value := range configMap.Data {
+		data[key] = value
+	}
+	names, targets := getOrphans(data, ingresses, time.Now())
+	if reflect.DeepEqual(data, configMap.Data) || (notFound && len(data) == 0) {
+		return names, targets
+	}
+
+	// The cache is shared; the update is made on a copy.
+	updated := configMap.DeepCopy()
+	updated.Data = data
+	configMaps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	var err error
+	if notFound {
+		_, err = configMaps.Create(updated)
+	} else {
+		_, err = configMaps.Update(updated)
+	}
+	if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		// The cache did not catch up with the last update yet; the event of the update triggers the next sync.
+		glog.V(3).Infof("ConfigMap %s/%s changed since it was cached; updating it on the next sync", s.namespace, k8scontext.OrphanedIngressesConfigMapName)
+	} else if err != nil {
+		// The config of the deleted Ingresses is retained meanwhile.
+		glog.Errorf("Unable to update ConfigMap %s/%s with the Ingresses with the retain orphan policy: %s", s.namespace, k8scontext.OrphanedIngressesConfigMapName, err)
+	}
+	return names, targets
+}
+
+// getOrphanedIngresses returns the deleted Ingresses whose config is retained, and the targets of their retained config.
+func (c AppGwIngressController) getOrphanedIngresses(ingresses []*v1beta1.Ingress) ([]string, []*ptv1.AzureIngressProhibitedTarget) {
+	if c.orphans == nil {
+		return nil, nil
+	}
+	names, targets := c.orphans.sync(ingresses)
+	if len(names) > 0 {
+		glog.V(3).Infof("Retaining the config of deleted Ingresses [%s]; remove their entries from ConfigMap %s/%s to release it",
+			strings.Join(names, ", "), c.orphans.namespace, k8scontext.OrphanedIngressesConfigMapName)
+	}
+	return names, targets
+}
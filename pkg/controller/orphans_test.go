@@ -0,0 +1,135 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+)
+
+var _ = Describe("Test retaining the config of deleted Ingresses", func() {
+	newIngress := func(name, policy string, rules ...v1beta1.IngressRule) *v1beta1.Ingress {
+		ingress := &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name, Annotations: map[string]string{}},
+			Spec:       v1beta1.IngressSpec{Rules: rules},
+		}
+		if policy != "" {
+			ingress.Annotations[annotations.OrphanPolicyKey] = policy
+		}
+		return ingress
+	}
+	newRule := func(host string, paths ...string) v1beta1.IngressRule {
+		rule := v1beta1.IngressRule{Host: host, IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{}}}
+		for _, path := range paths {
+			rule.HTTP.Paths = append(rule.HTTP.Paths, v1beta1.HTTPIngressPath{Path: path})
+		}
+		return rule
+	}
+
+	Context("test getOrphanTargets", func() {
+		It("should prohibit the paths of each rule, or the whole hostname for a default path", func() {
+			ingress := newIngress("shop", "retain",
+				newRule("shop.com", "/cart/*", "/pay/*"),
+				newRule("www.shop.com", "/api/*", "/"),
+				newRule("", ""),
+			)
+			Expect(getOrphanTargets(ingress)).To(Equal([]orphanTarget{
+				{Hostname: "shop.com", Paths: []string{"/cart/*", "/pay/*"}},
+				{Hostname: "www.shop.com"},
+				{Paths: []string{"/*"}},
+			}))
+		})
+	})
+
+	Context("test orphanStore", func() {
+		var client *testclient.Clientset
+		var store *orphanStore
+
+		BeforeEach(func() {
+			client = testclient.NewSimpleClientset()
+			k8sContext := &k8scontext.Context{Caches: &k8scontext.CacheCollection{OrphanedIngresses: cache.NewStore(cache.MetaNamespaceKeyFunc)}}
+			store = newOrphanStore(client, k8sContext, "agic")
+		})
+
+		// refreshCache stands for the informer of the ConfigMap, catching up with the API server.
+		refreshCache := func() {
+			configMap, err := client.CoreV1().ConfigMaps("agic").Get(k8scontext.OrphanedIngressesConfigMapName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(store.k8sContext.Caches.OrphanedIngresses.Add(configMap)).To(Succeed())
+		}
+
+		It("should record the Ingresses with the retain policy, and retain them once deleted", func() {
+			shop := newIngress("shop", "retain", newRule("shop.com", "/cart/*"))
+			blog := newIngress("blog", "", newRule("blog.com"))
+
+			names, targets := store.sync([]*v1beta1.Ingress{shop, blog})
+			Expect(names).To(BeEmpty())
+			Expect(targets).To(BeEmpty())
+			configMap, err := client.CoreV1().ConfigMaps("agic").Get(k8scontext.OrphanedIngressesConfigMapName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configMap.Data).To(Equal(map[string]string{"web.shop": `{"targets":[{"hostname":"shop.com","paths":["/cart/*"]}]}`}))
+			refreshCache()
+
+			// The Ingress is deleted.
+			names, targets = store.sync([]*v1beta1.Ingress{blog})
+			Expect(names).To(Equal([]string{"web/shop"}))
+			Expect(targets).To(HaveLen(1))
+			Expect(targets[0].Name).To(Equal("orphan-web.shop-0"))
+			Expect(targets[0].Spec).To(Equal(ptv1.AzureIngressProhibitedTargetSpec{Hostname: "shop.com", Paths: []string{"/cart/*"}}))
+			configMap, err = client.CoreV1().ConfigMaps("agic").Get(k8scontext.OrphanedIngressesConfigMapName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configMap.Data["web.shop"]).To(ContainSubstring(`"orphanedAt":`))
+			refreshCache()
+
+			// The entry is removed to release the config.
+			configMap.Data = map[string]string{}
+			_, err = client.CoreV1().ConfigMaps("agic").Update(configMap)
+			Expect(err).ToNot(HaveOccurred())
+			refreshCache()
+			names, targets = store.sync([]*v1beta1.Ingress{blog})
+			Expect(names).To(BeEmpty())
+			Expect(targets).To(BeEmpty())
+		})
+
+		It("should retain the config from the cached ConfigMap when it cannot be updated", func() {
+			shop := newIngress("shop", "retain", newRule("shop.com"))
+			store.sync([]*v1beta1.Ingress{shop})
+			refreshCache()
+			client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("unavailable")
+			})
+
+			names, targets := store.sync(nil)
+			Expect(names).To(Equal([]string{"web/shop"}))
+			Expect(targets).To(HaveLen(1))
+		})
+
+		It("should forget the Ingresses, which no longer have the retain policy", func() {
+			data := map[string]string{"web.shop": `{"targets":[{"hostname":"shop.com"}]}`}
+			names, _ := getOrphans(data, []*v1beta1.Ingress{newIngress("shop", "delete", newRule("shop.com"))}, metav1.Now().Time)
+			Expect(names).To(BeEmpty())
+			Expect(data).To(BeEmpty())
+		})
+
+		It("should not create the ConfigMap when no Ingress has the retain policy", func() {
+			store.sync([]*v1beta1.Ingress{newIngress("blog", "", newRule("blog.com"))})
+			_, err := client.CoreV1().ConfigMaps("agic").Get(k8scontext.OrphanedIngressesConfigMapName, metav1.GetOptions{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
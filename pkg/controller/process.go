@@ -6,7 +6,6 @@
 package controller
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -14,8 +13,11 @@ import (
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
 	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/audit"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
@@ -24,25 +26,70 @@ import (
 // Process is the callback function that will be executed for every event
 // in the EventQueue.
 func (c AppGwIngressController) Process(event events.Event) error {
-	ctx := context.Background()
+	start := time.Now()
+	if c.watchdog != nil {
+		// The ARM requests of the event are made with its own context, so the watchdog can abandon them when it stalls.
+		var done func()
+		c.ctx, done = c.watchdog.startEvent(c.ctx, c.eventTimeout)
+		defer done()
+	}
+	err := c.process(event)
+	c.customMetrics.observeSync(time.Since(start), err)
+	if err != nil {
+		c.health.recordError(err)
+	}
+	return err
+}
+
+func (c AppGwIngressController) process(event events.Event) error {
+	ctx := c.ctx
 
 	// Get current application gateway config
-	appGw, err := c.appGwClient.Get(ctx, c.appGwIdentifier.ResourceGroup, c.appGwIdentifier.AppGwName)
+	appGw, err := c.getAppGw()
 	if err != nil {
 		glog.Errorf("unable to get specified ApplicationGateway [%v], check ApplicationGateway identifier, error=[%v]", c.appGwIdentifier.AppGwName, err.Error())
 		return errors.New("unable to get specified ApplicationGateway")
 	}
+	c.health.observeAppGw(&appGw)
+	c.customMetrics.observeAppGw(&appGw)
 
 	envVars := environment.GetEnv()
 
+	if appGw.ID != nil {
+		c.reconcileDiagnosticSettings(envVars, *appGw.ID)
+	}
+
 	cbCtx := &appgw.ConfigBuilderContext{
 		ServiceList:  c.k8sContext.ListServices(),
 		IngressList:  c.k8sContext.ListHTTPIngresses(),
 		EnvVariables: envVars,
 	}
 
+	if envVars.BackendDefaultsConfigMap != "" {
+		var errs []error
+		cbCtx.BackendDefaults, errs = appgw.ParseBackendDefaults(c.k8sContext.GetBackendDefaults())
+		for _, err := range errs {
+			glog.Warningf("ConfigMap %s/%s: %s", envVars.AGICPodNamespace, envVars.BackendDefaultsConfigMap, err)
+		}
+	}
+
+	// Read before the rules of the Ingresses are pruned below; the hostnames and paths of the Ingresses are recorded as they are.
+	cbCtx.OrphanedIngresses, cbCtx.RetainedTargets = c.getOrphanedIngresses(cbCtx.IngressList)
+
+	// The Ingresses in dry run are left out of the config, and reported once it is generated.
+	var dryRunIngresses []*v1beta1.Ingress
+	cbCtx.IngressList, dryRunIngresses = splitDryRunIngresses(cbCtx.IngressList)
+
 	if envVars.EnableBrownfieldDeployment == "true" {
 		prohibitedTargets := c.k8sContext.ListAzureProhibitedTargets()
+		c.reportProhibitedTargetImpact(event, appGw, prohibitedTargets)
+		if envVars.ProhibitedTargetsConfigMap != "" {
+			configMapTargets, errs := brownfield.ParseProhibitedTargets(c.k8sContext.GetProhibitedTargetsConfigMap())
+			for _, err := range errs {
+				glog.Warningf("ConfigMap %s/%s: %s", envVars.AGICPodNamespace, envVars.ProhibitedTargetsConfigMap, err)
+			}
+			prohibitedTargets = append(prohibitedTargets, configMapTargets...)
+		}
 		if len(prohibitedTargets) > 0 {
 			cbCtx.ProhibitedTargets = prohibitedTargets
 			cbCtx.EnableBrownfieldDeployment = true
@@ -82,6 +129,21 @@ func (c AppGwIngressController) Process(event events.Event) error {
 		return err
 	}
 
+	// Capture the existing config for the audit log; the config builder modifies it.
+	existingSnapshot, err := audit.NewSnapshot(&appGw)
+	if err != nil {
+		glog.Error("Unable to capture the existing App Gateway config for the audit log:", err)
+	}
+
+	// Keep a copy of the existing config, which the config builder modifies, to add the new backends to it first,
+	// and to generate the config of the Ingresses in dry run.
+	var existingAppGw *n.ApplicationGateway
+	if envVars.EnableTwoPhaseApply == "true" || len(dryRunIngresses) > 0 {
+		if existingAppGw, err = copyAppGw(&appGw); err != nil {
+			glog.Error("Unable to copy the existing App Gateway config; applying the config at once, without reporting the dry runs:", err)
+		}
+	}
+
 	// Create a configbuilder based on current appgw config
 	configBuilder := appgw.NewConfigBuilder(c.k8sContext, &c.appGwIdentifier, &appGw, c.recorder)
 
@@ -96,17 +158,48 @@ func (c AppGwIngressController) Process(event events.Event) error {
 		glog.Error("ConfigBuilder Build returned error:", err)
 		return err
 	}
+	c.readiness.markConfigBuilt()
+	c.customMetrics.observeConfig(generatedAppGw)
 
 	// Run post validations to report errors in the config generation.
 	if err = configBuilder.PostBuildValidate(cbCtx); err != nil {
 		glog.Error("ConfigBuilder PostBuildValidate returned error:", err)
 	}
 
+	c.reportDryRuns(cbCtx, dryRunIngresses, existingAppGw, generatedAppGw)
+
 	if c.configIsSame(&appGw) {
 		glog.V(3).Info("cache: Config has NOT changed! No need to connect to ARM.")
+		c.readiness.markConfigApplied()
+		c.health.markSynced(&appGw)
+		c.refreshFrontendIP(envVars, &appGw)
+		c.markKnativeIngressesReady(envVars)
 		return nil
 	}
 
+	// Warn about the App Gateway approaching the limits of its SKU before applying a config, which changes it.
+	for _, warning := range appgw.GetScaleLimitWarnings(generatedAppGw, envVars.ScaleWarningThreshold) {
+		glog.Warning(warning)
+		c.recordAGICPodEvent(envVars, v1.EventTypeWarning, events.ReasonScaleLimitApproaching, warning)
+	}
+
+	if envVars.ObserveOnly == "true" {
+		c.reportObservedChange(event, envVars, existingSnapshot, generatedAppGw)
+		c.updateCache(&appGw)
+		// There is nothing to apply in observe-only mode; reporting the changes is what AGIC is expected to do.
+		c.readiness.markConfigApplied()
+		c.health.markSynced(&appGw)
+		return nil
+	}
+
+	if existingAppGw != nil && envVars.EnableTwoPhaseApply == "true" {
+		var ready bool
+		if existingSnapshot, ready = c.applyTwoPhase(event, existingSnapshot, existingAppGw, generatedAppGw); !ready {
+			// The generated config is applied by the event queued to check the new backends again.
+			return nil
+		}
+	}
+
 	glog.V(3).Info("BEGIN ApplicationGateway deployment")
 	defer glog.V(3).Info("END ApplicationGateway deployment")
 
@@ -115,7 +208,13 @@ func (c AppGwIngressController) Process(event events.Event) error {
 	deploymentStart := time.Now()
 	// Initiate deployment
 	appGwFuture, err := c.appGwClient.CreateOrUpdate(ctx, c.appGwIdentifier.ResourceGroup, c.appGwIdentifier.AppGwName, *generatedAppGw)
+	// The correlation ID of the PUT request identifies the update in the Azure activity log.
+	correlationID := ""
+	if resp := appGwFuture.Response(); resp != nil {
+		correlationID = resp.Header.Get(audit.CorrelationIDHeader)
+	}
 	if err != nil {
+		c.addAuditEntry(event, existingSnapshot, generatedAppGw, correlationID, err)
 		// Reset cache
 		c.configCache = nil
 		configJSON, _ := c.dumpSanitizedJSON(&appGw, logToFile)
@@ -124,6 +223,7 @@ func (c AppGwIngressController) Process(event events.Event) error {
 	}
 	// Wait until deployment finshes and save the error message
 	err = appGwFuture.WaitForCompletionRef(ctx, c.appGwClient.BaseClient.Client)
+	c.addAuditEntry(event, existingSnapshot, generatedAppGw, correlationID, err)
 	configJSON, _ := c.dumpSanitizedJSON(&appGw, logToFile)
 	glog.V(5).Info(string(configJSON))
 
@@ -139,6 +239,36 @@ func (c AppGwIngressController) Process(event events.Event) error {
 
 	glog.V(3).Info("cache: Updated with latest applied config.")
 	c.updateCache(&appGw)
+	if appliedAppGw, err := appGwFuture.Result(c.appGwClient); err != nil {
+		glog.Error("Unable to get the result of the App Gateway update:", err)
+		c.health.markSynced(nil)
+	} else {
+		c.saveLastApplied(&appliedAppGw)
+		c.health.observeAppGw(&appliedAppGw)
+		c.health.markSynced(&appliedAppGw)
+	}
+	c.reconcileNSGRules(envVars, generatedAppGw)
+	c.refreshFrontendIP(envVars, generatedAppGw)
+	c.readiness.markConfigApplied()
+	c.markKnativeIngressesReady(envVars)
 
 	return nil
 }
+
+// addAuditEntry records an App Gateway update, along with the Kubernetes event, which triggered it, in the audit log.
+func (c AppGwIngressController) addAuditEntry(event events.Event, existing audit.Snapshot, generatedAppGw *n.ApplicationGateway, correlationID string, err error) {
+	entry := audit.Entry{
+		Timestamp:     time.Now(),
+		Trigger:       audit.NewObject(event),
+		CorrelationID: correlationID,
+	}
+	if updated, snapshotErr := audit.NewSnapshot(generatedAppGw); snapshotErr != nil {
+		glog.Error("Unable to capture the updated App Gateway config for the audit log:", snapshotErr)
+	} else if existing != nil {
+		entry.Added, entry.Modified, entry.Removed = audit.Diff(existing, updated)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.auditLog.Add(entry)
+}
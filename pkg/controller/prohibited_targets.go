@@ -0,0 +1,82 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// protectedResources remembers the listeners and routing rules, which were protected by prohibited targets
+// when the controller last processed an event. Names are nil until the first event is processed.
+type protectedResources struct {
+	names map[string]interface{}
+}
+
+// reportProhibitedTargetImpact emits an event on the AzureIngressProhibitedTarget, which triggered the given event,
+// listing the existing listeners and routing rules, which became protected or unprotected as a result.
+// It must be given the App Gateway before the config builder modifies it.
+func (c AppGwIngressController) reportProhibitedTargetImpact(event events.Event, appGw n.ApplicationGateway, prohibitedTargets []*ptv1.AzureIngressProhibitedTarget) {
+	if c.protected == nil || appGw.ApplicationGatewayPropertiesFormat == nil {
+		return
+	}
+	current := brownfield.NewExistingResources(appGw, prohibitedTargets, nil).GetBlacklist().ProtectedListenersAndRules()
+	previous := c.protected.names
+	c.protected.names = current
+
+	target := getProhibitedTarget(event.Value)
+	if previous == nil || target == nil {
+		return
+	}
+
+	nowProtected, noLongerProtected := diffNames(previous, current)
+	if len(nowProtected) == 0 && len(noLongerProtected) == 0 {
+		glog.V(3).Infof("%s of AzureIngressProhibitedTarget %s/%s did not change which listeners and rules are protected",
+			events.EventTypeLookup[event.Type], target.Namespace, target.Name)
+		return
+	}
+
+	message := fmt.Sprintf("%s of AzureIngressProhibitedTarget %s/%s: now protected [%s]; no longer protected [%s]",
+		events.EventTypeLookup[event.Type], target.Namespace, target.Name, strings.Join(nowProtected, ", "), strings.Join(noLongerProtected, ", "))
+	glog.Info(message)
+	c.recorder.Event(target, v1.EventTypeNormal, events.ReasonProhibitedTargetImpact, message)
+}
+
+// getProhibitedTarget returns the AzureIngressProhibitedTarget carried by an event, or nil for other objects.
+func getProhibitedTarget(obj interface{}) *ptv1.AzureIngressProhibitedTarget {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	target, _ := obj.(*ptv1.AzureIngressProhibitedTarget)
+	return target
+}
+
+// diffNames returns the sorted names, which are only in the current set, and the ones only in the previous set.
+func diffNames(previous, current map[string]interface{}) (added, removed []string) {
+	for name := range current {
+		if _, exists := previous[name]; !exists {
+			added = append(added, name)
+		}
+	}
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
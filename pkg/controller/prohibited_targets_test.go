@@ -0,0 +1,66 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
+)
+
+var _ = Describe("Test the impact of prohibited targets", func() {
+	appGw := fixtures.GetAppGateway()
+	prohibitedTargets := fixtures.GetAzureIngressProhibitedTargets()
+
+	var controller AppGwIngressController
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(100)
+		controller = AppGwIngressController{
+			recorder:  recorder,
+			protected: &protectedResources{},
+		}
+	})
+
+	It("should not emit events for the first event processed", func() {
+		event := events.Event{Type: events.Create, Value: prohibitedTargets[0]}
+		controller.reportProhibitedTargetImpact(event, appGw, prohibitedTargets)
+		Expect(controller.protected.names).ToNot(BeEmpty())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should list the listeners and rules protected by a new prohibited target", func() {
+		controller.reportProhibitedTargetImpact(events.Event{Type: events.Update}, appGw, nil)
+		event := events.Event{Type: events.Create, Value: prohibitedTargets[0]}
+		controller.reportProhibitedTargetImpact(event, appGw, prohibitedTargets)
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(events.ReasonProhibitedTargetImpact),
+			ContainSubstring("now protected [httpListeners/"),
+			ContainSubstring("no longer protected []"))))
+	})
+
+	It("should list the listeners and rules no longer protected by a deleted prohibited target", func() {
+		controller.reportProhibitedTargetImpact(events.Event{Type: events.Update}, appGw, prohibitedTargets)
+		event := events.Event{Type: events.Delete, Value: cache.DeletedFinalStateUnknown{Obj: prohibitedTargets[0]}}
+		controller.reportProhibitedTargetImpact(event, appGw, []*ptv1.AzureIngressProhibitedTarget{})
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring("now protected []"),
+			ContainSubstring("no longer protected [httpListeners/"))))
+	})
+
+	It("should not emit events when nothing changed", func() {
+		controller.reportProhibitedTargetImpact(events.Event{Type: events.Update}, appGw, prohibitedTargets)
+		event := events.Event{Type: events.Update, Value: prohibitedTargets[0]}
+		controller.reportProhibitedTargetImpact(event, appGw, prohibitedTargets)
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
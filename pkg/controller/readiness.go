@@ -0,0 +1,61 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// readiness tracks the progress of the controller towards a working App Gateway config.
+// Its flags are set by the worker and read by the HTTP server, hence atomic.
+type readiness struct {
+	informersSynced int32
+	configBuilt     int32
+	configApplied   int32
+}
+
+func (r *readiness) markInformersSynced() {
+	atomic.StoreInt32(&r.informersSynced, 1)
+}
+
+func (r *readiness) markConfigBuilt() {
+	atomic.StoreInt32(&r.configBuilt, 1)
+}
+
+func (r *readiness) markConfigApplied() {
+	atomic.StoreInt32(&r.configApplied, 1)
+}
+
+func (r *readiness) informersHaveSynced() bool {
+	return atomic.LoadInt32(&r.informersSynced) == 1
+}
+
+// notReadyReason returns why the controller is not ready, or an empty string when it is.
+func (r *readiness) notReadyReason(requireApply bool) string {
+	if atomic.LoadInt32(&r.informersSynced) == 0 {
+		return "Kubernetes informers have not synced yet"
+	}
+	if atomic.LoadInt32(&r.configBuilt) == 0 {
+		return "no App Gateway config has been built yet"
+	}
+	if requireApply && atomic.LoadInt32(&r.configApplied) == 0 {
+		return "no App Gateway config has been applied yet"
+	}
+	return ""
+}
+
+// ReadinessHandler serves /readyz: it fails until the informers synced and the first App Gateway config was built,
+// and, with requireApply, successfully applied to the App Gateway. Once ready, the controller stays ready.
+func (c *AppGwIngressController) ReadinessHandler(requireApply bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reason := c.readiness.notReadyReason(requireApply); reason != "" {
+			http.Error(w, reason, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
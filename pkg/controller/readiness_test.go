@@ -0,0 +1,49 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test the readiness endpoint", func() {
+	var controller *AppGwIngressController
+
+	readyz := func(requireApply bool) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		controller.ReadinessHandler(requireApply).ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+		return recorder
+	}
+
+	BeforeEach(func() {
+		controller = &AppGwIngressController{readiness: &readiness{}}
+	})
+
+	It("should fail until the informers synced and a config was built", func() {
+		Expect(readyz(false).Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(readyz(false).Body.String()).To(ContainSubstring("informers have not synced"))
+
+		controller.readiness.markInformersSynced()
+		Expect(readyz(false).Body.String()).To(ContainSubstring("no App Gateway config has been built"))
+
+		controller.readiness.markConfigBuilt()
+		Expect(readyz(false).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should fail until a config was applied when required", func() {
+		controller.readiness.markInformersSynced()
+		controller.readiness.markConfigBuilt()
+		Expect(readyz(true).Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(readyz(true).Body.String()).To(ContainSubstring("no App Gateway config has been applied"))
+
+		controller.readiness.markConfigApplied()
+		Expect(readyz(true).Code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,55 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/doctor"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// ValidateSubnetPeriodically checks the subnet of the App Gateway right away, then at every interval until the controller stops.
+// The problems found are logged and emitted as events of the AGIC Pod, with hints on how to fix them.
+func (c *AppGwIngressController) ValidateSubnetPeriodically(envVariables environment.EnvVariables, interval time.Duration) {
+	subnetsClient := n.NewSubnetsClient(c.appGwIdentifier.SubscriptionID)
+	subnetsClient.Authorizer = c.appGwClient.Authorizer
+	routeTablesClient := n.NewRouteTablesClient(c.appGwIdentifier.SubscriptionID)
+	routeTablesClient.Authorizer = c.appGwClient.Authorizer
+
+	wait.Until(func() {
+		c.validateSubnet(envVariables, subnetsClient, routeTablesClient)
+	}, interval, c.stopChannel)
+}
+
+// validateSubnet reports the problems of the subnet of the App Gateway; failing to get the subnet is only logged.
+func (c *AppGwIngressController) validateSubnet(envVariables environment.EnvVariables, subnetsClient n.SubnetsClient, routeTablesClient n.RouteTablesClient) {
+	appGw, err := c.getAppGw()
+	if err != nil {
+		glog.Error("Unable to get App Gateway to validate its subnet: ", err)
+		return
+	}
+	subnetID, subnet, routeTable, err := doctor.GetGatewaySubnet(c.ctx, subnetsClient, routeTablesClient, appGw)
+	if err != nil {
+		glog.Error("Unable to validate the subnet of the App Gateway: ", err)
+		return
+	}
+
+	for _, finding := range doctor.CheckSubnet(subnetID, subnet, routeTable, appGw) {
+		if finding.Severity == doctor.SeverityOK {
+			glog.V(3).Info(finding.Message)
+			continue
+		}
+		glog.Warning(finding.Message)
+		c.recordAGICPodEvent(envVariables, v1.EventTypeWarning, events.ReasonSubnetMisconfigured, finding.Message)
+	}
+}
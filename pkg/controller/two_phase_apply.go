@@ -0,0 +1,390 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/audit"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+const (
+	// DefaultTwoPhaseApplyTimeout is how long AGIC waits for the new backends to be healthy, before applying the generated config anyway.
+	DefaultTwoPhaseApplyTimeout = 5 * time.Minute
+
+	// twoPhaseHealthInterval is how often the health of the new backends is checked.
+	twoPhaseHealthInterval = 10 * time.Second
+)
+
+// twoPhaseApply tracks the backends, which an intermediate config added, until the App Gateway reports them healthy. Rather than
+// waiting for them, the worker checks their health once per event, and a timer queues an event for the next check. It is used
+// by the worker and by the timer, hence the mutex.
+type twoPhaseApply struct {
+	mutex   sync.Mutex
+	timeout time.Duration
+
+	// pending are the added addresses, which are not yet healthy; deadline is when the generated config is applied regardless.
+	pending  []string
+	deadline time.Time
+
+	// check queues the next check; nil when none is queued.
+	check *time.Timer
+}
+
+func newTwoPhaseApply(timeout time.Duration) *twoPhaseApply {
+	return &twoPhaseApply{timeout: timeout}
+}
+
+// stage returns the addresses to wait for, and until when: the new addresses, and the ones still pending, which the generated
+// config routes to. The addresses of an earlier intermediate config, which the generated config replaced meanwhile, are dropped.
+func (t *twoPhaseApply) stage(newAddresses []string, routedAddresses map[string]bool, now time.Time) ([]string, time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	pending := append([]string{}, newAddresses...)
+	seen := make(map[string]bool)
+	for _, address := range newAddresses {
+		seen[address] = true
+	}
+	for _, address := range t.pending {
+		if routedAddresses[address] && !seen[address] {
+			pending = append(pending, address)
+		}
+	}
+	if len(pending) == 0 {
+		t.pending, t.deadline = nil, time.Time{}
+		return nil, time.Time{}
+	}
+	if t.deadline.IsZero() {
+		t.deadline = now.Add(t.timeout)
+	}
+	t.pending = pending
+	return pending, t.deadline
+}
+
+// wait records the addresses still pending, and queues the next check.
+func (t *twoPhaseApply) wait(pending []string, queue func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.pending = pending
+	if t.check != nil {
+		t.check.Stop()
+	}
+	t.check = time.AfterFunc(twoPhaseHealthInterval, queue)
+}
+
+// done forgets the pending addresses, once the generated config may be applied.
+func (t *twoPhaseApply) done() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.pending, t.deadline = nil, time.Time{}
+	if t.check != nil {
+		t.check.Stop()
+		t.check = nil
+	}
+}
+
+// copyAppGw returns a deep copy of the App Gateway, which the config builder does not modify.
+func copyAppGw(appGw *n.ApplicationGateway) (*n.ApplicationGateway, error) {
+	appGwJSON, err := json.Marshal(appGw)
+	if err != nil {
+		return nil, err
+	}
+	var copied n.ApplicationGateway
+	if err := json.Unmarshal(appGwJSON, &copied); err != nil {
+		return nil, err
+	}
+	copied.Name = appGw.Name
+	return &copied, nil
+}
+
+// getIntermediateConfig returns the existing config, with the backend pools, HTTP settings and probes of the generated config added
+// to it, and the new addresses added to the existing pools. Rules and listeners are left as they are, so traffic keeps going to the
+// existing backends while the new ones are staged, and the App Gateway probes the new addresses of the pools in use. The generated
+// config then routes to the new pools and settings, and removes the replaced ones. It also returns the new addresses of the pools in
+// use, which are waited for. Returns nil when the generated config adds nothing, since there is then nothing to stage.
+func getIntermediateConfig(existing, generated *n.ApplicationGateway) (*n.ApplicationGateway, []string) {
+	if existing.ApplicationGatewayPropertiesFormat == nil || generated.ApplicationGatewayPropertiesFormat == nil ||
+		existing.BackendAddressPools == nil || generated.BackendAddressPools == nil {
+		return nil, nil
+	}
+
+	poolsInUse := getPoolsInUse(existing)
+	pools := append([]n.ApplicationGatewayBackendAddressPool{}, *existing.BackendAddressPools...)
+	poolIndex := make(map[string]int)
+	for idx, pool := range pools {
+		poolIndex[nameOf(pool.Name)] = idx
+	}
+
+	staged := false
+	var newAddresses []string
+	for _, pool := range *generated.BackendAddressPools {
+		idx, exists := poolIndex[nameOf(pool.Name)]
+		if !exists {
+			// The App Gateway does not probe the pools no rule uses yet; they are staged, but not waited for.
+			pools = append(pools, pool)
+			staged = true
+			continue
+		}
+		added := getAddedAddresses(pools[idx], pool)
+		if len(added) == 0 {
+			continue
+		}
+		merged := pools[idx]
+		properties := *merged.ApplicationGatewayBackendAddressPoolPropertiesFormat
+		addresses := append(append([]n.ApplicationGatewayBackendAddress{}, *properties.BackendAddresses...), added...)
+		properties.BackendAddresses = &addresses
+		merged.ApplicationGatewayBackendAddressPoolPropertiesFormat = &properties
+		pools[idx] = merged
+		staged = true
+		if poolsInUse[strings.ToLower(nameOf(merged.ID))] {
+			for _, address := range added {
+				newAddresses = append(newAddresses, addressOf(address))
+			}
+		}
+	}
+
+	intermediate := *existing
+	properties := *existing.ApplicationGatewayPropertiesFormat
+	properties.BackendAddressPools = &pools
+	if existing.Probes != nil && generated.Probes != nil {
+		probes := append([]n.ApplicationGatewayProbe{}, *existing.Probes...)
+		for _, probe := range *generated.Probes {
+			if !hasProbe(probes, nameOf(probe.Name)) {
+				probes = append(probes, probe)
+				staged = true
+			}
+		}
+		properties.Probes = &probes
+	}
+	if existing.BackendHTTPSettingsCollection != nil && generated.BackendHTTPSettingsCollection != nil {
+		settingsCollection := append([]n.ApplicationGatewayBackendHTTPSettings{}, *existing.BackendHTTPSettingsCollection...)
+		for _, settings := range *generated.BackendHTTPSettingsCollection {
+			if !hasHTTPSettings(settingsCollection, nameOf(settings.Name)) {
+				settingsCollection = append(settingsCollection, settings)
+				staged = true
+			}
+		}
+		properties.BackendHTTPSettingsCollection = &settingsCollection
+	}
+	if !staged {
+		return nil, nil
+	}
+	intermediate.ApplicationGatewayPropertiesFormat = &properties
+	return &intermediate, newAddresses
+}
+
+// getRoutedAddresses returns the addresses of the backend pools, which the routing rules of the App Gateway use.
+func getRoutedAddresses(appGw *n.ApplicationGateway) map[string]bool {
+	routed := make(map[string]bool)
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.BackendAddressPools == nil {
+		return routed
+	}
+	poolsInUse := getPoolsInUse(appGw)
+	for _, pool := range *appGw.BackendAddressPools {
+		if !poolsInUse[strings.ToLower(nameOf(pool.ID))] || pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || pool.BackendAddresses == nil {
+			continue
+		}
+		for _, address := range *pool.BackendAddresses {
+			routed[addressOf(address)] = true
+		}
+	}
+	return routed
+}
+
+// getPoolsInUse returns the lower case IDs of the backend pools, which request routing rules and URL path maps route to.
+func getPoolsInUse(appGw *n.ApplicationGateway) map[string]bool {
+	inUse := make(map[string]bool)
+	add := func(pool *n.SubResource) {
+		if pool != nil && pool.ID != nil {
+			inUse[strings.ToLower(*pool.ID)] = true
+		}
+	}
+	if appGw.RequestRoutingRules != nil {
+		for _, rule := range *appGw.RequestRoutingRules {
+			if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat != nil {
+				add(rule.BackendAddressPool)
+			}
+		}
+	}
+	if appGw.URLPathMaps != nil {
+		for _, pathMap := range *appGw.URLPathMaps {
+			if pathMap.ApplicationGatewayURLPathMapPropertiesFormat == nil {
+				continue
+			}
+			add(pathMap.DefaultBackendAddressPool)
+			if pathMap.PathRules == nil {
+				continue
+			}
+			for _, pathRule := range *pathMap.PathRules {
+				if pathRule.ApplicationGatewayPathRulePropertiesFormat != nil {
+					add(pathRule.BackendAddressPool)
+				}
+			}
+		}
+	}
+	return inUse
+}
+
+// getAddedAddresses returns the addresses of the generated pool, which the existing pool does not have.
+func getAddedAddresses(existing, generated n.ApplicationGatewayBackendAddressPool) []n.ApplicationGatewayBackendAddress {
+	if generated.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || generated.BackendAddresses == nil ||
+		existing.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || existing.BackendAddresses == nil {
+		return nil
+	}
+	existingAddresses := make(map[string]interface{})
+	for _, address := range *existing.BackendAddresses {
+		existingAddresses[addressOf(address)] = nil
+	}
+	var added []n.ApplicationGatewayBackendAddress
+	for _, address := range *generated.BackendAddresses {
+		if _, exists := existingAddresses[addressOf(address)]; !exists {
+			added = append(added, address)
+		}
+	}
+	return added
+}
+
+// getPendingBackends returns the addresses, which the App Gateway does not yet report healthy with all the HTTP settings probing them.
+func getPendingBackends(health n.ApplicationGatewayBackendHealth, addresses []string) []string {
+	reported := make(map[string]bool)
+	if health.BackendAddressPools != nil {
+		for _, pool := range *health.BackendAddressPools {
+			if pool.BackendHTTPSettingsCollection == nil {
+				continue
+			}
+			for _, settingsHealth := range *pool.BackendHTTPSettingsCollection {
+				if settingsHealth.Servers == nil {
+					continue
+				}
+				for _, server := range *settingsHealth.Servers {
+					if server.Address == nil {
+						continue
+					}
+					healthy, seen := reported[*server.Address]
+					reported[*server.Address] = (healthy || !seen) && server.Health == n.Up
+				}
+			}
+		}
+	}
+	var pending []string
+	for _, address := range addresses {
+		if !reported[address] {
+			pending = append(pending, address)
+		}
+	}
+	return pending
+}
+
+// applyTwoPhase stages the new backends with an intermediate config, and returns whether the generated config may be applied: once
+// the App Gateway reports the new backends healthy, or past the timeout, so a backend which never becomes healthy does not block
+// updates. Otherwise the next check is queued, and the worker moves on to other events meanwhile. The returned snapshot is the one
+// the audit entry of the generated config is compared with.
+func (c AppGwIngressController) applyTwoPhase(event events.Event, existingSnapshot audit.Snapshot, existing, generated *n.ApplicationGateway) (audit.Snapshot, bool) {
+	if c.twoPhase == nil {
+		return existingSnapshot, true
+	}
+	intermediate, newAddresses := getIntermediateConfig(existing, generated)
+	pending, deadline := c.twoPhase.stage(newAddresses, getRoutedAddresses(generated), time.Now())
+	if intermediate != nil {
+		snapshot, err := c.applyIntermediateConfig(event, existingSnapshot, intermediate, newAddresses)
+		if err != nil {
+			glog.Warning("Unable to stage the new backends before the App Gateway config; applying the config at once: ", err)
+			c.twoPhase.done()
+			return existingSnapshot, true
+		}
+		existingSnapshot = snapshot
+	}
+	if len(pending) == 0 {
+		return existingSnapshot, true
+	}
+	if time.Now().After(deadline) {
+		glog.Warningf("Backends [%s] are not healthy after %s; applying the App Gateway config anyway", strings.Join(pending, ", "), c.twoPhase.timeout)
+		c.twoPhase.done()
+		return existingSnapshot, true
+	}
+
+	if health, err := c.getBackendHealth(); err != nil {
+		glog.Warning("Unable to get the health of the new backends: ", err)
+	} else {
+		pending = getPendingBackends(health, pending)
+	}
+	if len(pending) == 0 {
+		c.twoPhase.done()
+		return existingSnapshot, true
+	}
+	glog.V(3).Infof("Waiting for backends [%s] to be healthy before applying the App Gateway config", strings.Join(pending, ", "))
+	c.twoPhase.wait(pending, func() {
+		c.k8sContext.UpdateChannel.In() <- events.Event{Type: events.Update}
+	})
+	return existingSnapshot, false
+}
+
+// applyIntermediateConfig applies the intermediate config, and returns its snapshot.
+func (c AppGwIngressController) applyIntermediateConfig(event events.Event, existingSnapshot audit.Snapshot, intermediate *n.ApplicationGateway, newAddresses []string) (audit.Snapshot, error) {
+	glog.V(3).Infof("Staging new backends [%s] before routing traffic to them", strings.Join(newAddresses, ", "))
+	appGwFuture, err := c.appGwClient.CreateOrUpdate(c.ctx, c.appGwIdentifier.ResourceGroup, c.appGwIdentifier.AppGwName, *intermediate)
+	correlationID := ""
+	if resp := appGwFuture.Response(); resp != nil {
+		correlationID = resp.Header.Get(audit.CorrelationIDHeader)
+	}
+	if err == nil {
+		err = appGwFuture.WaitForCompletionRef(c.ctx, c.appGwClient.BaseClient.Client)
+	}
+	c.addAuditEntry(event, existingSnapshot, intermediate, correlationID, err)
+	if err != nil {
+		return existingSnapshot, err
+	}
+	// The App Gateway now has the intermediate config; a generated config equal to the one applied before still has to be applied.
+	if c.configCache != nil {
+		c.updateCache(intermediate)
+	}
+
+	intermediateSnapshot, err := audit.NewSnapshot(intermediate)
+	if err != nil {
+		glog.Error("Unable to capture the intermediate App Gateway config for the audit log:", err)
+		return existingSnapshot, nil
+	}
+	return intermediateSnapshot, nil
+}
+
+func nameOf(name *string) string {
+	if name == nil {
+		return ""
+	}
+	return *name
+}
+
+func addressOf(address n.ApplicationGatewayBackendAddress) string {
+	if address.IPAddress != nil {
+		return *address.IPAddress
+	}
+	return nameOf(address.Fqdn)
+}
+
+func hasProbe(probes []n.ApplicationGatewayProbe, name string) bool {
+	for _, probe := range probes {
+		if nameOf(probe.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasHTTPSettings(settingsCollection []n.ApplicationGatewayBackendHTTPSettings, name string) bool {
+	for _, settings := range settingsCollection {
+		if nameOf(settings.Name) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test applying the new backends before the App Gateway config", func() {
+	const poolID = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.Network/applicationGateways/--appgw--/backendAddressPools/"
+
+	newPool := func(name string, addresses ...string) n.ApplicationGatewayBackendAddressPool {
+		var backendAddresses []n.ApplicationGatewayBackendAddress
+		for _, address := range addresses {
+			backendAddresses = append(backendAddresses, n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr(address)})
+		}
+		return n.ApplicationGatewayBackendAddressPool{
+			Name: to.StringPtr(name),
+			ID:   to.StringPtr(poolID + name),
+			ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+				BackendAddresses: &backendAddresses,
+			},
+		}
+	}
+	newAppGw := func(pools []n.ApplicationGatewayBackendAddressPool, settings ...string) *n.ApplicationGateway {
+		var settingsCollection []n.ApplicationGatewayBackendHTTPSettings
+		for _, name := range settings {
+			settingsCollection = append(settingsCollection, n.ApplicationGatewayBackendHTTPSettings{Name: to.StringPtr(name)})
+		}
+		return &n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				BackendAddressPools:           &pools,
+				BackendHTTPSettingsCollection: &settingsCollection,
+				Probes:                        &[]n.ApplicationGatewayProbe{},
+				RequestRoutingRules: &[]n.ApplicationGatewayRequestRoutingRule{{
+					Name: to.StringPtr("rule"),
+					ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+						BackendAddressPool: &n.SubResource{ID: to.StringPtr(poolID + "pool-web")},
+					},
+				}},
+			},
+		}
+	}
+	addressesOf := func(pool n.ApplicationGatewayBackendAddressPool) []string {
+		var addresses []string
+		for _, address := range *pool.BackendAddresses {
+			addresses = append(addresses, *address.IPAddress)
+		}
+		return addresses
+	}
+
+	Context("test getIntermediateConfig", func() {
+		It("should add the new addresses to the pools in use, and keep the replaced ones", func() {
+			existing := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1", "10.0.0.2")}, "settings-web")
+			generated := newAppGw([]n.ApplicationGatewayBackendAddressPool{
+				newPool("pool-web", "10.0.0.2", "10.0.0.3"),
+				newPool("pool-api", "10.0.1.1"),
+			}, "settings-web", "settings-api")
+
+			intermediate, newAddresses := getIntermediateConfig(existing, generated)
+			Expect(newAddresses).To(Equal([]string{"10.0.0.3"}))
+			pools := *intermediate.BackendAddressPools
+			Expect(pools).To(HaveLen(2))
+			Expect(addressesOf(pools[0])).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}))
+			Expect(addressesOf(pools[1])).To(Equal([]string{"10.0.1.1"}))
+			Expect(*intermediate.BackendHTTPSettingsCollection).To(HaveLen(2))
+			Expect(intermediate.RequestRoutingRules).To(Equal(existing.RequestRoutingRules))
+
+			// The existing config is not modified.
+			Expect(addressesOf((*existing.BackendAddressPools)[0])).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+			Expect(*existing.BackendHTTPSettingsCollection).To(HaveLen(1))
+		})
+
+		It("should stage, but not wait for, the addresses of pools no rule uses", func() {
+			existing := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1"), newPool("pool-unused", "10.0.2.1")})
+			generated := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1"), newPool("pool-unused", "10.0.2.2")})
+
+			intermediate, newAddresses := getIntermediateConfig(existing, generated)
+			Expect(intermediate).ToNot(BeNil())
+			Expect(addressesOf((*intermediate.BackendAddressPools)[1])).To(Equal([]string{"10.0.2.1", "10.0.2.2"}))
+			Expect(newAddresses).To(BeEmpty())
+		})
+
+		It("should stage the new pools and settings without routing to them", func() {
+			existing := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1")}, "settings-web")
+			generated := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web-8080", "10.0.0.1")}, "settings-web-8080")
+
+			intermediate, newAddresses := getIntermediateConfig(existing, generated)
+			Expect(newAddresses).To(BeEmpty())
+			Expect(*intermediate.BackendAddressPools).To(HaveLen(2))
+			Expect(*intermediate.BackendHTTPSettingsCollection).To(HaveLen(2))
+			Expect(intermediate.RequestRoutingRules).To(Equal(existing.RequestRoutingRules))
+		})
+
+		It("should have nothing to apply first when addresses are only removed", func() {
+			existing := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1", "10.0.0.2")})
+			generated := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1")})
+
+			intermediate, _ := getIntermediateConfig(existing, generated)
+			Expect(intermediate).To(BeNil())
+		})
+	})
+
+	Context("test twoPhaseApply", func() {
+		It("should wait for the new addresses, and the pending ones still routed to, until the deadline", func() {
+			now := time.Now()
+			twoPhase := newTwoPhaseApply(time.Minute)
+			pending, deadline := twoPhase.stage([]string{"10.0.0.2", "10.0.0.3"}, nil, now)
+			Expect(pending).To(Equal([]string{"10.0.0.2", "10.0.0.3"}))
+			Expect(deadline).To(Equal(now.Add(time.Minute)))
+			twoPhase.wait([]string{"10.0.0.3"}, func() {})
+
+			// The generated config replaced 10.0.0.2 meanwhile; the deadline of the staged addresses is kept.
+			pending, deadline = twoPhase.stage([]string{"10.0.0.4"}, map[string]bool{"10.0.0.3": true, "10.0.0.4": true}, now.Add(30*time.Second))
+			Expect(pending).To(Equal([]string{"10.0.0.4", "10.0.0.3"}))
+			Expect(deadline).To(Equal(now.Add(time.Minute)))
+
+			twoPhase.done()
+			pending, _ = twoPhase.stage(nil, map[string]bool{"10.0.0.3": true}, now)
+			Expect(pending).To(BeEmpty())
+		})
+
+		It("should only find the addresses of the pools in use routed to", func() {
+			appGw := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1"), newPool("pool-unused", "10.0.2.1")})
+			Expect(getRoutedAddresses(appGw)).To(Equal(map[string]bool{"10.0.0.1": true}))
+		})
+	})
+
+	Context("test getPendingBackends", func() {
+		newHealth := func(servers ...n.ApplicationGatewayBackendHealthServer) n.ApplicationGatewayBackendHealth {
+			return n.ApplicationGatewayBackendHealth{BackendAddressPools: &[]n.ApplicationGatewayBackendHealthPool{{
+				BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHealthHTTPSettings{{Servers: &servers}},
+			}}}
+		}
+
+		It("should report the addresses which are not up, or not probed yet", func() {
+			health := newHealth(
+				n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.0.0.1"), Health: n.Up},
+				n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.0.0.2"), Health: n.Unknown},
+				n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.0.0.3"), Health: n.Up},
+				n.ApplicationGatewayBackendHealthServer{Address: to.StringPtr("10.0.0.3"), Health: n.Down},
+			)
+			Expect(getPendingBackends(health, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"})).To(Equal([]string{"10.0.0.2", "10.0.0.3", "10.0.0.4"}))
+		})
+	})
+
+	Context("test copyAppGw", func() {
+		It("should not share the sub-resources with the copied App Gateway", func() {
+			appGw := newAppGw([]n.ApplicationGatewayBackendAddressPool{newPool("pool-web", "10.0.0.1")})
+			appGw.Name = to.StringPtr("--appgw--")
+			copied, err := copyAppGw(appGw)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*copied.Name).To(Equal("--appgw--"))
+
+			(*appGw.BackendAddressPools)[0] = newPool("pool-web", "10.0.0.2")
+			Expect(addressesOf((*copied.BackendAddressPools)[0])).To(Equal([]string{"10.0.0.1"}))
+		})
+	})
+})
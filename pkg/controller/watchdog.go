@@ -0,0 +1,148 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// watchdogRecoveriesMetric counts the informers and worker loops the watchdog recovered.
+const watchdogRecoveriesMetric = "watchdog_recoveries_total"
+
+// watchdog tracks the event the worker processes, so its ARM requests can be abandoned, and counts the recoveries.
+// It is used by the worker, the watchdog and the HTTP server, hence the mutex.
+type watchdog struct {
+	mutex sync.Mutex
+
+	// cancel abandons the ARM requests of the event in progress; nil when no event is processed.
+	cancel context.CancelFunc
+
+	// abandoned is when the event, whose ARM requests were abandoned, started to be processed; the loop of the worker is
+	// restarted when it still processes that event at the next check.
+	abandoned time.Time
+
+	// recoveries counts the recoveries by their Prometheus labels, as in `component="informer",resource="pods"`.
+	recoveries map[string]int
+}
+
+func newWatchdog() *watchdog {
+	return &watchdog{recoveries: make(map[string]int)}
+}
+
+// startEvent returns the context of the ARM requests of an event, and the function to call once the event is processed.
+// The requests are cancelled past the timeout, unless zero, so a stalled event ends even when the watchdog does not run.
+func (w *watchdog) startEvent(ctx context.Context, timeout time.Duration) (context.Context, func()) {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.cancel = cancel
+	return ctx, func() {
+		cancel()
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		w.cancel = nil
+	}
+}
+
+// abandon cancels the ARM requests of the event, which started to be processed at the given time; it returns false when
+// they were already abandoned.
+func (w *watchdog) abandon(processingSince time.Time) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.abandoned.Equal(processingSince) {
+		return false
+	}
+	w.abandoned = processingSince
+	if w.cancel != nil {
+		w.cancel()
+	}
+	return true
+}
+
+func (w *watchdog) countRecovery(labels string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.recoveries[labels]++
+}
+
+// write writes the count of the recoveries in the Prometheus text format.
+func (w *watchdog) write(out io.Writer) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, _ = fmt.Fprintf(out, "# HELP %s Stalled informers and worker loops the watchdog restarted, and events it abandoned.\n", watchdogRecoveriesMetric)
+	_, _ = fmt.Fprintf(out, "# TYPE %s counter\n", watchdogRecoveriesMetric)
+	var labels []string
+	for label := range w.recoveries {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		_, _ = fmt.Fprintf(out, "%s{%s} %d\n", watchdogRecoveriesMetric, label, w.recoveries[label])
+	}
+}
+
+// RunWatchdog checks at every interval, until the controller stops, that the informers follow the API server and that the
+// worker processes no event for longer than the stalled event timeout. A stalled informer is restarted. The ARM requests of
+// a stalled event are abandoned; when the event still does not end by the next check, the loop of the worker is restarted,
+// and the new loop processes the pending events while the stalled one, whose ARM requests all fail, ends in the background.
+// Each recovery is counted on /metrics and emitted as an event of the AGIC Pod.
+func (c *AppGwIngressController) RunWatchdog(envVariables environment.EnvVariables, interval, stalledEventTimeout time.Duration) {
+	wait.Until(func() {
+		// The informers are only compared with the API server once they synced.
+		if !c.readiness.informersHaveSynced() {
+			return
+		}
+		for _, resource := range c.k8sContext.RecoverStalledInformers() {
+			c.recordRecovery(envVariables, fmt.Sprintf(`component="informer",resource="%s"`, resource),
+				fmt.Sprintf("The informer of %s missed changes to the cluster; it was restarted", resource))
+		}
+		c.recoverStalledWorker(envVariables, stalledEventTimeout, time.Now())
+	}, interval, c.stopChannel)
+}
+
+// recoverStalledWorker abandons the ARM requests of the event the worker processes for longer than the timeout, or restarts
+// the loop of the worker when the event did not end since they were abandoned.
+func (c *AppGwIngressController) recoverStalledWorker(envVariables environment.EnvVariables, stalledEventTimeout time.Duration, now time.Time) {
+	processingSince := c.worker.ProcessingSince()
+	if processingSince.IsZero() || now.Sub(processingSince) < stalledEventTimeout {
+		return
+	}
+
+	processingFor := now.Sub(processingSince).Round(time.Second)
+	pending := c.k8sContext.UpdateChannel.Len()
+	if c.watchdog.abandon(processingSince) {
+		c.recordRecovery(envVariables, `component="event"`,
+			fmt.Sprintf("The worker has processed an event for %s, with %d events pending; its App Gateway requests were abandoned", processingFor, pending))
+		return
+	}
+	c.worker.Restart()
+	c.recordRecovery(envVariables, `component="worker"`,
+		fmt.Sprintf("The worker has processed an event for %s, with %d events pending, and it did not end once its App Gateway requests were abandoned; the worker was restarted", processingFor, pending))
+}
+
+// recordRecovery counts the recovery, logs it and emits it as an event of the AGIC Pod.
+func (c *AppGwIngressController) recordRecovery(envVariables environment.EnvVariables, labels, message string) {
+	c.watchdog.countRecovery(labels)
+	glog.Warning(message)
+	c.recordAGICPodEvent(envVariables, v1.EventTypeWarning, events.ReasonWatchdogRecovery, message)
+}
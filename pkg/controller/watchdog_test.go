@@ -0,0 +1,129 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	"github.com/eapache/channels"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
+)
+
+var _ = Describe("Test the watchdog", func() {
+	Context("test watchdog", func() {
+		It("should abandon the ARM requests of the event in progress once", func() {
+			w := newWatchdog()
+			ctx, done := w.startEvent(context.Background(), 0)
+			since := time.Now()
+			Expect(w.abandon(since)).To(BeTrue())
+			Expect(ctx.Err()).To(Equal(context.Canceled))
+			Expect(w.abandon(since)).To(BeFalse())
+			done()
+
+			// Abandoning an event, which ended, cancels nothing.
+			_, done = w.startEvent(context.Background(), 0)
+			done()
+			Expect(w.abandon(since.Add(time.Minute))).To(BeTrue())
+		})
+
+		It("should cancel the ARM requests of an event past the timeout", func() {
+			w := newWatchdog()
+			ctx, done := w.startEvent(context.Background(), time.Millisecond)
+			defer done()
+			Eventually(ctx.Done(), time.Second).Should(BeClosed())
+			Expect(ctx.Err()).To(Equal(context.DeadlineExceeded))
+
+			ctx, done = w.startEvent(context.Background(), 0)
+			defer done()
+			_, hasDeadline := ctx.Deadline()
+			Expect(hasDeadline).To(BeFalse())
+		})
+
+		It("should export the count of the recoveries on /metrics", func() {
+			controller := &AppGwIngressController{health: newHealth(), watchdog: newWatchdog()}
+			controller.watchdog.countRecovery(`component="worker"`)
+			controller.watchdog.countRecovery(`component="informer",resource="pods"`)
+			controller.watchdog.countRecovery(`component="informer",resource="pods"`)
+
+			recorder := httptest.NewRecorder()
+			controller.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+			Expect(recorder.Body.String()).To(ContainSubstring("# TYPE watchdog_recoveries_total counter\n" +
+				"watchdog_recoveries_total{component=\"informer\",resource=\"pods\"} 2\n" +
+				"watchdog_recoveries_total{component=\"worker\"} 1\n"))
+		})
+	})
+
+	Context("test recoverStalledWorker", func() {
+		var controller *AppGwIngressController
+		var stopChannel chan struct{}
+		var release chan struct{}
+		var eventContexts chan context.Context
+		var processed chan events.Event
+
+		BeforeEach(func() {
+			stopChannel = make(chan struct{})
+			release = make(chan struct{})
+			eventContexts = make(chan context.Context, 1)
+			controller = &AppGwIngressController{
+				k8sContext: &k8scontext.Context{UpdateChannel: channels.NewRingChannel(1024)},
+				watchdog:   newWatchdog(),
+			}
+			processed = make(chan events.Event, 1)
+			controller.worker = worker.NewWorker(worker.NewFakeProcessor(func(event events.Event) error {
+				if event.Type != events.Create {
+					processed <- event
+					return nil
+				}
+				eventCtx, done := controller.watchdog.startEvent(context.Background(), 0)
+				defer done()
+				eventContexts <- eventCtx
+				<-release
+				return nil
+			}), worker.DefaultOptions())
+			controller.worker.Run(controller.k8sContext.UpdateChannel, stopChannel)
+		})
+
+		AfterEach(func() {
+			close(release)
+			close(stopChannel)
+		})
+
+		It("should abandon the stalled event, then restart the worker, which processes the pending events", func() {
+			env := environment.GetFakeEnv()
+			controller.recoverStalledWorker(env, time.Minute, time.Now())
+			Expect(controller.watchdog.recoveries).To(BeEmpty())
+
+			controller.k8sContext.UpdateChannel.In() <- events.Event{Type: events.Create}
+			Eventually(func() bool { return controller.worker.ProcessingSince().IsZero() }, time.Second).Should(BeFalse())
+			since := controller.worker.ProcessingSince()
+			var eventCtx context.Context
+			Eventually(eventContexts, time.Second).Should(Receive(&eventCtx))
+
+			controller.recoverStalledWorker(env, time.Minute, since.Add(30*time.Second))
+			Expect(controller.watchdog.recoveries).To(BeEmpty())
+
+			controller.recoverStalledWorker(env, time.Minute, since.Add(2*time.Minute))
+			Expect(controller.watchdog.recoveries).To(Equal(map[string]int{`component="event"`: 1}))
+			Expect(eventCtx.Err()).To(Equal(context.Canceled))
+
+			controller.recoverStalledWorker(env, time.Minute, since.Add(4*time.Minute))
+			Expect(controller.watchdog.recoveries).To(Equal(map[string]int{`component="event"`: 1, `component="worker"`: 1}))
+			Expect(controller.worker.ProcessingSince().IsZero()).To(BeTrue())
+
+			// The new loop does not wait for the stalled event.
+			controller.k8sContext.UpdateChannel.In() <- events.Event{Type: events.Update}
+			Eventually(processed, time.Second).Should(Receive(Equal(events.Event{Type: events.Update})))
+		})
+	})
+})
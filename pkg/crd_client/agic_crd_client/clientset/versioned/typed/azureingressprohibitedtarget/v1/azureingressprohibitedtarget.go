@@ -39,6 +39,7 @@ type AzureIngressProhibitedTargetsGetter interface {
 type AzureIngressProhibitedTargetInterface interface {
 	Create(*v1.AzureIngressProhibitedTarget) (*v1.AzureIngressProhibitedTarget, error)
 	Update(*v1.AzureIngressProhibitedTarget) (*v1.AzureIngressProhibitedTarget, error)
+	UpdateStatus(*v1.AzureIngressProhibitedTarget) (*v1.AzureIngressProhibitedTarget, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (*v1.AzureIngressProhibitedTarget, error)
@@ -132,6 +133,22 @@ func (c *azureIngressProhibitedTargets) Update(azureIngressProhibitedTarget *v1.
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *azureIngressProhibitedTargets) UpdateStatus(azureIngressProhibitedTarget *v1.AzureIngressProhibitedTarget) (result *v1.AzureIngressProhibitedTarget, err error) {
+	result = &v1.AzureIngressProhibitedTarget{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("azureingressprohibitedtargets").
+		Name(azureIngressProhibitedTarget.Name).
+		SubResource("status").
+		Body(azureIngressProhibitedTarget).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the azureIngressProhibitedTarget and deletes it. Returns an error if one occurs.
 func (c *azureIngressProhibitedTargets) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
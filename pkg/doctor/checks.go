@@ -0,0 +1,291 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package doctor
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+)
+
+const (
+	checkAzureAuth         = "Azure authentication"
+	checkAppGwReachable    = "App Gateway reachability"
+	checkARMPermissions    = "ARM permissions"
+	checkRBAC              = "Kubernetes RBAC"
+	checkRoutes            = "Subnet routes"
+	checkSubnet            = "Subnet configuration"
+	checkProhibitedTargets = "Prohibited targets"
+	checkAnnotations       = "Ingress annotations"
+)
+
+const (
+	// reservedSubnetAddresses is the number of addresses Azure reserves in every subnet.
+	reservedSubnetAddresses = 5
+
+	// maxV2Instances is the number of instances a v2 App Gateway scales out to when autoscaling has no upper bound.
+	maxV2Instances = 125
+
+	// defaultV1Instances is the number of instances of a v1 App Gateway without a capacity.
+	defaultV1Instances = 2
+
+	// recommendedV2PrefixLength is the length of the address prefix Azure recommends for the subnet of a v2 App Gateway.
+	recommendedV2PrefixLength = 24
+)
+
+// CheckAnnotations reports the annotations of the Ingresses, which have invalid values or are not known to AGIC.
+func CheckAnnotations(ingresses []*v1beta1.Ingress) Report {
+	var report Report
+	for _, ingress := range ingresses {
+		for _, err := range annotations.Validate(ingress) {
+			report = append(report, Finding{
+				Check:    checkAnnotations,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("Ingress %s/%s: %s", ingress.Namespace, ingress.Name, err),
+			})
+		}
+	}
+	if len(report) == 0 {
+		report = append(report, Finding{Check: checkAnnotations, Severity: SeverityOK, Message: fmt.Sprintf("the annotations of %d Ingresses are valid", len(ingresses))})
+	}
+	return report
+}
+
+// CheckProhibitedTargets reports the prohibited targets, which prohibit everything, and the hosts and paths of the Ingresses,
+// which AGIC ignores because they overlap with a prohibited target.
+func CheckProhibitedTargets(ingresses []*v1beta1.Ingress, prohibitedTargets []*ptv1.AzureIngressProhibitedTarget) Report {
+	var report Report
+	for _, target := range prohibitedTargets {
+		if target.Spec.Hostname == "" && len(target.Spec.Paths) == 0 {
+			report = append(report, Finding{
+				Check:    checkProhibitedTargets,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("AzureIngressProhibitedTarget %s/%s has neither a hostname nor paths, and prohibits all App Gateway config", target.Namespace, target.Name),
+			})
+		}
+	}
+
+	blacklist := brownfield.GetTargetBlacklist(prohibitedTargets)
+	for _, ingress := range ingresses {
+		var overlapping []string
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				target := brownfield.Target{Hostname: rule.Host, Path: path.Path}
+				if target.IsBlacklisted(blacklist) {
+					overlapping = append(overlapping, rule.Host+path.Path)
+				}
+			}
+		}
+		if len(overlapping) > 0 {
+			report = append(report, Finding{
+				Check:    checkProhibitedTargets,
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("Ingress %s/%s: [%s] overlap with prohibited targets and are ignored",
+					ingress.Namespace, ingress.Name, strings.Join(overlapping, ", ")),
+			})
+		}
+	}
+
+	if len(report) == 0 {
+		report = append(report, Finding{Check: checkProhibitedTargets, Severity: SeverityOK, Message: "no Ingress overlaps with a prohibited target"})
+	}
+	return report
+}
+
+// CheckRoutes reports the nodes, whose Pod CIDR is not routed from the App Gateway subnet through the node.
+// With kubenet nodes have a Pod CIDR, and the App Gateway subnet must be associated with the route table of the cluster;
+// with Azure CNI Pods have addresses of the virtual network, and no routes are needed.
+func CheckRoutes(subnetID string, routeTable *n.RouteTable, nodes []v1.Node) Report {
+	var kubenetNodes []v1.Node
+	for _, node := range nodes {
+		if node.Spec.PodCIDR != "" {
+			kubenetNodes = append(kubenetNodes, node)
+		}
+	}
+	if len(kubenetNodes) == 0 {
+		return Report{{Check: checkRoutes, Severity: SeverityOK, Message: "nodes have no Pod CIDR (Azure CNI); no routes are needed"}}
+	}
+	if routeTable == nil {
+		return Report{{Check: checkRoutes, Severity: SeverityError,
+			Message: fmt.Sprintf("nodes use kubenet, but App Gateway subnet %s has no route table; associate it with the route table of the cluster", subnetID)}}
+	}
+
+	nextHops := make(map[string]string)
+	if routeTable.RouteTablePropertiesFormat != nil && routeTable.Routes != nil {
+		for _, route := range *routeTable.Routes {
+			if route.RoutePropertiesFormat == nil || route.AddressPrefix == nil {
+				continue
+			}
+			nextHop := ""
+			if route.NextHopIPAddress != nil {
+				nextHop = *route.NextHopIPAddress
+			}
+			nextHops[*route.AddressPrefix] = nextHop
+		}
+	}
+
+	var report Report
+	for _, node := range kubenetNodes {
+		nextHop, exists := nextHops[node.Spec.PodCIDR]
+		if !exists {
+			report = append(report, Finding{Check: checkRoutes, Severity: SeverityError,
+				Message: fmt.Sprintf("the route table of the App Gateway subnet has no route to Pod CIDR %s of node %s", node.Spec.PodCIDR, node.Name)})
+			continue
+		}
+		if internalIP := nodeInternalIP(node); internalIP != "" && nextHop != internalIP {
+			report = append(report, Finding{Check: checkRoutes, Severity: SeverityError,
+				Message: fmt.Sprintf("Pod CIDR %s of node %s is routed to %s instead of the node address %s", node.Spec.PodCIDR, node.Name, nextHop, internalIP)})
+		}
+	}
+	if len(report) == 0 {
+		report = append(report, Finding{Check: checkRoutes, Severity: SeverityOK, Message: fmt.Sprintf("the Pod CIDRs of all %d nodes are routed", len(kubenetNodes))})
+	}
+	return report
+}
+
+// CheckSubnet reports the settings of the subnet of the App Gateway, which Azure does not support or which limit the App Gateway:
+// an address space too small for all instances, delegations, service endpoint policies, resources other than App Gateways,
+// and, for v2 SKUs, a default route not going to the Internet. Every problem comes with a hint on how to fix it.
+func CheckSubnet(subnetID string, subnet n.Subnet, routeTable *n.RouteTable, appGw n.ApplicationGateway) Report {
+	var report Report
+	add := func(severity Severity, format string, args ...interface{}) {
+		report = append(report, Finding{Check: checkSubnet, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+	if subnet.SubnetPropertiesFormat == nil {
+		add(SeverityError, "subnet %s has no properties", subnetID)
+		return report
+	}
+	isV2 := isV2Sku(appGw)
+
+	instances := getMaxInstances(appGw, isV2)
+	needed := instances
+	if hasPrivateFrontendIP(appGw) {
+		needed++
+	}
+	usable, prefixLength := 0, 0
+	for _, prefix := range getAddressPrefixes(subnet) {
+		_, ipNet, err := net.ParseCIDR(prefix)
+		if err != nil {
+			add(SeverityError, "unable to parse address prefix %s of subnet %s: %s", prefix, subnetID, err)
+			continue
+		}
+		ones, bits := ipNet.Mask.Size()
+		usable += (1 << uint(bits-ones)) - reservedSubnetAddresses
+		if prefixLength == 0 || ones < prefixLength {
+			prefixLength = ones
+		}
+	}
+	if usable < needed {
+		add(SeverityError, "subnet %s has %d usable addresses, but the App Gateway may need %d for up to %d instances; move the App Gateway to a larger subnet",
+			subnetID, usable, needed, instances)
+	} else if isV2 && prefixLength > recommendedV2PrefixLength {
+		add(SeverityWarning, "subnet %s is smaller than the /%d recommended for v2 App Gateways; use a /%d subnet to leave room for autoscaling",
+			subnetID, recommendedV2PrefixLength, recommendedV2PrefixLength)
+	}
+
+	if subnet.Delegations != nil {
+		for _, delegation := range *subnet.Delegations {
+			service := ""
+			if delegation.ServiceDelegationPropertiesFormat != nil && delegation.ServiceName != nil {
+				service = *delegation.ServiceName
+			}
+			add(SeverityError, "subnet %s is delegated to %s; remove the delegation, as App Gateways need a subnet without delegations", subnetID, service)
+		}
+	}
+
+	if subnet.ServiceEndpointPolicies != nil && len(*subnet.ServiceEndpointPolicies) > 0 {
+		add(SeverityError, "subnet %s has service endpoint policies, which App Gateways do not support; remove them from the subnet", subnetID)
+	}
+
+	if subnet.IPConfigurations != nil {
+		for _, ipConfiguration := range *subnet.IPConfigurations {
+			if ipConfiguration.ID == nil || strings.Contains(strings.ToLower(*ipConfiguration.ID), "/providers/microsoft.network/applicationgateways/") {
+				continue
+			}
+			add(SeverityError, "subnet %s also hosts %s; App Gateways need a dedicated subnet, move the other resources to another subnet", subnetID, *ipConfiguration.ID)
+		}
+	}
+
+	if isV2 && routeTable != nil && routeTable.RouteTablePropertiesFormat != nil && routeTable.Routes != nil {
+		for _, route := range *routeTable.Routes {
+			if route.RoutePropertiesFormat == nil || route.AddressPrefix == nil || *route.AddressPrefix != "0.0.0.0/0" {
+				continue
+			}
+			if route.NextHopType != n.RouteNextHopTypeInternet {
+				add(SeverityError, "the route table of subnet %s sends 0.0.0.0/0 to %s, which v2 App Gateways do not support; route 0.0.0.0/0 to the Internet, or remove the route",
+					subnetID, route.NextHopType)
+			}
+		}
+	}
+
+	if len(report) == 0 {
+		add(SeverityOK, "subnet %s meets the requirements of the App Gateway", subnetID)
+	}
+	return report
+}
+
+// isV2Sku tells whether the App Gateway has a Standard_v2 or WAF_v2 SKU.
+func isV2Sku(appGw n.ApplicationGateway) bool {
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.Sku == nil {
+		return false
+	}
+	return appGw.Sku.Tier == n.ApplicationGatewayTierStandardV2 || appGw.Sku.Tier == n.ApplicationGatewayTierWAFV2
+}
+
+// getMaxInstances returns the number of instances the App Gateway may scale out to.
+func getMaxInstances(appGw n.ApplicationGateway, isV2 bool) int {
+	if appGw.ApplicationGatewayPropertiesFormat == nil {
+		return defaultV1Instances
+	}
+	if appGw.AutoscaleConfiguration != nil {
+		if appGw.AutoscaleConfiguration.MaxCapacity != nil {
+			return int(*appGw.AutoscaleConfiguration.MaxCapacity)
+		}
+		return maxV2Instances
+	}
+	if appGw.Sku != nil && appGw.Sku.Capacity != nil {
+		return int(*appGw.Sku.Capacity)
+	}
+	if isV2 {
+		return maxV2Instances
+	}
+	return defaultV1Instances
+}
+
+// hasPrivateFrontendIP tells whether the App Gateway takes an address of its subnet for a private frontend IP.
+func hasPrivateFrontendIP(appGw n.ApplicationGateway) bool {
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.FrontendIPConfigurations == nil {
+		return false
+	}
+	for _, ip := range *appGw.FrontendIPConfigurations {
+		if ip.ApplicationGatewayFrontendIPConfigurationPropertiesFormat != nil && ip.PrivateIPAddress != nil && *ip.PrivateIPAddress != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getAddressPrefixes returns the address prefixes of the subnet, whether it has one or several.
+func getAddressPrefixes(subnet n.Subnet) []string {
+	if subnet.AddressPrefixes != nil && len(*subnet.AddressPrefixes) > 0 {
+		return *subnet.AddressPrefixes
+	}
+	if subnet.AddressPrefix != nil {
+		return []string{*subnet.AddressPrefix}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package doctor
+
+import (
+	"bytes"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// doctor_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test the checks of the doctor", func() {
+
+	Context("test Report", func() {
+		report := Report{
+			{Check: "a", Severity: SeverityOK, Message: "fine"},
+			{Check: "b", Severity: SeverityWarning, Message: "hmm"},
+			{Check: "c", Severity: SeverityError, Message: "broken"},
+			{Check: "d", Severity: SeverityWarning, Message: "odd"},
+		}
+
+		It("should print the most severe findings first", func() {
+			var out bytes.Buffer
+			report.Print(&out)
+			Expect(out.String()).To(Equal("[ERROR] c: broken\n[WARNING] b: hmm\n[WARNING] d: odd\n[OK] a: fine\n"))
+			Expect(report.HasErrors()).To(BeTrue())
+			Expect(report[:2].HasErrors()).To(BeFalse())
+		})
+	})
+
+	Context("test CheckAnnotations()", func() {
+		It("should report invalid and unknown annotations", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequestTimeoutKey] = "thirty"
+			ingress.Annotations[annotations.ApplicationGatewayPrefix+"/cookie-affinity"] = "true"
+			report := CheckAnnotations([]*v1beta1.Ingress{ingress})
+			Expect(report).To(HaveLen(2))
+			Expect(report[0].Severity).To(Equal(SeverityWarning))
+			Expect(report[0].Message).To(ContainSubstring("cookie-affinity"))
+			Expect(report[1].Message).To(ContainSubstring(annotations.RequestTimeoutKey))
+		})
+
+		It("should pass valid annotations", func() {
+			report := CheckAnnotations([]*v1beta1.Ingress{tests.NewIngressFixture()})
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+	})
+
+	Context("test CheckProhibitedTargets()", func() {
+		newTarget := func(hostname string, paths ...string) *ptv1.AzureIngressProhibitedTarget {
+			return &ptv1.AzureIngressProhibitedTarget{
+				ObjectMeta: metav1.ObjectMeta{Namespace: tests.Namespace, Name: "target"},
+				Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: hostname, Paths: paths},
+			}
+		}
+
+		It("should report Ingress paths overlapping with a prohibited target", func() {
+			targets := []*ptv1.AzureIngressProhibitedTarget{newTarget(tests.Host, tests.URLPath)}
+			report := CheckProhibitedTargets([]*v1beta1.Ingress{tests.NewIngressFixture()}, targets)
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityWarning))
+			Expect(report[0].Message).To(ContainSubstring(tests.Host + tests.URLPath))
+		})
+
+		It("should report prohibited targets, which prohibit everything", func() {
+			report := CheckProhibitedTargets(nil, []*ptv1.AzureIngressProhibitedTarget{newTarget("")})
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Message).To(ContainSubstring("prohibits all App Gateway config"))
+		})
+
+		It("should pass when nothing overlaps", func() {
+			targets := []*ptv1.AzureIngressProhibitedTarget{newTarget(tests.OtherHost)}
+			report := CheckProhibitedTargets([]*v1beta1.Ingress{tests.NewIngressFixture()}, targets)
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+	})
+
+	Context("test CheckRoutes()", func() {
+		const subnetID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/appgw"
+
+		newNode := func(name, podCIDR, internalIP string) v1.Node {
+			return v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       v1.NodeSpec{PodCIDR: podCIDR},
+				Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: internalIP}}},
+			}
+		}
+		routeTable := &n.RouteTable{
+			RouteTablePropertiesFormat: &n.RouteTablePropertiesFormat{
+				Routes: &[]n.Route{
+					{RoutePropertiesFormat: &n.RoutePropertiesFormat{AddressPrefix: to.StringPtr("10.244.0.0/24"), NextHopIPAddress: to.StringPtr("10.240.0.4")}},
+					{RoutePropertiesFormat: &n.RoutePropertiesFormat{AddressPrefix: to.StringPtr("10.244.1.0/24"), NextHopIPAddress: to.StringPtr("10.240.0.9")}},
+				},
+			},
+		}
+
+		It("should pass with Azure CNI", func() {
+			report := CheckRoutes(subnetID, nil, []v1.Node{newNode("node-0", "", "10.240.0.4")})
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+
+		It("should fail with kubenet and no route table", func() {
+			report := CheckRoutes(subnetID, nil, []v1.Node{newNode("node-0", "10.244.0.0/24", "10.240.0.4")})
+			Expect(report.HasErrors()).To(BeTrue())
+			Expect(report[0].Message).To(ContainSubstring("has no route table"))
+		})
+
+		It("should report missing and misdirected routes", func() {
+			nodes := []v1.Node{
+				newNode("node-0", "10.244.0.0/24", "10.240.0.4"),
+				newNode("node-1", "10.244.1.0/24", "10.240.0.5"),
+				newNode("node-2", "10.244.2.0/24", "10.240.0.6"),
+			}
+			report := CheckRoutes(subnetID, routeTable, nodes)
+			Expect(report).To(HaveLen(2))
+			Expect(report[0].Message).To(ContainSubstring("node-1 is routed to 10.240.0.9"))
+			Expect(report[1].Message).To(ContainSubstring("no route to Pod CIDR 10.244.2.0/24 of node node-2"))
+		})
+
+		It("should pass when all Pod CIDRs are routed", func() {
+			report := CheckRoutes(subnetID, routeTable, []v1.Node{newNode("node-0", "10.244.0.0/24", "10.240.0.4")})
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+	})
+
+	Context("test CheckSubnet()", func() {
+		const subnetID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/appgw"
+
+		newAppGw := func(tier n.ApplicationGatewayTier, capacity int32) n.ApplicationGateway {
+			return n.ApplicationGateway{
+				ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+					Sku: &n.ApplicationGatewaySku{Tier: tier, Capacity: to.Int32Ptr(capacity)},
+				},
+			}
+		}
+		newSubnet := func(prefix string) n.Subnet {
+			return n.Subnet{SubnetPropertiesFormat: &n.SubnetPropertiesFormat{AddressPrefix: to.StringPtr(prefix)}}
+		}
+
+		It("should pass a /24 subnet of a v2 App Gateway", func() {
+			report := CheckSubnet(subnetID, newSubnet("10.1.0.0/24"), nil, newAppGw(n.ApplicationGatewayTierStandardV2, 10))
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+
+		It("should warn about a v2 subnet smaller than /24", func() {
+			report := CheckSubnet(subnetID, newSubnet("10.1.0.0/26"), nil, newAppGw(n.ApplicationGatewayTierStandardV2, 10))
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityWarning))
+			Expect(report[0].Message).To(ContainSubstring("/24"))
+		})
+
+		It("should fail a subnet too small for all instances and the private frontend IP", func() {
+			appGw := newAppGw(n.ApplicationGatewayTierStandard, 2)
+			appGw.FrontendIPConfigurations = &[]n.ApplicationGatewayFrontendIPConfiguration{
+				{ApplicationGatewayFrontendIPConfigurationPropertiesFormat: &n.ApplicationGatewayFrontendIPConfigurationPropertiesFormat{PrivateIPAddress: to.StringPtr("10.1.0.4")}},
+			}
+			Expect(CheckSubnet(subnetID, newSubnet("10.1.0.0/29"), nil, appGw).HasErrors()).To(BeFalse())
+
+			appGw.Sku.Capacity = to.Int32Ptr(3)
+			report := CheckSubnet(subnetID, newSubnet("10.1.0.0/29"), nil, appGw)
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityError))
+			Expect(report[0].Message).To(ContainSubstring("has 3 usable addresses, but the App Gateway may need 4 for up to 3 instances"))
+		})
+
+		It("should size a v2 subnet for the upper bound of autoscaling", func() {
+			appGw := newAppGw(n.ApplicationGatewayTierWAFV2, 0)
+			appGw.Sku.Capacity = nil
+			appGw.AutoscaleConfiguration = &n.ApplicationGatewayAutoscaleConfiguration{MinCapacity: to.Int32Ptr(2)}
+			report := CheckSubnet(subnetID, newSubnet("10.1.0.0/25"), nil, appGw)
+			Expect(report.HasErrors()).To(BeTrue())
+			Expect(report[0].Message).To(ContainSubstring("up to 125 instances"))
+
+			appGw.AutoscaleConfiguration.MaxCapacity = to.Int32Ptr(20)
+			report = CheckSubnet(subnetID, newSubnet("10.1.0.0/24"), nil, appGw)
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+
+		It("should report delegations, service endpoint policies and other resources in the subnet", func() {
+			subnet := newSubnet("10.1.0.0/24")
+			subnet.Delegations = &[]n.Delegation{
+				{ServiceDelegationPropertiesFormat: &n.ServiceDelegationPropertiesFormat{ServiceName: to.StringPtr("Microsoft.Web/serverFarms")}},
+			}
+			subnet.ServiceEndpointPolicies = &[]n.ServiceEndpointPolicy{{ID: to.StringPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/serviceEndpointPolicies/policy")}}
+			subnet.IPConfigurations = &[]n.IPConfiguration{
+				{ID: to.StringPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/appgw/gatewayIPConfigurations/appGatewayIpConfig")},
+				{ID: to.StringPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/vm-nic/ipConfigurations/ipconfig1")},
+			}
+			report := CheckSubnet(subnetID, subnet, nil, newAppGw(n.ApplicationGatewayTierStandardV2, 2))
+			Expect(report).To(HaveLen(3))
+			Expect(report[0].Message).To(ContainSubstring("delegated to Microsoft.Web/serverFarms"))
+			Expect(report[1].Message).To(ContainSubstring("service endpoint policies"))
+			Expect(report[2].Message).To(ContainSubstring("networkInterfaces/vm-nic"))
+		})
+
+		It("should fail a default route not going to the Internet with a v2 SKU only", func() {
+			routeTable := &n.RouteTable{
+				RouteTablePropertiesFormat: &n.RouteTablePropertiesFormat{
+					Routes: &[]n.Route{
+						{RoutePropertiesFormat: &n.RoutePropertiesFormat{AddressPrefix: to.StringPtr("0.0.0.0/0"), NextHopType: n.RouteNextHopTypeVirtualAppliance}},
+					},
+				},
+			}
+			report := CheckSubnet(subnetID, newSubnet("10.1.0.0/24"), routeTable, newAppGw(n.ApplicationGatewayTierStandardV2, 2))
+			Expect(report).To(HaveLen(1))
+			Expect(report[0].Severity).To(Equal(SeverityError))
+			Expect(report[0].Message).To(ContainSubstring("sends 0.0.0.0/0 to VirtualAppliance"))
+
+			report = CheckSubnet(subnetID, newSubnet("10.1.0.0/24"), routeTable, newAppGw(n.ApplicationGatewayTierStandard, 2))
+			Expect(report[0].Severity).To(Equal(SeverityOK))
+		})
+	})
+})
@@ -0,0 +1,271 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+)
+
+// Severity tells how urgently a finding needs attention.
+type Severity int
+
+const (
+	// SeverityError is a problem, which prevents AGIC from working.
+	SeverityError Severity = iota
+	// SeverityWarning is a problem, which may cause some Ingresses to not work as expected.
+	SeverityWarning
+	// SeverityOK is a check, which passed.
+	SeverityOK
+)
+
+var severityNames = map[Severity]string{
+	SeverityError:   "ERROR",
+	SeverityWarning: "WARNING",
+	SeverityOK:      "OK",
+}
+
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// Finding is the result of a check.
+type Finding struct {
+	Check    string
+	Severity Severity
+	Message  string
+}
+
+// Report is the list of findings of all checks.
+type Report []Finding
+
+// Sorted returns the findings with errors first, then warnings, then passed checks; the order of the checks is kept otherwise.
+func (r Report) Sorted() Report {
+	sorted := make(Report, len(r))
+	copy(sorted, r)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Severity < sorted[j].Severity
+	})
+	return sorted
+}
+
+// HasErrors tells whether any check found a problem, which prevents AGIC from working.
+func (r Report) HasErrors() bool {
+	for _, finding := range r {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the findings, most severe first.
+func (r Report) Print(w io.Writer) {
+	for _, finding := range r.Sorted() {
+		_, _ = fmt.Fprintf(w, "[%s] %s: %s\n", finding.Severity, finding.Check, finding.Message)
+	}
+}
+
+// Doctor runs the checks of the common reasons AGIC fails, in one go, with the clients AGIC itself would use.
+type Doctor struct {
+	Env               environment.EnvVariables
+	Namespaces        []string
+	KubeClient        kubernetes.Interface
+	CrdClient         versioned.Interface
+	AppGwClient       n.ApplicationGatewaysClient
+	SubnetsClient     n.SubnetsClient
+	RouteTablesClient n.RouteTablesClient
+}
+
+// NewDoctor creates the Azure clients of the subscription of the App Gateway; their Authorizer is set by Run.
+func NewDoctor(env environment.EnvVariables, namespaces []string, kubeClient kubernetes.Interface, crdClient versioned.Interface) *Doctor {
+	return &Doctor{
+		Env:               env,
+		Namespaces:        namespaces,
+		KubeClient:        kubeClient,
+		CrdClient:         crdClient,
+		AppGwClient:       n.NewApplicationGatewaysClient(env.SubscriptionID),
+		SubnetsClient:     n.NewSubnetsClient(env.SubscriptionID),
+		RouteTablesClient: n.NewRouteTablesClient(env.SubscriptionID),
+	}
+}
+
+// Run performs all checks. Checks depending on a failed check are skipped, and reported as such.
+func (d *Doctor) Run() Report {
+	var report Report
+	add := func(check string, severity Severity, format string, args ...interface{}) {
+		report = append(report, Finding{Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	report = append(report, d.checkKubernetes()...)
+
+	authorizer, credential, err := azure.GetAuthorizer(azure.NewDefaultCredentialChain(d.Env))
+	if err != nil {
+		add(checkAzureAuth, SeverityError, "unable to authenticate with Azure: %s; skipping the checks of the App Gateway", err)
+		return report
+	}
+	add(checkAzureAuth, SeverityOK, "authenticated with %s", credential.Name())
+	d.AppGwClient.Authorizer = authorizer
+	d.SubnetsClient.Authorizer = authorizer
+	d.RouteTablesClient.Authorizer = authorizer
+
+	appGw, err := d.AppGwClient.Get(context.Background(), d.Env.ResourceGroupName, d.Env.AppGwName)
+	if err != nil {
+		add(checkAppGwReachable, SeverityError, "unable to get App Gateway %s in resource group %s: %s; skipping the checks of the App Gateway",
+			d.Env.AppGwName, d.Env.ResourceGroupName, err)
+		return report
+	}
+	add(checkAppGwReachable, SeverityOK, "App Gateway %s is reachable", d.Env.AppGwName)
+
+	if err := azure.CheckAppGwPermissions(d.AppGwClient, d.Env.SubscriptionID, d.Env.ResourceGroupName, d.Env.AppGwName, d.Env.ObserveOnly == "true"); err != nil {
+		severity := SeverityError
+		if _, notListed := err.(azure.PermissionsNotListedError); notListed {
+			severity = SeverityWarning
+		}
+		add(checkARMPermissions, severity, "%s", err)
+	} else {
+		add(checkARMPermissions, SeverityOK, "the identity has the permissions AGIC needs on the App Gateway")
+	}
+
+	report = append(report, d.checkNetwork(appGw)...)
+	return report
+}
+
+// checkKubernetes checks the RBAC permissions of AGIC, and the Ingresses and prohibited targets it would process.
+func (d *Doctor) checkKubernetes() Report {
+	var report Report
+	if err := k8scontext.CheckPermissions(d.KubeClient, d.Namespaces, d.Env); err != nil {
+		if _, notChecked := err.(k8scontext.PermissionsNotCheckedError); !notChecked {
+			return Report{{Check: checkRBAC, Severity: SeverityError, Message: fmt.Sprintf("%s; skipping the checks of Ingresses", err)}}
+		}
+		report = append(report, Finding{Check: checkRBAC, Severity: SeverityWarning, Message: err.Error()})
+	} else {
+		report = append(report, Finding{Check: checkRBAC, Severity: SeverityOK, Message: "AGIC is allowed to watch all resources it needs"})
+	}
+
+	ingresses, err := d.listIngresses()
+	if err != nil {
+		return append(report, Finding{Check: checkAnnotations, Severity: SeverityError, Message: fmt.Sprintf("unable to list Ingresses: %s", err)})
+	}
+	report = append(report, CheckAnnotations(ingresses)...)
+
+	if d.Env.EnableBrownfieldDeployment != "true" {
+		return report
+	}
+	var prohibitedTargets []*ptv1.AzureIngressProhibitedTarget
+	if d.Env.ProhibitedTargetsCRD != "false" {
+		targets, err := d.CrdClient.AzureingressprohibitedtargetsV1().AzureIngressProhibitedTargets(metav1.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return append(report, Finding{Check: checkProhibitedTargets, Severity: SeverityError, Message: fmt.Sprintf("unable to list AzureIngressProhibitedTargets: %s", err)})
+		}
+		for idx := range targets.Items {
+			prohibitedTargets = append(prohibitedTargets, &targets.Items[idx])
+		}
+	}
+	if d.Env.ProhibitedTargetsConfigMap != "" {
+		configMap, err := d.KubeClient.CoreV1().ConfigMaps(d.Env.AGICPodNamespace).Get(d.Env.ProhibitedTargetsConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return append(report, Finding{Check: checkProhibitedTargets, Severity: SeverityError, Message: fmt.Sprintf("unable to get the ConfigMap with prohibited targets: %s", err)})
+		}
+		configMapTargets, errs := brownfield.ParseProhibitedTargets(configMap)
+		for _, err := range errs {
+			report = append(report, Finding{Check: checkProhibitedTargets, Severity: SeverityWarning, Message: fmt.Sprintf("ConfigMap %s/%s: %s", configMap.Namespace, configMap.Name, err)})
+		}
+		prohibitedTargets = append(prohibitedTargets, configMapTargets...)
+	}
+	return append(report, CheckProhibitedTargets(ingresses, prohibitedTargets)...)
+}
+
+// listIngresses returns the Ingresses annotated for AGIC in the watched namespaces.
+func (d *Doctor) listIngresses() ([]*v1beta1.Ingress, error) {
+	namespaces := d.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	var ingresses []*v1beta1.Ingress
+	for _, namespace := range namespaces {
+		list, err := d.KubeClient.ExtensionsV1beta1().Ingresses(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for idx := range list.Items {
+			if isAGIC, _ := annotations.IsApplicationGatewayIngress(&list.Items[idx]); isAGIC {
+				ingresses = append(ingresses, &list.Items[idx])
+			}
+		}
+	}
+	return ingresses, nil
+}
+
+// checkNetwork checks the subnet of the App Gateway, and that it routes to the Pods of every node.
+func (d *Doctor) checkNetwork(appGw n.ApplicationGateway) Report {
+	subnetID, subnet, routeTable, err := GetGatewaySubnet(context.Background(), d.SubnetsClient, d.RouteTablesClient, appGw)
+	if err != nil {
+		return Report{{Check: checkSubnet, Severity: SeverityError, Message: fmt.Sprintf("%s; skipping the checks of the subnet", err)}}
+	}
+	report := CheckSubnet(subnetID, subnet, routeTable, appGw)
+
+	nodes, err := d.KubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return append(report, Finding{Check: checkRoutes, Severity: SeverityError, Message: fmt.Sprintf("unable to list nodes: %s", err)})
+	}
+	return append(report, CheckRoutes(subnetID, routeTable, nodes.Items)...)
+}
+
+// GetGatewaySubnet returns the ID of the subnet of the App Gateway, the subnet, and its route table, which is nil when it has none.
+func GetGatewaySubnet(ctx context.Context, subnetsClient n.SubnetsClient, routeTablesClient n.RouteTablesClient, appGw n.ApplicationGateway) (string, n.Subnet, *n.RouteTable, error) {
+	subnetID, err := azure.GetSubnetID(appGw)
+	if err != nil {
+		return "", n.Subnet{}, nil, err
+	}
+	resourceGroup, vnetName, subnetName, err := azure.ParseSubnetID(subnetID)
+	if err != nil {
+		return subnetID, n.Subnet{}, nil, err
+	}
+	subnet, err := subnetsClient.Get(ctx, resourceGroup, vnetName, subnetName, "")
+	if err != nil {
+		return subnetID, n.Subnet{}, nil, fmt.Errorf("unable to get subnet %s: %s", subnetID, err)
+	}
+	if subnet.SubnetPropertiesFormat == nil || subnet.RouteTable == nil || subnet.RouteTable.ID == nil {
+		return subnetID, subnet, nil, nil
+	}
+
+	routeTableResourceGroup, routeTableName, err := azure.ParseRouteTableID(*subnet.RouteTable.ID)
+	if err != nil {
+		return subnetID, subnet, nil, err
+	}
+	routeTable, err := routeTablesClient.Get(ctx, routeTableResourceGroup, routeTableName, "")
+	if err != nil {
+		return subnetID, subnet, nil, fmt.Errorf("unable to get route table %s: %s", *subnet.RouteTable.ID, err)
+	}
+	return subnetID, subnet, &routeTable, nil
+}
+
+// nodeInternalIP returns the internal IP address of the node, or an empty string when it has none.
+func nodeInternalIP(node v1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == v1.NodeInternalIP {
+			return address.Address
+		}
+	}
+	return ""
+}
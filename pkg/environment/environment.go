@@ -10,6 +10,7 @@ import (
 	"regexp"
 
 	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
@@ -25,6 +26,9 @@ const (
 	// AuthLocationVarName is the name of the AZURE_AUTH_LOCATION
 	AuthLocationVarName = "AZURE_AUTH_LOCATION"
 
+	// UseAzureCLIAuthVarName is the name of the APPGW_USE_AZURE_CLI_AUTH
+	UseAzureCLIAuthVarName = "APPGW_USE_AZURE_CLI_AUTH"
+
 	// WatchNamespaceVarName is the name of the KUBERNETES_WATCHNAMESPACE
 	WatchNamespaceVarName = "KUBERNETES_WATCHNAMESPACE"
 
@@ -40,37 +44,206 @@ const (
 	// EnableIstioIntegrationVarName is a feature flag enabling observation of Istio specific CRDs
 	EnableIstioIntegrationVarName = "APPGW_ENABLE_ISTIO_INTEGRATION"
 
+	// EnableKnativeIntegrationVarName is a feature flag enabling the translation of the Ingresses of Knative Serving
+	EnableKnativeIntegrationVarName = "APPGW_ENABLE_KNATIVE_INTEGRATION"
+
 	// EnableSaveConfigToFileVarName is a feature flag, which enables saving the App Gwy config to disk.
 	EnableSaveConfigToFileVarName = "APPGW_ENABLE_SAVE_CONFIG_TO_FILE"
+
+	// AllowedFrontendPortsVarName is a comma separated list of ports and port ranges (e.g. "80,443,8000-8999"),
+	// which restricts the frontend ports AGIC may open on the App Gateway. All ports are allowed when unset.
+	AllowedFrontendPortsVarName = "APPGW_ALLOWED_FRONTEND_PORTS"
+
+	// EnableHostnameOwnershipVarName is a feature flag, which lets only the namespace that first claimed a hostname
+	// configure it on the App Gateway.
+	EnableHostnameOwnershipVarName = "APPGW_ENABLE_HOSTNAME_OWNERSHIP"
+
+	// NamespaceQuotasVarName is a JSON object with the limits on listeners, paths and certificates AGIC creates for each namespace,
+	// for instance {"*": {"listeners": 10, "paths": 100, "certificates": 10}}. The "*" key applies to all other namespaces.
+	NamespaceQuotasVarName = "APPGW_NAMESPACE_QUOTAS"
+
+	// ScaleWarningThresholdVarName is the percentage of an App Gateway SKU limit, such as the maximum number of listeners,
+	// at which AGIC warns that the generated config is approaching the limit. Zero disables the warnings.
+	ScaleWarningThresholdVarName = "APPGW_SCALE_WARNING_THRESHOLD"
+
+	// ObserveOnlyVarName is a feature flag, which makes AGIC report the changes it would make to the App Gateway,
+	// with events and in its logs, without ever updating the App Gateway.
+	ObserveOnlyVarName = "APPGW_OBSERVE_ONLY"
+
+	// EnableZoneAwareBackendsVarName is a feature flag, which limits the backend pools of an App Gateway pinned to
+	// availability zones to the Pods on nodes in these zones, as long as there are any.
+	EnableZoneAwareBackendsVarName = "APPGW_ENABLE_ZONE_AWARE_BACKENDS"
+
+	// ExcludeVirtualNodePodsVarName is the default for leaving the Pods on virtual nodes out of the backend pools: "true" excludes them,
+	// "false" keeps them unless a Service is annotated otherwise. Nodes are not watched, and the annotation is ignored, when it is not set.
+	ExcludeVirtualNodePodsVarName = "APPGW_EXCLUDE_VIRTUAL_NODE_PODS"
+
+	// ExcludeNodesSelectorVarName is a label selector, such as "agentpool=infra"; the Pods on matching nodes are left out of the backend pools.
+	ExcludeNodesSelectorVarName = "APPGW_EXCLUDE_NODES_SELECTOR"
+
+	// ExcludeNodesTaintsVarName is a comma separated list of taints as "key" or "key=value", such as
+	// "kubernetes.azure.com/scalesetpriority=spot"; the Pods on nodes with any of these taints are left out of the backend pools.
+	ExcludeNodesTaintsVarName = "APPGW_EXCLUDE_NODES_TAINTS"
+
+	// DiagnosticsWorkspaceIDVarName is the resource ID of the Log Analytics workspace, which AGIC configures the App Gateway to send its logs to.
+	DiagnosticsWorkspaceIDVarName = "APPGW_DIAGNOSTICS_WORKSPACE_ID"
+
+	// DiagnosticsStorageAccountIDVarName is the resource ID of the storage account, which AGIC configures the App Gateway to archive its logs to.
+	DiagnosticsStorageAccountIDVarName = "APPGW_DIAGNOSTICS_STORAGE_ACCOUNT_ID"
+
+	// DiagnosticsLogsVarName is the comma separated list of the log categories of the App Gateway, which AGIC enables.
+	DiagnosticsLogsVarName = "APPGW_DIAGNOSTICS_LOGS"
+
+	// ManageNSGRulesVarName is a feature flag enabling the management of the security rules allowing traffic to the frontend ports
+	// of the App Gateway, in the network security group of its subnet.
+	ManageNSGRulesVarName = "APPGW_MANAGE_NSG_RULES"
+
+	// BackendDefaultsConfigMapVarName is the name of the ConfigMap, in the namespace of AGIC, with the defaults of the backend settings of all Ingresses.
+	BackendDefaultsConfigMapVarName = "APPGW_BACKEND_DEFAULTS_CONFIGMAP"
+
+	// DefaultSSLCertificateVarName is the TLS secret, as "namespace/name", with the certificate of the HTTPS listeners of Ingresses
+	// which have TLS sections, or are annotated with https-only, but no certificate for the host of a rule.
+	DefaultSSLCertificateVarName = "APPGW_DEFAULT_SSL_CERTIFICATE"
+
+	// AuditWorkspaceIDVarName is the workspace ID of the Log Analytics workspace, which the audit log of the App Gateway updates is sent to.
+	AuditWorkspaceIDVarName = "APPGW_AUDIT_WORKSPACE_ID"
+
+	// AuditWorkspaceKeyVarName is the shared key of the Log Analytics workspace of the audit log.
+	AuditWorkspaceKeyVarName = "APPGW_AUDIT_WORKSPACE_KEY"
+
+	// NotificationWebhookURLVarName is the URL, which a JSON notification is posted to after each App Gateway update.
+	NotificationWebhookURLVarName = "APPGW_NOTIFICATION_WEBHOOK_URL"
+
+	// NotificationEventGridKeyVarName is the access key of the Event Grid topic; when set, the notification webhook is the endpoint of the topic.
+	NotificationEventGridKeyVarName = "APPGW_NOTIFICATION_EVENT_GRID_KEY"
+
+	// EnableTwoPhaseApplyVarName is a feature flag, which adds the new backends to the App Gateway and waits for them to be healthy,
+	// before applying a config routing traffic to them.
+	EnableTwoPhaseApplyVarName = "APPGW_ENABLE_TWO_PHASE_APPLY"
+
+	// ProhibitedTargetsConfigMapVarName is the name of the ConfigMap, in the namespace of AGIC, with prohibited targets in addition to
+	// the AzureIngressProhibitedTarget CRDs, for clusters where installing CRDs is not allowed.
+	ProhibitedTargetsConfigMapVarName = "APPGW_PROHIBITED_TARGETS_CONFIGMAP"
+
+	// ProhibitedTargetsCRDVarName set to "false" stops AGIC from watching AzureIngressProhibitedTarget CRDs, when the CRD is not installed.
+	ProhibitedTargetsCRDVarName = "APPGW_PROHIBITED_TARGETS_CRD"
+
+	// WAFRequestBodyCheckVarName set to "false" stops the web application firewall of the App Gateway from inspecting request bodies.
+	WAFRequestBodyCheckVarName = "APPGW_WAF_REQUEST_BODY_CHECK"
+
+	// WAFMaxRequestBodySizeVarName is the size, in KB, of the largest request body the web application firewall inspects.
+	WAFMaxRequestBodySizeVarName = "APPGW_WAF_MAX_REQUEST_BODY_SIZE_KB"
+
+	// WAFFileUploadLimitVarName is the size, in MB, of the largest file upload the web application firewall lets through.
+	WAFFileUploadLimitVarName = "APPGW_WAF_FILE_UPLOAD_LIMIT_MB"
+
+	// AGICPodNameVarName is the name of the AGIC Pod, which events about the controller itself are attached to.
+	AGICPodNameVarName = "AGIC_POD_NAME"
+
+	// AGICPodNamespaceVarName is the namespace of the AGIC Pod.
+	AGICPodNamespaceVarName = "AGIC_POD_NAMESPACE"
 )
 
 // EnvVariables is a struct storing values for environment variables.
 type EnvVariables struct {
-	SubscriptionID             string
-	ResourceGroupName          string
-	AppGwName                  string
-	AuthLocation               string
-	WatchNamespace             string
-	UsePrivateIP               string
-	VerbosityLevel             string
-	EnableBrownfieldDeployment string
-	EnableIstioIntegration     string
-	EnableSaveConfigToFile     string
+	SubscriptionID              string
+	ResourceGroupName           string
+	AppGwName                   string
+	AuthLocation                string
+	UseAzureCLIAuth             string
+	WatchNamespace              string
+	UsePrivateIP                string
+	VerbosityLevel              string
+	EnableBrownfieldDeployment  string
+	EnableIstioIntegration      string
+	EnableKnativeIntegration    string
+	EnableSaveConfigToFile      string
+	AllowedFrontendPorts        string
+	EnableHostnameOwnership     string
+	NamespaceQuotas             string
+	ScaleWarningThreshold       string
+	ObserveOnly                 string
+	EnableZoneAwareBackends     string
+	ExcludeVirtualNodePods      string
+	ExcludeNodesSelector        string
+	ExcludeNodesTaints          string
+	DiagnosticsWorkspaceID      string
+	DiagnosticsStorageAccountID string
+	DiagnosticsLogs             string
+	ManageNSGRules              string
+	BackendDefaultsConfigMap    string
+	DefaultSSLCertificate       string
+	AuditWorkspaceID            string
+	AuditWorkspaceKey           string
+	NotificationWebhookURL      string
+	NotificationEventGridKey    string
+	ProhibitedTargetsConfigMap  string
+	ProhibitedTargetsCRD        string
+	EnableTwoPhaseApply         string
+	WAFRequestBodyCheck         string
+	WAFMaxRequestBodySize       string
+	WAFFileUploadLimit          string
+	AGICPodName                 string
+	AGICPodNamespace            string
 }
 
+var percentageValidator = regexp.MustCompile(`^(100|[1-9]?[0-9])$`)
+
+var boolValidator = regexp.MustCompile(`^(true|false)$`)
+
+var taintsValidator = regexp.MustCompile(`^\s*[^\s,=]+(=[^\s,=]*)?(\s*,\s*[^\s,=]+(=[^\s,=]*)?)*\s*$`)
+
+var secretKeyValidator = regexp.MustCompile(`^[^/\s]+/[^/\s]+$`)
+
+// requestBodySizeValidator accepts 1 to 128 (KB), and fileUploadLimitValidator 0 to 500 (MB).
+var requestBodySizeValidator = regexp.MustCompile(`^([1-9]|[1-9][0-9]|1[01][0-9]|12[0-8])$`)
+
+var fileUploadLimitValidator = regexp.MustCompile(`^([0-9]|[1-9][0-9]|[1-4][0-9][0-9]|500)$`)
+
+var portRangesValidator = regexp.MustCompile(`^\s*\d+(\s*-\s*\d+)?(\s*,\s*\d+(\s*-\s*\d+)?)*\s*$`)
+
 // GetEnv returns values for defined environment variables for Ingress Controller.
 func GetEnv() EnvVariables {
 	env := EnvVariables{
-		SubscriptionID:             os.Getenv(SubscriptionIDVarName),
-		ResourceGroupName:          os.Getenv(ResourceGroupNameVarName),
-		AppGwName:                  os.Getenv(AppGwNameVarName),
-		AuthLocation:               os.Getenv(AuthLocationVarName),
-		WatchNamespace:             os.Getenv(WatchNamespaceVarName),
-		UsePrivateIP:               os.Getenv(UsePrivateIPVarName),
-		VerbosityLevel:             os.Getenv(VerbosityLevelVarName),
-		EnableBrownfieldDeployment: os.Getenv(EnableBrownfieldDeploymentVarName),
-		EnableIstioIntegration:     os.Getenv(EnableIstioIntegrationVarName),
-		EnableSaveConfigToFile:     os.Getenv(EnableSaveConfigToFileVarName),
+		SubscriptionID:              os.Getenv(SubscriptionIDVarName),
+		ResourceGroupName:           os.Getenv(ResourceGroupNameVarName),
+		AppGwName:                   os.Getenv(AppGwNameVarName),
+		AuthLocation:                os.Getenv(AuthLocationVarName),
+		UseAzureCLIAuth:             os.Getenv(UseAzureCLIAuthVarName),
+		WatchNamespace:              os.Getenv(WatchNamespaceVarName),
+		UsePrivateIP:                os.Getenv(UsePrivateIPVarName),
+		VerbosityLevel:              os.Getenv(VerbosityLevelVarName),
+		EnableBrownfieldDeployment:  os.Getenv(EnableBrownfieldDeploymentVarName),
+		EnableIstioIntegration:      os.Getenv(EnableIstioIntegrationVarName),
+		EnableKnativeIntegration:    os.Getenv(EnableKnativeIntegrationVarName),
+		EnableSaveConfigToFile:      os.Getenv(EnableSaveConfigToFileVarName),
+		AllowedFrontendPorts:        GetEnvironmentVariable(AllowedFrontendPortsVarName, "", portRangesValidator),
+		EnableHostnameOwnership:     os.Getenv(EnableHostnameOwnershipVarName),
+		NamespaceQuotas:             os.Getenv(NamespaceQuotasVarName),
+		ScaleWarningThreshold:       GetEnvironmentVariable(ScaleWarningThresholdVarName, "80", percentageValidator),
+		ObserveOnly:                 os.Getenv(ObserveOnlyVarName),
+		EnableZoneAwareBackends:     os.Getenv(EnableZoneAwareBackendsVarName),
+		ExcludeVirtualNodePods:      GetEnvironmentVariable(ExcludeVirtualNodePodsVarName, "", boolValidator),
+		ExcludeNodesSelector:        os.Getenv(ExcludeNodesSelectorVarName),
+		ExcludeNodesTaints:          GetEnvironmentVariable(ExcludeNodesTaintsVarName, "", taintsValidator),
+		DiagnosticsWorkspaceID:      os.Getenv(DiagnosticsWorkspaceIDVarName),
+		DiagnosticsStorageAccountID: os.Getenv(DiagnosticsStorageAccountIDVarName),
+		DiagnosticsLogs:             GetEnvironmentVariable(DiagnosticsLogsVarName, "ApplicationGatewayAccessLog,ApplicationGatewayPerformanceLog,ApplicationGatewayFirewallLog", nil),
+		ManageNSGRules:              GetEnvironmentVariable(ManageNSGRulesVarName, "", boolValidator),
+		BackendDefaultsConfigMap:    os.Getenv(BackendDefaultsConfigMapVarName),
+		DefaultSSLCertificate:       GetEnvironmentVariable(DefaultSSLCertificateVarName, "", secretKeyValidator),
+		AuditWorkspaceID:            os.Getenv(AuditWorkspaceIDVarName),
+		AuditWorkspaceKey:           os.Getenv(AuditWorkspaceKeyVarName),
+		NotificationWebhookURL:      os.Getenv(NotificationWebhookURLVarName),
+		NotificationEventGridKey:    os.Getenv(NotificationEventGridKeyVarName),
+		ProhibitedTargetsConfigMap:  os.Getenv(ProhibitedTargetsConfigMapVarName),
+		ProhibitedTargetsCRD:        GetEnvironmentVariable(ProhibitedTargetsCRDVarName, "true", boolValidator),
+		EnableTwoPhaseApply:         GetEnvironmentVariable(EnableTwoPhaseApplyVarName, "", boolValidator),
+		WAFRequestBodyCheck:         GetEnvironmentVariable(WAFRequestBodyCheckVarName, "", boolValidator),
+		WAFMaxRequestBodySize:       GetEnvironmentVariable(WAFMaxRequestBodySizeVarName, "", requestBodySizeValidator),
+		WAFFileUploadLimit:          GetEnvironmentVariable(WAFFileUploadLimitVarName, "", fileUploadLimitValidator),
+		AGICPodName:                 os.Getenv(AGICPodNameVarName),
+		AGICPodNamespace:            os.Getenv(AGICPodNamespaceVarName),
 	}
 
 	return env
@@ -85,6 +258,22 @@ func ValidateEnv(env EnvVariables) {
 	if env.WatchNamespace == "" {
 		glog.V(1).Infof("%s is not set. Watching all available namespaces.", WatchNamespaceVarName)
 	}
+
+	if _, err := labels.Parse(env.ExcludeNodesSelector); err != nil {
+		glog.Fatalf("Error while parsing the label selector in %s: %s", ExcludeNodesSelectorVarName, err)
+	}
+
+	if env.BackendDefaultsConfigMap != "" && env.AGICPodNamespace == "" {
+		glog.Fatalf("%s requires the namespace of AGIC in %s", BackendDefaultsConfigMapVarName, AGICPodNamespaceVarName)
+	}
+
+	if env.ProhibitedTargetsConfigMap != "" && env.AGICPodNamespace == "" {
+		glog.Fatalf("%s requires the namespace of AGIC in %s", ProhibitedTargetsConfigMapVarName, AGICPodNamespaceVarName)
+	}
+
+	if env.AuditWorkspaceID != "" && env.AuditWorkspaceKey == "" {
+		glog.Fatalf("%s requires the shared key of the workspace in %s", AuditWorkspaceIDVarName, AuditWorkspaceKeyVarName)
+	}
 }
 
 // GetEnvironmentVariable is an augmentation of os.Getenv, providing it with a default value.
@@ -31,10 +31,17 @@ func IsMissingAnnotations(e error) bool {
 	return e == ErrMissingAnnotations
 }
 
-// NewInvalidAnnotationContent returns a new InvalidContent error
-func NewInvalidAnnotationContent(name string, val interface{}) error {
+// NewInvalidAnnotationContent returns a new InvalidContent error, naming the annotation, its value and the accepted format
+func NewInvalidAnnotationContent(name string, val interface{}, format string) error {
 	return InvalidContent{
-		Name: fmt.Sprintf("the annotation %v does not contain a valid value (%v)", name, val),
+		Name: fmt.Sprintf("the annotation %v has the invalid value %q; expected %s", name, fmt.Sprint(val), format),
+	}
+}
+
+// NewUnknownAnnotation returns a new InvalidContent error for an annotation, which is not known to the controller
+func NewUnknownAnnotation(name string) error {
+	return InvalidContent{
+		Name: fmt.Sprintf("the annotation %v is not known to the controller and is ignored", name),
 	}
 }
 
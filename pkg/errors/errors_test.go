@@ -25,7 +25,7 @@ func TestInvalidContent(t *testing.T) {
 	if IsInvalidContent(ErrMissingAnnotations) {
 		t.Error("expected false")
 	}
-	err := NewInvalidAnnotationContent("demo", "")
+	err := NewInvalidAnnotationContent("demo", "", "a value")
 	if !IsInvalidContent(err) {
 		t.Error("expected true")
 	}
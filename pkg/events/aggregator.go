@@ -0,0 +1,138 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// DefaultAggregationInterval is how often a warning, which keeps recurring, is emitted again.
+const DefaultAggregationInterval = 10 * time.Minute
+
+// eventKey identifies identical events: same object, type, reason and message.
+type eventKey struct {
+	object    string
+	eventType string
+	reason    string
+	message   string
+}
+
+// aggregatedEvent counts the occurrences of an event since it was last emitted.
+type aggregatedEvent struct {
+	lastEmitted time.Time
+	suppressed  int
+}
+
+// aggregatingRecorder emits a warning, which recurs identically on every resync, at most once per interval.
+// Kubernetes folds identical events into a single Event, whose count and last timestamp are refreshed every time it is emitted;
+// the occurrences in between are only counted. This keeps the rate limit of the events of an object, which Kubernetes
+// shares between all its events, for new problems.
+type aggregatingRecorder struct {
+	recorder record.EventRecorder
+	interval time.Duration
+	now      func() time.Time
+
+	mutex  sync.Mutex
+	events map[eventKey]*aggregatedEvent
+}
+
+// NewAggregatingRecorder returns a recorder emitting identical warnings at most once per interval.
+// Normal events are always emitted. The recorder is returned as is when the interval is not positive.
+func NewAggregatingRecorder(recorder record.EventRecorder, interval time.Duration) record.EventRecorder {
+	if interval <= 0 {
+		return recorder
+	}
+	return &aggregatingRecorder{
+		recorder: recorder,
+		interval: interval,
+		now:      time.Now,
+		events:   make(map[eventKey]*aggregatedEvent),
+	}
+}
+
+// Event emits the event, unless it is a warning already emitted within the interval.
+func (r *aggregatingRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.shouldEmit(object, eventtype, reason, message) {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf is just like Event, but with Sprintf for the message field.
+func (r *aggregatingRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// PastEventf is never aggregated, as it records an event which already happened.
+func (r *aggregatingRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.recorder.PastEventf(object, timestamp, eventtype, reason, messageFmt, args...)
+}
+
+// AnnotatedEventf is just like Eventf, but with annotations attached.
+func (r *aggregatingRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r.shouldEmit(object, eventtype, reason, message) {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// shouldEmit tells whether the event is a normal one, a new warning, or a warning last emitted longer than the interval ago.
+func (r *aggregatingRecorder) shouldEmit(object runtime.Object, eventtype, reason, message string) bool {
+	if eventtype != v1.EventTypeWarning {
+		return true
+	}
+	key := eventKey{object: objectKey(object), eventType: eventtype, reason: reason, message: message}
+	now := r.now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	event, exists := r.events[key]
+	if !exists {
+		r.forgetExpired(now)
+		r.events[key] = &aggregatedEvent{lastEmitted: now}
+		return true
+	}
+	if now.Sub(event.lastEmitted) < r.interval {
+		event.suppressed++
+		return false
+	}
+	if event.suppressed > 0 {
+		glog.V(5).Infof("Event %s of %s recurred %d times since %s: %s", reason, key.object, event.suppressed, event.lastEmitted.Format(time.RFC3339), message)
+	}
+	event.lastEmitted = now
+	event.suppressed = 0
+	return true
+}
+
+// forgetExpired drops the events, which did not recur within the interval; they are emitted right away when they do.
+func (r *aggregatingRecorder) forgetExpired(now time.Time) {
+	for key, event := range r.events {
+		if now.Sub(event.lastEmitted) >= r.interval {
+			delete(r.events, key)
+		}
+	}
+}
+
+// objectKey identifies the object of an event by its type and UID, or its namespace and name when it has no UID.
+func objectKey(object runtime.Object) string {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return fmt.Sprintf("%T", object)
+	}
+	if uid := accessor.GetUID(); uid != "" {
+		return fmt.Sprintf("%T/%s", object, uid)
+	}
+	return fmt.Sprintf("%T/%s/%s", object, accessor.GetNamespace(), accessor.GetName())
+}
@@ -0,0 +1,88 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package events
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// events_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test the aggregation of events", func() {
+	var fakeRecorder *record.FakeRecorder
+	var recorder *aggregatingRecorder
+	var now time.Time
+
+	ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress", UID: "ingress-uid"}}
+	otherIngress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other", UID: "other-uid"}}
+
+	emitted := func() []string {
+		var events []string
+		for {
+			select {
+			case event := <-fakeRecorder.Events:
+				events = append(events, event)
+			default:
+				return events
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		fakeRecorder = record.NewFakeRecorder(100)
+		recorder = NewAggregatingRecorder(fakeRecorder, time.Minute).(*aggregatingRecorder)
+		now = time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+		recorder.now = func() time.Time { return now }
+	})
+
+	It("should emit a recurring warning once per interval", func() {
+		for i := 0; i < 5; i++ {
+			recorder.Eventf(ingress, v1.EventTypeWarning, ReasonSecretNotFound, "secret %q not found", "default/tls")
+		}
+		Expect(emitted()).To(Equal([]string{"Warning SecretNotFound secret \"default/tls\" not found"}))
+
+		now = now.Add(30 * time.Second)
+		recorder.Event(ingress, v1.EventTypeWarning, ReasonSecretNotFound, "secret \"default/tls\" not found")
+		Expect(emitted()).To(BeEmpty())
+		Expect(recorder.events).To(HaveLen(1))
+		for _, event := range recorder.events {
+			Expect(event.suppressed).To(Equal(5))
+		}
+
+		now = now.Add(30 * time.Second)
+		recorder.Event(ingress, v1.EventTypeWarning, ReasonSecretNotFound, "secret \"default/tls\" not found")
+		Expect(emitted()).To(HaveLen(1))
+	})
+
+	It("should emit distinct warnings and normal events right away", func() {
+		recorder.Event(ingress, v1.EventTypeWarning, ReasonSecretNotFound, "invalid")
+		recorder.Event(ingress, v1.EventTypeWarning, ReasonSecretNotFound, "also invalid")
+		recorder.Event(ingress, v1.EventTypeWarning, ReasonServiceNotFound, "invalid")
+		recorder.Event(otherIngress, v1.EventTypeWarning, ReasonSecretNotFound, "invalid")
+		recorder.Event(ingress, v1.EventTypeNormal, ReasonSecretNotFound, "invalid")
+		recorder.Event(ingress, v1.EventTypeNormal, ReasonSecretNotFound, "invalid")
+		Expect(emitted()).To(HaveLen(6))
+	})
+
+	It("should forget warnings which stopped recurring", func() {
+		recorder.Event(ingress, v1.EventTypeWarning, ReasonSecretNotFound, "invalid")
+		now = now.Add(2 * time.Minute)
+		recorder.Event(otherIngress, v1.EventTypeWarning, ReasonSecretNotFound, "invalid")
+		Expect(recorder.events).To(HaveLen(1))
+		Expect(emitted()).To(HaveLen(2))
+	})
+
+	It("should not wrap the recorder without an interval", func() {
+		Expect(NewAggregatingRecorder(fakeRecorder, 0)).To(Equal(fakeRecorder))
+	})
+})
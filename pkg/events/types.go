@@ -23,4 +23,61 @@ const (
 
 	// ReasonPortResolutionError is a reason for an event to be emitted.
 	ReasonPortResolutionError = "PortResolutionError"
+
+	// ReasonFrontendPortNotAllowed is a reason for an event to be emitted.
+	ReasonFrontendPortNotAllowed = "FrontendPortNotAllowed"
+
+	// ReasonHostnameConflict is a reason for an event to be emitted.
+	ReasonHostnameConflict = "HostnameConflict"
+
+	// ReasonNamespaceQuotaExceeded is a reason for an event to be emitted.
+	ReasonNamespaceQuotaExceeded = "NamespaceQuotaExceeded"
+
+	// ReasonARMAuthFailure is a reason for an event to be emitted.
+	ReasonARMAuthFailure = "ARMAuthFailure"
+
+	// ReasonProhibitedTargetImpact is a reason for an event to be emitted.
+	ReasonProhibitedTargetImpact = "ProhibitedTargetImpact"
+
+	// ReasonScaleLimitApproaching is a reason for an event to be emitted.
+	ReasonScaleLimitApproaching = "ScaleLimitApproaching"
+
+	// ReasonObservedConfigChange is a reason for an event to be emitted.
+	ReasonObservedConfigChange = "ObservedConfigChange"
+
+	// ReasonGRPCNotSupported is a reason for an event to be emitted.
+	ReasonGRPCNotSupported = "GRPCNotSupported"
+
+	// ReasonDiagnosticSettingsFailure is a reason for an event to be emitted.
+	ReasonDiagnosticSettingsFailure = "DiagnosticSettingsFailure"
+
+	// ReasonReferencedResourceNotFound is a reason for an event to be emitted.
+	ReasonReferencedResourceNotFound = "ReferencedResourceNotFound"
+
+	// ReasonNSGRuleFailure is a reason for an event to be emitted.
+	ReasonNSGRuleFailure = "NSGRuleFailure"
+
+	// ReasonSubnetMisconfigured is a reason for an event to be emitted.
+	ReasonSubnetMisconfigured = "SubnetMisconfigured"
+
+	// ReasonFrontendIPChanged is a reason for an event to be emitted.
+	ReasonFrontendIPChanged = "FrontendIPChanged"
+
+	// ReasonBackendUnhealthy is a reason for an event to be emitted.
+	ReasonBackendUnhealthy = "BackendUnhealthy"
+
+	// ReasonPathConflict is a reason for an event to be emitted.
+	ReasonPathConflict = "PathConflict"
+
+	// ReasonHTTPSOnlyWithoutTLS is a reason for an event to be emitted.
+	ReasonHTTPSOnlyWithoutTLS = "HTTPSOnlyWithoutTLS"
+
+	// ReasonInvalidAnnotation is a reason for an event to be emitted.
+	ReasonInvalidAnnotation = "InvalidAnnotation"
+
+	// ReasonDryRun is a reason for an event to be emitted.
+	ReasonDryRun = "DryRun"
+
+	// ReasonWatchdogRecovery is a reason for an event to be emitted.
+	ReasonWatchdogRecovery = "WatchdogRecovery"
 )
@@ -0,0 +1,244 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+// Package generator creates synthetic Kubernetes clusters (Ingresses, Services, Endpoints and Pods).
+// It is used by the config builder benchmarks and can be used to reproduce scale issues on a real cluster.
+package generator
+
+import (
+	"fmt"
+	"io"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+const (
+	// ServicePort is the port exposed by every generated Service.
+	ServicePort = int32(80)
+
+	// ContainerPort is the port exposed by every generated Pod.
+	ContainerPort = int32(8080)
+
+	// ProbePath is the path of the readiness probe of every generated Pod.
+	ProbePath = "/healthz"
+
+	selectorKey = "app"
+)
+
+// Options describes the shape of the synthetic cluster.
+type Options struct {
+	// Namespaces is the number of namespaces the objects are spread across.
+	Namespaces int
+
+	// IngressesPerNamespace is the number of Ingress resources in each namespace. Each Ingress has its own host and Service.
+	IngressesPerNamespace int
+
+	// PathsPerIngress is the number of paths in the single rule of each Ingress.
+	PathsPerIngress int
+
+	// EndpointsPerService is the number of Pods (and endpoint addresses) behind each Service.
+	EndpointsPerService int
+}
+
+// Cluster is the collection of objects created by Generate.
+type Cluster struct {
+	Ingresses []*v1beta1.Ingress
+	Services  []*v1.Service
+	Endpoints []*v1.Endpoints
+	Pods      []*v1.Pod
+}
+
+// Generate creates a synthetic cluster. The output is deterministic for given Options.
+func Generate(opts Options) *Cluster {
+	cluster := &Cluster{}
+	for nsIdx := 0; nsIdx < opts.Namespaces; nsIdx++ {
+		namespace := fmt.Sprintf("namespace-%d", nsIdx)
+		for ingIdx := 0; ingIdx < opts.IngressesPerNamespace; ingIdx++ {
+			name := fmt.Sprintf("app-%d", ingIdx)
+			host := fmt.Sprintf("%s.%s.example.com", name, namespace)
+			service := newService(namespace, name)
+			cluster.Services = append(cluster.Services, service)
+			cluster.Ingresses = append(cluster.Ingresses, newIngress(namespace, name, host, opts.PathsPerIngress))
+
+			endpoints := newEndpoints(namespace, name)
+			for podIdx := 0; podIdx < opts.EndpointsPerService; podIdx++ {
+				ip := fmt.Sprintf("10.%d.%d.%d", nsIdx%256, ingIdx%256, podIdx%256)
+				pod := newPod(namespace, fmt.Sprintf("%s-%d", name, podIdx), name, ip)
+				cluster.Pods = append(cluster.Pods, pod)
+				endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, v1.EndpointAddress{
+					IP: ip,
+					TargetRef: &v1.ObjectReference{
+						Kind:      "Pod",
+						Namespace: namespace,
+						Name:      pod.Name,
+					},
+				})
+			}
+			cluster.Endpoints = append(cluster.Endpoints, endpoints)
+		}
+	}
+	return cluster
+}
+
+// Objects returns all objects of the cluster; Services come before the Ingresses which reference them.
+func (c *Cluster) Objects() []runtime.Object {
+	var objects []runtime.Object
+	for _, obj := range c.Services {
+		objects = append(objects, obj)
+	}
+	for _, obj := range c.Endpoints {
+		objects = append(objects, obj)
+	}
+	for _, obj := range c.Pods {
+		objects = append(objects, obj)
+	}
+	for _, obj := range c.Ingresses {
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// WriteYAML writes the cluster as a multi-document YAML stream, which can be applied with kubectl.
+// Endpoints are omitted, since Kubernetes maintains those for Services with selectors.
+func (c *Cluster) WriteYAML(w io.Writer) error {
+	for _, obj := range c.Objects() {
+		if _, isEndpoints := obj.(*v1.Endpoints); isEndpoints {
+			continue
+		}
+		objYAML, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "---\n"); err != nil {
+			return err
+		}
+		if _, err := w.Write(objYAML); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newIngress(namespace, name, host string, paths int) *v1beta1.Ingress {
+	httpPaths := make([]v1beta1.HTTPIngressPath, 0, paths)
+	for pathIdx := 0; pathIdx < paths; pathIdx++ {
+		httpPaths = append(httpPaths, v1beta1.HTTPIngressPath{
+			Path: fmt.Sprintf("/path-%d/*", pathIdx),
+			Backend: v1beta1.IngressBackend{
+				ServiceName: name,
+				ServicePort: intstr.FromInt(int(ServicePort)),
+			},
+		})
+	}
+	ingress := &v1beta1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "extensions/v1beta1", Kind: "Ingress"},
+		ObjectMeta: newObjectMeta(namespace, name),
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: httpPaths,
+						},
+					},
+				},
+			},
+		},
+	}
+	ingress.Annotations = map[string]string{
+		annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass,
+	}
+	return ingress
+}
+
+func newService(namespace, name string) *v1.Service {
+	return &v1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: newObjectMeta(namespace, name),
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{selectorKey: name},
+			Ports: []v1.ServicePort{
+				{
+					Name:       "http",
+					Protocol:   v1.ProtocolTCP,
+					Port:       ServicePort,
+					TargetPort: intstr.FromInt(int(ContainerPort)),
+				},
+			},
+		},
+	}
+}
+
+func newEndpoints(namespace, name string) *v1.Endpoints {
+	return &v1.Endpoints{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+		ObjectMeta: newObjectMeta(namespace, name),
+		Subsets: []v1.EndpointSubset{
+			{
+				Ports: []v1.EndpointPort{
+					{
+						Name:     "http",
+						Port:     ContainerPort,
+						Protocol: v1.ProtocolTCP,
+					},
+				},
+			},
+		},
+	}
+}
+
+func newPod(namespace, name, app, ip string) *v1.Pod {
+	pod := &v1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: newObjectMeta(namespace, name),
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  app,
+					Image: "mcr.microsoft.com/dotnet/core/samples:aspnetapp",
+					Ports: []v1.ContainerPort{
+						{
+							Name:          "http",
+							ContainerPort: ContainerPort,
+							Protocol:      v1.ProtocolTCP,
+						},
+					},
+					ReadinessProbe: &v1.Probe{
+						Handler: v1.Handler{
+							HTTPGet: &v1.HTTPGetAction{
+								Path: ProbePath,
+								Port: intstr.FromInt(int(ContainerPort)),
+							},
+						},
+						PeriodSeconds:  10,
+						TimeoutSeconds: 5,
+					},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			PodIP: ip,
+		},
+	}
+	pod.Labels = map[string]string{selectorKey: app}
+	return pod
+}
+
+func newObjectMeta(namespace, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		UID:       types.UID(fmt.Sprintf("%s-%s", namespace, name)),
+	}
+}
@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package generator
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("test synthetic cluster generator", func() {
+	opts := Options{
+		Namespaces:            2,
+		IngressesPerNamespace: 3,
+		PathsPerIngress:       4,
+		EndpointsPerService:   5,
+	}
+
+	Context("test Generate()", func() {
+		cluster := Generate(opts)
+
+		It("should have created the requested number of objects", func() {
+			Expect(len(cluster.Ingresses)).To(Equal(6))
+			Expect(len(cluster.Services)).To(Equal(6))
+			Expect(len(cluster.Endpoints)).To(Equal(6))
+			Expect(len(cluster.Pods)).To(Equal(30))
+			Expect(len(cluster.Ingresses[0].Spec.Rules[0].HTTP.Paths)).To(Equal(4))
+			Expect(len(cluster.Endpoints[0].Subsets[0].Addresses)).To(Equal(5))
+			Expect(len(cluster.Objects())).To(Equal(48))
+		})
+
+		It("should be deterministic", func() {
+			Expect(Generate(opts)).To(Equal(cluster))
+		})
+	})
+
+	Context("test WriteYAML()", func() {
+		cluster := Generate(opts)
+
+		It("should write all objects except Endpoints", func() {
+			var buf bytes.Buffer
+			err := cluster.WriteYAML(&buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(strings.Count(buf.String(), "---\n")).To(Equal(42))
+			Expect(buf.String()).To(ContainSubstring("kind: Ingress"))
+			Expect(buf.String()).ToNot(ContainSubstring("kind: Endpoints"))
+		})
+	})
+})
@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchBackendDefaults creates the informer of the single ConfigMap with the backend defaults,
+// so changing the defaults triggers an update of the App Gateway like changing an Ingress does.
+func (c *Context) watchBackendDefaults(namespace, name string) {
+	informer := c.newConfigMapInformer(namespace, name)
+	c.informers.BackendDefaults = informer
+	c.Caches.BackendDefaults = informer.GetStore()
+}
+
+// newConfigMapInformer creates the informer of a single ConfigMap, whose changes are sent to the update channel.
+func (c *Context) newConfigMapInformer(namespace, name string) cache.SharedIndexInformer {
+	factory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, c.resyncPeriod,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	h := handlers{c}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    h.addFunc,
+		UpdateFunc: h.updateFunc,
+		DeleteFunc: h.deleteFunc,
+	})
+	return informer
+}
+
+// GetBackendDefaults returns the data of the ConfigMap with the backend defaults,
+// or nil when none is configured or it does not exist.
+func (c *Context) GetBackendDefaults() map[string]string {
+	if c.Caches.BackendDefaults == nil {
+		return nil
+	}
+	items := c.Caches.BackendDefaults.List()
+	if len(items) == 0 {
+		glog.V(5).Info("The ConfigMap with the backend defaults does not exist")
+		return nil
+	}
+	return items[0].(*v1.ConfigMap).Data
+}
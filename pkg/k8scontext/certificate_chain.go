@@ -0,0 +1,127 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	"github.com/golang/glog"
+)
+
+// normalizeCertificateChain returns the certificates of tls.crt without duplicates, ordered from the leaf to the root,
+// each followed by its issuer. Certificates, which are not part of the chain of the leaf, are kept at the end.
+func normalizeCertificateChain(data []byte) ([]byte, error) {
+	var certs []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if !containsCertificate(certs, cert) {
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+
+	ordered := []*x509.Certificate{findLeaf(certs)}
+	for current := ordered[0]; !isSelfSigned(current); {
+		issuer := findIssuer(certs, current, ordered)
+		if issuer == nil {
+			break
+		}
+		ordered = append(ordered, issuer)
+		current = issuer
+	}
+	for _, cert := range certs {
+		if !containsCertificate(ordered, cert) {
+			glog.V(3).Infof("certificate %q is not part of the chain of %q", cert.Subject, ordered[0].Subject)
+			ordered = append(ordered, cert)
+		}
+	}
+
+	var chain bytes.Buffer
+	for _, cert := range ordered {
+		if err := pem.Encode(&chain, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return nil, err
+		}
+	}
+	return chain.Bytes(), nil
+}
+
+// findLeaf returns the first certificate, which is not the issuer of another certificate, preferring ones not self-signed.
+func findLeaf(certs []*x509.Certificate) *x509.Certificate {
+	var selfSigned *x509.Certificate
+	for _, cert := range certs {
+		if isIssuerOfAny(certs, cert) {
+			continue
+		}
+		if !isSelfSigned(cert) {
+			return cert
+		}
+		if selfSigned == nil {
+			selfSigned = cert
+		}
+	}
+	if selfSigned != nil {
+		return selfSigned
+	}
+	return certs[0]
+}
+
+func isIssuerOfAny(certs []*x509.Certificate, issuer *x509.Certificate) bool {
+	for _, cert := range certs {
+		if cert != issuer && issuedBy(cert, issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+// findIssuer returns the certificate, which issued the given one and is not in the chain yet.
+func findIssuer(certs []*x509.Certificate, cert *x509.Certificate, chain []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range certs {
+		if !containsCertificate(chain, candidate) && issuedBy(cert, candidate) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func issuedBy(cert, issuer *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 {
+		return bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	return true
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return issuedBy(cert, cert)
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, existing := range certs {
+		if existing.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The k8scontext package declares its own Context, which clashes with the one of Ginkgo when dot-imported.
+var _ = ginkgo.Describe("Test normalizeCertificateChain()", func() {
+	type issued struct {
+		cert *x509.Certificate
+		key  *ecdsa.PrivateKey
+	}
+
+	newCertificate := func(serial int64, name string, parent *issued) issued {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Now(),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		}
+		parentCert, parentKey := template, key
+		if parent != nil {
+			parentCert, parentKey = parent.cert, parent.key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parentCert, key.Public(), parentKey)
+		Expect(err).ToNot(HaveOccurred())
+		cert, err := x509.ParseCertificate(der)
+		Expect(err).ToNot(HaveOccurred())
+		return issued{cert: cert, key: key}
+	}
+
+	toPEM := func(certs ...issued) []byte {
+		var data []byte
+		for _, cert := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.cert.Raw})...)
+		}
+		return data
+	}
+
+	var root, intermediate, leaf, unrelated issued
+
+	ginkgo.BeforeEach(func() {
+		root = newCertificate(1, "root", nil)
+		intermediate = newCertificate(2, "intermediate", &root)
+		leaf = newCertificate(3, "www.contoso.com", &intermediate)
+		unrelated = newCertificate(4, "unrelated", nil)
+	})
+
+	ginkgo.It("should keep a chain in the right order", func() {
+		Expect(normalizeCertificateChain(toPEM(leaf, intermediate, root))).To(Equal(toPEM(leaf, intermediate, root)))
+	})
+
+	ginkgo.It("should reorder and deduplicate the chain", func() {
+		Expect(normalizeCertificateChain(toPEM(root, leaf, intermediate, leaf))).To(Equal(toPEM(leaf, intermediate, root)))
+	})
+
+	ginkgo.It("should keep certificates outside of the chain at the end", func() {
+		Expect(normalizeCertificateChain(toPEM(intermediate, unrelated, leaf))).To(Equal(toPEM(leaf, intermediate, unrelated)))
+	})
+
+	ginkgo.It("should fail without certificates", func() {
+		_, err := normalizeCertificateChain([]byte("not a certificate"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -18,7 +18,11 @@ import (
 	"github.com/knative/pkg/apis/istio/v1alpha3"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -30,23 +34,31 @@ import (
 	istio_versioned "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned"
 	istio_externalversions "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/informers/externalversions"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/knative"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
 
 // NewContext creates a context based on a Kubernetes client instance.
-func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface, istioCrdClient istio_versioned.Interface, namespaces []string, resyncPeriod time.Duration) *Context {
+func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface, istioCrdClient istio_versioned.Interface, dynamicClient dynamic.Interface, namespaces []string, resyncPeriod time.Duration) *Context {
 	updateChannel := channels.NewRingChannel(1024)
 
 	var options []informers.SharedInformerOption
 	var crdOptions []externalversions.SharedInformerOption
+	informerNamespace := metav1.NamespaceAll
 	for _, namespace := range namespaces {
 		options = append(options, informers.WithNamespace(namespace))
 		crdOptions = append(crdOptions, externalversions.WithNamespace(namespace))
+		// Like the informer factory, the informers watch the last namespace.
+		informerNamespace = namespace
 	}
 	informerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, resyncPeriod, options...)
 	crdInformerFactory := externalversions.NewSharedInformerFactoryWithOptions(crdClient, resyncPeriod, crdOptions...)
 	istioCrdInformerFactory := istio_externalversions.NewSharedInformerFactoryWithOptions(istioCrdClient, resyncPeriod)
+	// Nodes are not namespaced; these are watched regardless of the namespaces.
+	clusterInformerFactory := informers.NewSharedInformerFactory(kubeClient, resyncPeriod)
+	// Knative Ingresses, like Istio resources, are watched regardless of the namespaces; ClusterIngresses are not namespaced.
+	knativeInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, resyncPeriod)
 
 	informerCollection := InformerCollection{
 		Endpoints: informerFactory.Core().V1().Endpoints().Informer(),
@@ -54,22 +66,30 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 		Pods:      informerFactory.Core().V1().Pods().Informer(),
 		Secret:    informerFactory.Core().V1().Secrets().Informer(),
 		Service:   informerFactory.Core().V1().Services().Informer(),
+		Nodes:     clusterInformerFactory.Core().V1().Nodes().Informer(),
 
 		AzureIngressProhibitedLocation: crdInformerFactory.Azureingressprohibitedtargets().V1().AzureIngressProhibitedTargets().Informer(),
 
 		IstioGateway:        istioCrdInformerFactory.Networking().V1alpha3().Gateways().Informer(),
 		IstioVirtualService: istioCrdInformerFactory.Networking().V1alpha3().VirtualServices().Informer(),
+
+		KnativeIngress:        knativeInformerFactory.ForResource(knative.IngressesResource).Informer(),
+		KnativeClusterIngress: knativeInformerFactory.ForResource(knative.ClusterIngressesResource).Informer(),
 	}
 
+	// The informers the watchdog may restart have caches, which are replaced along with them.
 	cacheCollection := CacheCollection{
-		Endpoints:                      informerCollection.Endpoints.GetStore(),
-		Ingress:                        informerCollection.Ingress.GetStore(),
-		Pods:                           informerCollection.Pods.GetStore(),
-		Secret:                         informerCollection.Secret.GetStore(),
-		Service:                        informerCollection.Service.GetStore(),
+		Endpoints:                      newRestartableStore(informerCollection.Endpoints.GetStore()),
+		Ingress:                        newRestartableStore(informerCollection.Ingress.GetStore()),
+		Pods:                           newRestartableStore(informerCollection.Pods.GetStore()),
+		Secret:                         newRestartableStore(informerCollection.Secret.GetStore()),
+		Service:                        newRestartableStore(informerCollection.Service.GetStore()),
+		Nodes:                          informerCollection.Nodes.GetStore(),
 		AzureIngressProhibitedLocation: informerCollection.AzureIngressProhibitedLocation.GetStore(),
 		IstioGateway:                   informerCollection.IstioGateway.GetStore(),
 		IstioVirtualService:            informerCollection.IstioVirtualService.GetStore(),
+		KnativeIngress:                 informerCollection.KnativeIngress.GetStore(),
+		KnativeClusterIngress:          informerCollection.KnativeClusterIngress.GetStore(),
 	}
 
 	context := &Context{
@@ -78,6 +98,12 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 		Caches:                 &cacheCollection,
 		CertificateSecretStore: NewSecretStore(),
 		UpdateChannel:          updateChannel,
+		kubeClient:             kubeClient,
+		resyncPeriod:           resyncPeriod,
+		namespace:              informerNamespace,
+		informerOptions:        options,
+		crdClient:              crdClient,
+		dynamicClient:          dynamicClient,
 	}
 
 	h := handlers{context}
@@ -100,13 +126,29 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 		DeleteFunc: h.secretDeleteFunc,
 	}
 
+	knativeResourceHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    h.knativeAddFunc,
+		UpdateFunc: h.knativeUpdateFunc,
+		DeleteFunc: h.deleteFunc,
+	}
+
+	// Nodes joining or leaving the cluster bring and take their Pods along, which are followed on their own.
+	nodeResourceHandler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: h.updateFunc,
+	}
+
+	informerCollection.watched = newWatchedInformers(&informerCollection, &cacheCollection, resourceHandler, ingressResourceHandler, secretResourceHandler)
+
 	// Register event handlers.
 	informerCollection.Endpoints.AddEventHandler(resourceHandler)
 	informerCollection.Ingress.AddEventHandler(ingressResourceHandler)
 	informerCollection.Pods.AddEventHandler(resourceHandler)
 	informerCollection.Secret.AddEventHandler(secretResourceHandler)
 	informerCollection.Service.AddEventHandler(resourceHandler)
+	informerCollection.Nodes.AddEventHandler(nodeResourceHandler)
 	informerCollection.AzureIngressProhibitedLocation.AddEventHandler(resourceHandler)
+	informerCollection.KnativeIngress.AddEventHandler(knativeResourceHandler)
+	informerCollection.KnativeClusterIngress.AddEventHandler(knativeResourceHandler)
 
 	return context
 }
@@ -114,6 +156,22 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 // Run executes informer collection.
 func (c *Context) Run(stopChannel chan struct{}, omitCRDs bool, envVariables environment.EnvVariables) {
 	glog.V(1).Infoln("k8s context run started")
+	if envVariables.BackendDefaultsConfigMap != "" {
+		c.watchBackendDefaults(envVariables.AGICPodNamespace, envVariables.BackendDefaultsConfigMap)
+	}
+	if watchProhibitedTargetsCRD(envVariables) {
+		c.writeProhibitedTargetStatus(stopChannel)
+	}
+	if envVariables.EnableBrownfieldDeployment == "true" && envVariables.ProhibitedTargetsConfigMap != "" {
+		c.watchProhibitedTargetsConfigMap(envVariables.AGICPodNamespace, envVariables.ProhibitedTargetsConfigMap)
+	}
+	if envVariables.AGICPodNamespace != "" {
+		c.watchOrphanedIngresses(envVariables.AGICPodNamespace)
+	}
+	if envVariables.DefaultSSLCertificate != "" {
+		namespace, name := utils.ParseResourceKey(envVariables.DefaultSSLCertificate)
+		c.watchDefaultSSLCertificate(namespace, name)
+	}
 	c.informers.Run(stopChannel, omitCRDs, envVariables)
 	glog.V(1).Infoln("k8s context run finished")
 }
@@ -125,6 +183,8 @@ func (i *InformerCollection) Run(stopCh chan struct{}, omitCRDs bool, envVariabl
 		i.AzureIngressProhibitedLocation: nil,
 		i.IstioGateway:                   nil,
 		i.IstioVirtualService:            nil,
+		i.KnativeIngress:                 nil,
+		i.KnativeClusterIngress:          nil,
 	}
 
 	sharedInformers := []cache.SharedInformer{
@@ -136,7 +196,7 @@ func (i *InformerCollection) Run(stopCh chan struct{}, omitCRDs bool, envVariabl
 	}
 
 	// For AGIC to watch for these CRDs the EnableBrownfieldDeploymentVarName env variable must be set to true
-	if envVariables.EnableBrownfieldDeployment == "true" {
+	if watchProhibitedTargetsCRD(envVariables) {
 		sharedInformers = append(sharedInformers,
 			i.AzureIngressProhibitedLocation)
 	}
@@ -146,8 +206,38 @@ func (i *InformerCollection) Run(stopCh chan struct{}, omitCRDs bool, envVariabl
 			i.IstioGateway, i.IstioVirtualService)
 	}
 
+	if envVariables.EnableKnativeIntegration == "true" {
+		sharedInformers = append(sharedInformers,
+			i.KnativeIngress, i.KnativeClusterIngress)
+	}
+
+	if i.BackendDefaults != nil {
+		sharedInformers = append(sharedInformers, i.BackendDefaults)
+	}
+
+	if i.ProhibitedTargetsConfigMap != nil {
+		sharedInformers = append(sharedInformers, i.ProhibitedTargetsConfigMap)
+	}
+
+	if i.OrphanedIngresses != nil {
+		sharedInformers = append(sharedInformers, i.OrphanedIngresses)
+	}
+
+	if i.DefaultSSLCertificate != nil {
+		sharedInformers = append(sharedInformers, i.DefaultSSLCertificate)
+	}
+
+	// Nodes are only watched for their labels and taints; their frequent status updates do not trigger events.
+	if watchNodes(envVariables) {
+		sharedInformers = append(sharedInformers, i.Nodes)
+	}
+
 	for _, informer := range sharedInformers {
-		go informer.Run(stopCh)
+		if watched := i.watchedInformer(informer); watched != nil {
+			watched.run(stopCh)
+		} else {
+			go informer.Run(stopCh)
+		}
 		// NOTE: Delyan could not figure out how to make informer.HasSynced == true for the CRDs in unit tests
 		// so until we do that - we omit WaitForCacheSync for CRDs in unit testing
 		if _, isCRD := crds[informer]; isCRD {
@@ -197,21 +287,12 @@ func (c *Context) GetEndpointsByService(serviceKey string) (*v1.Endpoints, error
 }
 
 // ListPodsByServiceSelector returns pods that are associated with a specific service.
+// The labels are compared in place, as this runs for every backend against every Pod of the cluster.
 func (c *Context) ListPodsByServiceSelector(selector map[string]string) []*v1.Pod {
-	selectorSet := mapset.NewSet()
-	for k, v := range selector {
-		selectorSet.Add(k + ":" + v)
-	}
-
 	var podList []*v1.Pod
 	for _, podInterface := range c.Caches.Pods.List() {
 		pod := podInterface.(*v1.Pod)
-		podLabelSet := mapset.NewSet()
-		for k, v := range pod.Labels {
-			podLabelSet.Add(k + ":" + v)
-		}
-
-		if selectorSet.IsSubset(podLabelSet) {
+		if hasLabels(pod.Labels, selector) {
 			podList = append(podList, pod)
 		}
 	}
@@ -219,8 +300,34 @@ func (c *Context) ListPodsByServiceSelector(selector map[string]string) []*v1.Po
 	return podList
 }
 
-// IsPodReferencedByAnyIngress provides whether a POD is useful i.e. a POD is used by an ingress
+// hasLabels tells whether the labels include all the labels of the selector.
+func hasLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if value, exists := labels[k]; !exists || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// GetPodByIP returns the running Pod with the given IP address, or nil when there is none.
+// Pods which completed are left out, as their address may have been given to another Pod.
+func (c *Context) GetPodByIP(ip string) *v1.Pod {
+	for _, podInterface := range c.Caches.Pods.List() {
+		pod := podInterface.(*v1.Pod)
+		if pod.Status.PodIP == ip && pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			return pod
+		}
+	}
+	return nil
+}
+
+// IsPodReferencedByAnyIngress provides whether a POD is useful i.e. a POD is used by an ingress, through a Service or a pod selector
 func (c *Context) IsPodReferencedByAnyIngress(pod *v1.Pod) bool {
+	if c.isPodSelectedByAnyIngress(pod) {
+		return true
+	}
+
 	// first find all the services
 	services := c.listServicesByPodSelector(pod)
 
@@ -248,6 +355,8 @@ func (c *Context) ListHTTPIngresses() []*v1beta1.Ingress {
 			ingressList = append(ingressList, ingress)
 		}
 	}
+	// Knative Ingresses are translated into Ingresses, so they are processed like the Ingresses they are equivalent to.
+	ingressList = append(ingressList, c.listKnativeIngresses()...)
 	// Sorting the return list ensures that the iterations over this list and
 	// subsequently created structs have deterministic order. This increases
 	// cache hits, and lowers the load on ARM.
@@ -308,6 +417,28 @@ func (c *Context) GetService(serviceKey string) *v1.Service {
 	return service
 }
 
+// watchNodes tells whether a feature needing the nodes of the cluster, such as their zones, labels or taints, is enabled.
+func watchNodes(envVariables environment.EnvVariables) bool {
+	return envVariables.EnableZoneAwareBackends == "true" || envVariables.ExcludeVirtualNodePods != "" ||
+		envVariables.ExcludeNodesSelector != "" || envVariables.ExcludeNodesTaints != ""
+}
+
+// watchProhibitedTargetsCRD tells whether AzureIngressProhibitedTarget CRDs are watched: with brownfield deployment enabled,
+// unless the CRD is turned off because it cannot be installed, and the prohibited targets are in a ConfigMap instead.
+func watchProhibitedTargetsCRD(envVariables environment.EnvVariables) bool {
+	return envVariables.EnableBrownfieldDeployment == "true" && envVariables.ProhibitedTargetsCRD != "false"
+}
+
+// GetNode returns the node with the given name, or nil when nodes are not watched or the node does not exist.
+func (c *Context) GetNode(nodeName string) *v1.Node {
+	nodeInterface, exist, err := c.Caches.Nodes.GetByKey(nodeName)
+	if err != nil || !exist {
+		glog.V(5).Infof("unable to get node %s from store", nodeName)
+		return nil
+	}
+	return nodeInterface.(*v1.Node)
+}
+
 // GetSecret returns the secret identified by the key
 func (c *Context) GetSecret(secretKey string) *v1.Secret {
 	secretInterface, exist, err := c.Caches.Secret.GetByKey(secretKey)
@@ -402,6 +533,25 @@ func hasTCPPort(service *v1.Service) bool {
 	return false
 }
 
+// isPodSelectedByAnyIngress tells whether an Ingress in the namespace of the Pod selects it as a backend with the backend-pod-selectors annotation.
+func (c *Context) isPodSelectedByAnyIngress(pod *v1.Pod) bool {
+	for _, ingress := range c.ListHTTPIngresses() {
+		if ingress.Namespace != pod.Namespace {
+			continue
+		}
+		selectors, err := annotations.BackendPodSelectors(ingress)
+		if err != nil {
+			continue
+		}
+		for _, selector := range selectors {
+			if labels.SelectorFromSet(selector).Matches(labels.Set(pod.Labels)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (c *Context) listServicesByPodSelector(pod *v1.Pod) []*v1.Service {
 	labelSet := mapset.NewSet()
 	for k, v := range pod.Labels {
@@ -0,0 +1,79 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// watchDefaultSSLCertificate creates the informer of the single secret with the default certificate, which may be in a namespace
+// AGIC does not watch otherwise. The secret is converted as soon as it is seen, as no Ingress references it.
+func (c *Context) watchDefaultSSLCertificate(namespace, name string) {
+	factory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, c.resyncPeriod,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := factory.Core().V1().Secrets().Informer()
+	h := handlers{c}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    h.defaultSSLCertificateAddFunc,
+		UpdateFunc: h.defaultSSLCertificateUpdateFunc,
+		DeleteFunc: h.defaultSSLCertificateDeleteFunc,
+	})
+	c.informers.DefaultSSLCertificate = informer
+	c.DefaultSSLCertificate = utils.GetResourceKey(namespace, name)
+}
+
+func (h handlers) defaultSSLCertificateAddFunc(obj interface{}) {
+	sec := obj.(*v1.Secret)
+	if h.context.CertificateSecretStore.convertSecret(utils.GetResourceKey(sec.Namespace, sec.Name), sec) {
+		h.context.UpdateChannel.In() <- events.Event{
+			Type:  events.Create,
+			Value: obj,
+		}
+	}
+}
+
+func (h handlers) defaultSSLCertificateUpdateFunc(oldObj, newObj interface{}) {
+	if !isRelevantUpdate(oldObj, newObj) {
+		return
+	}
+	sec := newObj.(*v1.Secret)
+	if h.context.CertificateSecretStore.convertSecret(utils.GetResourceKey(sec.Namespace, sec.Name), sec) {
+		h.context.UpdateChannel.In() <- events.Event{
+			Type:  events.Update,
+			Value: newObj,
+		}
+	}
+}
+
+func (h handlers) defaultSSLCertificateDeleteFunc(obj interface{}) {
+	sec, ok := obj.(*v1.Secret)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			// unable to get from tombstone
+			return
+		}
+		sec, _ = tombstone.Obj.(*v1.Secret)
+	}
+	if sec == nil {
+		return
+	}
+	h.context.CertificateSecretStore.eraseSecret(utils.GetResourceKey(sec.Namespace, sec.Name))
+	h.context.UpdateChannel.In() <- events.Event{
+		Type:  events.Delete,
+		Value: obj,
+	}
+}
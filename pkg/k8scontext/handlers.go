@@ -1,8 +1,6 @@
 package k8scontext
 
 import (
-	"reflect"
-
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/client-go/tools/cache"
@@ -76,7 +74,7 @@ func (h handlers) ingressDeleteFunc(obj interface{}) {
 }
 
 func (h handlers) ingressUpdateFunc(oldObj, newObj interface{}) {
-	if reflect.DeepEqual(oldObj, newObj) {
+	if !isRelevantUpdate(oldObj, newObj) {
 		return
 	}
 	oldIng := oldObj.(*v1beta1.Ingress)
@@ -130,7 +128,7 @@ func (h handlers) secretAddFunc(obj interface{}) {
 }
 
 func (h handlers) secretUpdateFunc(oldObj, newObj interface{}) {
-	if reflect.DeepEqual(oldObj, newObj) {
+	if !isRelevantUpdate(oldObj, newObj) {
 		return
 	}
 
@@ -180,7 +178,7 @@ func (h handlers) addFunc(obj interface{}) {
 }
 
 func (h handlers) updateFunc(oldObj, newObj interface{}) {
-	if reflect.DeepEqual(oldObj, newObj) {
+	if !isRelevantUpdate(oldObj, newObj) {
 		return
 	}
 	h.context.UpdateChannel.In() <- events.Event{
@@ -0,0 +1,39 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+)
+
+// PublishIngressAddress sets the IP address of the App Gateway in the status of the Ingresses meant for AGIC, which do not
+// have it yet, and returns the Ingresses updated. Failures to update an Ingress are logged, and retried on the next call.
+func (c *Context) PublishIngressAddress(ip string) []*v1beta1.Ingress {
+	var updated []*v1beta1.Ingress
+	for _, obj := range c.Caches.Ingress.List() {
+		ingress := obj.(*v1beta1.Ingress)
+		if !isIngressApplicationGateway(ingress) || hasAddress(ingress, ip) {
+			continue
+		}
+		ingress = ingress.DeepCopy()
+		ingress.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: ip}}
+		result, err := c.kubeClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).UpdateStatus(ingress)
+		if err != nil {
+			glog.Errorf("Unable to publish address %s in the status of Ingress %s/%s: %s", ip, ingress.Namespace, ingress.Name, err)
+			continue
+		}
+		updated = append(updated, result)
+	}
+	return updated
+}
+
+// hasAddress tells whether the status of the Ingress has the IP address, and no other.
+func hasAddress(ingress *v1beta1.Ingress, ip string) bool {
+	addresses := ingress.Status.LoadBalancer.Ingress
+	return len(addresses) == 1 && addresses[0].IP == ip && addresses[0].Hostname == ""
+}
@@ -16,6 +16,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
 
@@ -94,7 +96,7 @@ var _ = Describe("K8scontext", func() {
 		Expect(err).Should(BeNil(), "Unabled to create ingress resource due to: %v", err)
 
 		// Create a `k8scontext` to start listening to ingress resources.
-		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, []string{ingressNS}, 1000*time.Second)
+		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), []string{ingressNS}, 1000*time.Second)
 
 		Expect(ctxt).ShouldNot(BeNil(), "Unable to create `k8scontext`")
 	})
@@ -312,6 +314,51 @@ var _ = Describe("K8scontext", func() {
 			// run IsPodReferencedByAnyIngress: false
 			Expect(ctxt.IsPodReferencedByAnyIngress(pod)).To(BeFalse(), "Expected is Pod is not selected by the service and ingress.")
 		})
+
+		It("should be able to select pods selected by the ingress without a service", func() {
+			// start context for syncing
+			ctxt.Run(stopChannel, true, environment.GetFakeEnv())
+
+			// select the POD by label in the ingress
+			selectingIngress := ingress.DeepCopy()
+			selectingIngress.Annotations[annotations.BackendPodSelectorsKey] = "web-0: random=random"
+			_, err := k8sClient.ExtensionsV1beta1().Ingresses(ingressNS).Update(selectingIngress)
+			Expect(err).Should(BeNil(), "Unable to update ingress resource due to: %v", err)
+
+			selectedPod := pod.DeepCopy()
+			selectedPod.Labels = map[string]string{
+				"random": "random",
+			}
+			_, err = k8sClient.CoreV1().Pods(ingressNS).Create(selectedPod)
+			Expect(err).Should(BeNil(), "Unable to create pod resource due to: %v", err)
+
+			// wait for sync
+			waitContextSync(ctxt, selectingIngress, selectedPod)
+
+			// run IsPodReferencedByAnyIngress: true
+			Expect(ctxt.IsPodReferencedByAnyIngress(selectedPod)).To(BeTrue(), "Expected is Pod is selected by the ingress.")
+		})
+	})
+
+	Context("Checking if we are able to follow nodes", func() {
+		It("should follow changes to the labels and the taints of the nodes", func() {
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "aks-spot-0", Labels: map[string]string{"agentpool": "spot"}}}
+			_, err := k8sClient.CoreV1().Nodes().Create(node)
+			Expect(err).Should(BeNil(), "Unable to create node resource due to: %v", err)
+
+			envVariables := environment.GetFakeEnv()
+			envVariables.ExcludeNodesSelector = "agentpool=spot"
+			ctxt.Run(stopChannel, true, envVariables)
+			waitContextSync(ctxt, ingress)
+
+			taintedNode := node.DeepCopy()
+			taintedNode.Spec.Taints = []v1.Taint{{Key: "node.kubernetes.io/unschedulable", Effect: v1.TaintEffectNoSchedule}}
+			_, err = k8sClient.CoreV1().Nodes().Update(taintedNode)
+			Expect(err).Should(BeNil(), "Unable to update node resource due to: %v", err)
+
+			waitContextSync(ctxt, taintedNode)
+			Expect(ctxt.GetNode(node.Name).Spec.Taints).To(Equal(taintedNode.Spec.Taints))
+		})
 	})
 
 	Context("Checking if we are able to skip unrelated endpoints events", func() {
@@ -372,4 +419,26 @@ var _ = Describe("K8scontext", func() {
 			Expect(ctxt.IsEndpointReferencedByAnyIngress(endpoints)).To(BeFalse(), "Expected is endpoints is not selected by the service and ingress.")
 		})
 	})
+
+	Context("Checking if we are able to publish the address of the App Gateway", func() {
+		It("should set the address in the status of the Ingresses which do not have it", func() {
+			ctxt.Run(stopChannel, true, environment.GetFakeEnv())
+
+			updated := ctxt.PublishIngressAddress("1.2.3.4")
+			Expect(updated).To(HaveLen(1))
+			Expect(updated[0].Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{{IP: "1.2.3.4"}}))
+
+			stored, err := k8sClient.ExtensionsV1beta1().Ingresses(ingressNS).Get(ingressName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{{IP: "1.2.3.4"}}))
+
+			// The cache is updated by the informer, once it observed the change.
+			Eventually(func() []v1.LoadBalancerIngress {
+				obj, _, _ := ctxt.Caches.Ingress.GetByKey(ingressNS + "/" + ingressName)
+				return obj.(*v1beta1.Ingress).Status.LoadBalancer.Ingress
+			}).Should(HaveLen(1))
+			Expect(ctxt.PublishIngressAddress("1.2.3.4")).To(BeEmpty())
+			Expect(ctxt.PublishIngressAddress("5.6.7.8")).To(HaveLen(1))
+		})
+	})
 })
@@ -0,0 +1,119 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/knative"
+)
+
+// listKnativeUnstructured returns the Knative Ingresses and ClusterIngresses meant for AGIC, as watched.
+func (c *Context) listKnativeUnstructured() []*unstructured.Unstructured {
+	var objs []*unstructured.Unstructured
+	for _, store := range []cache.Store{c.Caches.KnativeIngress, c.Caches.KnativeClusterIngress} {
+		if store == nil {
+			continue
+		}
+		for _, item := range store.List() {
+			obj, ok := item.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			if ingress, err := knative.FromUnstructured(obj); err == nil && knative.IsApplicationGatewayIngress(ingress) {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	return objs
+}
+
+// listKnativeIngresses returns the Knative Ingresses and ClusterIngresses meant for AGIC, translated into Ingresses.
+func (c *Context) listKnativeIngresses() []*v1beta1.Ingress {
+	var ingresses []*v1beta1.Ingress
+	for _, obj := range c.listKnativeUnstructured() {
+		ingress, _ := knative.FromUnstructured(obj)
+		translated, _ := knative.ToIngresses(ingress)
+		ingresses = append(ingresses, translated...)
+	}
+	return ingresses
+}
+
+// MarkKnativeIngressesReady reports the Knative Ingresses and ClusterIngresses meant for AGIC as ready in their status,
+// once their current generation was applied to the App Gateway. Knative Serving only routes traffic to ready Ingresses.
+func (c *Context) MarkKnativeIngressesReady() {
+	if c.dynamicClient == nil {
+		return
+	}
+	for _, obj := range c.listKnativeUnstructured() {
+		if knative.IsReady(obj) {
+			continue
+		}
+		updated := obj.DeepCopy()
+		if err := knative.MarkReady(updated); err != nil {
+			glog.Errorf("Unable to set the status of %s %s: %s", obj.GetKind(), obj.GetName(), err)
+			continue
+		}
+		var client dynamic.ResourceInterface = c.dynamicClient.Resource(knative.ClusterIngressesResource)
+		if obj.GetNamespace() != "" {
+			client = c.dynamicClient.Resource(knative.IngressesResource).Namespace(obj.GetNamespace())
+		}
+		if _, err := client.UpdateStatus(updated, metav1.UpdateOptions{}); err != nil {
+			glog.Errorf("Unable to update the status of %s %s: %s", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+}
+
+// knative resource handlers
+func (h handlers) knativeAddFunc(obj interface{}) {
+	if !h.logKnativeWarnings(obj) {
+		return
+	}
+	h.addFunc(obj)
+}
+
+func (h handlers) knativeUpdateFunc(oldObj, newObj interface{}) {
+	oldUnstructured, oldOK := oldObj.(*unstructured.Unstructured)
+	newUnstructured, newOK := newObj.(*unstructured.Unstructured)
+	// The status AGIC itself updates does not change the App Gateway config.
+	if oldOK && newOK && oldUnstructured.GetGeneration() == newUnstructured.GetGeneration() &&
+		oldUnstructured.GetAnnotations()[knative.IngressClassKey] == newUnstructured.GetAnnotations()[knative.IngressClassKey] {
+		return
+	}
+	h.logKnativeWarnings(newObj)
+	h.context.UpdateChannel.In() <- events.Event{
+		Type:  events.Update,
+		Value: newObj,
+	}
+}
+
+// logKnativeWarnings logs the parts of a Knative Ingress, which App Gateway cannot honor,
+// and tells whether the Ingress is meant for AGIC.
+func (h handlers) logKnativeWarnings(obj interface{}) bool {
+	unstructuredObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+	ingress, err := knative.FromUnstructured(unstructuredObj)
+	if err != nil {
+		glog.Errorf("Unable to parse %s %s: %s", unstructuredObj.GetKind(), unstructuredObj.GetName(), err)
+		return false
+	}
+	if !knative.IsApplicationGatewayIngress(ingress) {
+		return false
+	}
+	_, warnings := knative.ToIngresses(ingress)
+	for _, warning := range warnings {
+		glog.Warning(warning)
+	}
+	return true
+}
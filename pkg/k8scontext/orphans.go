@@ -0,0 +1,36 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+)
+
+// OrphanedIngressesConfigMapName is the name of the ConfigMap, in AGIC's namespace, with the hostnames and paths of the Ingresses
+// with the retain orphan policy. Once such an Ingress is deleted, its config is retained until its entry is removed from the ConfigMap.
+const OrphanedIngressesConfigMapName = "appgw-orphaned-ingresses"
+
+// watchOrphanedIngresses creates the informer of the ConfigMap of orphaned Ingresses, so removing an entry releases
+// the retained config like deleting an Ingress does.
+func (c *Context) watchOrphanedIngresses(namespace string) {
+	informer := c.newConfigMapInformer(namespace, OrphanedIngressesConfigMapName)
+	c.informers.OrphanedIngresses = informer
+	c.Caches.OrphanedIngresses = informer.GetStore()
+}
+
+// GetOrphanedIngressesConfigMap returns the ConfigMap of orphaned Ingresses, or nil when AGIC's namespace is not known or it does not exist.
+func (c *Context) GetOrphanedIngressesConfigMap() *v1.ConfigMap {
+	if c.Caches.OrphanedIngresses == nil {
+		return nil
+	}
+	items := c.Caches.OrphanedIngresses.List()
+	if len(items) == 0 {
+		glog.V(5).Info("The ConfigMap of orphaned Ingresses does not exist")
+		return nil
+	}
+	return items[0].(*v1.ConfigMap)
+}
@@ -0,0 +1,146 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/knative"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// permission is a verb AGIC performs on a Kubernetes resource.
+type permission struct {
+	group    string
+	resource string
+	verb     string
+
+	// clusterWide is set for resources, which are watched in all namespaces regardless of APPGW_WATCH_NAMESPACE.
+	clusterWide bool
+
+	// namespace is set for resources, which are only watched in that namespace regardless of APPGW_WATCH_NAMESPACE.
+	namespace string
+}
+
+func (p permission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource = fmt.Sprintf("%s.%s", p.resource, p.group)
+	}
+	return fmt.Sprintf("%s %s", p.verb, resource)
+}
+
+// getRequiredPermissions returns the permissions needed by the informers started by InformerCollection.Run and by the event recorder.
+func getRequiredPermissions(envVariables environment.EnvVariables) []permission {
+	var required []permission
+	watch := func(group string, clusterWide bool, resources ...string) {
+		for _, resource := range resources {
+			for _, verb := range []string{"list", "watch"} {
+				required = append(required, permission{group: group, resource: resource, verb: verb, clusterWide: clusterWide})
+			}
+		}
+	}
+
+	watch("", false, "endpoints", "pods", "services", "secrets")
+	watch("extensions", false, "ingresses")
+	if watchProhibitedTargetsCRD(envVariables) {
+		watch("appgw.ingress.k8s.io", false, "azureingressprohibitedtargets")
+	}
+	if envVariables.EnableIstioIntegration == "true" {
+		watch("networking.istio.io", true, "gateways", "virtualservices")
+	}
+	if envVariables.EnableKnativeIntegration == "true" {
+		watch(knative.Group, true, "ingresses", "clusteringresses")
+	}
+	if watchNodes(envVariables) {
+		watch("", true, "nodes")
+	}
+	// The ConfigMap of orphaned Ingresses is watched whenever the namespace of AGIC is known.
+	if envVariables.AGICPodNamespace != "" {
+		for _, verb := range []string{"list", "watch"} {
+			required = append(required, permission{resource: "configmaps", verb: verb, namespace: envVariables.AGICPodNamespace})
+		}
+	}
+	if envVariables.DefaultSSLCertificate != "" {
+		namespace, _ := utils.ParseResourceKey(envVariables.DefaultSSLCertificate)
+		for _, verb := range []string{"list", "watch"} {
+			required = append(required, permission{resource: "secrets", verb: verb, namespace: namespace})
+		}
+	}
+	required = append(required, permission{resource: "events", verb: "create"})
+	return required
+}
+
+// PermissionsNotCheckedError is returned when some permissions could not be checked, as when the API server did not answer
+// the SelfSubjectAccessReviews, and none of the permissions checked is missing. AGIC may run regardless; a missing permission
+// then surfaces as informers which do not sync.
+type PermissionsNotCheckedError struct {
+	errs []string
+}
+
+func (e PermissionsNotCheckedError) Error() string {
+	return fmt.Sprintf("unable to check the Kubernetes permissions: %s", strings.Join(e.errs, "; "))
+}
+
+// CheckPermissions verifies with SelfSubjectAccessReviews that AGIC is allowed to watch all resources it needs
+// in the given namespaces (all namespaces when empty). Without these permissions the informers never sync.
+// The returned error lists every missing permission, or is a PermissionsNotCheckedError when none is known to be missing,
+// but some could not be checked.
+func CheckPermissions(kubeClient kubernetes.Interface, namespaces []string, envVariables environment.EnvVariables) error {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var missing, notChecked []string
+	for _, required := range getRequiredPermissions(envVariables) {
+		scopes := namespaces
+		if required.clusterWide {
+			scopes = []string{""}
+		} else if required.namespace != "" {
+			scopes = []string{required.namespace}
+		}
+		for _, namespace := range scopes {
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: namespace,
+						Group:     required.group,
+						Resource:  required.resource,
+						Verb:      required.verb,
+					},
+				},
+			}
+			scope := "in all namespaces"
+			if namespace != "" {
+				scope = fmt.Sprintf("in namespace %s", namespace)
+			}
+			response, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+			if err != nil {
+				notChecked = append(notChecked, fmt.Sprintf("%s %s: %s", required, scope, err))
+				continue
+			}
+			if response.Status.Allowed {
+				continue
+			}
+			glog.V(3).Infof("Missing permission to %s %s: %s", required, scope, response.Status.Reason)
+			missing = append(missing, fmt.Sprintf("%s %s", required, scope))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing Kubernetes permissions (check the ClusterRole bound to AGIC's service account): %s", strings.Join(missing, "; "))
+	}
+	if len(notChecked) > 0 {
+		return PermissionsNotCheckedError{errs: notChecked}
+	}
+	return nil
+}
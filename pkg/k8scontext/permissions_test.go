@@ -0,0 +1,130 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+)
+
+var _ = Describe("Test CheckPermissions()", func() {
+
+	// newClient returns a client, which denies the given "verb resource" pairs and allows everything else.
+	newClient := func(denied ...string) (*testclient.Clientset, *[]authorizationv1.ResourceAttributes) {
+		var reviewed []authorizationv1.ResourceAttributes
+		client := testclient.NewSimpleClientset()
+		client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			reviewed = append(reviewed, *attributes)
+			review.Status.Allowed = true
+			for _, d := range denied {
+				if d == attributes.Verb+" "+attributes.Resource {
+					review.Status.Allowed = false
+				}
+			}
+			return true, review, nil
+		})
+		return client, &reviewed
+	}
+
+	It("should succeed when all permissions are granted", func() {
+		client, reviewed := newClient()
+		Expect(k8scontext.CheckPermissions(client, nil, environment.GetFakeEnv())).To(Succeed())
+		Expect(*reviewed).To(ContainElement(authorizationv1.ResourceAttributes{Group: "extensions", Resource: "ingresses", Verb: "watch"}))
+		Expect(*reviewed).To(ContainElement(authorizationv1.ResourceAttributes{Resource: "events", Verb: "create"}))
+	})
+
+	It("should list every missing permission", func() {
+		client, _ := newClient("list secrets", "watch ingresses")
+		err := k8scontext.CheckPermissions(client, []string{"ns-a", "ns-b"}, environment.GetFakeEnv())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("list secrets in namespace ns-a"))
+		Expect(err.Error()).To(ContainSubstring("list secrets in namespace ns-b"))
+		Expect(err.Error()).To(ContainSubstring("watch ingresses.extensions in namespace ns-a"))
+		Expect(err.Error()).ToNot(ContainSubstring("pods"))
+	})
+
+	It("should check the CRDs of enabled features", func() {
+		env := environment.GetFakeEnv()
+		env.EnableBrownfieldDeployment = "true"
+		env.EnableIstioIntegration = "true"
+		client, _ := newClient("watch azureingressprohibitedtargets", "list virtualservices")
+		err := k8scontext.CheckPermissions(client, []string{"ns-a"}, env)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("watch azureingressprohibitedtargets.appgw.ingress.k8s.io in namespace ns-a"))
+		Expect(err.Error()).To(ContainSubstring("list virtualservices.networking.istio.io in all namespaces"))
+	})
+
+	It("should check the ConfigMap with the backend defaults in the namespace of AGIC", func() {
+		env := environment.GetFakeEnv()
+		env.BackendDefaultsConfigMap = "backend-defaults"
+		env.AGICPodNamespace = "agic"
+		client, _ := newClient("watch configmaps")
+		err := k8scontext.CheckPermissions(client, []string{"ns-a"}, env)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("watch configmaps in namespace agic"))
+		Expect(err.Error()).ToNot(ContainSubstring("configmaps in namespace ns-a"))
+	})
+
+	It("should check the ConfigMap with prohibited targets instead of the CRD when the CRD is turned off", func() {
+		env := environment.GetFakeEnv()
+		env.EnableBrownfieldDeployment = "true"
+		env.ProhibitedTargetsConfigMap = "prohibited-targets"
+		env.ProhibitedTargetsCRD = "false"
+		env.AGICPodNamespace = "agic"
+		client, reviewed := newClient("list configmaps")
+		err := k8scontext.CheckPermissions(client, []string{"ns-a"}, env)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("list configmaps in namespace agic"))
+		for _, attributes := range *reviewed {
+			Expect(attributes.Resource).ToNot(Equal("azureingressprohibitedtargets"))
+		}
+	})
+
+	It("should check the secrets in the namespace of the default certificate", func() {
+		env := environment.GetFakeEnv()
+		env.DefaultSSLCertificate = "ingress-system/default-tls"
+		client, _ := newClient("list secrets", "watch secrets")
+		err := k8scontext.CheckPermissions(client, []string{"ns-a"}, env)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("list secrets in namespace ingress-system"))
+		Expect(err.Error()).To(ContainSubstring("watch secrets in namespace ingress-system"))
+	})
+
+	It("should tell the permissions which could not be checked apart from the missing ones", func() {
+		// failPods fails the reviews of the permissions on Pods, as when the API server does not answer.
+		failPods := func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			if review.Spec.ResourceAttributes.Resource == "pods" {
+				return true, &authorizationv1.SelfSubjectAccessReview{}, errors.New("connection refused")
+			}
+			return false, nil, nil
+		}
+
+		client, _ := newClient()
+		client.PrependReactor("create", "selfsubjectaccessreviews", failPods)
+		err := k8scontext.CheckPermissions(client, nil, environment.GetFakeEnv())
+		Expect(err).To(BeAssignableToTypeOf(k8scontext.PermissionsNotCheckedError{}))
+		Expect(err.Error()).To(ContainSubstring("list pods in all namespaces: connection refused"))
+
+		// A missing permission takes precedence.
+		client, _ = newClient("watch ingresses")
+		client.PrependReactor("create", "selfsubjectaccessreviews", failPods)
+		err = k8scontext.CheckPermissions(client, nil, environment.GetFakeEnv())
+		Expect(err).ToNot(BeAssignableToTypeOf(k8scontext.PermissionsNotCheckedError{}))
+		Expect(err.Error()).To(ContainSubstring("watch ingresses.extensions in all namespaces"))
+	})
+})
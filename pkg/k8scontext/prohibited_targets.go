@@ -0,0 +1,98 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+)
+
+// writeProhibitedTargetStatus writes in the status of each AzureIngressProhibitedTarget whether AGIC accepted it, and what it
+// prohibits. The status only depends on the prohibited target, so it is written apart from the processing of events: the
+// prohibited targets created or changed are queued by the informer, and their status is written from a goroutine until AGIC
+// stops. Failed writes are retried with backoff. Writing the status does not change the spec, so it does not trigger an event.
+func (c *Context) writeProhibitedTargetStatus(stopChannel <-chan struct{}) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "prohibited-target-status")
+	enqueue := func(obj interface{}) {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			queue.Add(key)
+		}
+	}
+	c.informers.AzureIngressProhibitedLocation.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) { enqueue(newObj) },
+	})
+
+	go func() {
+		<-stopChannel
+		queue.ShutDown()
+	}()
+	go func() {
+		for c.writeNextProhibitedTargetStatus(queue) {
+		}
+	}()
+}
+
+// writeNextProhibitedTargetStatus writes the status of the next prohibited target in the queue, and returns false once the
+// queue is shut down. The prohibited target is read from the cache, so its latest version gets the status.
+func (c *Context) writeNextProhibitedTargetStatus(queue workqueue.RateLimitingInterface) bool {
+	key, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(key)
+
+	obj, exists, err := c.Caches.AzureIngressProhibitedLocation.GetByKey(key.(string))
+	if err != nil || !exists {
+		queue.Forget(key)
+		return true
+	}
+	target := obj.(*ptv1.AzureIngressProhibitedTarget)
+	if err := c.updateProhibitedTargetStatus(target, brownfield.GetProhibitedTargetStatus(target)); err != nil {
+		glog.Errorf("Unable to update the status of AzureIngressProhibitedTarget %s: %s", key, err)
+		queue.AddRateLimited(key)
+		return true
+	}
+	queue.Forget(key)
+	return true
+}
+
+// updateProhibitedTargetStatus writes the status of the AzureIngressProhibitedTarget, unless it already has it.
+func (c *Context) updateProhibitedTargetStatus(target *ptv1.AzureIngressProhibitedTarget, status ptv1.AzureIngressProhibitedTargetStatus) error {
+	if c.crdClient == nil || target.Status == status {
+		return nil
+	}
+	updated := target.DeepCopy()
+	updated.Status = status
+	_, err := c.crdClient.AzureingressprohibitedtargetsV1().AzureIngressProhibitedTargets(updated.Namespace).UpdateStatus(updated)
+	return err
+}
+
+// watchProhibitedTargetsConfigMap creates the informer of the single ConfigMap with prohibited targets,
+// so editing it updates the App Gateway like changing an AzureIngressProhibitedTarget does.
+func (c *Context) watchProhibitedTargetsConfigMap(namespace, name string) {
+	informer := c.newConfigMapInformer(namespace, name)
+	c.informers.ProhibitedTargetsConfigMap = informer
+	c.Caches.ProhibitedTargetsConfigMap = informer.GetStore()
+}
+
+// GetProhibitedTargetsConfigMap returns the ConfigMap with prohibited targets, or nil when none is configured or it does not exist.
+func (c *Context) GetProhibitedTargetsConfigMap() *v1.ConfigMap {
+	if c.Caches.ProhibitedTargetsConfigMap == nil {
+		return nil
+	}
+	items := c.Caches.ProhibitedTargetsConfigMap.List()
+	if len(items) == 0 {
+		glog.V(5).Info("The ConfigMap with prohibited targets does not exist")
+		return nil
+	}
+	return items[0].(*v1.ConfigMap)
+}
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = ginkgo.Describe("Test the status of the prohibited targets", func() {
+	var ctxt *Context
+	var crdClient *fake.Clientset
+	var queue workqueue.RateLimitingInterface
+	var target *ptv1.AzureIngressProhibitedTarget
+	key := tests.Namespace + "/prohibit-host"
+
+	getStatus := func() ptv1.AzureIngressProhibitedTargetStatus {
+		updated, err := crdClient.AzureingressprohibitedtargetsV1().AzureIngressProhibitedTargets(tests.Namespace).Get(target.Name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return updated.Status
+	}
+
+	ginkgo.BeforeEach(func() {
+		target = &ptv1.AzureIngressProhibitedTarget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: tests.Namespace, Name: "prohibit-host", Generation: 2},
+			Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: tests.Host},
+		}
+		crdClient = fake.NewSimpleClientset()
+		ctxt = &Context{
+			Caches:    &CacheCollection{AzureIngressProhibitedLocation: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+			crdClient: crdClient,
+		}
+		Expect(ctxt.Caches.AzureIngressProhibitedLocation.Add(target)).To(Succeed())
+		queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	})
+
+	ginkgo.AfterEach(func() {
+		queue.ShutDown()
+	})
+
+	ginkgo.It("should write the status of the queued prohibited target", func() {
+		_, err := crdClient.AzureingressprohibitedtargetsV1().AzureIngressProhibitedTargets(tests.Namespace).Create(target)
+		Expect(err).ToNot(HaveOccurred())
+
+		queue.Add(key)
+		Expect(ctxt.writeNextProhibitedTargetStatus(queue)).To(BeTrue())
+		Expect(getStatus()).To(Equal(brownfield.GetProhibitedTargetStatus(target)))
+		Expect(getStatus().Accepted).To(BeTrue())
+		Expect(queue.Len()).To(BeZero())
+	})
+
+	ginkgo.It("should retry a failed write", func() {
+		// The prohibited target is in the cache, but not in the API server.
+		queue.Add(key)
+		Expect(ctxt.writeNextProhibitedTargetStatus(queue)).To(BeTrue())
+		Expect(queue.NumRequeues(key)).To(Equal(1))
+	})
+
+	ginkgo.It("should stop once the queue is shut down", func() {
+		queue.ShutDown()
+		Expect(ctxt.writeNextProhibitedTargetStatus(queue)).To(BeFalse())
+	})
+
+	ginkgo.It("should not trigger an event when the status is written", func() {
+		updated := target.DeepCopy()
+		updated.Status = brownfield.GetProhibitedTargetStatus(target)
+		Expect(isRelevantUpdate(target, updated)).To(BeFalse())
+	})
+})
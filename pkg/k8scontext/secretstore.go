@@ -7,6 +7,10 @@ package k8scontext
 
 import (
 	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -24,16 +28,26 @@ type SecretsKeeper interface {
 	eraseSecret(secretKey string)
 }
 
+// TLSKeyPassphraseKey is the key of the Secret data with the passphrase of an encrypted tls.key.
+const TLSKeyPassphraseKey = "tls.key.passphrase"
+
+// passphraseEnvVar passes the passphrase of an encrypted key to openssl, so it does not show in the process list.
+const passphraseEnvVar = "AGIC_TLS_KEY_PASSPHRASE"
+
 // SecretsStore maintains a cache of the deployment secrets.
 type SecretsStore struct {
 	conversionSync sync.Mutex
 	Cache          cache.ThreadSafeStore
+
+	// convertedVersions holds the resource version of each converted secret, so unchanged secrets are not converted again.
+	convertedVersions map[string]string
 }
 
 // NewSecretStore creates a new SecretsKeeper object
 func NewSecretStore() SecretsKeeper {
 	return &SecretsStore{
-		Cache: cache.NewThreadSafeStore(cache.Indexers{}, cache.Indices{}),
+		Cache:             cache.NewThreadSafeStore(cache.Indexers{}, cache.Indices{}),
+		convertedVersions: make(map[string]string),
 	}
 }
 
@@ -53,6 +67,7 @@ func (s *SecretsStore) eraseSecret(secretKey string) {
 	defer s.conversionSync.Unlock()
 
 	s.Cache.Delete(secretKey)
+	delete(s.convertedVersions, secretKey)
 }
 
 func (s *SecretsStore) convertSecret(secretKey string, secret *v1.Secret) bool {
@@ -70,6 +85,30 @@ func (s *SecretsStore) convertSecret(secretKey string, secret *v1.Secret) bool {
 		return false
 	}
 
+	if version, converted := s.convertedVersions[secretKey]; converted && secret.ResourceVersion != "" && version == secret.ResourceVersion {
+		if _, exists := s.Cache.Get(secretKey); exists {
+			glog.V(5).Infof("secret [%v] is unchanged since its last conversion", secretKey)
+			return true
+		}
+	}
+
+	keyPEM, encrypted, err := getPrivateKeyPEM(secret.Data["tls.key"])
+	if err != nil {
+		glog.Errorf("secret [%v] has an invalid tls.key: %v", secretKey, err)
+		return false
+	}
+	passphrase := secret.Data[TLSKeyPassphraseKey]
+	if encrypted && len(passphrase) == 0 {
+		glog.Errorf("secret [%v] has an encrypted tls.key, but %s is not defined", secretKey, TLSKeyPassphraseKey)
+		return false
+	}
+
+	certPEM, err := normalizeCertificateChain(secret.Data["tls.crt"])
+	if err != nil {
+		glog.Errorf("secret [%v] has an invalid tls.crt: %v", secretKey, err)
+		return false
+	}
+
 	tempfileCert, err := ioutil.TempFile("", "appgw-ingress-cert")
 	if err != nil {
 		glog.Error("unable to create temporary file for certificate conversion")
@@ -84,19 +123,26 @@ func (s *SecretsStore) convertSecret(secretKey string, secret *v1.Secret) bool {
 	}
 	defer os.Remove(tempfileKey.Name())
 
-	if err := writeFileDecode(secret.Data["tls.crt"], tempfileCert); err != nil {
+	if err := writeFileDecode(certPEM, tempfileCert); err != nil {
 		glog.Errorf("unable to write secret [%v].tls.crt to temporary file, error: %v", secretKey, err)
 		return false
 	}
 
-	if err := writeFileDecode(secret.Data["tls.key"], tempfileKey); err != nil {
+	if err := writeFileDecode(keyPEM, tempfileKey); err != nil {
 		glog.Errorf("unable to write secret [%v].tls.key to temporary file, error: %v", secretKey, err)
 		return false
 	}
 
 	// both cert and key are in temp file now, call openssl
 	var cout, cerr bytes.Buffer
-	cmd := exec.Command("openssl", "pkcs12", "-export", "-in", tempfileCert.Name(), "-inkey", tempfileKey.Name(), "-password", "pass:msazure")
+	args := []string{"pkcs12", "-export", "-in", tempfileCert.Name(), "-inkey", tempfileKey.Name(), "-password", "pass:msazure"}
+	if encrypted {
+		args = append(args, "-passin", "env:"+passphraseEnvVar)
+	}
+	cmd := exec.Command("openssl", args...)
+	if encrypted {
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", passphraseEnvVar, passphrase))
+	}
 	cmd.Stderr = &cerr
 	cmd.Stdout = &cout
 
@@ -117,10 +163,53 @@ func (s *SecretsStore) convertSecret(secretKey string, secret *v1.Secret) bool {
 	} else {
 		s.Cache.Add(secretKey, pfxCert)
 	}
+	if s.convertedVersions == nil {
+		s.convertedVersions = make(map[string]string)
+	}
+	s.convertedVersions[secretKey] = secret.ResourceVersion
 
 	return true
 }
 
+// getPrivateKeyPEM returns the PEM block of the private key in tls.key, which may also contain other blocks, such as EC parameters.
+// PKCS#1 RSA keys, EC keys and PKCS#8 keys are supported, in the clear or encrypted.
+func getPrivateKeyPEM(data []byte) (keyPEM []byte, encrypted bool, err error) {
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, false, errors.New("no private key found")
+		}
+
+		switch block.Type {
+		case "ENCRYPTED PRIVATE KEY":
+			return pem.EncodeToMemory(block), true, nil
+		case "RSA PRIVATE KEY", "EC PRIVATE KEY", "PRIVATE KEY":
+			// Keys in the legacy OpenSSL format are encrypted with a header, rather than in a block of their own.
+			if _, hasEncryptionHeader := block.Headers["DEK-Info"]; hasEncryptionHeader {
+				return pem.EncodeToMemory(block), true, nil
+			}
+			if err := parsePrivateKey(block); err != nil {
+				return nil, false, err
+			}
+			return pem.EncodeToMemory(block), false, nil
+		}
+	}
+}
+
+func parsePrivateKey(block *pem.Block) error {
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		_, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		_, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		_, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	return err
+}
+
 func writeFileDecode(data []byte, fileHandle *os.File) error {
 	if _, err := fileHandle.Write(data); err != nil {
 		return err
@@ -0,0 +1,117 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The k8scontext package declares its own Context, which clashes with the one of Ginkgo when dot-imported.
+var _ = ginkgo.Describe("Test SecretsStore", func() {
+	const secretKey = "test-namespace/test-secret"
+
+	newCertificatePEM := func(key crypto.Signer) []byte {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "www.contoso.com"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		Expect(err).ToNot(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	newSecret := func(key crypto.Signer, keyPEM []byte) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"},
+			Type:       "kubernetes.io/tls",
+			Data: map[string][]byte{
+				"tls.crt": newCertificatePEM(key),
+				"tls.key": keyPEM,
+			},
+		}
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var store *SecretsStore
+
+	ginkgo.BeforeEach(func() {
+		store = NewSecretStore().(*SecretsStore)
+	})
+
+	ginkgo.It("should convert PKCS#1 RSA keys", func() {
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+		Expect(store.convertSecret(secretKey, newSecret(rsaKey, keyPEM))).To(BeTrue())
+		Expect(store.GetPfxCertificate(secretKey)).ToNot(BeEmpty())
+	})
+
+	ginkgo.It("should convert EC keys preceded by their parameters", func() {
+		der, err := x509.MarshalECPrivateKey(ecKey)
+		Expect(err).ToNot(HaveOccurred())
+		keyPEM := append(
+			pem.EncodeToMemory(&pem.Block{Type: "EC PARAMETERS", Bytes: []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})...)
+		Expect(store.convertSecret(secretKey, newSecret(ecKey, keyPEM))).To(BeTrue())
+		Expect(store.GetPfxCertificate(secretKey)).ToNot(BeEmpty())
+	})
+
+	ginkgo.It("should convert PKCS#8 keys", func() {
+		der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+		Expect(err).ToNot(HaveOccurred())
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		Expect(store.convertSecret(secretKey, newSecret(ecKey, keyPEM))).To(BeTrue())
+	})
+
+	ginkgo.It("should convert encrypted keys given their passphrase", func() {
+		block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), []byte("secret"), x509.PEMCipherAES256)
+		Expect(err).ToNot(HaveOccurred())
+		secret := newSecret(rsaKey, pem.EncodeToMemory(block))
+		Expect(store.convertSecret(secretKey, secret)).To(BeFalse())
+
+		secret.Data[TLSKeyPassphraseKey] = []byte("wrong")
+		Expect(store.convertSecret(secretKey, secret)).To(BeFalse())
+
+		secret.Data[TLSKeyPassphraseKey] = []byte("secret")
+		Expect(store.convertSecret(secretKey, secret)).To(BeTrue())
+	})
+
+	ginkgo.It("should reject malformed keys", func() {
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("not a key")})
+		Expect(store.convertSecret(secretKey, newSecret(rsaKey, keyPEM))).To(BeFalse())
+	})
+
+	ginkgo.It("should not convert a secret again until its resource version changes", func() {
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+		secret := newSecret(rsaKey, keyPEM)
+		Expect(store.convertSecret(secretKey, secret)).To(BeTrue())
+		converted := store.GetPfxCertificate(secretKey)
+
+		// PFX conversion is not deterministic, so a new conversion yields a different certificate.
+		Expect(store.convertSecret(secretKey, secret)).To(BeTrue())
+		Expect(store.GetPfxCertificate(secretKey)).To(Equal(converted))
+
+		secret.ResourceVersion = "2"
+		Expect(store.convertSecret(secretKey, secret)).To(BeTrue())
+		Expect(store.GetPfxCertificate(secretKey)).ToNot(Equal(converted))
+	})
+})
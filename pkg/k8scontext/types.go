@@ -1,9 +1,15 @@
 package k8scontext
 
 import (
+	"time"
+
 	"github.com/eapache/channels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
 
@@ -15,10 +21,20 @@ type InformerCollection struct {
 	Secret                         cache.SharedIndexInformer
 	Service                        cache.SharedIndexInformer
 	Namespace                      cache.SharedIndexInformer
+	Nodes                          cache.SharedIndexInformer
 	AzureIngressManagedLocation    cache.SharedInformer
 	AzureIngressProhibitedLocation cache.SharedInformer
 	IstioGateway                   cache.SharedIndexInformer
 	IstioVirtualService            cache.SharedIndexInformer
+	KnativeIngress                 cache.SharedIndexInformer
+	KnativeClusterIngress          cache.SharedIndexInformer
+	BackendDefaults                cache.SharedIndexInformer
+	ProhibitedTargetsConfigMap     cache.SharedIndexInformer
+	OrphanedIngresses              cache.SharedIndexInformer
+	DefaultSSLCertificate          cache.SharedIndexInformer
+
+	// watched are the informers the watchdog restarts when they stall.
+	watched []*watchedInformer
 }
 
 // CacheCollection : all the listers from the informers.
@@ -29,10 +45,16 @@ type CacheCollection struct {
 	Secret                         cache.Store
 	Service                        cache.Store
 	Namespaces                     cache.Store
+	Nodes                          cache.Store
 	AzureIngressManagedLocation    cache.Store
 	AzureIngressProhibitedLocation cache.Store
 	IstioGateway                   cache.Store
 	IstioVirtualService            cache.Store
+	KnativeIngress                 cache.Store
+	KnativeClusterIngress          cache.Store
+	BackendDefaults                cache.Store
+	ProhibitedTargetsConfigMap     cache.Store
+	OrphanedIngresses              cache.Store
 }
 
 // Context : cache and listener for k8s resources.
@@ -43,5 +65,22 @@ type Context struct {
 
 	ingressSecretsMap utils.ThreadsafeMultiMap
 
+	// DefaultSSLCertificate is the key of the secret with the default certificate in the CertificateSecretStore, empty when none is configured.
+	DefaultSSLCertificate string
+
+	// kubeClient and resyncPeriod create the informer of the ConfigMap with the backend defaults, once its name is known.
+	kubeClient   kubernetes.Interface
+	resyncPeriod time.Duration
+
+	// namespace and informerOptions create the informers anew, when the watchdog restarts them.
+	namespace       string
+	informerOptions []informers.SharedInformerOption
+
+	// crdClient updates the status of the AzureIngressProhibitedTargets.
+	crdClient versioned.Interface
+
+	// dynamicClient updates the status of the Knative Ingresses, which have no typed client.
+	dynamicClient dynamic.Interface
+
 	UpdateChannel *channels.RingChannel
 }
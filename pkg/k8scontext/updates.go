@@ -0,0 +1,89 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+)
+
+// isRelevantUpdate tells whether an update to a watched object changes any of the fields AGIC builds the App Gateway config from.
+// Resyncs and updates to the status or the metadata of an object, including the Ingress status written by AGIC itself, do not.
+func isRelevantUpdate(oldObj, newObj interface{}) bool {
+	switch newObj := newObj.(type) {
+	case *v1beta1.Ingress:
+		if oldObj, ok := oldObj.(*v1beta1.Ingress); ok {
+			return !reflect.DeepEqual(oldObj.Spec, newObj.Spec) || !reflect.DeepEqual(oldObj.Annotations, newObj.Annotations)
+		}
+	case *v1.Service:
+		if oldObj, ok := oldObj.(*v1.Service); ok {
+			return !reflect.DeepEqual(oldObj.Spec, newObj.Spec)
+		}
+	case *v1.Endpoints:
+		// Only ready addresses become members of backend pools; in autoscaling clusters the not ready addresses change constantly.
+		if oldObj, ok := oldObj.(*v1.Endpoints); ok {
+			return !reflect.DeepEqual(getReadyAddresses(oldObj), getReadyAddresses(newObj))
+		}
+	case *v1.Pod:
+		// Pods are matched to Services by their labels, and health probes are derived from the probes of their containers.
+		// Backends selecting Pods without a Service are built from the IP and the readiness of the Pods.
+		if oldObj, ok := oldObj.(*v1.Pod); ok {
+			return !reflect.DeepEqual(oldObj.Spec, newObj.Spec) || !reflect.DeepEqual(oldObj.Labels, newObj.Labels) ||
+				oldObj.Status.PodIP != newObj.Status.PodIP || isPodReady(oldObj) != isPodReady(newObj)
+		}
+	case *v1.Node:
+		// Pods are left out of backend pools by the zone, the labels and the taints of their nodes.
+		if oldObj, ok := oldObj.(*v1.Node); ok {
+			return !reflect.DeepEqual(oldObj.Labels, newObj.Labels) || !reflect.DeepEqual(oldObj.Spec.Taints, newObj.Spec.Taints)
+		}
+	case *v1.Secret:
+		if oldObj, ok := oldObj.(*v1.Secret); ok {
+			return oldObj.Type != newObj.Type || !reflect.DeepEqual(oldObj.Data, newObj.Data)
+		}
+	case *v1.ConfigMap:
+		if oldObj, ok := oldObj.(*v1.ConfigMap); ok {
+			return !reflect.DeepEqual(oldObj.Data, newObj.Data)
+		}
+	case *ptv1.AzureIngressProhibitedTarget:
+		if oldObj, ok := oldObj.(*ptv1.AzureIngressProhibitedTarget); ok {
+			return !reflect.DeepEqual(oldObj.Spec, newObj.Spec)
+		}
+	}
+	return !reflect.DeepEqual(oldObj, newObj)
+}
+
+// getReadyAddresses returns the set of ready addresses of the Endpoints, each combined with every port it serves.
+// Addresses are named by IP, or by hostname when they have no IP, the same way backend pools are built.
+func getReadyAddresses(endpoints *v1.Endpoints) map[string]interface{} {
+	addresses := make(map[string]interface{})
+	for _, subset := range endpoints.Subsets {
+		for _, port := range subset.Ports {
+			for _, address := range subset.Addresses {
+				host := address.IP
+				if host == "" {
+					host = address.Hostname
+				}
+				addresses[fmt.Sprintf("%s %s:%d/%s", port.Name, host, port.Port, port.Protocol)] = nil
+			}
+		}
+	}
+	return addresses
+}
+
+// isPodReady tells whether the Pod has the Ready condition.
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
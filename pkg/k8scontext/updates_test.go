@@ -0,0 +1,117 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// The k8scontext package declares its own Context, which clashes with the one of Ginkgo when dot-imported.
+var _ = ginkgo.Describe("Test isRelevantUpdate()", func() {
+	ginkgo.It("should ignore Ingress status and metadata updates", func() {
+		oldIngress := tests.NewIngressFixture()
+		newIngress := oldIngress.DeepCopy()
+		newIngress.ResourceVersion = "2"
+		newIngress.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+		Expect(isRelevantUpdate(oldIngress, newIngress)).To(BeFalse())
+	})
+
+	ginkgo.It("should follow Ingress spec and annotation updates", func() {
+		oldIngress := tests.NewIngressFixture()
+		newIngress := oldIngress.DeepCopy()
+		newIngress.Spec.Rules[0].Host = "other.com"
+		Expect(isRelevantUpdate(oldIngress, newIngress)).To(BeTrue())
+
+		newIngress = oldIngress.DeepCopy()
+		newIngress.Annotations["appgw.ingress.kubernetes.io/connection-draining"] = "true"
+		Expect(isRelevantUpdate(oldIngress, newIngress)).To(BeTrue())
+	})
+
+	ginkgo.It("should ignore Pod status updates", func() {
+		oldPod := tests.NewPodFixture("pod", tests.Namespace, "container", 8080)
+		newPod := oldPod.DeepCopy()
+		newPod.Status.Phase = v1.PodRunning
+		Expect(isRelevantUpdate(oldPod, newPod)).To(BeFalse())
+
+		newPod.Labels = map[string]string{"app": "other"}
+		Expect(isRelevantUpdate(oldPod, newPod)).To(BeTrue())
+	})
+
+	ginkgo.It("should follow Pods getting an IP or becoming ready", func() {
+		oldPod := tests.NewPodFixture("pod", tests.Namespace, "container", 8080)
+		oldPod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse}}
+		newPod := oldPod.DeepCopy()
+		newPod.Status.PodIP = "10.9.8.7"
+		Expect(isRelevantUpdate(oldPod, newPod)).To(BeTrue())
+
+		oldPod = newPod.DeepCopy()
+		newPod.Status.Conditions[0].Status = v1.ConditionTrue
+		Expect(isRelevantUpdate(oldPod, newPod)).To(BeTrue())
+
+		// Other conditions do not matter.
+		oldPod = newPod.DeepCopy()
+		newPod.Status.Conditions = append(newPod.Status.Conditions, v1.PodCondition{Type: v1.PodScheduled, Status: v1.ConditionTrue})
+		Expect(isRelevantUpdate(oldPod, newPod)).To(BeFalse())
+	})
+
+	ginkgo.It("should only follow changes to the labels and the taints of Nodes", func() {
+		oldNode := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"agentpool": "spot"}}}
+		newNode := oldNode.DeepCopy()
+		newNode.ResourceVersion = "2"
+		newNode.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+		Expect(isRelevantUpdate(oldNode, newNode)).To(BeFalse())
+
+		newNode.Spec.Taints = []v1.Taint{{Key: "kubernetes.azure.com/scalesetpriority", Value: "spot", Effect: v1.TaintEffectNoSchedule}}
+		Expect(isRelevantUpdate(oldNode, newNode)).To(BeTrue())
+
+		newNode = oldNode.DeepCopy()
+		newNode.Labels["failure-domain.beta.kubernetes.io/zone"] = "westus2-1"
+		Expect(isRelevantUpdate(oldNode, newNode)).To(BeTrue())
+	})
+
+	ginkgo.It("should only follow changes to the ready addresses of Endpoints", func() {
+		oldEndpoints := tests.NewEndpointsFixture()
+		oldEndpoints.Subsets[0].Addresses = append(oldEndpoints.Subsets[0].Addresses, v1.EndpointAddress{IP: "10.9.8.6"})
+		newEndpoints := oldEndpoints.DeepCopy()
+		newEndpoints.ResourceVersion = "2"
+		newEndpoints.Subsets[0].NotReadyAddresses = []v1.EndpointAddress{{IP: "10.9.8.5"}}
+		Expect(isRelevantUpdate(oldEndpoints, newEndpoints)).To(BeFalse())
+
+		// The order of the ready addresses does not matter.
+		addresses := newEndpoints.Subsets[0].Addresses
+		addresses[0], addresses[1] = addresses[1], addresses[0]
+		Expect(isRelevantUpdate(oldEndpoints, newEndpoints)).To(BeFalse())
+
+		newEndpoints.Subsets[0].Addresses = append(newEndpoints.Subsets[0].Addresses, v1.EndpointAddress{IP: "10.9.8.5"})
+		Expect(isRelevantUpdate(oldEndpoints, newEndpoints)).To(BeTrue())
+
+		newEndpoints = oldEndpoints.DeepCopy()
+		newEndpoints.Subsets[0].Ports[0].Port++
+		Expect(isRelevantUpdate(oldEndpoints, newEndpoints)).To(BeTrue())
+	})
+
+	ginkgo.It("should follow Secret data updates", func() {
+		oldSecret := &v1.Secret{Data: map[string][]byte{"tls.crt": []byte("old")}}
+		newSecret := oldSecret.DeepCopy()
+		newSecret.ResourceVersion = "2"
+		Expect(isRelevantUpdate(oldSecret, newSecret)).To(BeFalse())
+
+		newSecret.Data["tls.crt"] = []byte("new")
+		Expect(isRelevantUpdate(oldSecret, newSecret)).To(BeTrue())
+	})
+
+	ginkgo.It("should compare other objects in full", func() {
+		oldObj := &v1beta1.IngressBackend{ServiceName: "a"}
+		Expect(isRelevantUpdate(oldObj, &v1beta1.IngressBackend{ServiceName: "a"})).To(BeFalse())
+		Expect(isRelevantUpdate(oldObj, &v1beta1.IngressBackend{ServiceName: "b"})).To(BeTrue())
+	})
+})
@@ -0,0 +1,332 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// informerSyncTimeout is how long a restarted informer may take to sync; the stalled informer is kept until it did.
+const informerSyncTimeout = time.Minute
+
+// watchCacheListOptions list the resources from the watch cache of the API server, which spares etcd the load of full lists.
+var watchCacheListOptions = metav1.ListOptions{ResourceVersion: "0"}
+
+// watchedInformer is an informer, which is restarted when its cache misses changes, as when its watch silently stopped.
+type watchedInformer struct {
+	resource string
+
+	// informer is the field of the InformerCollection, which is replaced on restart.
+	informer *cache.SharedIndexInformer
+	store    *restartableStore
+	handler  cache.ResourceEventHandler
+
+	newInformer func(informers.SharedInformerFactory) cache.SharedIndexInformer
+	list        func(client kubernetes.Interface, namespace string) (runtime.Object, error)
+
+	// mutex guards the informer, its channels and the pending changes, which the watchdog replaces while Run may read them.
+	mutex       sync.Mutex
+	stopChannel <-chan struct{}
+	restart     chan struct{}
+
+	// pending are the changes missing from the cache at the last check, by key: their resource version, empty for a deletion.
+	pending map[string]string
+}
+
+// run runs the informer until AGIC stops, and records the channels restarting it.
+func (w *watchedInformer) run(stopChannel <-chan struct{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.stopChannel = stopChannel
+	w.restart = runInformer(*w.informer, stopChannel)
+}
+
+// runInformer runs the informer until AGIC stops, or until the returned channel is closed to restart it.
+func runInformer(informer cache.SharedInformer, stopChannel <-chan struct{}) chan struct{} {
+	restart := make(chan struct{})
+	informerStop := make(chan struct{})
+	go func() {
+		select {
+		case <-stopChannel:
+		case <-restart:
+		}
+		close(informerStop)
+	}()
+	go informer.Run(informerStop)
+	return restart
+}
+
+// newWatchedInformers returns the informers of the resources AGIC builds the App Gateway config from.
+func newWatchedInformers(informerCollection *InformerCollection, cacheCollection *CacheCollection, resourceHandler, ingressResourceHandler, secretResourceHandler cache.ResourceEventHandler) []*watchedInformer {
+	return []*watchedInformer{
+		{
+			resource: "endpoints",
+			informer: &informerCollection.Endpoints,
+			store:    cacheCollection.Endpoints.(*restartableStore),
+			handler:  resourceHandler,
+			newInformer: func(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+				return factory.Core().V1().Endpoints().Informer()
+			},
+			list: func(client kubernetes.Interface, namespace string) (runtime.Object, error) {
+				return client.CoreV1().Endpoints(namespace).List(watchCacheListOptions)
+			},
+		},
+		{
+			resource: "ingresses",
+			informer: &informerCollection.Ingress,
+			store:    cacheCollection.Ingress.(*restartableStore),
+			handler:  ingressResourceHandler,
+			newInformer: func(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+				return factory.Extensions().V1beta1().Ingresses().Informer()
+			},
+			list: func(client kubernetes.Interface, namespace string) (runtime.Object, error) {
+				return client.ExtensionsV1beta1().Ingresses(namespace).List(watchCacheListOptions)
+			},
+		},
+		{
+			resource: "pods",
+			informer: &informerCollection.Pods,
+			store:    cacheCollection.Pods.(*restartableStore),
+			handler:  resourceHandler,
+			newInformer: func(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+				return factory.Core().V1().Pods().Informer()
+			},
+			list: func(client kubernetes.Interface, namespace string) (runtime.Object, error) {
+				return client.CoreV1().Pods(namespace).List(watchCacheListOptions)
+			},
+		},
+		{
+			resource: "secrets",
+			informer: &informerCollection.Secret,
+			store:    cacheCollection.Secret.(*restartableStore),
+			handler:  secretResourceHandler,
+			newInformer: func(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+				return factory.Core().V1().Secrets().Informer()
+			},
+			list: func(client kubernetes.Interface, namespace string) (runtime.Object, error) {
+				return client.CoreV1().Secrets(namespace).List(watchCacheListOptions)
+			},
+		},
+		{
+			resource: "services",
+			informer: &informerCollection.Service,
+			store:    cacheCollection.Service.(*restartableStore),
+			handler:  resourceHandler,
+			newInformer: func(factory informers.SharedInformerFactory) cache.SharedIndexInformer {
+				return factory.Core().V1().Services().Informer()
+			},
+			list: func(client kubernetes.Interface, namespace string) (runtime.Object, error) {
+				return client.CoreV1().Services(namespace).List(watchCacheListOptions)
+			},
+		},
+	}
+}
+
+// watchedInformer returns the watched informer, which is the given informer, if any.
+func (i *InformerCollection) watchedInformer(informer cache.SharedInformer) *watchedInformer {
+	for _, watched := range i.watched {
+		watched.mutex.Lock()
+		isWatched := *watched.informer == informer
+		watched.mutex.Unlock()
+		if isWatched {
+			return watched
+		}
+	}
+	return nil
+}
+
+// RecoverStalledInformers compares the caches of the informers of Ingresses, Services, Endpoints, Pods and Secrets with
+// the API server, and restarts the informers, whose cache still misses a change it already missed at the previous check.
+// It returns the resources of the informers restarted. The informers must have been run.
+func (c *Context) RecoverStalledInformers() []string {
+	var restarted []string
+	for _, watched := range c.informers.watched {
+		if c.recoverStalledInformer(watched) {
+			restarted = append(restarted, watched.resource)
+		}
+	}
+	return restarted
+}
+
+// recoverStalledInformer checks the informer, and returns whether it was restarted.
+func (c *Context) recoverStalledInformer(watched *watchedInformer) bool {
+	watched.mutex.Lock()
+	defer watched.mutex.Unlock()
+	listed, err := c.listResourceVersions(watched)
+	if err != nil {
+		glog.Errorf("Unable to list %s to check the informer: %s", watched.resource, err)
+		return false
+	}
+	pending := getPendingChanges(listed, getResourceVersions(watched.store.List()))
+	stalled := getStalledChanges(watched.pending, pending)
+	watched.pending = pending
+	if len(stalled) == 0 {
+		return false
+	}
+
+	glog.Warningf("The informer of %s missed changes to %s since the last check; restarting it", watched.resource, stalled)
+	if err := c.restartInformer(watched); err != nil {
+		glog.Errorf("Unable to restart the informer of %s: %s", watched.resource, err)
+		return false
+	}
+	watched.pending = nil
+	return true
+}
+
+// restartInformer runs a new informer of the resource, and replaces the stalled one once the new one synced.
+// The caller holds the mutex of the watched informer.
+func (c *Context) restartInformer(watched *watchedInformer) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, c.resyncPeriod, c.informerOptions...)
+	informer := watched.newInformer(factory)
+	informer.AddEventHandler(watched.handler)
+	restart := runInformer(informer, watched.stopChannel)
+	if err := wait.PollImmediate(100*time.Millisecond, informerSyncTimeout, func() (bool, error) { return informer.HasSynced(), nil }); err != nil {
+		close(restart)
+		return fmt.Errorf("the new informer did not sync within %s: %s", informerSyncTimeout, err)
+	}
+
+	close(watched.restart)
+	watched.store.set(informer.GetStore())
+	*watched.informer = informer
+	watched.restart = restart
+	return nil
+}
+
+// listResourceVersions returns the resource versions of the resources in the API server, by key, in the namespace the informers watch.
+// The resources are listed from the watch cache of the API server, rather than from etcd, like the informers list them.
+func (c *Context) listResourceVersions(watched *watchedInformer) (map[string]string, error) {
+	list, err := watched.list(c.kubeClient, c.namespace)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, 0, len(objects))
+	for _, object := range objects {
+		items = append(items, object)
+	}
+	return getResourceVersions(items), nil
+}
+
+// getResourceVersions returns the resource versions of the objects, by key.
+func getResourceVersions(objects []interface{}) map[string]string {
+	resourceVersions := make(map[string]string)
+	for _, object := range objects {
+		key, err := cache.MetaNamespaceKeyFunc(object)
+		if err != nil {
+			continue
+		}
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			continue
+		}
+		resourceVersions[key] = accessor.GetResourceVersion()
+	}
+	return resourceVersions
+}
+
+// getPendingChanges returns the changes in the API server missing from the cache, by key: the resource version of the
+// resource, or an empty one when the resource was deleted.
+func getPendingChanges(listed, cached map[string]string) map[string]string {
+	pending := make(map[string]string)
+	for key, resourceVersion := range listed {
+		if cachedVersion, exists := cached[key]; !exists || cachedVersion != resourceVersion {
+			pending[key] = resourceVersion
+		}
+	}
+	for key := range cached {
+		if _, exists := listed[key]; !exists {
+			pending[key] = ""
+		}
+	}
+	return pending
+}
+
+// getStalledChanges returns the keys of the changes missing from the cache at both checks. A change missed at one check may
+// be on its way to the cache; the same change still missing a check later means the informer stopped following the API server.
+func getStalledChanges(previous, current map[string]string) []string {
+	var stalled []string
+	for key, resourceVersion := range current {
+		if previousVersion, exists := previous[key]; exists && previousVersion == resourceVersion {
+			stalled = append(stalled, key)
+		}
+	}
+	sort.Strings(stalled)
+	return stalled
+}
+
+// restartableStore is the cache of an informer, which may be restarted; the cache of the new informer replaces the one of
+// the stalled informer for all readers at once.
+type restartableStore struct {
+	mutex sync.RWMutex
+	store cache.Store
+}
+
+func newRestartableStore(store cache.Store) *restartableStore {
+	return &restartableStore{store: store}
+}
+
+func (s *restartableStore) get() cache.Store {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.store
+}
+
+func (s *restartableStore) set(store cache.Store) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.store = store
+}
+
+func (s *restartableStore) Add(obj interface{}) error {
+	return s.get().Add(obj)
+}
+
+func (s *restartableStore) Update(obj interface{}) error {
+	return s.get().Update(obj)
+}
+
+func (s *restartableStore) Delete(obj interface{}) error {
+	return s.get().Delete(obj)
+}
+
+func (s *restartableStore) List() []interface{} {
+	return s.get().List()
+}
+
+func (s *restartableStore) ListKeys() []string {
+	return s.get().ListKeys()
+}
+
+func (s *restartableStore) Get(obj interface{}) (interface{}, bool, error) {
+	return s.get().Get(obj)
+}
+
+func (s *restartableStore) GetByKey(key string) (interface{}, bool, error) {
+	return s.get().GetByKey(key)
+}
+
+func (s *restartableStore) Replace(list []interface{}, resourceVersion string) error {
+	return s.get().Replace(list, resourceVersion)
+}
+
+func (s *restartableStore) Resync() error {
+	return s.get().Resync()
+}
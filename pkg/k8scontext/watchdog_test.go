@@ -0,0 +1,58 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istio_fake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = ginkgo.Describe("Test the watchdog of the informers", func() {
+	ginkgo.It("should find the changes missing from the cache", func() {
+		listed := map[string]string{"web/shop": "2", "web/blog": "5", "web/new": "7"}
+		cached := map[string]string{"web/shop": "1", "web/blog": "5", "web/deleted": "3"}
+		Expect(getPendingChanges(listed, cached)).To(Equal(map[string]string{"web/shop": "2", "web/new": "7", "web/deleted": ""}))
+	})
+
+	ginkgo.It("should only find stalled the changes missing at both checks", func() {
+		previous := map[string]string{"web/shop": "2", "web/new": "7", "web/deleted": ""}
+		current := map[string]string{"web/shop": "4", "web/new": "7", "web/deleted": "", "web/other": "9"}
+		Expect(getStalledChanges(previous, current)).To(Equal([]string{"web/deleted", "web/new"}))
+		Expect(getStalledChanges(nil, current)).To(BeEmpty())
+	})
+
+	ginkgo.It("should restart the informer, whose cache missed a change", func() {
+		stopChannel := make(chan struct{})
+		defer close(stopChannel)
+		ingress := tests.NewIngressFixture()
+		k8sClient := testclient.NewSimpleClientset(ingress)
+		ctxt := NewContext(k8sClient, fake.NewSimpleClientset(), istio_fake.NewSimpleClientset(), dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), []string{tests.Namespace}, 1000*time.Second)
+		ctxt.Run(stopChannel, true, environment.GetFakeEnv())
+		Expect(ctxt.Caches.Ingress.List()).To(HaveLen(1))
+		Expect(ctxt.RecoverStalledInformers()).To(BeEmpty())
+
+		// The informer misses the Ingress, as when its watch stopped.
+		Expect(ctxt.Caches.Ingress.Delete(ingress)).To(Succeed())
+		stalledInformer := ctxt.informers.Ingress
+
+		// A change missed at one check may still be on its way.
+		Expect(ctxt.RecoverStalledInformers()).To(BeEmpty())
+		Expect(ctxt.RecoverStalledInformers()).To(Equal([]string{"ingresses"}))
+		Expect(ctxt.informers.Ingress).ToNot(BeIdenticalTo(stalledInformer))
+		Expect(ctxt.Caches.Ingress.List()).To(HaveLen(1))
+		Expect(ctxt.RecoverStalledInformers()).To(BeEmpty())
+	})
+})
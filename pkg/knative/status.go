@@ -0,0 +1,48 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package knative
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// readyConditions are the conditions of an Ingress, which Knative Serving waits for before routing traffic to a Route.
+var readyConditions = []string{"LoadBalancerReady", "NetworkConfigured", "Ready"}
+
+// IsReady tells whether the status of the Ingress reports its current generation as ready.
+func IsReady(obj *unstructured.Unstructured) bool {
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observed != obj.GetGeneration() {
+		return false
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]interface{})
+		if ok && fields["type"] == "Ready" {
+			return fields["status"] == "True"
+		}
+	}
+	return false
+}
+
+// MarkReady sets the status of the Ingress to ready, once AGIC applied it to the App Gateway.
+func MarkReady(obj *unstructured.Unstructured) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	var conditions []interface{}
+	for _, conditionType := range readyConditions {
+		conditions = append(conditions, map[string]interface{}{
+			"type":               conditionType,
+			"status":             "True",
+			"lastTransitionTime": now,
+		})
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(obj.Object, obj.GetGeneration(), "status", "observedGeneration")
+}
@@ -0,0 +1,30 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package knative
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// knative_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test the status of Knative Ingresses", func() {
+	It("marks the current generation as ready", func() {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetGeneration(2)
+		Expect(IsReady(obj)).To(BeFalse())
+
+		Expect(MarkReady(obj)).To(Succeed())
+		Expect(IsReady(obj)).To(BeTrue())
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		Expect(conditions).To(HaveLen(3))
+
+		obj.SetGeneration(3)
+		Expect(IsReady(obj)).To(BeFalse())
+	})
+})
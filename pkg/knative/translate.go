@@ -0,0 +1,190 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package knative
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// FromUnstructured converts an Ingress or ClusterIngress watched with the dynamic client.
+func FromUnstructured(obj *unstructured.Unstructured) (*Ingress, error) {
+	ingress := &Ingress{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), ingress); err != nil {
+		return nil, err
+	}
+	return ingress, nil
+}
+
+// IsApplicationGatewayIngress tells whether Knative Serving meant AGIC to act on the Ingress.
+func IsApplicationGatewayIngress(ingress *Ingress) bool {
+	return ingress.Annotations[IngressClassKey] == IngressClass
+}
+
+// ToIngresses translates a Knative Ingress into Kubernetes Ingresses annotated for AGIC, one per namespace of the backends,
+// and returns the warnings about the parts of it, which App Gateway cannot honor. Cluster local Ingresses and hosts are left out.
+func ToIngresses(ingress *Ingress) ([]*v1beta1.Ingress, []string) {
+	if ingress.Spec.Visibility == VisibilityClusterLocal {
+		return nil, nil
+	}
+
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("%s %s: %s", ingress.Kind, ingressKey(ingress), fmt.Sprintf(format, args...)))
+	}
+
+	byNamespace := make(map[string]*v1beta1.Ingress)
+	timeouts := make(map[string]int64)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			split := getMainSplit(path.Splits)
+			if split == nil {
+				warn("path %q has no backend", path.Path)
+				continue
+			}
+			if len(path.Splits) > 1 {
+				warn("App Gateway cannot split traffic by percentage; all traffic of path %q goes to %s/%s, which receives %d%%",
+					path.Path, split.ServiceNamespace, split.ServiceName, split.Percent)
+			}
+			namespace := split.ServiceNamespace
+			if namespace == "" {
+				namespace = ingress.Namespace
+			}
+
+			translated, exists := byNamespace[namespace]
+			if !exists {
+				translated = newIngress(ingress, namespace)
+				byNamespace[namespace] = translated
+			}
+			for _, host := range rule.Hosts {
+				if isClusterLocalHost(host, namespace) {
+					continue
+				}
+				httpRule := getHTTPRule(translated, host)
+				httpRule.Paths = append(httpRule.Paths, v1beta1.HTTPIngressPath{
+					Path: toPathPattern(path.Path),
+					Backend: v1beta1.IngressBackend{
+						ServiceName: split.ServiceName,
+						ServicePort: split.ServicePort,
+					},
+				})
+			}
+			if seconds := int64(path.timeoutOrZero().Seconds()); seconds > timeouts[namespace] {
+				timeouts[namespace] = seconds
+			}
+		}
+	}
+
+	var namespaces []string
+	for namespace, translated := range byNamespace {
+		if len(translated.Spec.Rules) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	var ingresses []*v1beta1.Ingress
+	for _, namespace := range namespaces {
+		translated := byNamespace[namespace]
+		// App Gateway has a single request timeout per backend; the longest timeout of the paths is kept.
+		if _, exists := translated.Annotations[annotations.RequestTimeoutKey]; !exists && timeouts[namespace] > 0 {
+			translated.Annotations[annotations.RequestTimeoutKey] = strconv.FormatInt(timeouts[namespace], 10)
+		}
+		ingresses = append(ingresses, translated)
+	}
+	return ingresses, warnings
+}
+
+// newIngress returns an empty Kubernetes Ingress for the backends of the Knative Ingress in the namespace. It carries the AGIC
+// annotations of the Knative Ingress, which Knative Serving copies from the annotations of the Route.
+func newIngress(ingress *Ingress, namespace string) *v1beta1.Ingress {
+	ingressAnnotations := map[string]string{
+		annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass,
+	}
+	for key, value := range ingress.Annotations {
+		if strings.HasPrefix(key, annotations.ApplicationGatewayPrefix+"/") {
+			ingressAnnotations[key] = value
+		}
+	}
+	return &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "knative-" + ingress.Name,
+			Namespace:         namespace,
+			UID:               types.UID(fmt.Sprintf("%s-%s", ingress.UID, namespace)),
+			CreationTimestamp: ingress.CreationTimestamp,
+			Annotations:       ingressAnnotations,
+		},
+	}
+}
+
+// getHTTPRule returns the rule of the host, which is added to the Ingress when it has none yet, so each host has a single rule.
+func getHTTPRule(ingress *v1beta1.Ingress, host string) *v1beta1.HTTPIngressRuleValue {
+	for idx := range ingress.Spec.Rules {
+		if ingress.Spec.Rules[idx].Host == host {
+			return ingress.Spec.Rules[idx].HTTP
+		}
+	}
+	rule := v1beta1.IngressRule{
+		Host:             host,
+		IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{}},
+	}
+	ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+	return rule.HTTP
+}
+
+// getMainSplit returns the backend receiving the largest percentage of the traffic, or nil when there is none.
+// A single backend without a percentage receives all traffic.
+func getMainSplit(splits []IngressBackendSplit) *IngressBackendSplit {
+	if len(splits) == 1 {
+		return &splits[0]
+	}
+	var main *IngressBackendSplit
+	for idx := range splits {
+		if main == nil || splits[idx].Percent > main.Percent {
+			main = &splits[idx]
+		}
+	}
+	return main
+}
+
+// toPathPattern returns the App Gateway path pattern matching the paths with the prefix of the Knative Ingress path.
+// The empty path, and "/", match all paths.
+func toPathPattern(path string) string {
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return ""
+	}
+	return path + "/*"
+}
+
+// isClusterLocalHost tells whether the host is only resolvable within the cluster,
+// such as "helloworld.default.svc.cluster.local" or "helloworld.default".
+func isClusterLocalHost(host, namespace string) bool {
+	return strings.HasSuffix(host, ".svc.cluster.local") ||
+		strings.HasSuffix(host, ".svc") ||
+		(strings.Count(host, ".") == 1 && strings.HasSuffix(host, "."+namespace))
+}
+
+// ingressKey returns the namespace and name of a namespaced Ingress, or the name of a ClusterIngress.
+func ingressKey(ingress *Ingress) string {
+	if ingress.Namespace == "" {
+		return ingress.Name
+	}
+	return ingress.Namespace + "/" + ingress.Name
+}
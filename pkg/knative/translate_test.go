@@ -0,0 +1,136 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package knative
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// knative_suite_test.go launches these Ginkgo tests
+
+var _ = Describe("Test the translation of Knative Ingresses", func() {
+	newIngress := func(paths ...HTTPIngressPath) *Ingress {
+		return &Ingress{
+			TypeMeta: metav1.TypeMeta{Kind: "Ingress"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "helloworld",
+				Namespace: "default",
+				UID:       "uid",
+				Annotations: map[string]string{
+					IngressClassKey: IngressClass,
+					annotations.ApplicationGatewayPrefix + "/ssl-redirect": "true",
+					"serving.knative.dev/creator":                          "someone",
+				},
+			},
+			Spec: IngressSpec{
+				Rules: []IngressRule{{
+					Hosts: []string{"helloworld.default.svc.cluster.local", "helloworld.default", "helloworld.default.example.com"},
+					HTTP:  &HTTPIngressRuleValue{Paths: paths},
+				}},
+			},
+		}
+	}
+	split := func(namespace, name string, percent int) IngressBackendSplit {
+		return IngressBackendSplit{ServiceNamespace: namespace, ServiceName: name, ServicePort: intstr.FromInt(80), Percent: percent}
+	}
+
+	Context("Test ToIngresses()", func() {
+		It("translates the public hosts into an Ingress for AGIC", func() {
+			ingresses, warnings := ToIngresses(newIngress(HTTPIngressPath{Splits: []IngressBackendSplit{split("default", "helloworld-00001", 0)}}))
+			Expect(warnings).To(BeEmpty())
+			Expect(ingresses).To(HaveLen(1))
+			ingress := ingresses[0]
+			Expect(ingress.Name).To(Equal("knative-helloworld"))
+			Expect(ingress.Namespace).To(Equal("default"))
+			Expect(string(ingress.UID)).To(Equal("uid-default"))
+			Expect(ingress.Annotations).To(Equal(map[string]string{
+				annotations.IngressClassKey:                            annotations.ApplicationGatewayIngressClass,
+				annotations.ApplicationGatewayPrefix + "/ssl-redirect": "true",
+			}))
+			Expect(ingress.Spec.Rules).To(HaveLen(1))
+			Expect(ingress.Spec.Rules[0].Host).To(Equal("helloworld.default.example.com"))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths).To(HaveLen(1))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal(""))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName).To(Equal("helloworld-00001"))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServicePort).To(Equal(intstr.FromInt(80)))
+		})
+
+		It("sends all traffic to the backend with the largest percentage, and warns", func() {
+			ingresses, warnings := ToIngresses(newIngress(HTTPIngressPath{Splits: []IngressBackendSplit{
+				split("default", "helloworld-00001", 10),
+				split("default", "helloworld-00002", 90),
+			}}))
+			Expect(warnings).To(HaveLen(1))
+			Expect(warnings[0]).To(ContainSubstring("Ingress default/helloworld: App Gateway cannot split traffic"))
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName).To(Equal("helloworld-00002"))
+		})
+
+		It("creates an Ingress per namespace of the backends", func() {
+			ingresses, _ := ToIngresses(newIngress(
+				HTTPIngressPath{Path: "/a/", Splits: []IngressBackendSplit{split("ns-b", "b", 0)}},
+				HTTPIngressPath{Path: "/b", Splits: []IngressBackendSplit{split("ns-a", "a", 0)}},
+			))
+			Expect(ingresses).To(HaveLen(2))
+			Expect(ingresses[0].Namespace).To(Equal("ns-a"))
+			Expect(ingresses[0].Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/b/*"))
+			Expect(ingresses[1].Namespace).To(Equal("ns-b"))
+			Expect(ingresses[1].Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/a/*"))
+		})
+
+		It("keeps the longest timeout of the paths as the request timeout", func() {
+			ingresses, _ := ToIngresses(newIngress(
+				HTTPIngressPath{Path: "/a", Splits: []IngressBackendSplit{split("default", "a", 0)}, Timeout: &metav1.Duration{Duration: 30 * time.Second}},
+				HTTPIngressPath{Path: "/b", Splits: []IngressBackendSplit{split("default", "b", 0)}, Timeout: &metav1.Duration{Duration: 2 * time.Minute}},
+			))
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Annotations[annotations.RequestTimeoutKey]).To(Equal("120"))
+		})
+
+		It("skips cluster local Ingresses", func() {
+			ingress := newIngress(HTTPIngressPath{Splits: []IngressBackendSplit{split("default", "helloworld-00001", 0)}})
+			ingress.Spec.Visibility = VisibilityClusterLocal
+			ingresses, warnings := ToIngresses(ingress)
+			Expect(ingresses).To(BeEmpty())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("warns about paths without backends", func() {
+			ingresses, warnings := ToIngresses(newIngress(HTTPIngressPath{Path: "/a"}))
+			Expect(ingresses).To(BeEmpty())
+			Expect(warnings).To(ConsistOf(`Ingress default/helloworld: path "/a" has no backend`))
+		})
+	})
+
+	Context("Test FromUnstructured() and IsApplicationGatewayIngress()", func() {
+		It("reads the class of the Ingress", func() {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": Group + "/" + Version,
+				"kind":       "ClusterIngress",
+				"metadata": map[string]interface{}{
+					"name":        "helloworld",
+					"annotations": map[string]interface{}{IngressClassKey: IngressClass},
+				},
+			}}
+			ingress, err := FromUnstructured(obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsApplicationGatewayIngress(ingress)).To(BeTrue())
+
+			obj.SetAnnotations(map[string]string{IngressClassKey: "istio.ingress.networking.knative.dev"})
+			ingress, err = FromUnstructured(obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsApplicationGatewayIngress(ingress)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,92 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package knative
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// The types below mirror the fields of the Ingress and ClusterIngress resources of Knative Serving
+// (networking.internal.knative.dev/v1alpha1), which AGIC translates. Both resources share the same spec.
+
+const (
+	// Group is the API group of the Ingress and ClusterIngress resources of Knative Serving.
+	Group = "networking.internal.knative.dev"
+
+	// Version is the API version of the Ingress and ClusterIngress resources of Knative Serving.
+	Version = "v1alpha1"
+
+	// IngressClassKey is the annotation Knative Serving sets on Ingresses, naming the controller meant to act on them.
+	IngressClassKey = "networking.knative.dev/ingress.class"
+
+	// IngressClass is the value of IngressClassKey, which designates Application Gateway Ingress Controller.
+	// Knative Serving uses it when configured with ingress.class in its config-network ConfigMap.
+	IngressClass = "appgw.ingress.networking.knative.dev"
+
+	// VisibilityClusterLocal marks Ingresses only reachable from within the cluster; AGIC ignores these.
+	VisibilityClusterLocal = "ClusterLocal"
+)
+
+var (
+	// IngressesResource identifies the namespaced Ingresses of Knative Serving.
+	IngressesResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "ingresses"}
+
+	// ClusterIngressesResource identifies the cluster wide Ingresses of Knative Serving.
+	ClusterIngressesResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "clusteringresses"}
+)
+
+// Ingress is a Knative Ingress or ClusterIngress.
+type Ingress struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IngressSpec `json:"spec,omitempty"`
+}
+
+// IngressSpec describes the hosts and paths of an Ingress, and the traffic splits of each path.
+type IngressSpec struct {
+	Rules      []IngressRule `json:"rules,omitempty"`
+	Visibility string        `json:"visibility,omitempty"`
+}
+
+// IngressRule routes the paths of its hosts.
+type IngressRule struct {
+	Hosts []string              `json:"hosts,omitempty"`
+	HTTP  *HTTPIngressRuleValue `json:"http,omitempty"`
+}
+
+// HTTPIngressRuleValue is the list of paths of a rule.
+type HTTPIngressRuleValue struct {
+	Paths []HTTPIngressPath `json:"paths"`
+}
+
+// HTTPIngressPath splits the traffic of a path between backends.
+type HTTPIngressPath struct {
+	Path          string                `json:"path,omitempty"`
+	Splits        []IngressBackendSplit `json:"splits"`
+	AppendHeaders map[string]string     `json:"appendHeaders,omitempty"`
+	Timeout       *metav1.Duration      `json:"timeout,omitempty"`
+}
+
+// IngressBackendSplit is a backend receiving a percentage of the traffic of a path.
+type IngressBackendSplit struct {
+	ServiceNamespace string             `json:"serviceNamespace"`
+	ServiceName      string             `json:"serviceName"`
+	ServicePort      intstr.IntOrString `json:"servicePort"`
+	Percent          int                `json:"percent,omitempty"`
+}
+
+// timeoutOrZero returns the timeout of the path, or zero when it has none.
+func (p HTTPIngressPath) timeoutOrZero() time.Duration {
+	if p.Timeout == nil {
+		return 0
+	}
+	return p.Timeout.Duration
+}
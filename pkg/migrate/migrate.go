@@ -0,0 +1,558 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
+)
+
+// Manifests are the Kubernetes objects equivalent to the listeners and routing rules of an existing App Gateway:
+// an Ingress for each hostname, and a Service without selector, with its Endpoints, for each backend pool. What cannot be
+// expressed with an Ingress is protected from AGIC with a prohibited target, and explained in the warnings.
+type Manifests struct {
+	Ingresses         []*v1beta1.Ingress
+	Services          []*v1.Service
+	Endpoints         []*v1.Endpoints
+	ProhibitedTargets []*ptv1.AzureIngressProhibitedTarget
+	Warnings          []string
+}
+
+// route is a path of a listener, and the backend pool and HTTP settings it routes to; path is empty for the default backend.
+type route struct {
+	path     string
+	pool     *n.ApplicationGatewayBackendAddressPool
+	settings *n.ApplicationGatewayBackendHTTPSettings
+}
+
+// host gathers the listeners of a hostname, which become a single Ingress.
+type host struct {
+	name          string
+	hasHTTP       bool
+	hasHTTPS      bool
+	sslRedirect   bool
+	certificate   string
+	httpRoutes    []route
+	httpsRoutes   []route
+	untranslated  []string
+	prohibitWhole bool
+}
+
+// generator holds the sub-resources of the App Gateway by ID, and the manifests generated so far.
+type generator struct {
+	appGw     n.ApplicationGateway
+	namespace string
+	manifests Manifests
+
+	hosts    map[string]*host
+	services map[string]*v1.Service
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Generate translates the listeners and routing rules of the App Gateway into manifests in the given namespace.
+func Generate(appGw n.ApplicationGateway, namespace string) Manifests {
+	g := &generator{
+		appGw:     appGw,
+		namespace: namespace,
+		hosts:     make(map[string]*host),
+		services:  make(map[string]*v1.Service),
+	}
+	if appGw.ApplicationGatewayPropertiesFormat == nil || appGw.RequestRoutingRules == nil {
+		g.warn("the App Gateway has no request routing rules")
+		return g.manifests
+	}
+
+	rules := append([]n.ApplicationGatewayRequestRoutingRule{}, *appGw.RequestRoutingRules...)
+	sort.Slice(rules, func(i, j int) bool { return nameOf(rules[i].Name) < nameOf(rules[j].Name) })
+	for _, rule := range rules {
+		g.addRule(rule)
+	}
+
+	var hostNames []string
+	for name := range g.hosts {
+		hostNames = append(hostNames, name)
+	}
+	sort.Strings(hostNames)
+	for _, name := range hostNames {
+		g.addIngress(g.hosts[name])
+	}
+
+	var serviceNames []string
+	for name := range g.services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		g.manifests.Services = append(g.manifests.Services, g.services[name])
+	}
+	g.addEndpoints()
+	return g.manifests
+}
+
+func (g *generator) warn(format string, args ...interface{}) {
+	g.manifests.Warnings = append(g.manifests.Warnings, fmt.Sprintf(format, args...))
+}
+
+// addRule records the routes of the listener of the rule, or why they cannot be translated.
+func (g *generator) addRule(rule n.ApplicationGatewayRequestRoutingRule) {
+	ruleName := nameOf(rule.Name)
+	if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil || rule.HTTPListener == nil {
+		g.warn("request routing rule %s has no listener, and is skipped", ruleName)
+		return
+	}
+	listener := g.findListener(rule.HTTPListener.ID)
+	if listener == nil || listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil {
+		g.warn("the listener of request routing rule %s does not exist, and the rule is skipped", ruleName)
+		return
+	}
+	h := g.getHost(nameOf(listener.HostName))
+
+	port := g.findFrontendPort(listener.FrontendPort)
+	https := listener.Protocol == n.HTTPS
+	if (https && port != 443) || (!https && port != 80) {
+		g.warn("listener %s uses port %d with %s, which Ingresses cannot express; host %s is left to the App Gateway",
+			nameOf(listener.Name), port, listener.Protocol, describeHost(h.name))
+		h.prohibitWhole = true
+		return
+	}
+
+	if rule.RedirectConfiguration != nil {
+		if !https && g.redirectsToHTTPS(rule.RedirectConfiguration.ID, h.name) {
+			h.hasHTTP = true
+			h.sslRedirect = true
+			return
+		}
+		g.warn("request routing rule %s redirects, which Ingresses cannot express; host %s is left to the App Gateway", ruleName, describeHost(h.name))
+		h.prohibitWhole = true
+		return
+	}
+
+	var routes []route
+	switch {
+	case rule.URLPathMap != nil:
+		routes = g.getPathMapRoutes(h, ruleName, rule.URLPathMap.ID)
+	case rule.BackendAddressPool != nil:
+		routes = []route{{pool: g.findPool(rule.BackendAddressPool.ID), settings: g.findHTTPSettings(rule.BackendHTTPSettings)}}
+	default:
+		g.warn("request routing rule %s has neither a backend pool nor a URL path map, and is skipped", ruleName)
+		return
+	}
+
+	if https {
+		h.hasHTTPS = true
+		h.httpsRoutes = routes
+		if listener.SslCertificate != nil && listener.SslCertificate.ID != nil {
+			h.certificate = lastSegment(*listener.SslCertificate.ID)
+		}
+	} else {
+		h.hasHTTP = true
+		h.httpRoutes = routes
+	}
+}
+
+// getPathMapRoutes returns the routes of the URL path map; paths which redirect are recorded as untranslated.
+func (g *generator) getPathMapRoutes(h *host, ruleName string, pathMapID *string) []route {
+	var pathMap *n.ApplicationGatewayURLPathMap
+	if g.appGw.URLPathMaps != nil && pathMapID != nil {
+		for idx := range *g.appGw.URLPathMaps {
+			if strings.EqualFold(nameOf((*g.appGw.URLPathMaps)[idx].ID), *pathMapID) {
+				pathMap = &(*g.appGw.URLPathMaps)[idx]
+			}
+		}
+	}
+	if pathMap == nil || pathMap.ApplicationGatewayURLPathMapPropertiesFormat == nil {
+		g.warn("the URL path map of request routing rule %s does not exist, and the rule is skipped", ruleName)
+		return nil
+	}
+
+	var routes []route
+	if pathMap.PathRules != nil {
+		for _, pathRule := range *pathMap.PathRules {
+			if pathRule.ApplicationGatewayPathRulePropertiesFormat == nil || pathRule.Paths == nil {
+				continue
+			}
+			if pathRule.RedirectConfiguration != nil || pathRule.BackendAddressPool == nil {
+				g.warn("path rule %s of URL path map %s redirects, which Ingresses cannot express; paths [%s] are left to the App Gateway",
+					nameOf(pathRule.Name), nameOf(pathMap.Name), strings.Join(*pathRule.Paths, ", "))
+				h.untranslated = append(h.untranslated, *pathRule.Paths...)
+				continue
+			}
+			for _, path := range *pathRule.Paths {
+				routes = append(routes, route{
+					path:     path,
+					pool:     g.findPool(pathRule.BackendAddressPool.ID),
+					settings: g.findHTTPSettings(pathRule.BackendHTTPSettings),
+				})
+			}
+		}
+	}
+	if pathMap.DefaultBackendAddressPool != nil {
+		routes = append(routes, route{
+			pool:     g.findPool(pathMap.DefaultBackendAddressPool.ID),
+			settings: g.findHTTPSettings(pathMap.DefaultBackendHTTPSettings),
+		})
+	} else if pathMap.DefaultRedirectConfiguration != nil {
+		g.warn("URL path map %s redirects by default, which Ingresses cannot express; host %s is left to the App Gateway",
+			nameOf(pathMap.Name), describeHost(h.name))
+		h.prohibitWhole = true
+	}
+	return routes
+}
+
+// addIngress creates the Ingress of the host, or the prohibited target protecting it.
+func (g *generator) addIngress(h *host) {
+	if h.prohibitWhole {
+		g.addProhibitedTarget(h.name, nil)
+		return
+	}
+	routes := h.httpsRoutes
+	if !h.hasHTTPS {
+		routes = h.httpRoutes
+	} else if h.httpRoutes != nil && !reflect.DeepEqual(h.httpRoutes, h.httpsRoutes) {
+		g.warn("the HTTP and HTTPS listeners of host %s route differently; the Ingress routes both like the HTTPS listener", describeHost(h.name))
+	}
+	if len(h.untranslated) > 0 {
+		g.addProhibitedTarget(h.name, h.untranslated)
+	}
+
+	ingressName := sanitizeName(h.name)
+	if h.name == "" {
+		ingressName = "default"
+	}
+	ingress := &v1beta1.Ingress{
+		TypeMeta: metav1.TypeMeta{APIVersion: "extensions/v1beta1", Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ingressName,
+			Namespace:   g.namespace,
+			Annotations: map[string]string{annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass},
+		},
+	}
+	if h.sslRedirect {
+		ingress.Annotations[annotations.SslRedirectKey] = "true"
+	}
+	if h.hasHTTPS && !h.hasHTTP {
+		ingress.Annotations[annotations.HTTPSOnlyKey] = "true"
+	}
+	if h.hasHTTPS {
+		secretName := sanitizeName(h.certificate)
+		tls := v1beta1.IngressTLS{SecretName: secretName}
+		if h.name != "" {
+			tls.Hosts = []string{h.name}
+		}
+		ingress.Spec.TLS = []v1beta1.IngressTLS{tls}
+		g.warn("create the TLS secret %s/%s with the certificate %s of host %s; the App Gateway does not return private keys",
+			g.namespace, secretName, h.certificate, describeHost(h.name))
+	}
+
+	var paths []v1beta1.HTTPIngressPath
+	var settingsOfIngress *n.ApplicationGatewayBackendHTTPSettings
+	for _, r := range routes {
+		backend, ok := g.getBackend(r)
+		if !ok {
+			if r.path != "" {
+				g.addProhibitedTarget(h.name, []string{r.path})
+			} else {
+				g.addProhibitedTarget(h.name, nil)
+			}
+			continue
+		}
+		if settingsOfIngress == nil {
+			settingsOfIngress = r.settings
+			for key, value := range getSettingsAnnotations(r.settings) {
+				ingress.Annotations[key] = value
+			}
+		} else if !reflect.DeepEqual(getSettingsAnnotations(settingsOfIngress), getSettingsAnnotations(r.settings)) {
+			g.warn("the backends of host %s have different HTTP settings; the Ingress uses those of %s for all of them",
+				describeHost(h.name), nameOf(settingsOfIngress.Name))
+		}
+		paths = append(paths, v1beta1.HTTPIngressPath{Path: r.path, Backend: backend})
+	}
+	if len(paths) == 0 {
+		return
+	}
+	ingress.Spec.Rules = []v1beta1.IngressRule{{
+		Host:             h.name,
+		IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{Paths: paths}},
+	}}
+	g.manifests.Ingresses = append(g.manifests.Ingresses, ingress)
+}
+
+// getBackend returns the backend of the Ingress for the route, and adds the port of the HTTP settings to the Service of the pool.
+func (g *generator) getBackend(r route) (v1beta1.IngressBackend, bool) {
+	if r.pool == nil || r.settings == nil || r.settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat == nil || r.settings.Port == nil {
+		g.warn("a route of path %q has no backend pool or HTTP settings", r.path)
+		return v1beta1.IngressBackend{}, false
+	}
+	if r.settings.Protocol == n.HTTPS {
+		g.warn("HTTP settings %s use HTTPS to the backend, which AGIC does not configure; the Ingress uses HTTP", nameOf(r.settings.Name))
+	}
+	addresses := getIPAddresses(r.pool)
+	if addresses == nil {
+		g.warn("backend pool %s has no addresses, or FQDNs, which a Service cannot point to", nameOf(r.pool.Name))
+		return v1beta1.IngressBackend{}, false
+	}
+
+	serviceName := sanitizeName(nameOf(r.pool.Name))
+	service, exists := g.services[serviceName]
+	if !exists {
+		service = &v1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: g.namespace},
+		}
+		g.services[serviceName] = service
+	}
+	port := *r.settings.Port
+	hasPort := false
+	for _, servicePort := range service.Spec.Ports {
+		hasPort = hasPort || servicePort.Port == port
+	}
+	if !hasPort {
+		service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+			Name:       fmt.Sprintf("port-%d", port),
+			Protocol:   v1.ProtocolTCP,
+			Port:       port,
+			TargetPort: intstr.FromInt(int(port)),
+		})
+	}
+	return v1beta1.IngressBackend{ServiceName: serviceName, ServicePort: intstr.FromInt(int(port))}, true
+}
+
+// addEndpoints adds the Endpoints of each Service, with the addresses of its backend pool.
+func (g *generator) addEndpoints() {
+	for _, service := range g.manifests.Services {
+		var addresses []v1.EndpointAddress
+		for _, pool := range *g.appGw.BackendAddressPools {
+			if sanitizeName(nameOf(pool.Name)) != service.Name {
+				continue
+			}
+			for _, address := range getIPAddresses(&pool) {
+				addresses = append(addresses, v1.EndpointAddress{IP: address})
+			}
+		}
+		var ports []v1.EndpointPort
+		for _, servicePort := range service.Spec.Ports {
+			ports = append(ports, v1.EndpointPort{Name: servicePort.Name, Port: servicePort.Port, Protocol: v1.ProtocolTCP})
+		}
+		g.manifests.Endpoints = append(g.manifests.Endpoints, &v1.Endpoints{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+			ObjectMeta: metav1.ObjectMeta{Name: service.Name, Namespace: g.namespace},
+			Subsets:    []v1.EndpointSubset{{Addresses: addresses, Ports: ports}},
+		})
+	}
+}
+
+// getSettingsAnnotations returns the annotations of an Ingress, which AGIC translates back into the given HTTP settings.
+func getSettingsAnnotations(settings *n.ApplicationGatewayBackendHTTPSettings) map[string]string {
+	settingsAnnotations := make(map[string]string)
+	if settings.RequestTimeout != nil {
+		settingsAnnotations[annotations.RequestTimeoutKey] = fmt.Sprint(*settings.RequestTimeout)
+	}
+	if settings.CookieBasedAffinity == n.Enabled {
+		settingsAnnotations[annotations.CookieBasedAffinityKey] = "true"
+	}
+	if settings.ConnectionDraining != nil && settings.ConnectionDraining.Enabled != nil && *settings.ConnectionDraining.Enabled {
+		settingsAnnotations[annotations.ConnectionDrainingKey] = "true"
+		if settings.ConnectionDraining.DrainTimeoutInSec != nil {
+			settingsAnnotations[annotations.ConnectionDrainingTimeoutKey] = fmt.Sprint(*settings.ConnectionDraining.DrainTimeoutInSec)
+		}
+	}
+	if settings.Path != nil && *settings.Path != "" {
+		settingsAnnotations[annotations.BackendPathPrefixKey] = *settings.Path
+	}
+	return settingsAnnotations
+}
+
+// addProhibitedTarget protects the paths of the host, or the whole host without paths, from changes by AGIC.
+func (g *generator) addProhibitedTarget(hostName string, paths []string) {
+	name := sanitizeName(hostName)
+	if hostName == "" {
+		name = "default"
+		if len(paths) == 0 {
+			// A prohibited target without hostname nor paths would prohibit all App Gateway config.
+			paths = []string{"/*"}
+		}
+	}
+	for _, target := range g.manifests.ProhibitedTargets {
+		if target.Spec.Hostname != hostName {
+			continue
+		}
+		switch {
+		case len(target.Spec.Paths) == 0:
+			// The whole host is prohibited already.
+		case len(paths) == 0:
+			target.Spec.Paths = nil
+		default:
+			target.Spec.Paths = append(target.Spec.Paths, paths...)
+		}
+		return
+	}
+	g.manifests.ProhibitedTargets = append(g.manifests.ProhibitedTargets, &ptv1.AzureIngressProhibitedTarget{
+		TypeMeta:   metav1.TypeMeta{APIVersion: ptv1.SchemeGroupVersion.String(), Kind: "AzureIngressProhibitedTarget"},
+		ObjectMeta: metav1.ObjectMeta{Name: "prohibit-" + name, Namespace: g.namespace},
+		Spec:       ptv1.AzureIngressProhibitedTargetSpec{Hostname: hostName, Paths: paths},
+	})
+}
+
+// WriteYAML writes the warnings as comments, followed by the manifests as a stream of YAML documents.
+func (m Manifests) WriteYAML(w io.Writer) error {
+	for _, warning := range m.Warnings {
+		if _, err := fmt.Fprintf(w, "# WARNING: %s\n", warning); err != nil {
+			return err
+		}
+	}
+	var objects []interface{}
+	for _, target := range m.ProhibitedTargets {
+		objects = append(objects, target)
+	}
+	for idx := range m.Services {
+		objects = append(objects, m.Services[idx], m.Endpoints[idx])
+	}
+	for _, ingress := range m.Ingresses {
+		objects = append(objects, ingress)
+	}
+	for _, obj := range objects {
+		content, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) getHost(name string) *host {
+	if _, exists := g.hosts[name]; !exists {
+		g.hosts[name] = &host{name: name}
+	}
+	return g.hosts[name]
+}
+
+func (g *generator) findListener(id *string) *n.ApplicationGatewayHTTPListener {
+	if g.appGw.HTTPListeners == nil || id == nil {
+		return nil
+	}
+	for idx := range *g.appGw.HTTPListeners {
+		if strings.EqualFold(nameOf((*g.appGw.HTTPListeners)[idx].ID), *id) {
+			return &(*g.appGw.HTTPListeners)[idx]
+		}
+	}
+	return nil
+}
+
+func (g *generator) findFrontendPort(reference *n.SubResource) int32 {
+	if g.appGw.FrontendPorts == nil || reference == nil || reference.ID == nil {
+		return 0
+	}
+	for _, port := range *g.appGw.FrontendPorts {
+		if strings.EqualFold(nameOf(port.ID), *reference.ID) && port.ApplicationGatewayFrontendPortPropertiesFormat != nil && port.Port != nil {
+			return *port.Port
+		}
+	}
+	return 0
+}
+
+func (g *generator) findPool(id *string) *n.ApplicationGatewayBackendAddressPool {
+	if g.appGw.BackendAddressPools == nil || id == nil {
+		return nil
+	}
+	for idx := range *g.appGw.BackendAddressPools {
+		if strings.EqualFold(nameOf((*g.appGw.BackendAddressPools)[idx].ID), *id) {
+			return &(*g.appGw.BackendAddressPools)[idx]
+		}
+	}
+	return nil
+}
+
+func (g *generator) findHTTPSettings(reference *n.SubResource) *n.ApplicationGatewayBackendHTTPSettings {
+	if g.appGw.BackendHTTPSettingsCollection == nil || reference == nil || reference.ID == nil {
+		return nil
+	}
+	for idx := range *g.appGw.BackendHTTPSettingsCollection {
+		if strings.EqualFold(nameOf((*g.appGw.BackendHTTPSettingsCollection)[idx].ID), *reference.ID) {
+			return &(*g.appGw.BackendHTTPSettingsCollection)[idx]
+		}
+	}
+	return nil
+}
+
+// redirectsToHTTPS tells whether the redirect configuration sends requests to an HTTPS listener of the same host, as ssl-redirect does.
+func (g *generator) redirectsToHTTPS(id *string, hostName string) bool {
+	if g.appGw.RedirectConfigurations == nil || id == nil {
+		return false
+	}
+	for _, redirect := range *g.appGw.RedirectConfigurations {
+		if !strings.EqualFold(nameOf(redirect.ID), *id) || redirect.ApplicationGatewayRedirectConfigurationPropertiesFormat == nil ||
+			redirect.TargetListener == nil {
+			continue
+		}
+		target := g.findListener(redirect.TargetListener.ID)
+		return target != nil && target.ApplicationGatewayHTTPListenerPropertiesFormat != nil &&
+			target.Protocol == n.HTTPS && nameOf(target.HostName) == hostName
+	}
+	return false
+}
+
+// getIPAddresses returns the IP addresses of the pool, or nil when it has none, or has FQDNs.
+func getIPAddresses(pool *n.ApplicationGatewayBackendAddressPool) []string {
+	if pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || pool.BackendAddresses == nil {
+		return nil
+	}
+	var addresses []string
+	for _, address := range *pool.BackendAddresses {
+		if address.IPAddress == nil || *address.IPAddress == "" {
+			return nil
+		}
+		addresses = append(addresses, *address.IPAddress)
+	}
+	return addresses
+}
+
+// sanitizeName turns the name of an App Gateway resource or a hostname into a valid name of a Kubernetes object.
+func sanitizeName(name string) string {
+	sanitized := strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if len(sanitized) > 63 {
+		sanitized = strings.Trim(sanitized[:63], "-.")
+	}
+	if sanitized == "" {
+		return "unnamed"
+	}
+	return sanitized
+}
+
+func describeHost(hostName string) string {
+	if hostName == "" {
+		return "*"
+	}
+	return hostName
+}
+
+func lastSegment(id string) string {
+	return id[strings.LastIndex(id, "/")+1:]
+}
+
+func nameOf(name *string) string {
+	if name == nil {
+		return ""
+	}
+	return *name
+}
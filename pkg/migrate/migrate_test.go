@@ -0,0 +1,161 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package migrate
+
+import (
+	"bytes"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-12-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+var _ = Describe("Test generating manifests from an existing App Gateway", func() {
+	const appGwID = "/subscriptions/--subscription--/resourceGroups/--group--/providers/Microsoft.Network/applicationGateways/--appgw--"
+	ref := func(kind, name string) *n.SubResource {
+		return &n.SubResource{ID: to.StringPtr(appGwID + "/" + kind + "/" + name)}
+	}
+	id := func(kind, name string) *string {
+		return ref(kind, name).ID
+	}
+
+	var appGw n.ApplicationGateway
+	BeforeEach(func() {
+		appGw = n.ApplicationGateway{ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+			FrontendPorts: &[]n.ApplicationGatewayFrontendPort{
+				{ID: id("frontendPorts", "http"), ApplicationGatewayFrontendPortPropertiesFormat: &n.ApplicationGatewayFrontendPortPropertiesFormat{Port: to.Int32Ptr(80)}},
+				{ID: id("frontendPorts", "https"), ApplicationGatewayFrontendPortPropertiesFormat: &n.ApplicationGatewayFrontendPortPropertiesFormat{Port: to.Int32Ptr(443)}},
+				{ID: id("frontendPorts", "admin"), ApplicationGatewayFrontendPortPropertiesFormat: &n.ApplicationGatewayFrontendPortPropertiesFormat{Port: to.Int32Ptr(8443)}},
+			},
+			HTTPListeners: &[]n.ApplicationGatewayHTTPListener{
+				{ID: id("httpListeners", "www-http"), Name: to.StringPtr("www-http"), ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					FrontendPort: ref("frontendPorts", "http"), Protocol: n.HTTP, HostName: to.StringPtr("www.contoso.com"),
+				}},
+				{ID: id("httpListeners", "www-https"), Name: to.StringPtr("www-https"), ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					FrontendPort: ref("frontendPorts", "https"), Protocol: n.HTTPS, HostName: to.StringPtr("www.contoso.com"),
+					SslCertificate: ref("sslCertificates", "Contoso_Cert"),
+				}},
+				{ID: id("httpListeners", "admin"), Name: to.StringPtr("admin"), ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					FrontendPort: ref("frontendPorts", "admin"), Protocol: n.HTTPS, HostName: to.StringPtr("admin.contoso.com"),
+				}},
+			},
+			RedirectConfigurations: &[]n.ApplicationGatewayRedirectConfiguration{
+				{ID: id("redirectConfigurations", "to-https"), ApplicationGatewayRedirectConfigurationPropertiesFormat: &n.ApplicationGatewayRedirectConfigurationPropertiesFormat{
+					TargetListener: ref("httpListeners", "www-https"),
+				}},
+			},
+			BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{
+				{ID: id("backendAddressPools", "web"), Name: to.StringPtr("Web_Pool"), ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+					BackendAddresses: &[]n.ApplicationGatewayBackendAddress{{IPAddress: to.StringPtr("10.1.0.4")}, {IPAddress: to.StringPtr("10.1.0.5")}},
+				}},
+				{ID: id("backendAddressPools", "legacy"), Name: to.StringPtr("legacy"), ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+					BackendAddresses: &[]n.ApplicationGatewayBackendAddress{{Fqdn: to.StringPtr("legacy.azurewebsites.net")}},
+				}},
+			},
+			BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHTTPSettings{
+				{ID: id("backendHttpSettingsCollection", "web"), Name: to.StringPtr("web"), ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{
+					Port: to.Int32Ptr(8080), Protocol: n.HTTP, RequestTimeout: to.Int32Ptr(60), CookieBasedAffinity: n.Enabled,
+				}},
+			},
+			URLPathMaps: &[]n.ApplicationGatewayURLPathMap{
+				{ID: id("urlPathMaps", "www"), Name: to.StringPtr("www"), ApplicationGatewayURLPathMapPropertiesFormat: &n.ApplicationGatewayURLPathMapPropertiesFormat{
+					DefaultBackendAddressPool:  ref("backendAddressPools", "web"),
+					DefaultBackendHTTPSettings: ref("backendHttpSettingsCollection", "web"),
+					PathRules: &[]n.ApplicationGatewayPathRule{
+						{Name: to.StringPtr("api"), ApplicationGatewayPathRulePropertiesFormat: &n.ApplicationGatewayPathRulePropertiesFormat{
+							Paths: &[]string{"/api/*"}, BackendAddressPool: ref("backendAddressPools", "web"), BackendHTTPSettings: ref("backendHttpSettingsCollection", "web"),
+						}},
+						{Name: to.StringPtr("old"), ApplicationGatewayPathRulePropertiesFormat: &n.ApplicationGatewayPathRulePropertiesFormat{
+							Paths: &[]string{"/old/*"}, BackendAddressPool: ref("backendAddressPools", "legacy"), BackendHTTPSettings: ref("backendHttpSettingsCollection", "web"),
+						}},
+					},
+				}},
+			},
+			RequestRoutingRules: &[]n.ApplicationGatewayRequestRoutingRule{
+				{Name: to.StringPtr("www-http"), ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+					HTTPListener: ref("httpListeners", "www-http"), RedirectConfiguration: ref("redirectConfigurations", "to-https"),
+				}},
+				{Name: to.StringPtr("www-https"), ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+					HTTPListener: ref("httpListeners", "www-https"), URLPathMap: ref("urlPathMaps", "www"),
+				}},
+				{Name: to.StringPtr("admin"), ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+					HTTPListener: ref("httpListeners", "admin"), BackendAddressPool: ref("backendAddressPools", "web"), BackendHTTPSettings: ref("backendHttpSettingsCollection", "web"),
+				}},
+			},
+		}}
+	})
+
+	It("should generate an Ingress for each host, with a Service and Endpoints for each pool", func() {
+		manifests := Generate(appGw, "migrated")
+
+		Expect(manifests.Ingresses).To(HaveLen(1))
+		ingress := manifests.Ingresses[0]
+		Expect(ingress.Name).To(Equal("www.contoso.com"))
+		Expect(ingress.Namespace).To(Equal("migrated"))
+		Expect(ingress.Annotations).To(Equal(map[string]string{
+			annotations.IngressClassKey:        annotations.ApplicationGatewayIngressClass,
+			annotations.SslRedirectKey:         "true",
+			annotations.RequestTimeoutKey:      "60",
+			annotations.CookieBasedAffinityKey: "true",
+		}))
+		Expect(ingress.Spec.TLS).To(Equal([]v1beta1.IngressTLS{{Hosts: []string{"www.contoso.com"}, SecretName: "contoso-cert"}}))
+		backend := v1beta1.IngressBackend{ServiceName: "web-pool", ServicePort: intstr.FromInt(8080)}
+		Expect(ingress.Spec.Rules).To(Equal([]v1beta1.IngressRule{{
+			Host: "www.contoso.com",
+			IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{Paths: []v1beta1.HTTPIngressPath{
+				{Path: "/api/*", Backend: backend},
+				{Backend: backend},
+			}}},
+		}}))
+
+		Expect(manifests.Services).To(HaveLen(1))
+		Expect(manifests.Services[0].Name).To(Equal("web-pool"))
+		Expect(manifests.Services[0].Spec.Selector).To(BeNil())
+		Expect(manifests.Services[0].Spec.Ports).To(Equal([]v1.ServicePort{{Name: "port-8080", Protocol: v1.ProtocolTCP, Port: 8080, TargetPort: intstr.FromInt(8080)}}))
+		Expect(manifests.Endpoints).To(HaveLen(1))
+		Expect(manifests.Endpoints[0].Subsets).To(Equal([]v1.EndpointSubset{{
+			Addresses: []v1.EndpointAddress{{IP: "10.1.0.4"}, {IP: "10.1.0.5"}},
+			Ports:     []v1.EndpointPort{{Name: "port-8080", Port: 8080, Protocol: v1.ProtocolTCP}},
+		}}))
+	})
+
+	It("should prohibit what Ingresses cannot express, and explain why", func() {
+		manifests := Generate(appGw, "migrated")
+
+		Expect(manifests.ProhibitedTargets).To(HaveLen(2))
+		Expect(manifests.ProhibitedTargets[0].Name).To(Equal("prohibit-admin.contoso.com"))
+		Expect(manifests.ProhibitedTargets[0].Spec.Hostname).To(Equal("admin.contoso.com"))
+		Expect(manifests.ProhibitedTargets[0].Spec.Paths).To(BeEmpty())
+		Expect(manifests.ProhibitedTargets[1].Spec.Hostname).To(Equal("www.contoso.com"))
+		Expect(manifests.ProhibitedTargets[1].Spec.Paths).To(Equal([]string{"/old/*"}))
+
+		Expect(manifests.Warnings).To(ContainElement(ContainSubstring("listener admin uses port 8443")))
+		Expect(manifests.Warnings).To(ContainElement(ContainSubstring("backend pool legacy has no addresses, or FQDNs")))
+		Expect(manifests.Warnings).To(ContainElement(ContainSubstring("create the TLS secret migrated/contoso-cert with the certificate Contoso_Cert")))
+	})
+
+	It("should write the warnings as comments, and the manifests as YAML documents", func() {
+		var out bytes.Buffer
+		Expect(Generate(appGw, "migrated").WriteYAML(&out)).To(Succeed())
+		Expect(out.String()).To(HavePrefix("# WARNING: "))
+		Expect(out.String()).To(ContainSubstring("---\napiVersion: appgw.ingress.k8s.io/v1\nkind: AzureIngressProhibitedTarget\n"))
+		Expect(out.String()).To(ContainSubstring("---\napiVersion: v1\nkind: Endpoints\n"))
+		Expect(out.String()).To(ContainSubstring("---\napiVersion: extensions/v1beta1\nkind: Ingress\n"))
+	})
+
+	It("should not prohibit everything for a listener without hostname", func() {
+		(*appGw.HTTPListeners)[2].HostName = nil
+		manifests := Generate(appGw, "migrated")
+		Expect(manifests.ProhibitedTargets[0].Name).To(Equal("prohibit-default"))
+		Expect(manifests.ProhibitedTargets[0].Spec.Paths).To(Equal([]string{"/*"}))
+	})
+})
@@ -45,6 +45,14 @@ func GetResourceKey(namespace, name string) string {
 	return fmt.Sprintf("%v/%v", namespace, name)
 }
 
+// ParseResourceKey returns the namespace and name of a key in k8s format; the namespace is empty when the key has none.
+func ParseResourceKey(key string) (namespace, name string) {
+	if idx := strings.Index(key, "/"); idx >= 0 {
+		return key[:idx], key[idx+1:]
+	}
+	return "", key
+}
+
 // PrettyJSON Unmarshals and Marshall again with Indent so it is human readable
 func PrettyJSON(js []byte, prefix string) ([]byte, error) {
 	var jsonObj interface{}
@@ -30,6 +30,15 @@ var _ = Describe("Utils", func() {
 			It("Given a namespace and resource it should return the Kubernetes resource identifier.", func() {
 				Expect(utils.GetResourceKey("default", "pod")).To(Equal("default/pod"))
 			})
+
+			It("Given a Kubernetes resource identifier it should return the namespace and resource.", func() {
+				namespace, name := utils.ParseResourceKey("default/pod")
+				Expect(namespace).To(Equal("default"))
+				Expect(name).To(Equal("pod"))
+				namespace, name = utils.ParseResourceKey("node")
+				Expect(namespace).To(Equal(""))
+				Expect(name).To(Equal("node"))
+			})
 		})
 
 		Context("Test GetLastChunkOfSlashed", func() {
@@ -6,6 +6,12 @@
 package worker
 
 import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/eapache/channels"
+
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 )
 
@@ -15,8 +21,43 @@ type EventProcessor interface {
 	ShouldProcess(events.Event) (bool, string)
 }
 
+// Options tune the pace at which the worker processes events, trading how fast changes reach the App Gateway
+// for fewer requests to Azure Resource Manager.
+type Options struct {
+	// Jitter is the upper bound of a random pause after each processed event. Events arriving in the meantime are
+	// coalesced into one, and the updates of many AGIC instances sharing a subscription are spread over time. No pause when zero.
+	Jitter time.Duration
+
+	// ErrorBackoff is the pause after an event failed to be processed; it doubles with each consecutive failure, up to MaxErrorBackoff.
+	ErrorBackoff    time.Duration
+	MaxErrorBackoff time.Duration
+}
+
 // Worker listens on the eventChannel and runs the EventProcessor.Process
 // for each event.
 type Worker struct {
 	EventProcessor
+
+	options Options
+
+	// random draws the jitter; it is seeded per worker, so AGIC instances started together do not pause alike.
+	random *rand.Rand
+
+	// mutex guards the loop the worker runs, which the watchdog may replace, and the event it processes.
+	mutex sync.Mutex
+
+	// loop numbers the loops run by the worker; a loop, which was replaced, exits rather than processing another event.
+	loop         int
+	eventChannel *channels.RingChannel
+	stopChannel  chan struct{}
+
+	// loops counts the loops still running, replaced or not; stopped is set once no loop is to be started anymore.
+	loops   sync.WaitGroup
+	stopped bool
+
+	// processingSince is when the worker started processing the current event; zero while it waits for events or pauses.
+	processingSince time.Time
+
+	// done is closed when the worker stopped, after the events being processed by all its loops, if any, were processed.
+	done chan struct{}
 }
@@ -7,6 +7,7 @@ package worker
 
 import (
 	"encoding/json"
+	"math/rand"
 	"time"
 
 	"github.com/eapache/channels"
@@ -15,48 +16,187 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 )
 
-const sleepOnErrorSeconds = 5
+const (
+	// DefaultErrorBackoff is the pause after an event failed to be processed, unless configured otherwise.
+	DefaultErrorBackoff = 5 * time.Second
+
+	// DefaultMaxErrorBackoff is the upper bound of the pause after consecutive failures, unless configured otherwise.
+	DefaultMaxErrorBackoff = 5 * time.Minute
+)
+
+// DefaultOptions returns the options of a worker, which pauses for DefaultErrorBackoff after a failure, twice as long after
+// each consecutive failure up to DefaultMaxErrorBackoff, and never otherwise.
+func DefaultOptions() Options {
+	return Options{
+		ErrorBackoff:    DefaultErrorBackoff,
+		MaxErrorBackoff: DefaultMaxErrorBackoff,
+	}
+}
 
 // NewWorker creates a worker with a callback function. The callback
 // function is executed for each event in the queue.
-func NewWorker(processor EventProcessor) *Worker {
+func NewWorker(processor EventProcessor, options Options) *Worker {
+	if options.MaxErrorBackoff < options.ErrorBackoff {
+		options.MaxErrorBackoff = options.ErrorBackoff
+	}
 	w := &Worker{
 		EventProcessor: processor,
+		options:        options,
+		random:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		done:           make(chan struct{}),
 	}
 
 	return w
 }
 
 // Run starts the worker which listens for events in eventChannel. It loops until
-// stopChannel is closed.
+// stopChannel is closed; an event being processed when stopChannel is closed is processed to completion.
 func (w *Worker) Run(eventChannel *channels.RingChannel, stopChannel chan struct{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.eventChannel = eventChannel
+	w.stopChannel = stopChannel
+	w.startLoop()
+
 	go func() {
-		for {
-			select {
-			case in := <-eventChannel.Out():
-				event := in.(events.Event)
-
-				if jsonEvent, err := json.Marshal(event); err != nil {
-					glog.Error("Failed marshalling event:", err)
-				} else {
-					glog.V(5).Infof("Received event: %s", jsonEvent)
-				}
-
-				if shouldProcess, reason := w.ShouldProcess(event); !shouldProcess {
-					glog.V(5).Infof("Skipping event: %s", reason)
-					continue
-				}
-
-				// Use callback to process event.
-				if err := w.Process(event); err != nil {
-					glog.Error("Processing event failed:", err)
-					time.Sleep(sleepOnErrorSeconds * time.Second)
-				} else {
-					glog.V(3).Infoln("Successfully processed event")
-				}
-			case <-stopChannel:
-				break
+		<-stopChannel
+		// No loop is started once stopped, so the loops waited for are all the loops.
+		w.mutex.Lock()
+		w.stopped = true
+		w.mutex.Unlock()
+		w.loops.Wait()
+		close(w.done)
+	}()
+}
+
+// Restart replaces the loop of the worker with a new one, when the event it processes does not end. The new loop processes
+// the events queued meanwhile right away; the replaced loop exits once its event ends, without processing another one.
+// The caller must have abandoned the event first, so it no longer changes the App Gateway while the new loop does.
+func (w *Worker) Restart() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.loop == 0 || w.stopped {
+		return
+	}
+	w.processingSince = time.Time{}
+	w.startLoop()
+}
+
+// ProcessingSince returns when the worker started processing the current event, or zero when it is not processing one.
+func (w *Worker) ProcessingSince() time.Time {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.processingSince
+}
+
+// startLoop starts a new loop; the caller holds the mutex.
+func (w *Worker) startLoop() {
+	w.loop++
+	w.loops.Add(1)
+	go func(loop int) {
+		defer w.loops.Done()
+		w.run(loop, w.eventChannel, w.stopChannel)
+	}(w.loop)
+}
+
+// setProcessing records the start or, with a zero time, the end of the processing of an event by the loop; it returns
+// false when the loop was replaced, and is to exit.
+func (w *Worker) setProcessing(loop int, since time.Time) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if loop != w.loop {
+		return false
+	}
+	w.processingSince = since
+	return true
+}
+
+func (w *Worker) run(loop int, eventChannel *channels.RingChannel, stopChannel chan struct{}) {
+	failures := 0
+	for {
+		// Events still queued are not processed once stopped, even though both channels may be ready.
+		select {
+		case <-stopChannel:
+			return
+		default:
+		}
+
+		select {
+		case in := <-eventChannel.Out():
+			event := in.(events.Event)
+
+			if jsonEvent, err := json.Marshal(event); err != nil {
+				glog.Error("Failed marshalling event:", err)
+			} else {
+				glog.V(5).Infof("Received event: %s", jsonEvent)
 			}
+
+			if shouldProcess, reason := w.ShouldProcess(event); !shouldProcess {
+				glog.V(5).Infof("Skipping event: %s", reason)
+				continue
+			}
+
+			if !w.setProcessing(loop, time.Now()) {
+				// The loop was replaced while waiting for the event; the new loop processes it.
+				eventChannel.In() <- event
+				return
+			}
+
+			// Use callback to process event.
+			err := w.Process(event)
+			if replaced := !w.setProcessing(loop, time.Time{}); replaced {
+				glog.Infof("Processing of the event ended after the worker was restarted: %v", err)
+				return
+			}
+			if err != nil {
+				failures++
+				backoff := w.errorBackoff(failures)
+				glog.Errorf("Processing event failed %d times in a row; retrying in %s: %s", failures, backoff, err)
+				w.pause(backoff, stopChannel)
+			} else {
+				failures = 0
+				glog.V(3).Infoln("Successfully processed event")
+				w.pause(w.jitter(), stopChannel)
+			}
+		case <-stopChannel:
+			return
 		}
-	}()
+	}
+}
+
+// Done returns a channel, which is closed once the worker stopped running, and every loop it ran, including the loops it
+// replaced, exited.
+func (w *Worker) Done() <-chan struct{} {
+	return w.done
+}
+
+// errorBackoff returns the pause after the given number of consecutive failures.
+func (w *Worker) errorBackoff(failures int) time.Duration {
+	backoff := w.options.ErrorBackoff
+	for i := 1; i < failures && backoff < w.options.MaxErrorBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > w.options.MaxErrorBackoff {
+		backoff = w.options.MaxErrorBackoff
+	}
+	return backoff
+}
+
+// jitter returns a random pause up to the configured jitter.
+func (w *Worker) jitter() time.Duration {
+	if w.options.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(w.random.Int63n(int64(w.options.Jitter)))
+}
+
+// pause waits for the duration, or until the worker is stopped.
+func (w *Worker) pause(duration time.Duration, stopChannel chan struct{}) {
+	if duration <= 0 {
+		return
+	}
+	select {
+	case <-time.After(duration):
+	case <-stopChannel:
+	}
 }
@@ -6,6 +6,7 @@
 package worker
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/eapache/channels"
@@ -36,7 +37,7 @@ var _ = Describe("Worker Test", func() {
 				backChannel <- struct{}{}
 				return nil
 			})
-			worker := NewWorker(eventProcessor)
+			worker := NewWorker(eventProcessor, DefaultOptions())
 			worker.Run(eventChannel, stopChannel)
 
 			ingress := *tests.NewIngressFixture()
@@ -57,4 +58,103 @@ var _ = Describe("Worker Test", func() {
 			Expect(processCalled).To(Equal(true), "Worker was not able to call process function within timeout")
 		})
 	})
+
+	Context("Check that worker stops", func() {
+		It("Should complete the event being processed before stopping", func() {
+			processing := make(chan struct{})
+			release := make(chan struct{})
+			processed := false
+			eventProcessor := NewFakeProcessor(func(events.Event) error {
+				close(processing)
+				<-release
+				processed = true
+				return nil
+			})
+			// AfterEach closes the shared stopChannel; this worker is stopped in the test.
+			stop := make(chan struct{})
+			worker := NewWorker(eventProcessor, DefaultOptions())
+			worker.Run(eventChannel, stop)
+
+			eventChannel.In() <- events.Event{
+				Type:  events.Create,
+				Value: *tests.NewIngressFixture(),
+			}
+			Eventually(processing, time.Second).Should(BeClosed())
+
+			close(stop)
+			Consistently(worker.Done(), 100*time.Millisecond).ShouldNot(BeClosed())
+			close(release)
+			Eventually(worker.Done(), time.Second).Should(BeClosed())
+			Expect(processed).To(BeTrue())
+		})
+	})
+
+	Context("Check that a stalled worker is restarted", func() {
+		It("Should process the pending events in a new loop right away, and end the stalled loop once its event ends", func() {
+			release := make(chan struct{})
+			var calls int32
+			processed := make(chan events.Event, 10)
+			eventProcessor := NewFakeProcessor(func(event events.Event) error {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					<-release
+				}
+				processed <- event
+				return nil
+			})
+			// AfterEach closes the shared stopChannel; this worker is stopped in the test.
+			stop := make(chan struct{})
+			worker := NewWorker(eventProcessor, DefaultOptions())
+			Expect(worker.ProcessingSince().IsZero()).To(BeTrue())
+			worker.Run(eventChannel, stop)
+
+			eventChannel.In() <- events.Event{Type: events.Create, Value: "stalled"}
+			Eventually(func() bool { return worker.ProcessingSince().IsZero() }, time.Second).Should(BeFalse())
+
+			worker.Restart()
+			Expect(worker.ProcessingSince().IsZero()).To(BeTrue())
+			eventChannel.In() <- events.Event{Type: events.Update, Value: "pending"}
+			Eventually(processed, time.Second).Should(Receive(Equal(events.Event{Type: events.Update, Value: "pending"})))
+
+			// The worker is done only once the stalled loop ended too.
+			close(stop)
+			Consistently(worker.Done(), 100*time.Millisecond).ShouldNot(BeClosed())
+			close(release)
+			Eventually(processed, time.Second).Should(Receive(Equal(events.Event{Type: events.Create, Value: "stalled"})))
+			Eventually(worker.Done(), time.Second).Should(BeClosed())
+		})
+	})
+
+	Context("Check the pauses of the worker", func() {
+		It("Should double the error backoff up to the maximum", func() {
+			worker := NewWorker(NewFakeProcessor(nil), Options{ErrorBackoff: time.Second, MaxErrorBackoff: 5 * time.Second})
+			Expect(worker.errorBackoff(1)).To(Equal(time.Second))
+			Expect(worker.errorBackoff(2)).To(Equal(2 * time.Second))
+			Expect(worker.errorBackoff(3)).To(Equal(4 * time.Second))
+			Expect(worker.errorBackoff(4)).To(Equal(5 * time.Second))
+			Expect(worker.errorBackoff(100)).To(Equal(5 * time.Second))
+		})
+
+		It("Should double the error backoff by default", func() {
+			worker := NewWorker(NewFakeProcessor(nil), DefaultOptions())
+			Expect(worker.errorBackoff(1)).To(Equal(5 * time.Second))
+			Expect(worker.errorBackoff(2)).To(Equal(10 * time.Second))
+			Expect(worker.errorBackoff(3)).To(Equal(20 * time.Second))
+			Expect(worker.errorBackoff(6)).To(Equal(160 * time.Second))
+			Expect(worker.errorBackoff(7)).To(Equal(5 * time.Minute))
+			Expect(worker.errorBackoff(100)).To(Equal(5 * time.Minute))
+		})
+
+		It("Should not back off below the initial backoff", func() {
+			worker := NewWorker(NewFakeProcessor(nil), Options{ErrorBackoff: 3 * time.Second})
+			Expect(worker.errorBackoff(5)).To(Equal(3 * time.Second))
+		})
+
+		It("Should pause up to the jitter after each event", func() {
+			worker := NewWorker(NewFakeProcessor(nil), Options{Jitter: 10 * time.Millisecond})
+			for i := 0; i < 100; i++ {
+				Expect(worker.jitter()).To(And(BeNumerically(">=", 0), BeNumerically("<", 10*time.Millisecond)))
+			}
+			Expect(NewWorker(NewFakeProcessor(nil), DefaultOptions()).jitter()).To(BeZero())
+		})
+	})
 })
@@ -0,0 +1,77 @@
+#!/bin/bash
+
+# Runs the config builder benchmarks and compares them with the stored baseline. The median time of each benchmark may be at
+# most TIME_THRESHOLD percent over the baseline, and its median allocations ALLOCS_THRESHOLD percent. Allocations hardly vary
+# from one run to the next, unlike the time, which depends on the load of the machine. With --update, the results become the
+# new baseline. The baseline should be recorded on the build agents, which run this script.
+
+# colors
+COLOR_RESET='\e[0m'
+COLOR_RED='\e[101;97m'
+COLOR_GREEN='\e[42;97m'
+
+BASELINE=${BASELINE:-.pipelines/benchmarks/baseline.txt}
+TIME_THRESHOLD=${TIME_THRESHOLD:-100}
+ALLOCS_THRESHOLD=${ALLOCS_THRESHOLD:-10}
+COUNT=${COUNT:-5}
+RESULTS=$(mktemp)
+
+if ! go test -run='^$' -bench=Build -benchmem -count="$COUNT" ./pkg/appgw/ > "$RESULTS"; then
+    cat "$RESULTS"
+    echo -e "$COLOR_RED Benchmarks FAILED $COLOR_RESET"
+    exit 1
+fi
+
+if [ "$1" == "--update" ]; then
+    grep '^Benchmark.*ns/op' "$RESULTS" > "$BASELINE"
+    echo "Updated the baseline in $BASELINE"
+    exit 0
+fi
+
+# benchstat shows the statistics of the change, when it is installed (go get golang.org/x/perf/cmd/benchstat).
+if command -v benchstat > /dev/null; then
+    benchstat "$BASELINE" "$RESULTS"
+else
+    grep '^Benchmark.*ns/op' "$RESULTS"
+fi
+
+# Prints "<benchmark> <median ns/op> <median allocs/op>" for each benchmark of the results.
+medians() {
+    awk '/^Benchmark.*ns\/op/ {
+        name = $1
+        sub(/-[0-9]+$/, "", name)
+        for (i = 3; i < NF; i++) {
+            if ($(i + 1) == "ns/op") { ns[name] = ns[name] " " $i }
+            if ($(i + 1) == "allocs/op") { allocs[name] = allocs[name] " " $i }
+        }
+    }
+    function median(values,    sorted, n, i, j, tmp) {
+        n = split(values, sorted, " ")
+        for (i = 1; i <= n; i++) {
+            for (j = i + 1; j <= n; j++) {
+                if (sorted[j] + 0 < sorted[i] + 0) { tmp = sorted[i]; sorted[i] = sorted[j]; sorted[j] = tmp }
+            }
+        }
+        return sorted[int((n + 1) / 2)]
+    }
+    END {
+        for (name in ns) { print name, median(ns[name]), median(allocs[name]) }
+    }' "$1"
+}
+
+if ! join <(medians "$BASELINE" | sort) <(medians "$RESULTS" | sort) | awk -v time_threshold="$TIME_THRESHOLD" -v allocs_threshold="$ALLOCS_THRESHOLD" '
+    function check(name, metric, baseline, current, threshold) {
+        if (current > baseline * (1 + threshold / 100)) {
+            printf "%s: %s went from %d to %d, more than %d%% over the baseline\n", name, metric, baseline, current, threshold
+            regressed = 1
+        }
+    }
+    {
+        check($1, "ns/op", $2, $4, time_threshold)
+        check($1, "allocs/op", $3, $5, allocs_threshold)
+    }
+    END { exit regressed }'; then
+    echo -e "$COLOR_RED Benchmarks REGRESSED $COLOR_RESET"
+    exit 1
+fi
+echo -e "$COLOR_GREEN Benchmarks SUCCEEDED $COLOR_RESET"
@@ -2,25 +2,53 @@
 
 set -auexo pipefail
 
-# This script requres a checkout of https://github.com/kubernetes/code-generator in ../
+# Generates the deepcopy functions of the AGIC CRDs, and the clientsets, listers and informers of the AGIC and Istio CRDs in
+# pkg/crd_client, with the k8s.io/code-generator version pinned in go.mod. The generators only work in a GOPATH: the repository
+# is linked into a temporary one. client-gen generates UpdateStatus for the types with a Status field, which the CRDs serve as
+# a status subresource. Run this script from the root of the repository, after changing the types in pkg/apis.
 # For more information read https://blog.openshift.com/kubernetes-deep-dive-code-generation-customresources/
 
-echo -e "Cleanup previously generated code..."
-rm -rf pkg/client $(find ./pkg -name 'zz_*.go')
+PACKAGE=github.com/Azure/application-gateway-kubernetes-ingress
+CODE_GENERATOR_VERSION=$(awk '$1 == "k8s.io/code-generator" && $2 == "=>" { print $4 }' go.mod)
+
+WORKDIR=$(mktemp -d)
+trap 'rm -rf "$WORKDIR"' EXIT
+
+echo -e "Download k8s.io/code-generator $CODE_GENERATOR_VERSION..."
+mkdir "$WORKDIR/code-generator"
+(cd "$WORKDIR/code-generator" && go mod init code-generator)
+CODE_GENERATOR=$(cd "$WORKDIR/code-generator" && go mod download -json k8s.io/code-generator@"$CODE_GENERATOR_VERSION" | awk -F'"' '$2 == "Dir" { print $4 }')
+(
+    cd "$CODE_GENERATOR"
+    GOBIN="$WORKDIR/bin" GOFLAGS=-mod=mod go install ./cmd/client-gen ./cmd/lister-gen ./cmd/informer-gen ./cmd/deepcopy-gen
+)
 
-echo -e "Generate AzureIngressManagedTarget, AzureIngressProhibitedTarget..."
-../code-generator/generate-groups.sh \
-    all \
-    github.com/Azure/application-gateway-kubernetes-ingress/pkg/client \
-    github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis \
-    "azureingressmanagedtarget:v1 azureingressprohibitedtarget:v1"
+mkdir -p "$WORKDIR/src/$(dirname $PACKAGE)"
+ln -s "$PWD" "$WORKDIR/src/$PACKAGE"
+cd "$WORKDIR/src/$PACKAGE"
+export GOPATH="$WORKDIR" GO111MODULE=off
 
-go get github.com/knative/pkg/apis/istio/v1alpha3
+# generate <output package> <APIs package> <group/version packages> <generators>
+generate() {
+    local header="$CODE_GENERATOR/hack/boilerplate.go.txt"
+    if [[ "$4" == *deepcopy* ]]; then
+        "$WORKDIR/bin/deepcopy-gen" --go-header-file "$header" --input-dirs "$3" -O zz_generated.deepcopy --bounding-dirs "$2"
+    fi
+    "$WORKDIR/bin/client-gen" --go-header-file "$header" --clientset-name versioned --input-base "" --input "$3" \
+        --output-package "$1/clientset"
+    "$WORKDIR/bin/lister-gen" --go-header-file "$header" --input-dirs "$3" --output-package "$1/listers"
+    "$WORKDIR/bin/informer-gen" --go-header-file "$header" --input-dirs "$3" \
+        --versioned-clientset-package "$1/clientset/versioned" --listers-package "$1/listers" --output-package "$1/informers"
+}
+
+echo -e "Cleanup previously generated code..."
+rm -rf pkg/crd_client $(find ./pkg/apis -name 'zz_*.go')
 
-go vendor
+echo -e "Generate AzureIngressProhibitedTarget..."
+generate $PACKAGE/pkg/crd_client/agic_crd_client $PACKAGE/pkg/apis \
+    $PACKAGE/pkg/apis/azureingressprohibitedtarget/v1 deepcopy
 
+# The Istio types, and their deepcopy functions, come from the vendored github.com/knative/pkg.
 echo -e "Generate Istio CRDs..."
-../code-generator/generate-groups.sh \
-    all \
-    github.com/Azure/application-gateway-kubernetes-ingress/pkg/istio_client \
-    github.com/knative/pkg/apis "istio:v1alpha3"
+generate $PACKAGE/pkg/crd_client/istio_crd_client github.com/knative/pkg/apis \
+    github.com/knative/pkg/apis/istio/v1alpha3 ""